@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/nci/gsky/metrics"
+	proc "github.com/nci/gsky/processor"
+	"github.com/nci/gsky/utils"
+)
+
+// serveWCSContours computes the contour lines of the coverage
+// of a WCS GetCoverage request rendered as a single tile and
+// writes them as GeoJSON in the CRS of the request.
+func serveWCSContours(ctx context.Context, params utils.WCSParams, conf *utils.Config, idx int, styleLayer *utils.Layer, geoReq *proc.GeoTileRequest, w http.ResponseWriter, metricsCollector *metrics.MetricsCollector) {
+	contour, err := utils.NewContourConfig(styleLayer.Contour, params.ContourInterval, params.ContourLevels)
+	if err != nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("Malformed WCS GetCoverage request: %v", err), 400)
+		return
+	}
+	if contour == nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, "GeoJSON coverages require either contour lines in the style or the contour_interval or contour_levels parameters", 400)
+		return
+	}
+
+	if len(styleLayer.Overviews) > 0 {
+		bbox, err := utils.GetCanonicalBbox(geoReq.CRS, geoReq.BBox)
+		if err == nil {
+			reqRes := utils.GetPixelResolution(bbox, geoReq.Width, geoReq.Height)
+			iOvr := utils.FindLayerBestOverview(styleLayer, reqRes, false)
+			if iOvr >= 0 {
+				geoReq.Overview = &styleLayer.Overviews[iOvr]
+			}
+		}
+	}
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), time.Duration(conf.Layers[idx].WcsTimeout)*time.Second)
+	defer timeoutCancel()
+
+	errChan := make(chan error, 100)
+	tp := proc.InitTilePipeline(ctx, styleLayer.MASAddress, conf.ServiceConfig.WorkerNodes, conf.Layers[idx].MaxGrpcRecvMsgSize, conf.Layers[idx].WcsPolygonShardConcLimit, conf.ServiceConfig.MaxGrpcBufferSize, errChan)
+
+	var lines []utils.ContourLine
+	select {
+	case res := <-tp.Process(geoReq, *verbose):
+		if len(res) != 1 {
+			msg := fmt.Sprintf("WCS: contour lines require a single band, received %d", len(res))
+			Info.Printf(msg)
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, msg, 400)
+			return
+		}
+
+		lines, err = utils.RasterContours(res[0], contour, 0)
+		if err != nil {
+			Info.Printf("WCS: %v\n", err)
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, err.Error(), 500)
+			return
+		}
+	case err := <-errChan:
+		Info.Printf("WCS: error in the pipeline: %v\n", err)
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, err.Error(), 500)
+		return
+	case <-ctx.Done():
+		Error.Printf("Context cancelled with message: %v\n", ctx.Err())
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, ctx.Err().Error(), 500)
+		return
+	case <-timeoutCtx.Done():
+		Error.Printf("WCS pipeline timed out, threshold:%v seconds", conf.Layers[idx].WcsTimeout)
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, "WCS pipeline timed out", 500)
+		return
+	}
+
+	out, err := utils.EncodeContourGeoJSON(lines, utils.BBox2Geot(geoReq.Width, geoReq.Height, geoReq.BBox))
+	if err != nil {
+		Info.Printf("WCS: %v\n", err)
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	re := regexp.MustCompile(`[^a-zA-Z0-9\-_\s]`)
+	fileNameCoverages := re.ReplaceAllString(params.Coverages[0], `-`)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s.contours.geojson", fileNameCoverages, params.Time.Format(utils.ISOFormat)))
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.Write(out)
+}
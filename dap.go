@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/nci/gsky/metrics"
 	"github.com/nci/gsky/utils"
@@ -32,6 +33,12 @@ func serveDap(ctx context.Context, conf *utils.Config, r *http.Request, w http.R
 		return
 	}
 
+	// The .zarr path of a dataset returns the
+	// variables as a Zarr store instead of DAP4
+	if strings.HasSuffix(r.URL.Path, ".zarr") {
+		*wcsParams.Format = "Zarr"
+	}
+
 	serveWCS(ctx, *wcsParams, conf, r, w, query, metricsCollector)
 }
 
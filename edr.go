@@ -0,0 +1,586 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	geo "github.com/nci/geometry"
+	"github.com/nci/gsky/metrics"
+	proc "github.com/nci/gsky/processor"
+	"github.com/nci/gsky/utils"
+)
+
+// serveEDR serves the OGC API EDR position, area, trajectory
+// and cube queries. Position, area and trajectory queries are
+// time series computed by the drill pipeline while cube
+// queries are rendered by the WCS tile pipeline.
+func serveEDR(ctx context.Context, params utils.EDRParams, conf *utils.Config, r *http.Request, w http.ResponseWriter, metricsCollector *metrics.MetricsCollector) {
+	if params.Request == nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, "Malformed EDR, a Request field needs to be specified", 400)
+		return
+	}
+
+	if params.Collection == nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, "Malformed EDR, a collection needs to be specified", 400)
+		return
+	}
+
+	idx := -1
+	for i := range conf.Layers {
+		if conf.Layers[i].Name == *params.Collection {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		metricsCollector.Info.HTTPStatus = 404
+		http.Error(w, fmt.Sprintf("Collection not found: %s", *params.Collection), 404)
+		return
+	}
+
+	layer := &conf.Layers[idx]
+	if utils.CheckDisableServices(layer, "edr") {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, "EDR is disabled for this collection", 400)
+		return
+	}
+
+	dataLayer := getEDRDataLayer(layer)
+	if dataLayer == nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, "EDR: collection has no band expressions", 400)
+		return
+	}
+
+	// Drill results have one column per band expression
+	// or per variable if there is no expression
+	allParams := dataLayer.RGBExpressions.ExprNames
+	if len(dataLayer.RGBExpressions.Expressions) == 0 {
+		allParams = dataLayer.RGBExpressions.VarList
+	}
+
+	var paramIdx []int
+	if len(params.ParameterName) > 0 {
+		for _, name := range params.ParameterName {
+			found := false
+			for ip, p := range allParams {
+				if p == name {
+					paramIdx = append(paramIdx, ip)
+					found = true
+					break
+				}
+			}
+			if !found {
+				metricsCollector.Info.HTTPStatus = 400
+				http.Error(w, fmt.Sprintf("Unknown parameter-name: %s, valid parameters: %v", name, allParams), 400)
+				return
+			}
+		}
+	} else {
+		for ip := range allParams {
+			paramIdx = append(paramIdx, ip)
+		}
+	}
+	paramNames := make([]string, len(paramIdx))
+	for i, ip := range paramIdx {
+		paramNames[i] = allParams[ip]
+	}
+
+	format := "coveragejson"
+	if params.Format != nil {
+		format = *params.Format
+	}
+
+	ctx, ctxCancel := context.WithCancel(ctx)
+	defer ctxCancel()
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), time.Duration(layer.EdrTimeout)*time.Second)
+	defer timeoutCancel()
+
+	drill := func(feat string, startTime *time.Time, endTime *time.Time) (*utils.CovJSONSeries, error) {
+		series, err := edrDrill(ctx, timeoutCtx, conf, layer, dataLayer, feat, startTime, endTime, metricsCollector)
+		if err != nil {
+			return nil, err
+		}
+
+		// Keep the requested parameters only
+		selected := &utils.CovJSONSeries{Times: series.Times, Parameters: paramNames}
+		for _, ip := range paramIdx {
+			selected.Values = append(selected.Values, series.Values[ip])
+		}
+		return selected, nil
+	}
+
+	sendError := func(err error) {
+		Info.Printf("EDR: %v\n", err)
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, err.Error(), 500)
+	}
+
+	switch *params.Request {
+	case "position":
+		if params.Geometry == nil || (params.Geometry.Type != "POINT" && params.Geometry.Type != "MULTIPOINT") {
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, "EDR position queries require POINT or MULTIPOINT coords", 400)
+			return
+		}
+		if len(params.Geometry.Points) > utils.EDRMaxPoints {
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, fmt.Sprintf("Too many positions, maximum: %d", utils.EDRMaxPoints), 400)
+			return
+		}
+
+		var covs []*utils.CovJSONCoverage
+		var csv strings.Builder
+		fmt.Fprintf(&csv, "datetime,x,y,%s\n", strings.Join(paramNames, ","))
+		for _, pos := range params.Geometry.Points {
+			series, err := drill(utils.PointFeature(pos), params.StartTime, params.EndTime)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			covs = append(covs, utils.NewCovJSONPointSeries(pos[0], pos[1], series))
+			writeEDRSeriesCSV(&csv, fmt.Sprintf("%f,%f", pos[0], pos[1]), series)
+		}
+
+		if format == "csv" {
+			writeEDROutput(w, "text/csv", []byte(csv.String()))
+		} else if len(covs) == 1 {
+			writeEDRCovJSON(w, covs[0])
+		} else {
+			writeEDRCovJSON(w, &utils.CovJSONCoverageCollection{Type: "CoverageCollection", DomainType: "PointSeries", Parameters: utils.NewCovJSONParameters(paramNames), Coverages: covs})
+		}
+
+	case "area":
+		if params.Geometry == nil || (params.Geometry.Type != "POLYGON" && params.Geometry.Type != "MULTIPOLYGON") {
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, "EDR area queries require POLYGON or MULTIPOLYGON coords", 400)
+			return
+		}
+
+		feat := params.Geometry.GeoJSONFeature()
+		var geoFeat geo.Feature
+		err := json.Unmarshal([]byte(feat), &geoFeat)
+		if err != nil {
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, fmt.Sprintf("Invalid area geometry: %v", err), 400)
+			return
+		}
+
+		area := utils.GetArea(geoFeat.Geometry)
+		metricsCollector.Info.Indexer.GeometryArea = area
+		if area == 0.0 || area > layer.EdrMaxArea {
+			Info.Printf("EDR: the requested area %.02f, is too large.\n", area)
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, "The requested area is too large. Please try with a smaller one.", 400)
+			return
+		}
+
+		series, err := drill(feat, params.StartTime, params.EndTime)
+		if err != nil {
+			sendError(err)
+			return
+		}
+
+		if format == "csv" {
+			var csv strings.Builder
+			fmt.Fprintf(&csv, "datetime,%s\n", strings.Join(paramNames, ","))
+			writeEDRSeriesCSV(&csv, "", series)
+			writeEDROutput(w, "text/csv", []byte(csv.String()))
+		} else if len(params.Geometry.Polygons) == 1 {
+			writeEDRCovJSON(w, utils.NewCovJSONPolygonSeries(params.Geometry.Polygons[0], series))
+		} else {
+			// The series of a MultiPolygon is aggregated over
+			// all its polygons, hence the same ranges for all
+			var covs []*utils.CovJSONCoverage
+			for _, poly := range params.Geometry.Polygons {
+				covs = append(covs, utils.NewCovJSONPolygonSeries(poly, series))
+			}
+			writeEDRCovJSON(w, &utils.CovJSONCoverageCollection{Type: "CoverageCollection", DomainType: "PolygonSeries", Parameters: utils.NewCovJSONParameters(paramNames), Coverages: covs})
+		}
+
+	case "trajectory":
+		if params.Geometry == nil || params.Geometry.Type != "LINESTRING" {
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, "EDR trajectory queries require LINESTRING coords", 400)
+			return
+		}
+		if len(params.Geometry.Points) > utils.EDRMaxPoints {
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, fmt.Sprintf("Too many positions, maximum: %d", utils.EDRMaxPoints), 400)
+			return
+		}
+
+		// With M values each position is sampled at its own
+		// time, otherwise every position has the time series
+		// within datetime.
+		var times []string
+		var xs, ys []float64
+		values := make([][]*float64, len(paramNames))
+		for _, pos := range params.Geometry.Points {
+			startTime, endTime := params.StartTime, params.EndTime
+			if t := params.Geometry.Time(pos); t != nil {
+				startTime, endTime = t, t
+			}
+
+			series, err := drill(utils.PointFeature(pos), startTime, endTime)
+			if err != nil {
+				sendError(err)
+				return
+			}
+
+			for it, ts := range series.Times {
+				times = append(times, ts)
+				xs = append(xs, pos[0])
+				ys = append(ys, pos[1])
+				for ip := range paramNames {
+					values[ip] = append(values[ip], series.Values[ip][it])
+				}
+			}
+		}
+
+		if format == "csv" {
+			var csv strings.Builder
+			fmt.Fprintf(&csv, "datetime,x,y,%s\n", strings.Join(paramNames, ","))
+			for i := range times {
+				fmt.Fprintf(&csv, "%s,%f,%f", times[i], xs[i], ys[i])
+				for ip := range paramNames {
+					writeEDRValue(&csv, values[ip][i])
+				}
+				fmt.Fprint(&csv, "\n")
+			}
+			writeEDROutput(w, "text/csv", []byte(csv.String()))
+		} else {
+			writeEDRCovJSON(w, utils.NewCovJSONTrajectory(times, xs, ys, paramNames, values))
+		}
+
+	case "cube":
+		serveEDRCube(ctx, timeoutCtx, params, conf, idx, dataLayer, paramNames, format, w, metricsCollector)
+
+	default:
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("%s not recognised.", *params.Request), 400)
+	}
+}
+
+// getEDRDataLayer returns the layer or style holding the
+// band expressions to be queried for a collection.
+func getEDRDataLayer(layer *utils.Layer) *utils.Layer {
+	if layer.RGBExpressions != nil && len(layer.RGBExpressions.VarList) > 0 {
+		return layer
+	}
+	for i := range layer.Styles {
+		style := &layer.Styles[i]
+		if style.RGBExpressions != nil && len(style.RGBExpressions.VarList) > 0 {
+			return style
+		}
+	}
+	return nil
+}
+
+// edrDrill runs the drill pipeline over the feature and
+// returns the time series of every drill column.
+func edrDrill(ctx context.Context, timeoutCtx context.Context, conf *utils.Config, layer *utils.Layer, dataLayer *utils.Layer, feat string, startTime *time.Time, endTime *time.Time, metricsCollector *metrics.MetricsCollector) (*utils.CovJSONSeries, error) {
+	start := time.Time{}
+	if startTime != nil {
+		start = *startTime
+	}
+	end := time.Now().UTC()
+	if endTime != nil {
+		end = *endTime
+	}
+
+	geoReq := proc.GeoDrillRequest{Geometry: feat,
+		CRS:              "EPSG:4326",
+		Collection:       dataLayer.DataSource,
+		NameSpaces:       dataLayer.RGBExpressions.VarList,
+		BandExpr:         dataLayer.RGBExpressions,
+		Mask:             dataLayer.Mask,
+		VRTURL:           dataLayer.VRTURL,
+		StartTime:        start,
+		EndTime:          end,
+		ClipUpper:        float32(math.MaxFloat32),
+		ClipLower:        float32(-math.MaxFloat32),
+		RasterXSize:      layer.RasterXSize,
+		RasterYSize:      layer.RasterYSize,
+		GrpcConcLimit:    layer.GrpcWpsConcPerNode,
+		IndexTileXSize:   layer.IndexTileXSize,
+		IndexTileYSize:   layer.IndexTileYSize,
+		MetricsCollector: metricsCollector,
+	}
+
+	errChan := make(chan error, 100)
+	dp := proc.InitDrillPipeline(ctx, conf.ServiceConfig.MASAddress, conf.ServiceConfig.WorkerNodes, -1.0, -1.0, errChan)
+
+	bandStrides := layer.BandStrides
+	if bandStrides <= 0 {
+		bandStrides = 1
+	}
+
+	var csv string
+	select {
+	case csv = <-dp.Process(geoReq, "", "", bandStrides, true, "", "", *verbose):
+	case err := <-errChan:
+		return nil, fmt.Errorf("error in the drill pipeline: %v", err)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("context cancelled with message: %v", ctx.Err())
+	case <-timeoutCtx.Done():
+		return nil, fmt.Errorf("EDR request timed out, threshold: %v seconds", layer.EdrTimeout)
+	}
+
+	nCols := len(dataLayer.RGBExpressions.ExprNames)
+	if len(dataLayer.RGBExpressions.Expressions) == 0 {
+		nCols = len(dataLayer.RGBExpressions.VarList)
+	}
+
+	series := &utils.CovJSONSeries{Values: make([][]*float64, nCols)}
+	for _, line := range strings.Split(csv, "\n") {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		cols := strings.Split(line, ",")
+		if len(cols) != nCols+1 {
+			return nil, fmt.Errorf("unexpected drill result: %s", line)
+		}
+
+		series.Times = append(series.Times, cols[0])
+		for ic := 0; ic < nCols; ic++ {
+			var val *float64
+			var v float64
+			if _, err := fmt.Sscanf(cols[ic+1], "%f", &v); err == nil {
+				val = &v
+			}
+			series.Values[ic] = append(series.Values[ic], val)
+		}
+	}
+
+	return series, nil
+}
+
+// serveEDRCube renders the bbox at its native resolution
+// through the WCS tile pipeline and returns the grid.
+func serveEDRCube(ctx context.Context, timeoutCtx context.Context, params utils.EDRParams, conf *utils.Config, idx int, dataLayer *utils.Layer, paramNames []string, format string, w http.ResponseWriter, metricsCollector *metrics.MetricsCollector) {
+	layer := &conf.Layers[idx]
+	if len(params.BBox) != 4 {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, "EDR cube queries require a bbox", 400)
+		return
+	}
+
+	query := map[string][]string{"bbox": {fmt.Sprintf("%f,%f,%f,%f", params.BBox[0], params.BBox[1], params.BBox[2], params.BBox[3])}}
+	if params.DateTime != nil {
+		query["datetime"] = []string{*params.DateTime}
+	}
+	kvp, err := utils.OGCAPICoverageToWCS(layer, query)
+	if err != nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("Malformed EDR cube request: %v", err), 400)
+		return
+	}
+	wcsParams, err := utils.WCSParamsChecker(kvp, reWCSMap)
+	if err != nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("Malformed EDR cube request: %v", err), 400)
+		return
+	}
+
+	if wcsParams.Time == nil {
+		currentTime, err := utils.GetCurrentTimeStamp(layer.Dates)
+		if err != nil {
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		wcsParams.Time = currentTime
+	}
+
+	var endTime *time.Time
+	if layer.Accum == true {
+		step := time.Minute * time.Duration(60*24*layer.StepDays+60*layer.StepHours+layer.StepMinutes)
+		eT := wcsParams.Time.Add(step)
+		endTime = &eT
+	}
+
+	getGeoTileRequest := func(width int, height int) *proc.GeoTileRequest {
+		geoReq := &proc.GeoTileRequest{ConfigPayLoad: proc.ConfigPayLoad{NameSpaces: dataLayer.RGBExpressions.VarList,
+			BandExpr:            dataLayer.RGBExpressions,
+			Mask:                dataLayer.Mask,
+			ZoomLimit:           0.0,
+			PolygonSegments:     layer.WcsPolygonSegments,
+			GrpcConcLimit:       layer.GrpcWcsConcPerNode,
+			QueryLimit:          -1,
+			UserSrcSRS:          layer.UserSrcSRS,
+			UserSrcGeoTransform: layer.UserSrcGeoTransform,
+			GrpcTileXSize:       layer.GrpcTileXSize,
+			GrpcTileYSize:       layer.GrpcTileYSize,
+			IndexTileXSize:      layer.IndexTileXSize,
+			IndexTileYSize:      layer.IndexTileYSize,
+			SpatialExtent:       layer.SpatialExtent,
+			IndexResLimit:       layer.IndexResLimit,
+			MasQueryHint:        layer.MasQueryHint,
+			SRSCf:               layer.SRSCf,
+			FusionUnscale:       1,
+			MetricsCollector:    metricsCollector,
+		},
+			Collection: dataLayer.DataSource,
+			CRS:        *wcsParams.CRS,
+			BBox:       wcsParams.BBox,
+			OrigBBox:   wcsParams.BBox,
+			Height:     height,
+			Width:      width,
+			StartTime:  wcsParams.Time,
+			EndTime:    endTime,
+		}
+
+		if len(wcsParams.Axes) > 0 {
+			geoReq.Axes = make(map[string]*proc.GeoTileAxis)
+			for _, axis := range wcsParams.Axes {
+				geoReq.Axes[axis.Name] = &proc.GeoTileAxis{Start: axis.Start, End: axis.End, InValues: axis.InValues, Order: axis.Order, Aggregate: axis.Aggregate}
+			}
+		}
+		return geoReq
+	}
+
+	epsg, err := utils.ExtractEPSGCode(*wcsParams.CRS)
+	if err != nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("Invalid CRS code %s", *wcsParams.CRS), 400)
+		return
+	}
+
+	width, height, err := proc.ComputeReprojectionExtent(ctx, getGeoTileRequest(0, 0), conf.ServiceConfig.MASAddress, conf.ServiceConfig.WorkerNodes, epsg, wcsParams.BBox, *verbose)
+	if err != nil || width <= 0 || height <= 0 {
+		Info.Printf("EDR: failed to compute output extent: %v", err)
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, "EDR: failed to compute output extent", 500)
+		return
+	}
+
+	if width*height > layer.EdrMaxCells || width > layer.WcsMaxWidth || height > layer.WcsMaxHeight {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("The requested cube of %d x %d cells is too large, maximum cells: %d", width, height, layer.EdrMaxCells), 400)
+		return
+	}
+
+	errChan := make(chan error, 100)
+	tp := proc.InitTilePipeline(ctx, dataLayer.MASAddress, conf.ServiceConfig.WorkerNodes, layer.MaxGrpcRecvMsgSize, layer.WcsPolygonShardConcLimit, conf.ServiceConfig.MaxGrpcBufferSize, errChan)
+
+	var grid *utils.RasterGrid
+	select {
+	case res := <-tp.Process(getGeoTileRequest(width, height), *verbose):
+		grid, err = utils.NewRasterGrid(res, width, height)
+		if err != nil {
+			Info.Printf("EDR: %v\n", err)
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, err.Error(), 500)
+			return
+		}
+	case err := <-errChan:
+		Info.Printf("EDR: error in the pipeline: %v\n", err)
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, err.Error(), 500)
+		return
+	case <-ctx.Done():
+		Error.Printf("Context cancelled with message: %v\n", ctx.Err())
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, ctx.Err().Error(), 500)
+		return
+	case <-timeoutCtx.Done():
+		Error.Printf("EDR pipeline timed out, threshold:%v seconds", layer.EdrTimeout)
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, "EDR pipeline timed out", 500)
+		return
+	}
+
+	// Keep the requested parameters only
+	selected := &utils.RasterGrid{Width: grid.Width, Height: grid.Height, Times: grid.Times}
+	for ip, param := range grid.Parameters {
+		name := strings.SplitN(param, "#", 2)[0]
+		for _, p := range paramNames {
+			if p == name {
+				selected.Parameters = append(selected.Parameters, param)
+				selected.Values = append(selected.Values, grid.Values[ip])
+				break
+			}
+		}
+	}
+
+	nCells := len(selected.Parameters) * len(selected.Times) * width * height
+	if nCells > layer.EdrMaxCells {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("The requested cube of %d cells is too large, maximum cells: %d", nCells, layer.EdrMaxCells), 400)
+		return
+	}
+
+	if format != "csv" {
+		writeEDRCovJSON(w, utils.NewCovJSONGrid(selected, wcsParams.BBox, *wcsParams.CRS))
+		return
+	}
+
+	bbox := wcsParams.BBox
+	dx := (bbox[2] - bbox[0]) / float64(width)
+	dy := (bbox[3] - bbox[1]) / float64(height)
+
+	var csv strings.Builder
+	fmt.Fprintf(&csv, "datetime,x,y,%s\n", strings.Join(selected.Parameters, ","))
+	for it, ts := range selected.Times {
+		for iy := 0; iy < height; iy++ {
+			y := bbox[3] - (float64(iy)+0.5)*dy
+			for ix := 0; ix < width; ix++ {
+				x := bbox[0] + (float64(ix)+0.5)*dx
+				fmt.Fprintf(&csv, "%s,%f,%f", ts, x, y)
+				for ip := range selected.Parameters {
+					var val *float64
+					if data := selected.Values[ip][it]; data != nil && !math.IsNaN(data[iy*width+ix]) {
+						val = &data[iy*width+ix]
+					}
+					writeEDRValue(&csv, val)
+				}
+				fmt.Fprint(&csv, "\n")
+			}
+		}
+	}
+	writeEDROutput(w, "text/csv", []byte(csv.String()))
+}
+
+func writeEDRSeriesCSV(csv *strings.Builder, position string, series *utils.CovJSONSeries) {
+	for it, ts := range series.Times {
+		fmt.Fprint(csv, ts)
+		if len(position) > 0 {
+			fmt.Fprintf(csv, ",%s", position)
+		}
+		for ip := range series.Parameters {
+			writeEDRValue(csv, series.Values[ip][it])
+		}
+		fmt.Fprint(csv, "\n")
+	}
+}
+
+func writeEDRValue(csv *strings.Builder, val *float64) {
+	fmt.Fprint(csv, ",")
+	if val != nil {
+		fmt.Fprintf(csv, "%f", *val)
+	}
+}
+
+func writeEDRCovJSON(w http.ResponseWriter, doc interface{}) {
+	out, err := json.Marshal(doc)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	writeEDROutput(w, utils.CovJSONMediaType, out)
+}
+
+func writeEDROutput(w http.ResponseWriter, contentType string, out []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(out)))
+	w.Write(out)
+}
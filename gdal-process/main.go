@@ -72,6 +72,8 @@ func dataHandler(conn net.Conn, timeout int) {
 		out = gp.WarpRaster(in)
 	case "drill":
 		out = gp.DrillDataset(in)
+	case "point_drill":
+		out = gp.DrillPoints(in)
 	case "extent":
 		out = gp.ComputeReprojectExtent(in)
 	case "info":
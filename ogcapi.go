@@ -0,0 +1,582 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nci/gsky/metrics"
+	proc "github.com/nci/gsky/processor"
+	"github.com/nci/gsky/utils"
+)
+
+// ogcapiHandler serves the OGC API resources on
+// /ogcapi/{namespace}
+// /ogcapi/{namespace}/conformance
+// /ogcapi/{namespace}/tileMatrixSets[/{tileMatrixSetId}]
+// /ogcapi/{namespace}/collections
+// /ogcapi/{namespace}/collections/{collectionId}
+// /ogcapi/{namespace}/collections/{collectionId}/coverage
+// /ogcapi/{namespace}/collections/{collectionId}/coverage/domainset
+// /ogcapi/{namespace}/collections/{collectionId}/coverage/rangetype
+// /ogcapi/{namespace}/collections/{collectionId}[/styles/{styleId}]/map
+// /ogcapi/{namespace}/collections/{collectionId}[/styles/{styleId}]/map/tiles[/{tileMatrixSetId}[/{z}/{y}/{x}]]
+// /ogcapi/{namespace}/processes[/{processId}[/execution]]
+// /ogcapi/{namespace}/jobs[/{jobId}[/results]]
+// Each layer of the namespace is a collection. Coverage
+// requests are translated into WCS GetCoverage requests
+// while maps and map tiles are rendered by WMS GetMap.
+// Processes are the WPS processes of the namespace and
+// share their jobs with WPS asynchronous requests.
+func ogcapiHandler(w http.ResponseWriter, r *http.Request) {
+	var parts []string
+	for _, p := range strings.Split(r.URL.Path[len("/ogcapi/"):], "/") {
+		if len(p) > 0 {
+			parts = append(parts, p)
+		}
+	}
+
+	iRes := len(parts)
+	for i, p := range parts {
+		if p == "collections" || p == "conformance" || p == "tileMatrixSets" || p == "processes" || p == "jobs" {
+			iRes = i
+			break
+		}
+	}
+
+	namespace := "."
+	if iRes > 0 {
+		namespace = strings.Join(parts[:iRes], "/")
+	}
+
+	conf := getNamespaceConfig(namespace, w, r)
+	if conf == nil {
+		return
+	}
+
+	newConf := conf.Copy(r)
+	apiRoot := fmt.Sprintf("%s://%s/ogcapi", newConf.ServiceConfig.OWSProtocol, newConf.ServiceConfig.OWSHostname)
+	if namespace != "." {
+		apiRoot += "/" + namespace
+	}
+
+	if iRes == len(parts) {
+		writeOGCAPIJSON(w, &utils.OGCAPILandingPage{Title: "GSKY OGC API",
+			Description: "This service relies on GSKY - A Scalable, Distributed Geospatial Data Service.",
+			Links: []utils.OGCAPILink{
+				{Href: apiRoot, Rel: "self", Type: "application/json", Title: "This document"},
+				{Href: apiRoot + "/conformance", Rel: "conformance", Type: "application/json", Title: "Conformance classes"},
+				{Href: apiRoot + "/collections", Rel: "data", Type: "application/json", Title: "Collections"},
+				{Href: apiRoot + "/tileMatrixSets", Rel: "http://www.opengis.net/def/rel/ogc/1.0/tiling-schemes", Type: "application/json", Title: "Tile matrix sets"},
+				{Href: apiRoot + "/processes", Rel: "http://www.opengis.net/def/rel/ogc/1.0/processes", Type: "application/json", Title: "Processes"},
+				{Href: apiRoot + "/jobs", Rel: "http://www.opengis.net/def/rel/ogc/1.0/job-list", Type: "application/json", Title: "Jobs"},
+			}})
+		return
+	}
+
+	switch parts[iRes] {
+	case "conformance":
+		writeOGCAPIJSON(w, &utils.OGCAPIConformanceDoc{ConformsTo: utils.OGCAPIConformance})
+		return
+	case "tileMatrixSets":
+		rest := parts[iRes+1:]
+		if len(rest) == 0 {
+			tmsList := &utils.OGCAPITileMatrixSets{}
+			for _, tms := range utils.GetTileMatrixSets() {
+				tmsID := utils.OGCAPITileMatrixSetID(tms)
+				tmsList.TileMatrixSets = append(tmsList.TileMatrixSets, &utils.OGCAPITileMatrixSet{ID: tmsID,
+					Title: tms.Title,
+					URI:   "http://www.opengis.net/def/tilematrixset/OGC/1.0/" + tmsID,
+					Links: []utils.OGCAPILink{{Href: apiRoot + "/tileMatrixSets/" + tmsID, Rel: "self", Type: "application/json"}},
+				})
+			}
+			writeOGCAPIJSON(w, tmsList)
+			return
+		}
+		tms, err := utils.GetTileMatrixSet(rest[0])
+		if len(rest) > 1 || err != nil {
+			http.Error(w, fmt.Sprintf("Tile matrix set not found: %s", rest[0]), 404)
+			return
+		}
+		writeOGCAPIJSON(w, utils.NewOGCAPITileMatrixSet(tms))
+		return
+	case "processes":
+		serveOGCAPIProcesses(conf, apiRoot, parts[iRes+1:], w, r)
+		return
+	case "jobs":
+		serveOGCAPIJobs(conf, apiRoot, parts[iRes+1:], w, r)
+		return
+	}
+
+	err := utils.LoadConfigTimestamps(newConf, *verbose)
+	if err != nil {
+		log.Printf("OGC API LoadConfigTimestamps error: %v", err)
+	}
+
+	rest := parts[iRes+1:]
+	if len(rest) == 0 {
+		colls := &utils.OGCAPICollections{Links: []utils.OGCAPILink{{Href: apiRoot + "/collections", Rel: "self", Type: "application/json", Title: "This document"}}}
+		for iLayer := range conf.Layers {
+			hasCoverage := !utils.CheckDisableServices(&conf.Layers[iLayer], "wcs")
+			hasMap := !utils.CheckDisableServices(&conf.Layers[iLayer], "wms")
+			hasEDR := !utils.CheckDisableServices(&conf.Layers[iLayer], "edr")
+			if !hasCoverage && !hasMap && !hasEDR {
+				continue
+			}
+			layer := &newConf.Layers[iLayer]
+			colls.Collections = append(colls.Collections, utils.NewOGCAPICollection(layer, apiRoot+"/collections/"+layer.Name, hasCoverage, hasMap, hasEDR))
+		}
+		writeOGCAPIJSON(w, colls)
+		return
+	}
+
+	iLayer := -1
+	for i := range conf.Layers {
+		if conf.Layers[i].Name == rest[0] {
+			iLayer = i
+			break
+		}
+	}
+	var hasCoverage, hasMap, hasEDR bool
+	if iLayer >= 0 {
+		hasCoverage = !utils.CheckDisableServices(&conf.Layers[iLayer], "wcs")
+		hasMap = !utils.CheckDisableServices(&conf.Layers[iLayer], "wms")
+		hasEDR = !utils.CheckDisableServices(&conf.Layers[iLayer], "edr")
+	}
+	if !hasCoverage && !hasMap && !hasEDR {
+		http.Error(w, fmt.Sprintf("Collection not found: %s", rest[0]), 404)
+		return
+	}
+	layer := &newConf.Layers[iLayer]
+	collURL := apiRoot + "/collections/" + layer.Name
+
+	if len(rest) == 1 {
+		writeOGCAPIJSON(w, utils.NewOGCAPICollection(layer, collURL, hasCoverage, hasMap, hasEDR))
+		return
+	}
+
+	switch rest[1] {
+	case "position", "area", "cube", "trajectory":
+		if !hasEDR || len(rest) != 2 {
+			http.Error(w, fmt.Sprintf("EDR queries not available for collection: %s", layer.Name), 404)
+			return
+		}
+
+		query := r.URL.Query()
+		query.Set("service", "EDR")
+		query.Set("request", rest[1])
+		query.Set("collection", layer.Name)
+		r.URL.Path = "/ows/" + namespace
+		r.URL.RawQuery = query.Encode()
+		generalHandler(conf, w, r)
+		return
+	}
+
+	if rest[1] == "coverage" {
+		if !hasCoverage {
+			http.Error(w, fmt.Sprintf("Coverage not available for collection: %s", layer.Name), 404)
+			return
+		}
+
+		switch {
+		case len(rest) == 2:
+			kvp, err := utils.OGCAPICoverageToWCS(&conf.Layers[iLayer], r.URL.Query())
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Malformed coverage request: %v", err), 400)
+				return
+			}
+
+			// GetCoverage forwards the request URL to the
+			// cluster nodes, hence the rewrite into WCS KVP
+			r.URL.Path = "/ows/" + namespace
+			r.URL.RawQuery = kvp.Encode()
+			generalHandler(conf, w, r)
+		case len(rest) == 3 && rest[2] == "domainset":
+			writeOGCAPIJSON(w, utils.NewOGCAPIDomainSet(layer))
+		case len(rest) == 3 && rest[2] == "rangetype":
+			writeOGCAPIJSON(w, utils.NewOGCAPIRangeType(layer))
+		default:
+			http.Error(w, fmt.Sprintf("Invalid OGC API path: %s", r.URL.Path), 404)
+		}
+		return
+	}
+
+	style := ""
+	var mapRest []string
+	if rest[1] == "map" {
+		mapRest = rest[2:]
+	} else if rest[1] == "styles" && len(rest) >= 4 && rest[3] == "map" {
+		style = rest[2]
+		mapRest = rest[4:]
+	} else {
+		http.Error(w, fmt.Sprintf("Invalid OGC API path: %s", r.URL.Path), 404)
+		return
+	}
+
+	if !hasMap {
+		http.Error(w, fmt.Sprintf("Map not available for collection: %s", layer.Name), 404)
+		return
+	}
+	for i := range conf.Layers[iLayer].Styles {
+		if conf.Layers[iLayer].Styles[i].Name == style && utils.CheckDisableServices(&conf.Layers[iLayer].Styles[i], "wms") {
+			http.Error(w, fmt.Sprintf("Map not available for style: %s", style), 404)
+			return
+		}
+	}
+
+	mapURL := collURL + "/map"
+	if len(style) > 0 {
+		mapURL = collURL + "/styles/" + style + "/map"
+	}
+
+	if len(mapRest) == 0 {
+		kvp, err := utils.OGCAPIMapToWMS(&conf.Layers[iLayer], style, r.URL.Query())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Malformed map request: %v", err), 400)
+			return
+		}
+		serveOGCAPIMap(conf, namespace, kvp, w, r)
+		return
+	}
+
+	if mapRest[0] != "tiles" {
+		http.Error(w, fmt.Sprintf("Invalid OGC API path: %s", r.URL.Path), 404)
+		return
+	}
+
+	tileSet := func(tms *utils.TileMatrixSet) *utils.OGCAPITileSet {
+		tmsID := utils.OGCAPITileMatrixSetID(tms)
+		return &utils.OGCAPITileSet{Title: fmt.Sprintf("%s tiles in %s", layer.Title, tmsID),
+			DataType:         "map",
+			Crs:              utils.NewOGCAPITileMatrixSet(tms).Crs,
+			TileMatrixSetURI: "http://www.opengis.net/def/tilematrixset/OGC/1.0/" + tmsID,
+			Links: []utils.OGCAPILink{
+				{Href: mapURL + "/tiles/" + tmsID, Rel: "self", Type: "application/json"},
+				{Href: apiRoot + "/tileMatrixSets/" + tmsID, Rel: "http://www.opengis.net/def/rel/ogc/1.0/tiling-scheme", Type: "application/json"},
+				{Href: mapURL + "/tiles/" + tmsID + "/{tileMatrix}/{tileRow}/{tileCol}?f=png", Rel: "item", Type: "image/png", Templated: true},
+			},
+		}
+	}
+
+	switch len(mapRest) {
+	case 1:
+		tileSets := &utils.OGCAPITileSets{Links: []utils.OGCAPILink{{Href: mapURL + "/tiles", Rel: "self", Type: "application/json"}}}
+		for _, tms := range utils.GetTileMatrixSets() {
+			tileSets.TileSets = append(tileSets.TileSets, tileSet(tms))
+		}
+		writeOGCAPIJSON(w, tileSets)
+	case 2:
+		tms, err := utils.GetTileMatrixSet(mapRest[1])
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Tile matrix set not found: %s", mapRest[1]), 404)
+			return
+		}
+		writeOGCAPIJSON(w, tileSet(tms))
+	case 5:
+		tms, err := utils.GetTileMatrixSet(mapRest[1])
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Tile matrix set not found: %s", mapRest[1]), 404)
+			return
+		}
+
+		z, zErr := strconv.Atoi(mapRest[2])
+		y, yErr := strconv.Atoi(mapRest[3])
+		x, xErr := strconv.Atoi(strings.TrimSuffix(mapRest[4], ".png"))
+		if zErr != nil || yErr != nil || xErr != nil {
+			http.Error(w, fmt.Sprintf("Invalid tile path: %s, z, y and x must be integers", r.URL.Path), 400)
+			return
+		}
+
+		bbox, err := tms.TileBBox(z, y, x)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid tile: %v", err), 404)
+			return
+		}
+		tm := tms.TileMatrices[z]
+
+		query := r.URL.Query()
+		for _, key := range []string{"bbox", "bbox-crs", "crs", "width", "height"} {
+			delete(query, key)
+		}
+		kvp, err := utils.OGCAPIMapToWMS(&conf.Layers[iLayer], style, query)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Malformed map tile request: %v", err), 400)
+			return
+		}
+		kvp.Set("srs", tms.SRS)
+		kvp.Set("bbox", fmt.Sprintf("%f,%f,%f,%f", bbox[0], bbox[1], bbox[2], bbox[3]))
+		kvp.Set("width", fmt.Sprintf("%d", tm.TileWidth))
+		kvp.Set("height", fmt.Sprintf("%d", tm.TileHeight))
+		serveOGCAPIMap(conf, namespace, kvp, w, r)
+	default:
+		http.Error(w, fmt.Sprintf("Invalid OGC API path: %s", r.URL.Path), 404)
+	}
+}
+
+// serveOGCAPIMap renders a map through WMS GetMap. Query
+// parameters not consumed by the OGC API translation, such
+// as palette or colorscalerange, are passed through to WMS.
+func serveOGCAPIMap(conf *utils.Config, namespace string, kvp map[string][]string, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	for _, key := range utils.OGCAPIMapParams {
+		delete(query, key)
+	}
+	for key, val := range kvp {
+		query[key] = val
+	}
+
+	r.URL.Path = "/ows/" + namespace
+	r.URL.RawQuery = query.Encode()
+	generalHandler(conf, w, r)
+}
+
+func serveOGCAPIProcesses(conf *utils.Config, apiRoot string, rest []string, w http.ResponseWriter, r *http.Request) {
+	if len(rest) == 0 {
+		procList := &utils.OGCAPIProcessList{Processes: []*utils.OGCAPIProcessSummary{},
+			Links: []utils.OGCAPILink{{Href: apiRoot + "/processes", Rel: "self", Type: "application/json", Title: "This document"}},
+		}
+		for i := range conf.Processes {
+			process := &conf.Processes[i]
+			procList.Processes = append(procList.Processes, utils.NewOGCAPIProcessSummary(process, apiRoot+"/processes/"+process.Identifier))
+		}
+		writeOGCAPIJSON(w, procList)
+		return
+	}
+
+	var process *utils.Process
+	for i := range conf.Processes {
+		if conf.Processes[i].Identifier == rest[0] {
+			process = &conf.Processes[i]
+			break
+		}
+	}
+	if process == nil {
+		http.Error(w, fmt.Sprintf("Process not found: %s", rest[0]), 404)
+		return
+	}
+	procURL := apiRoot + "/processes/" + process.Identifier
+
+	switch {
+	case len(rest) == 1:
+		writeOGCAPIJSON(w, utils.NewOGCAPIProcess(process, procURL))
+	case len(rest) == 2 && rest[1] == "execution":
+		if r.Method != "POST" {
+			http.Error(w, "Process execution requires POST", 405)
+			return
+		}
+		executeOGCAPIProcess(conf, apiRoot, process, w, r)
+	default:
+		http.Error(w, fmt.Sprintf("Invalid OGC API path: %s", r.URL.Path), 404)
+	}
+}
+
+// executeOGCAPIProcess runs a process through the same
+// validation and drill pipeline as WPS Execute. Requests
+// preferring respond-async are submitted as jobs.
+func executeOGCAPIProcess(conf *utils.Config, apiRoot string, process *utils.Process, w http.ResponseWriter, r *http.Request) {
+	metricsCollector := metrics.NewMetricsCollector(metricsLogger)
+	defer metricsCollector.Log()
+
+	t0 := time.Now()
+	metricsCollector.Info.ReqTime = t0.Format(utils.ISOFormat)
+	defer func(t time.Time) { metricsCollector.Info.ReqDuration = time.Since(t) }(t0)
+
+	reqURL := r.URL.String()
+	metricsCollector.Info.URL.RawURL = reqURL
+	metricsCollector.Info.RemoteAddr = utils.ParseRemoteAddr(r)
+	metricsCollector.Info.HTTPStatus = 200
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("Failed to read execute request: %v", err), 400)
+		return
+	}
+
+	query, err := utils.OGCAPIExecuteToWPS(process, body)
+	if err != nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("Malformed execute request: %v", err), 400)
+		return
+	}
+
+	params, err := utils.WPSParamsChecker(query, reWPSMap)
+	if err != nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("Malformed execute request: %v", err), 400)
+		return
+	}
+
+	execProcess, feat, suffix, status, err := getWPSExecuteRequest(params, conf, reqURL, metricsCollector)
+	if err != nil {
+		metricsCollector.Info.HTTPStatus = status
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Prefer"), "respond-async") {
+		job, err := submitWPSJob(conf, execProcess, params, feat, suffix, metricsCollector)
+		if err != nil {
+			Error.Printf("OGC API: failed to submit job: %v\n", err)
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, err.Error(), 500)
+			return
+		}
+
+		out, err := json.Marshal(newOGCAPIStatusInfo(job, apiRoot))
+		if err != nil {
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		metricsCollector.Info.HTTPStatus = 201
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", apiRoot+"/jobs/"+job.ID)
+		w.Header().Set("Preference-Applied", "respond-async")
+		w.WriteHeader(201)
+		w.Write(out)
+		return
+	}
+
+	ctx, ctxCancel := context.WithCancel(r.Context())
+	defer ctxCancel()
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), time.Duration(execProcess.WpsTimeout)*time.Second)
+	defer timeoutCancel()
+
+	result, status, err := executeWPSProcess(ctx, timeoutCtx, conf, execProcess, params, feat, suffix, nil, metricsCollector)
+	if err != nil {
+		metricsCollector.Info.HTTPStatus = status
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	isRaw := params.Response != nil && *params.Response == "raw"
+	err = writeOGCAPIResults(w, result, isRaw)
+	if err != nil {
+		Error.Printf("OGC API: %v\n", err)
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, err.Error(), 500)
+	}
+}
+
+// serveOGCAPIJobs serves the jobs of the processes of the
+// namespace, including those submitted through WPS.
+func serveOGCAPIJobs(conf *utils.Config, apiRoot string, rest []string, w http.ResponseWriter, r *http.Request) {
+	isProcess := make(map[string]bool)
+	for i := range conf.Processes {
+		isProcess[conf.Processes[i].Identifier] = true
+	}
+
+	if len(rest) == 0 {
+		jobList := &utils.OGCAPIJobList{Jobs: []*utils.OGCAPIStatusInfo{},
+			Links: []utils.OGCAPILink{{Href: apiRoot + "/jobs", Rel: "self", Type: "application/json", Title: "This document"}},
+		}
+		for _, job := range wpsJobs.List(conf.ServiceConfig.NameSpace) {
+			if isProcess[job.ProcessID] {
+				jobList.Jobs = append(jobList.Jobs, newOGCAPIStatusInfo(job, apiRoot))
+			}
+		}
+		writeOGCAPIJSON(w, jobList)
+		return
+	}
+
+	job, err := wpsJobs.Get(conf.ServiceConfig.NameSpace, rest[0])
+	if err != nil || !isProcess[job.ProcessID] {
+		http.Error(w, fmt.Sprintf("Job not found: %s", rest[0]), 404)
+		return
+	}
+
+	switch {
+	case len(rest) == 1 && r.Method == "DELETE":
+		job, err = wpsJobs.Cancel(conf.ServiceConfig.NameSpace, job.ID)
+		if err != nil {
+			http.Error(w, err.Error(), 404)
+			return
+		}
+		writeOGCAPIJSON(w, newOGCAPIStatusInfo(job, apiRoot))
+	case len(rest) == 1:
+		writeOGCAPIJSON(w, newOGCAPIStatusInfo(job, apiRoot))
+	case len(rest) == 2 && rest[1] == "results":
+		if job.Status != proc.JobSucceeded {
+			msg := fmt.Sprintf("Result of job %s not ready, status: %s", job.ID, job.Status)
+			if job.Status == proc.JobFailed {
+				msg = fmt.Sprintf("Job %s failed: %s", job.ID, job.Message)
+			}
+			http.Error(w, msg, 404)
+			return
+		}
+
+		out, _, err := wpsJobs.Result(conf.ServiceConfig.NameSpace, job.ID)
+		if err == nil {
+			err = writeOGCAPIResults(w, string(out), false)
+		}
+		if err != nil {
+			Error.Printf("OGC API: %v\n", err)
+			http.Error(w, err.Error(), 500)
+		}
+	default:
+		http.Error(w, fmt.Sprintf("Invalid OGC API path: %s", r.URL.Path), 404)
+	}
+}
+
+func newOGCAPIStatusInfo(job *proc.Job, apiRoot string) *utils.OGCAPIStatusInfo {
+	jobURL := apiRoot + "/jobs/" + job.ID
+	info := &utils.OGCAPIStatusInfo{ProcessID: job.ProcessID,
+		Type:     "process",
+		JobID:    job.ID,
+		Status:   job.Status,
+		Message:  job.Message,
+		Created:  job.Created.Format(time.RFC3339),
+		Progress: job.PercentCompleted(),
+		Links:    []utils.OGCAPILink{{Href: jobURL, Rel: "self", Type: "application/json", Title: "Job status"}},
+	}
+	if !job.Started.IsZero() {
+		info.Started = job.Started.Format(time.RFC3339)
+	}
+	if !job.Finished.IsZero() {
+		info.Finished = job.Finished.Format(time.RFC3339)
+	}
+	if job.Status == proc.JobSucceeded {
+		info.Links = append(info.Links, utils.OGCAPILink{Href: jobURL + "/results", Rel: "http://www.opengis.net/def/rel/ogc/1.0/results", Type: "application/json", Title: "Job results"})
+	}
+	return info
+}
+
+// writeOGCAPIResults writes the outputs of a process as
+// an OGC API results document or, if raw, the value of
+// the first output.
+func writeOGCAPIResults(w http.ResponseWriter, result string, isRaw bool) error {
+	outputs, err := utils.ParseWPSOutputs(result)
+	if err != nil {
+		return err
+	}
+
+	if isRaw {
+		if len(outputs) == 0 {
+			return fmt.Errorf("process returned no outputs")
+		}
+		w.Header().Set("Content-Type", outputs[0].MimeType)
+		w.Write([]byte(outputs[0].Value))
+		return nil
+	}
+
+	writeOGCAPIJSON(w, utils.NewOGCAPIResults(outputs))
+	return nil
+}
+
+func writeOGCAPIJSON(w http.ResponseWriter, doc interface{}) {
+	out, err := json.Marshal(doc)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
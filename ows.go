@@ -49,6 +49,7 @@ var mutex *sync.Mutex
 var fileResolver *utils.RuntimeFileResolver
 var builtinPalettes *utils.BuiltinPalettes
 var mc *memcache.Client
+var wpsJobs *proc.JobManager
 var (
 	port            = flag.Int("p", 8080, "Server listening port.")
 	serverDataDir   = flag.String("data_dir", utils.DataDir, "Server data directory.")
@@ -60,11 +61,15 @@ var (
 	verbose         = flag.Bool("v", false, "Verbose mode for more server outputs.")
 	urlBase         = flag.String("url_base", "", "Advertise URLs relative to this server name and path. The default is to look this up from incoming request headers. Do not add a trailing slash")
 	version         = flag.Bool("version", false, "Get GSKY version")
+	wpsJobDir       = flag.String("wps_job_dir", "", "Directory storing the status and results of asynchronous WPS jobs, reloaded on restart. The default is a directory under the system temp directory.")
+	wpsJobExpiry    = flag.Int("wps_job_expiry", 86400, "Number of seconds the results of asynchronous WPS jobs are kept.")
 )
 
 var reWMSMap map[string]*regexp.Regexp
 var reWCSMap map[string]*regexp.Regexp
 var reWPSMap map[string]*regexp.Regexp
+var reWMTSMap map[string]*regexp.Regexp
+var reEDRMap map[string]*regexp.Regexp
 
 var (
 	Error *log.Logger
@@ -98,12 +103,22 @@ func init() {
 		"static/index.html",
 		"templates/WMS_GetCapabilities.tpl",
 		"templates/WMS_DescribeLayer.tpl",
+		"templates/WMS_GetFeatureInfo.tpl",
+		"templates/WMS_GetFeatureInfo_GML.tpl",
 		"templates/WMS_ServiceException.tpl",
+		"templates/WMTS_GetCapabilities.tpl",
 		"templates/WPS_DescribeProcess.tpl",
 		"templates/WPS_Execute.tpl",
+		"templates/WPS_ExecuteStatus.tpl",
+		"templates/WPS2_GetCapabilities.tpl",
+		"templates/WPS2_DescribeProcess.tpl",
+		"templates/WPS2_StatusInfo.tpl",
+		"templates/WPS2_Result.tpl",
 		"templates/WPS_GetCapabilities.tpl",
 		"templates/WCS_GetCapabilities.tpl",
 		"templates/WCS_DescribeCoverage.tpl",
+		"templates/WCS2_GetCapabilities.tpl",
+		"templates/WCS2_DescribeCoverage.tpl",
 		"zoom.png",
 	}
 
@@ -140,6 +155,20 @@ func init() {
 		mc = memcache.New(*mcURI)
 	}
 
+	jobDir := *wpsJobDir
+	if len(jobDir) == 0 {
+		jobDir = filepath.Join(os.TempDir(), "gsky_wps_jobs")
+		if rootConf, found := confMap["."]; found && rootConf != nil && len(rootConf.ServiceConfig.TempDir) > 0 {
+			jobDir = filepath.Join(rootConf.ServiceConfig.TempDir, "gsky_wps_jobs")
+		}
+	}
+	jobStore, err := proc.NewFileJobStore(jobDir)
+	if err != nil {
+		Error.Printf("Error in creating WPS job store: %v\n", err)
+		panic(err)
+	}
+	wpsJobs = proc.NewJobManager(jobStore, time.Duration(*wpsJobExpiry)*time.Second)
+
 	configMap = &sync.Map{}
 	configMap.Store("config", confMap)
 
@@ -152,6 +181,8 @@ func init() {
 	reWMSMap = utils.CompileWMSRegexMap()
 	reWCSMap = utils.CompileWCSRegexMap()
 	reWPSMap = utils.CompileWPSRegexMap()
+	reWMTSMap = utils.CompileWMTSRegexMap()
+	reEDRMap = utils.CompileEDRRegexMap()
 
 	utils.InitGdal()
 
@@ -284,31 +315,78 @@ func serveWMS(ctx context.Context, params utils.WMSParams, conf *utils.Config, r
 			params.Time = currentTime
 		}
 
-		var times []string
+		infoFormat := utils.FeatureInfoFormats[0]
+		if params.InfoFormat != nil {
+			infoFormat = *params.InfoFormat
+		}
+
+		info := &utils.FeatureInfo{X: x, Y: y}
+		if len(params.Layers) > 0 {
+			info.Layer = params.Layers[0]
+		}
+		if params.CRS != nil {
+			info.CRS = *params.CRS
+		}
 		for _, axis := range params.Axes {
 			if axis.Name == utils.WeightedTimeAxis {
 				for _, val := range axis.InValues {
-					t := time.Unix(int64(val), 0).UTC().Format(utils.ISOFormat)
-					times = append(times, fmt.Sprintf(`"%s"`, t))
+					info.Times = append(info.Times, time.Unix(int64(val), 0).UTC().Format(utils.ISOFormat))
 				}
 			}
 		}
-
-		var timeStr string
-		if len(times) > 0 {
-			timeStr = fmt.Sprintf(`"times": [%s]`, strings.Join(times, ","))
-		} else {
-			timeStr = fmt.Sprintf(`"time": "%s"`, (*params.Time).Format(utils.ISOFormat))
+		if len(info.Times) == 0 {
+			info.Time = (*params.Time).Format(utils.ISOFormat)
 		}
 
-		feat_info, err := proc.GetFeatureInfo(ctx, params, conf, getConfigMap(), *verbose, metricsCollector)
+		featInfo, err := proc.GetFeatureInfo(ctx, params, conf, getConfigMap(), *verbose, metricsCollector)
 		if err != nil {
-			feat_info = fmt.Sprintf(`"error": "%v"`, err)
+			info.Error = err.Error()
 			Error.Printf("%v\n", err)
+		} else {
+			info.Bands = featInfo.Bands
+			info.AvailableDates = featInfo.AvailableDates
+			info.DataLinks = featInfo.DataLinks
+		}
+
+		var resp []byte
+		var encErr error
+		switch infoFormat {
+		case utils.FeatureInfoHTML, utils.FeatureInfoGML:
+			tplPath := "templates/WMS_GetFeatureInfo.tpl"
+			if infoFormat == utils.FeatureInfoGML {
+				tplPath = "templates/WMS_GetFeatureInfo_GML.tpl"
+			}
+			tplPath, _ = fileResolver.Lookup(tplPath)
+			if infoFormat == utils.FeatureInfoHTML {
+				if idx, err := utils.GetLayerIndex(params, conf); err == nil {
+					styleLayer := &conf.Layers[idx]
+					if styleIdx, err := utils.GetLayerStyleIndex(params, conf, idx); err == nil && styleIdx >= 0 {
+						styleLayer = &conf.Layers[idx].Styles[styleIdx]
+					}
+					if len(styleLayer.FeatureInfoTemplate) > 0 {
+						tplPath = styleLayer.FeatureInfoTemplate
+					}
+				}
+			}
+			resp, encErr = utils.EncodeFeatureInfoTemplate(info, tplPath, infoFormat)
+		case utils.FeatureInfoText:
+			resp = utils.EncodeFeatureInfoText(info)
+		default:
+			resp, encErr = utils.EncodeFeatureInfoJSON(info)
+		}
+		if encErr != nil {
+			Error.Printf("%v\n", encErr)
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, encErr.Error(), 500)
+			return
 		}
 
-		resp := fmt.Sprintf(`{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"x":%f, "y":%f, %s, %s}}]}`, x, y, timeStr, feat_info)
-		w.Write([]byte(resp))
+		if infoFormat == utils.FeatureInfoHTML || infoFormat == utils.FeatureInfoText {
+			w.Header().Set("Content-Type", infoFormat+"; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", infoFormat)
+		}
+		w.Write(resp)
 
 	case "DescribeLayer":
 		conf = conf.Copy(r)
@@ -392,6 +470,14 @@ func serveWMS(ctx context.Context, params utils.WMSParams, conf *utils.Config, r
 			eT := params.Time.Add(step)
 			endTime = &eT
 		}
+		if params.EndTime != nil {
+			endTime = params.EndTime
+		}
+
+		var temporalAgg string
+		if params.Agg != nil {
+			temporalAgg = *params.Agg
+		}
 
 		if *params.Height > conf.Layers[idx].WmsMaxHeight || *params.Width > conf.Layers[idx].WmsMaxWidth {
 			http.Error(w, fmt.Sprintf("Requested width/height is too large, max width:%d, height:%d", conf.Layers[idx].WmsMaxWidth, conf.Layers[idx].WmsMaxHeight), 400)
@@ -427,27 +513,12 @@ func serveWMS(ctx context.Context, params utils.WMSParams, conf *utils.Config, r
 			scale = 0.0
 		}
 
-		palette := styleLayer.Palette
-		if params.Palette != nil {
-			palettes := styleLayer.Palettes
-			if len(palettes) == 0 {
-				palettes = builtinPalettes.Palettes
-			}
-			foundPalette := false
-			for _, p := range palettes {
-				if strings.ToLower(p.Name) == strings.ToLower(*params.Palette) {
-					palette = p
-					foundPalette = true
-					break
-				}
-			}
-			if !foundPalette {
-				msg := fmt.Sprintf("Requested palette not found: %s", *params.Palette)
-				Error.Printf(msg)
-				metricsCollector.Info.HTTPStatus = 400
-				http.Error(w, msg, 400)
-				return
-			}
+		palette, err := getStylePalette(styleLayer, params.Palette)
+		if err != nil {
+			Error.Printf("%v\n", err)
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, err.Error(), 400)
+			return
 		}
 
 		colourScale := styleLayer.ColourScale
@@ -461,6 +532,41 @@ func serveWMS(ctx context.Context, params utils.WMSParams, conf *utils.Config, r
 		}
 		reqRes := utils.GetPixelResolution(bbox, *params.Width, *params.Height)
 
+		resampling := styleLayer.Resampling
+		if params.Resampling != nil {
+			resampling = *params.Resampling
+		}
+
+		contour, err := utils.NewContourConfig(styleLayer.Contour, params.ContourInterval, params.ContourLevels)
+		if err != nil {
+			Error.Printf("%s\n", err)
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, fmt.Sprintf("Malformed WMS GetMap request: %v", err), 400)
+			return
+		}
+
+		isVectorTile := params.Format != nil && strings.ToLower(*params.Format) == utils.ContourMVTFormat
+		if isVectorTile && contour == nil {
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, "Vector tiles require either contour lines in the style or the contour_interval or contour_levels parameters", 400)
+			return
+		}
+
+		// Terrain rendering and contour lines need the
+		// neighbours of the pixels at the edges of the tile
+		reqBBox := params.BBox
+		reqWidth := *params.Width
+		reqHeight := *params.Height
+		padding := 0
+		if styleLayer.Terrain != nil || contour != nil {
+			padding = utils.TerrainPadding
+			xPad := (params.BBox[2] - params.BBox[0]) / float64(*params.Width) * float64(padding)
+			yPad := (params.BBox[3] - params.BBox[1]) / float64(*params.Height) * float64(padding)
+			reqBBox = []float64{params.BBox[0] - xPad, params.BBox[1] - yPad, params.BBox[2] + xPad, params.BBox[3] + yPad}
+			reqWidth += 2 * padding
+			reqHeight += 2 * padding
+		}
+
 		geoReq := &proc.GeoTileRequest{ConfigPayLoad: proc.ConfigPayLoad{NameSpaces: styleLayer.RGBExpressions.VarList,
 			BandExpr: styleLayer.RGBExpressions,
 			Mask:     styleLayer.Mask,
@@ -486,14 +592,16 @@ func serveWMS(ctx context.Context, params utils.WMSParams, conf *utils.Config, r
 			MasQueryHint:        conf.Layers[idx].MasQueryHint,
 			ReqRes:              reqRes,
 			SRSCf:               conf.Layers[idx].SRSCf,
+			TemporalAgg:         temporalAgg,
+			Resampling:          resampling,
 			MetricsCollector:    metricsCollector,
 		},
 			Collection:  styleLayer.DataSource,
 			CRS:         *params.CRS,
-			BBox:        params.BBox,
-			OrigBBox:    params.BBox,
-			Height:      *params.Height,
-			Width:       *params.Width,
+			BBox:        reqBBox,
+			OrigBBox:    reqBBox,
+			Height:      reqHeight,
+			Width:       reqWidth,
 			StartTime:   params.Time,
 			EndTime:     endTime,
 			ClipFeature: geojsonClipFeature,
@@ -527,6 +635,13 @@ func serveWMS(ctx context.Context, params utils.WMSParams, conf *utils.Config, r
 			geoReq.ConfigPayLoad.BandExpr = params.BandExpr
 		}
 
+		if len(temporalAgg) == 0 {
+			geoReq.Compositing = proc.NewCompositingParams(styleLayer, geoReq.NameSpaces, params.Time, false)
+			if geoReq.Compositing != nil {
+				geoReq.NameSpaces = append(append([]string{}, geoReq.NameSpaces...), geoReq.Compositing.ScoreVars...)
+			}
+		}
+
 		ctx, ctxCancel := context.WithCancel(ctx)
 		defer ctxCancel()
 		errChan := make(chan error, 100)
@@ -581,6 +696,59 @@ func serveWMS(ctx context.Context, params utils.WMSParams, conf *utils.Config, r
 				ColourScale: geoReq.ScaleParams.ColourScale,
 			}
 
+			var lines []utils.ContourLine
+			if contour != nil {
+				if len(res) != 1 {
+					msg := fmt.Sprintf("Contour lines require a single band, received %d", len(res))
+					Info.Printf(msg)
+					metricsCollector.Info.HTTPStatus = 500
+					http.Error(w, msg, 500)
+					return
+				}
+
+				lines, err = utils.RasterContours(res[0], contour, padding)
+				if err != nil {
+					Info.Printf("Error in the utils.RasterContours: %v\n", err)
+					metricsCollector.Info.HTTPStatus = 500
+					http.Error(w, err.Error(), 500)
+					return
+				}
+			}
+
+			if isVectorTile {
+				out, err := utils.EncodeContourMVT(lines, *params.Width, *params.Height, conf.Layers[idx].Name)
+				if err != nil {
+					Info.Printf("Error in the utils.EncodeContourMVT: %v\n", err)
+					metricsCollector.Info.HTTPStatus = 500
+					http.Error(w, err.Error(), 500)
+					return
+				}
+				w.Header().Set("Content-Type", utils.ContourMVTFormat)
+				w.Write(out)
+				return
+			}
+
+			var shade *utils.Float32Raster
+			if styleLayer.Terrain != nil {
+				res, shade, err = utils.RenderTerrain(res, styleLayer.Terrain, *params.CRS, params.BBox, *params.Width, *params.Height)
+				if err != nil {
+					Info.Printf("Error in the utils.RenderTerrain: %v\n", err)
+					metricsCollector.Info.HTTPStatus = 500
+					http.Error(w, err.Error(), 500)
+					return
+				}
+			} else if padding > 0 {
+				for i := range res {
+					res[i], err = utils.CropRaster(res[i], padding)
+					if err != nil {
+						Info.Printf("Error in the utils.CropRaster: %v\n", err)
+						metricsCollector.Info.HTTPStatus = 500
+						http.Error(w, err.Error(), 500)
+						return
+					}
+				}
+			}
+
 			norm, err := utils.Scale(res, scaleParams)
 			if err != nil {
 				Info.Printf("Error in the utils.Scale: %v\n", err)
@@ -589,6 +757,16 @@ func serveWMS(ctx context.Context, params utils.WMSParams, conf *utils.Config, r
 				return
 			}
 
+			if shade != nil && len(norm) == 1 {
+				norm, err = utils.BlendHillshade(norm[0], shade, palette)
+				if err != nil {
+					Info.Printf("Error in the utils.BlendHillshade: %v\n", err)
+					metricsCollector.Info.HTTPStatus = 500
+					http.Error(w, err.Error(), 500)
+					return
+				}
+			}
+
 			if len(norm) == 0 || norm[0].Width == 0 || norm[0].Height == 0 {
 				out, err := utils.GetEmptyTile(conf.Layers[idx].NoDataLegendPath, *params.Height, *params.Width)
 				if err != nil {
@@ -601,7 +779,12 @@ func serveWMS(ctx context.Context, params utils.WMSParams, conf *utils.Config, r
 				return
 			}
 
-			out, err := utils.EncodePNG(norm, palette)
+			var out []byte
+			if contour != nil {
+				out, err = utils.EncodeContourPNG(norm, palette, lines, contour)
+			} else {
+				out, err = utils.EncodePNG(norm, palette)
+			}
 			if err != nil {
 				Info.Printf("Error in the utils.EncodePNG: %v\n", err)
 				metricsCollector.Info.HTTPStatus = 500
@@ -650,14 +833,75 @@ func serveWMS(ctx context.Context, params utils.WMSParams, conf *utils.Config, r
 			styleLayer = &conf.Layers[idx].Styles[styleIdx]
 		}
 
-		b, err := ioutil.ReadFile(styleLayer.LegendPath)
-		if err != nil {
+		if len(styleLayer.LegendPath) > 0 {
+			b, err := ioutil.ReadFile(styleLayer.LegendPath)
+			if err == nil {
+				w.Write(b)
+				return
+			}
 			Error.Printf("Error reading legend image: %v, %v\n", styleLayer.LegendPath, err)
+		}
+
+		// Legends of single band styles without a static file
+		// are generated from their palette and scaling
+		if len(styleLayer.RGBProducts) != 1 {
 			metricsCollector.Info.HTTPStatus = 500
 			http.Error(w, "Legend graphics not found", 500)
 			return
 		}
-		w.Write(b)
+
+		palette, err := getStylePalette(styleLayer, params.Palette)
+		if err != nil {
+			Error.Printf("%v\n", err)
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, err.Error(), 400)
+			return
+		}
+
+		legend := &utils.LegendParams{Width: styleLayer.LegendWidth,
+			Height:      styleLayer.LegendHeight,
+			Orientation: styleLayer.LegendOrientation,
+			Title:       styleLayer.LegendTitle,
+			Units:       styleLayer.LegendUnits,
+			Ticks:       styleLayer.LegendTicks,
+			TickLabels:  styleLayer.LegendTickLabels,
+			TickCount:   styleLayer.LegendTickCount,
+			Palette:     palette,
+			Offset:      styleLayer.OffsetValue,
+			Scale:       styleLayer.ScaleValue,
+			Clip:        styleLayer.ClipValue,
+			ColourScale: styleLayer.ColourScale,
+		}
+		if len(legend.Title) == 0 {
+			legend.Title = styleLayer.Title
+		}
+		if params.Offset != nil && params.Clip != nil {
+			legend.Offset = *params.Offset
+			legend.Clip = *params.Clip
+			legend.Scale = 0.0
+		}
+		if params.ColourScale != nil {
+			legend.ColourScale = *params.ColourScale
+		}
+		if params.Width != nil && params.Height != nil {
+			if *params.Height > conf.Layers[idx].WmsMaxHeight || *params.Width > conf.Layers[idx].WmsMaxWidth {
+				metricsCollector.Info.HTTPStatus = 400
+				http.Error(w, fmt.Sprintf("Requested width/height is too large, max width:%d, height:%d", conf.Layers[idx].WmsMaxWidth, conf.Layers[idx].WmsMaxHeight), 400)
+				return
+			}
+			legend.Width = *params.Width
+			legend.Height = *params.Height
+		}
+
+		out, err := utils.EncodeLegend(legend)
+		if err != nil {
+			Error.Printf("%v\n", err)
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(out)
 
 	default:
 		metricsCollector.Info.HTTPStatus = 400
@@ -666,6 +910,26 @@ func serveWMS(ctx context.Context, params utils.WMSParams, conf *utils.Config, r
 
 }
 
+// getStylePalette returns the palette of a style or the palette
+// named in the request, which is looked up in the palettes of the
+// style or in the builtin palettes if the style has none.
+func getStylePalette(styleLayer *utils.Layer, name *string) (*utils.Palette, error) {
+	if name == nil {
+		return styleLayer.Palette, nil
+	}
+
+	palettes := styleLayer.Palettes
+	if len(palettes) == 0 {
+		palettes = builtinPalettes.Palettes
+	}
+	for _, p := range palettes {
+		if strings.ToLower(p.Name) == strings.ToLower(*name) {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("Requested palette not found: %s", *name)
+}
+
 func serveWCS(ctx context.Context, params utils.WCSParams, conf *utils.Config, r *http.Request, w http.ResponseWriter, query map[string][]string, metricsCollector *metrics.MetricsCollector) {
 	if params.Request == nil {
 		metricsCollector.Info.HTTPStatus = 400
@@ -678,7 +942,7 @@ func serveWCS(ctx context.Context, params utils.WCSParams, conf *utils.Config, r
 	case "GetCapabilities":
 		if params.Version != nil && !utils.CheckWCSVersion(*params.Version) {
 			metricsCollector.Info.HTTPStatus = 400
-			http.Error(w, fmt.Sprintf("This server can only accept WCS requests compliant with version 1.0.0: %s", reqURL), 400)
+			http.Error(w, fmt.Sprintf("This server can only accept WCS requests compliant with versions 1.0.0 and 2.0.1: %s", reqURL), 400)
 			return
 		}
 
@@ -723,7 +987,7 @@ func serveWCS(ctx context.Context, params utils.WCSParams, conf *utils.Config, r
 	case "GetCoverage":
 		if params.Version == nil || !utils.CheckWCSVersion(*params.Version) {
 			metricsCollector.Info.HTTPStatus = 400
-			http.Error(w, fmt.Sprintf("This server can only accept WCS requests compliant with version 1.0.0: %s", reqURL), 400)
+			http.Error(w, fmt.Sprintf("This server can only accept WCS requests compliant with versions 1.0.0 and 2.0.1: %s", reqURL), 400)
 			return
 		}
 
@@ -770,6 +1034,14 @@ func serveWCS(ctx context.Context, params utils.WCSParams, conf *utils.Config, r
 			eT := params.Time.Add(step)
 			endTime = &eT
 		}
+		if params.EndTime != nil {
+			endTime = params.EndTime
+		}
+
+		var temporalAgg string
+		if params.Agg != nil {
+			temporalAgg = *params.Agg
+		}
 
 		styleIdx, err := utils.GetCoverageStyleIndex(params, conf, idx)
 		if err != nil {
@@ -821,6 +1093,11 @@ func serveWCS(ctx context.Context, params utils.WCSParams, conf *utils.Config, r
 
 		_, isWorker := query["wbbox"]
 
+		resampling := styleLayer.Resampling
+		if params.Resampling != nil {
+			resampling = *params.Resampling
+		}
+
 		getGeoTileRequest := func(width int, height int, bbox []float64, offX int, offY int) *proc.GeoTileRequest {
 			geoReq := &proc.GeoTileRequest{ConfigPayLoad: proc.ConfigPayLoad{NameSpaces: styleLayer.RGBExpressions.VarList,
 				BandExpr: styleLayer.RGBExpressions,
@@ -847,6 +1124,8 @@ func serveWCS(ctx context.Context, params utils.WCSParams, conf *utils.Config, r
 				MasQueryHint:        conf.Layers[idx].MasQueryHint,
 				SRSCf:               conf.Layers[idx].SRSCf,
 				FusionUnscale:       1,
+				TemporalAgg:         temporalAgg,
+				Resampling:          resampling,
 				MetricsCollector:    metricsCollector,
 			},
 				Collection: styleLayer.DataSource,
@@ -877,6 +1156,13 @@ func serveWCS(ctx context.Context, params utils.WCSParams, conf *utils.Config, r
 				geoReq.ConfigPayLoad.BandExpr = params.BandExpr
 			}
 
+			if len(temporalAgg) == 0 {
+				geoReq.Compositing = proc.NewCompositingParams(styleLayer, geoReq.NameSpaces, params.Time, true)
+				if geoReq.Compositing != nil {
+					geoReq.NameSpaces = append(append([]string{}, geoReq.NameSpaces...), geoReq.Compositing.ScoreVars...)
+				}
+			}
+
 			return geoReq
 		}
 
@@ -932,6 +1218,18 @@ func serveWCS(ctx context.Context, params utils.WCSParams, conf *utils.Config, r
 			return
 		}
 
+		if strings.ToLower(*params.Format) == "geojson" {
+			geoReq := getGeoTileRequest(*params.Width, *params.Height, params.BBox, 0, 0)
+			serveWCSContours(ctx, params, conf, idx, styleLayer, geoReq, w, metricsCollector)
+			return
+		}
+
+		if format := strings.ToLower(*params.Format); format == "coveragejson" || format == "csv" {
+			geoReq := getGeoTileRequest(*params.Width, *params.Height, params.BBox, 0, 0)
+			serveWCSJSON(ctx, params, conf, idx, styleLayer, geoReq, w, metricsCollector)
+			return
+		}
+
 		if !isWorker {
 			if *params.Width > maxXTileSize || *params.Height > maxYTileSize {
 				tmpTileRequests := []*proc.GeoTileRequest{}
@@ -1117,8 +1415,14 @@ func serveWCS(ctx context.Context, params utils.WCSParams, conf *utils.Config, r
 
 		geot := utils.BBox2Geot(*params.Width, *params.Height, params.BBox)
 
+		// COGs, NetCDF files and Zarr stores are copied
+		// from a GeoTIFF once all the tiles are merged
 		driverFormat := *params.Format
-		if isWorker || driverFormat == "dap4" {
+		switch strings.ToLower(driverFormat) {
+		case "dap4", "cog", "netcdf", "zarr":
+			driverFormat = "geotiff"
+		}
+		if isWorker {
 			driverFormat = "geotiff"
 		}
 
@@ -1271,6 +1575,40 @@ func serveWCS(ctx context.Context, params utils.WCSParams, conf *utils.Config, r
 		utils.EncodeGdalClose(&hDstDS)
 		hDstDS = nil
 
+		if !isWorker && strings.ToLower(*params.Format) == "cog" {
+			cogFile, err := utils.EncodeGdalCOG(conf.ServiceConfig.TempDir, masterTempFile, conf.Layers[idx].COG)
+			if err != nil {
+				errMsg := fmt.Sprintf("EncodeGdalCOG() failed: %v", err)
+				Info.Printf(errMsg)
+				metricsCollector.Info.HTTPStatus = 500
+				http.Error(w, errMsg, 500)
+				return
+			}
+			defer utils.RemoveGdalTempFile(cogFile)
+			masterTempFile = cogFile
+		}
+
+		cfAttrs := map[string]interface{}{"Conventions": utils.CFConventions,
+			"title":  conf.Layers[idx].Title,
+			"source": "GSKY " + conf.Layers[idx].Name,
+		}
+		if len(conf.Layers[idx].Abstract) > 0 {
+			cfAttrs["summary"] = conf.Layers[idx].Abstract
+		}
+
+		if !isWorker && strings.ToLower(*params.Format) == "netcdf" {
+			ncFile, err := utils.EncodeNetCDF(conf.ServiceConfig.TempDir, masterTempFile, bandNames, conf.Layers[idx].NetCDF, cfAttrs)
+			if err != nil {
+				errMsg := fmt.Sprintf("EncodeNetCDF() failed: %v", err)
+				Info.Printf(errMsg)
+				metricsCollector.Info.HTTPStatus = 500
+				http.Error(w, errMsg, 500)
+				return
+			}
+			defer utils.RemoveGdalTempFile(ncFile)
+			masterTempFile = ncFile
+		}
+
 		if *params.Format == "dap4" {
 			err := utils.EncodeDap4(w, masterTempFile, bandNames, *verbose)
 			if err != nil {
@@ -1282,21 +1620,39 @@ func serveWCS(ctx context.Context, params utils.WCSParams, conf *utils.Config, r
 			return
 		}
 
+		ISOFormat := "2006-01-02T15:04:05.000Z"
+		fileNameDateTime := params.Time.Format(ISOFormat)
+
+		var re = regexp.MustCompile(`[^a-zA-Z0-9\-_\s]`)
+		fileNameCoverages := re.ReplaceAllString(params.Coverages[0], `-`)
+
+		if !isWorker && strings.ToLower(*params.Format) == "zarr" {
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s.zarr.zip", fileNameCoverages, fileNameDateTime))
+			w.Header().Set("Content-Type", "application/zip")
+
+			err := utils.EncodeZarr(w, masterTempFile, bandNames, conf.Layers[idx].Zarr, cfAttrs, *verbose)
+			if err != nil {
+				errMsg := fmt.Sprintf("EncodeZarr() failed: %v", err)
+				Info.Printf(errMsg)
+				metricsCollector.Info.HTTPStatus = 500
+				http.Error(w, errMsg, 500)
+			}
+			return
+		}
+
 		fileExt := "wcs"
 		contentType := "application/wcs"
 		switch strings.ToLower(*params.Format) {
 		case "geotiff":
 			fileExt = "tiff"
 			contentType = "application/geotiff"
+		case "cog":
+			fileExt = "tif"
+			contentType = "image/tiff; application=geotiff; profile=cloud-optimized"
 		case "netcdf":
 			fileExt = "nc"
 			contentType = "application/netcdf"
 		}
-		ISOFormat := "2006-01-02T15:04:05.000Z"
-		fileNameDateTime := params.Time.Format(ISOFormat)
-
-		var re = regexp.MustCompile(`[^a-zA-Z0-9\-_\s]`)
-		fileNameCoverages := re.ReplaceAllString(params.Coverages[0], `-`)
 
 		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s.%s", fileNameCoverages, fileNameDateTime, fileExt))
 		w.Header().Set("Content-Type", contentType)
@@ -1347,11 +1703,16 @@ func serveWPS(ctx context.Context, params utils.WPSParams, conf *utils.Config, r
 	}
 
 	reqURL := r.URL.String()
+	isV2 := params.Version != nil && *params.Version == "2.0.0"
 
 	switch *params.Request {
 	case "GetCapabilities":
 		newConf := conf.Copy(r)
-		tpl, _ := fileResolver.Lookup("templates/WPS_GetCapabilities.tpl")
+		tplFile := "templates/WPS_GetCapabilities.tpl"
+		if isV2 {
+			tplFile = "templates/WPS2_GetCapabilities.tpl"
+		}
+		tpl, _ := fileResolver.Lookup(tplFile)
 		err := utils.ExecuteWriteTemplateFile(w, newConf, tpl)
 		if err != nil {
 			metricsCollector.Info.HTTPStatus = 500
@@ -1366,206 +1727,514 @@ func serveWPS(ctx context.Context, params utils.WPSParams, conf *utils.Config, r
 			return
 		}
 		process := conf.Processes[idx]
-		tpl, _ := fileResolver.Lookup("templates/WPS_DescribeProcess.tpl")
+		tplFile := "templates/WPS_DescribeProcess.tpl"
+		if isV2 {
+			tplFile = "templates/WPS2_DescribeProcess.tpl"
+		}
+		tpl, _ := fileResolver.Lookup(tplFile)
 		err = utils.ExecuteWriteTemplateFile(w, process, tpl)
 		if err != nil {
 			metricsCollector.Info.HTTPStatus = 500
 			http.Error(w, err.Error(), 500)
 		}
 	case "Execute":
-		idx, err := utils.GetProcessIndex(params, conf)
+		process, feat, suffix, status, err := getWPSExecuteRequest(params, conf, reqURL, metricsCollector)
 		if err != nil {
-			Error.Printf("Requested process not found: %v, %v\n", err, reqURL)
-			metricsCollector.Info.HTTPStatus = 400
-			http.Error(w, fmt.Sprintf("%v: %s", err, reqURL), 400)
+			metricsCollector.Info.HTTPStatus = status
+			http.Error(w, err.Error(), status)
 			return
 		}
-		process := conf.Processes[idx]
-		if len(process.DataSources) == 0 {
-			Error.Printf("No data source specified")
-			metricsCollector.Info.HTTPStatus = 500
-			http.Error(w, "No data source specified", 500)
+
+		// Status updates are always available for stored
+		// responses, hence status alone does not make the
+		// request asynchronous.
+		isAsync := params.StoreExecuteResponse
+		if isV2 {
+			isAsync = params.Mode != nil && *params.Mode == "async"
+		}
+
+		if isAsync {
+			job, err := submitWPSJob(conf, process, params, feat, suffix, metricsCollector)
+			if err != nil {
+				Error.Printf("WPS: failed to submit job: %v\n", err)
+				metricsCollector.Info.HTTPStatus = 500
+				http.Error(w, err.Error(), 500)
+				return
+			}
+
+			writeWPSExecuteStatus(w, conf, r, process, job, isV2, metricsCollector)
 			return
 		}
 
-		if len(params.FeatCol.Features) == 0 {
-			Info.Printf("The request does not contain the 'feature' property.\n")
-			metricsCollector.Info.HTTPStatus = 400
-			http.Error(w, "The request does not contain the 'feature' property", 400)
+		ctx, ctxCancel := context.WithCancel(ctx)
+		defer ctxCancel()
+
+		timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), time.Duration(process.WpsTimeout)*time.Second)
+		defer timeoutCancel()
+
+		result, status, err := executeWPSProcess(ctx, timeoutCtx, conf, process, params, feat, suffix, nil, metricsCollector)
+		if err != nil {
+			metricsCollector.Info.HTTPStatus = status
+			http.Error(w, err.Error(), status)
 			return
 		}
 
-		var feat []byte
-		geom := params.FeatCol.Features[0].Geometry
-		switch geom := geom.(type) {
+		if isV2 {
+			writeWPSResult(w, result, nil, params.Response, metricsCollector)
+			return
+		}
 
-		case *geo.Point:
-			feat, _ = json.Marshal(&geo.Feature{Type: "Feature", Geometry: geom})
+		tpl, _ := fileResolver.Lookup("templates/WPS_Execute.tpl")
+		err = utils.ExecuteWriteTemplateFile(w, result, tpl)
+		if err != nil {
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, err.Error(), 500)
+		}
 
-		case *geo.Polygon, *geo.MultiPolygon:
-			area := utils.GetArea(geom)
-			metricsCollector.Info.Indexer.GeometryArea = area
-			if *verbose {
-				log.Println("Requested polygon has an area of", area)
-			}
-			if area == 0.0 || area > process.MaxArea {
-				Info.Printf("The requested area %.02f, is too large.\n", area)
+	case "GetExecuteStatus", "CancelExecute", "GetStatus", "GetResult", "Dismiss":
+		if params.JobID == nil {
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, "Malformed WPS, a jobid needs to be specified", 400)
+			return
+		}
+
+		var job *proc.Job
+		var err error
+		switch *params.Request {
+		case "CancelExecute", "Dismiss":
+			job, err = wpsJobs.Cancel(conf.ServiceConfig.NameSpace, *params.JobID)
+		default:
+			job, err = wpsJobs.Get(conf.ServiceConfig.NameSpace, *params.JobID)
+		}
+		if err != nil {
+			metricsCollector.Info.HTTPStatus = 404
+			http.Error(w, err.Error(), 404)
+			return
+		}
+
+		if *params.Request == "GetResult" {
+			if job.Status != proc.JobSucceeded {
+				msg := fmt.Sprintf("Result of job %s not ready, status: %s", job.ID, job.Status)
+				if job.Status == proc.JobFailed {
+					msg = fmt.Sprintf("Job %s failed: %s", job.ID, job.Message)
+				}
 				metricsCollector.Info.HTTPStatus = 400
-				http.Error(w, "The requested area is too large. Please try with a smaller one.", 400)
+				http.Error(w, msg, 400)
 				return
 			}
-			feat, _ = json.Marshal(&geo.Feature{Type: "Feature", Geometry: geom})
 
-		default:
-			metricsCollector.Info.HTTPStatus = 400
-			http.Error(w, "Geometry not supported. Only Features containing Polygon or MultiPolygon are available..", 400)
+			out, _, err := wpsJobs.Result(conf.ServiceConfig.NameSpace, job.ID)
+			if err != nil {
+				Error.Printf("WPS: %v\n", err)
+				metricsCollector.Info.HTTPStatus = 500
+				http.Error(w, err.Error(), 500)
+				return
+			}
+			writeWPSResult(w, string(out), job, params.Response, metricsCollector)
 			return
 		}
 
-		var result strings.Builder
-		ctx, ctxCancel := context.WithCancel(ctx)
-		defer ctxCancel()
+		process := &utils.Process{Identifier: job.ProcessID}
+		for i := range conf.Processes {
+			if conf.Processes[i].Identifier == job.ProcessID {
+				process = &conf.Processes[i]
+				break
+			}
+		}
+		writeWPSExecuteStatus(w, conf, r, process, job, isV2, metricsCollector)
 
-		timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), time.Duration(process.WpsTimeout)*time.Second)
-		defer timeoutCancel()
+	default:
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("%s not recognised.", *params.Request), 400)
+	}
+}
 
-		errChan := make(chan error, 100)
+// getWPSExecuteRequest validates the inputs of an Execute
+// request and returns the process along with the GeoJSON
+// feature to drill and the suffix of the outputs. On failure
+// it returns the HTTP status code of the error.
+func getWPSExecuteRequest(params utils.WPSParams, conf *utils.Config, reqURL string, metricsCollector *metrics.MetricsCollector) (*utils.Process, []byte, string, int, error) {
+	idx, err := utils.GetProcessIndex(params, conf)
+	if err != nil {
+		Error.Printf("Requested process not found: %v, %v\n", err, reqURL)
+		return nil, nil, "", 400, fmt.Errorf("%v: %s", err, reqURL)
+	}
+	process := conf.Processes[idx]
+	if len(process.DataSources) == 0 {
+		Error.Printf("No data source specified")
+		return nil, nil, "", 500, fmt.Errorf("No data source specified")
+	}
 
-		var suffix string
-		if params.GeometryId != nil {
-			geoId := strings.TrimSpace(*params.GeometryId)
-			if len(geoId) > 0 {
-				suffix = fmt.Sprintf("%s", geoId)
+	if len(params.FeatCol.Features) == 0 {
+		Info.Printf("The request does not contain the 'feature' property.\n")
+		return nil, nil, "", 400, fmt.Errorf("The request does not contain the 'feature' property")
+	}
+
+	// Zonal statistics are computed for every feature
+	// whereas other processes only drill the first one
+	if process.PixelStat == "zonal" {
+		if len(params.FeatCol.Features) > process.ZonalMaxFeatures {
+			return nil, nil, "", 400, fmt.Errorf("The request contains %d features, the maximum is %d", len(params.FeatCol.Features), process.ZonalMaxFeatures)
+		}
+
+		totalArea := 0.0
+		for i, f := range params.FeatCol.Features {
+			switch geom := f.Geometry.(type) {
+			case *geo.Polygon, *geo.MultiPolygon:
+				area := utils.GetArea(geom)
+				if area == 0.0 || area > process.MaxArea {
+					Info.Printf("The requested area %.02f of feature %d, is too large.\n", area, i)
+					return nil, nil, "", 400, fmt.Errorf("The requested area of feature %d is too large. Please try with a smaller one.", i)
+				}
+				totalArea += area
+			default:
+				return nil, nil, "", 400, fmt.Errorf("Geometry of feature %d not supported. Only Features containing Polygon or MultiPolygon are available.", i)
 			}
 		}
-		if len(suffix) < 2 {
-			suffix = fmt.Sprintf("%04d", rand.Intn(1000))
+		metricsCollector.Info.Indexer.GeometryArea = totalArea
+	}
+
+	// Point drills sample every point of the request,
+	// MultiPoint features being split into points
+	if process.PixelStat == "point" {
+		if len(params.FeatCol.Features) > process.PointMaxPoints {
+			return nil, nil, "", 400, fmt.Errorf("The request contains %d points, the maximum is %d", len(params.FeatCol.Features), process.PointMaxPoints)
 		}
 
-		for ids, dataSource := range process.DataSources {
-			if *verbose {
-				log.Printf("WPS: Processing '%v' (%d of %d)", dataSource.DataSource, ids+1, len(process.DataSources))
+		for i, f := range params.FeatCol.Features {
+			if _, isPoint := f.Geometry.(*geo.Point); !isPoint {
+				return nil, nil, "", 400, fmt.Errorf("Geometry of feature %d not supported. Only Features containing Point or MultiPoint are available.", i)
 			}
+		}
+	}
 
-			startDateTime := time.Time{}
-			stStartInput, errStartInput := time.Parse(utils.ISOFormat, *params.StartDateTime)
-			if errStartInput != nil {
-				if len(*params.StartDateTime) > 0 {
-					log.Printf("WPS: invalid input start date '%v' with error '%v'", *params.StartDateTime, errStartInput)
-				}
-				startDateTimeStr := strings.TrimSpace(dataSource.StartISODate)
-				if len(startDateTimeStr) > 0 {
-					st, errStart := time.Parse(utils.ISOFormat, startDateTimeStr)
-					if errStart != nil {
-						if *verbose {
-							log.Printf("WPS: Failed to parse start date '%v' into ISO format with error: %v, defaulting to no start date", startDateTimeStr, errStart)
-						}
-					} else {
-						startDateTime = st
-					}
+	// Transects are sampled along the line of the first feature
+	if _, isLine := params.FeatCol.Features[0].Geometry.(*geo.LineString); process.PixelStat == "transect" && !isLine {
+		return nil, nil, "", 400, fmt.Errorf("Geometry not supported. Only Features containing LineString are available.")
+	}
+
+	var feat []byte
+	geom := params.FeatCol.Features[0].Geometry
+	switch geom := geom.(type) {
+
+	case *geo.Point:
+		feat, _ = json.Marshal(&geo.Feature{Type: "Feature", Geometry: geom})
+
+	case *geo.LineString:
+		if process.PixelStat != "transect" {
+			return nil, nil, "", 400, fmt.Errorf("Geometry not supported. Only Features containing Polygon or MultiPolygon are available..")
+		}
+		feat, _ = json.Marshal(&geo.Feature{Type: "Feature", Geometry: geom})
+
+	case *geo.Polygon, *geo.MultiPolygon:
+		area := utils.GetArea(geom)
+		if process.PixelStat != "zonal" {
+			metricsCollector.Info.Indexer.GeometryArea = area
+		}
+		if *verbose {
+			log.Println("Requested polygon has an area of", area)
+		}
+		if area == 0.0 || area > process.MaxArea {
+			Info.Printf("The requested area %.02f, is too large.\n", area)
+			return nil, nil, "", 400, fmt.Errorf("The requested area is too large. Please try with a smaller one.")
+		}
+		feat, _ = json.Marshal(&geo.Feature{Type: "Feature", Geometry: geom})
+
+	default:
+		return nil, nil, "", 400, fmt.Errorf("Geometry not supported. Only Features containing Polygon or MultiPolygon are available..")
+	}
+
+	var suffix string
+	if params.GeometryId != nil {
+		geoId := strings.TrimSpace(*params.GeometryId)
+		if len(geoId) > 0 {
+			suffix = fmt.Sprintf("%s", geoId)
+		}
+	}
+	if len(suffix) < 2 {
+		suffix = fmt.Sprintf("%04d", rand.Intn(1000))
+	}
+
+	return &process, feat, suffix, 200, nil
+}
+
+// submitWPSJob runs a process asynchronously through
+// the WPS job manager.
+func submitWPSJob(conf *utils.Config, process *utils.Process, params utils.WPSParams, feat []byte, suffix string, metricsCollector *metrics.MetricsCollector) (*proc.Job, error) {
+	// The job outlives the HTTP request, hence its own
+	// metrics which are logged once the job finishes
+	jobMetrics := metrics.NewMetricsCollector(metricsLogger)
+	jobMetrics.Info.URL.RawURL = metricsCollector.Info.URL.RawURL
+	jobMetrics.Info.RemoteAddr = metricsCollector.Info.RemoteAddr
+	jobMetrics.Info.HTTPStatus = 200
+
+	timeout := time.Duration(process.WpsAsyncTimeout) * time.Second
+	return wpsJobs.Submit(conf.ServiceConfig.NameSpace, process.Identifier, timeout, func(jobCtx context.Context, progress *proc.DrillProgress) ([]byte, string, error) {
+		t0 := time.Now()
+		jobMetrics.Info.ReqTime = t0.Format(utils.ISOFormat)
+		defer func() {
+			jobMetrics.Info.ReqDuration = time.Since(t0)
+			jobMetrics.Log()
+		}()
+
+		result, status, err := executeWPSProcess(jobCtx, jobCtx, conf, process, params, feat, suffix, progress, jobMetrics)
+		if err != nil {
+			jobMetrics.Info.HTTPStatus = status
+			return nil, "", err
+		}
+		return []byte(result), "text/xml", nil
+	})
+}
+
+// executeWPSProcess drills every data source of a process over
+// the requested feature and returns the concatenated outputs.
+// On failure it returns the HTTP status code of the error.
+func executeWPSProcess(ctx context.Context, timeoutCtx context.Context, conf *utils.Config, process *utils.Process, params utils.WPSParams, feat []byte, suffix string, progress *proc.DrillProgress, metricsCollector *metrics.MetricsCollector) (string, int, error) {
+	switch process.PixelStat {
+	case "zonal":
+		return executeZonalStats(ctx, timeoutCtx, conf, process, params, progress)
+	case "point":
+		return executePointDrill(ctx, timeoutCtx, conf, process, params, progress, metricsCollector)
+	case "transect":
+		return executeTransect(ctx, timeoutCtx, conf, process, params, progress, metricsCollector)
+	}
+
+	var result strings.Builder
+	for ids := range process.DataSources {
+		dataSource := &process.DataSources[ids]
+		if *verbose {
+			log.Printf("WPS: Processing '%v' (%d of %d)", dataSource.DataSource, ids+1, len(process.DataSources))
+		}
+
+		geoReq, err := newWPSDrillRequest(process, dataSource, params, feat, metricsCollector)
+		if err != nil {
+			return "", 400, err
+		}
+
+		res, err := runWPSDrill(ctx, timeoutCtx, conf, process, dataSource, geoReq, suffix, dataSource.MetadataURL, wpsDrillApprox(process), progress)
+		if err != nil {
+			return "", 500, err
+		}
+		result.WriteString(res)
+	}
+
+	return result.String(), 200, nil
+}
+
+// wpsDrillApprox returns whether the drill of a process may
+// use the approximated means of the index. Percentiles and
+// histograms need the pixels, as do the zonal statistics.
+func wpsDrillApprox(process *utils.Process) bool {
+	if len(process.Percentiles) > 0 || len(process.HistogramBins) > 1 {
+		return false
+	}
+	return *process.Approx
+}
+
+// newWPSDrillRequest builds the drill request of a data
+// source from the dates and clipping bounds of a request.
+func newWPSDrillRequest(process *utils.Process, dataSource *utils.Layer, params utils.WPSParams, feat []byte, metricsCollector *metrics.MetricsCollector) (proc.GeoDrillRequest, error) {
+	startDateTime := time.Time{}
+	stStartInput, errStartInput := time.Parse(utils.ISOFormat, *params.StartDateTime)
+	if errStartInput != nil {
+		if len(*params.StartDateTime) > 0 {
+			log.Printf("WPS: invalid input start date '%v' with error '%v'", *params.StartDateTime, errStartInput)
+		}
+		startDateTimeStr := strings.TrimSpace(dataSource.StartISODate)
+		if len(startDateTimeStr) > 0 {
+			st, errStart := time.Parse(utils.ISOFormat, startDateTimeStr)
+			if errStart != nil {
+				if *verbose {
+					log.Printf("WPS: Failed to parse start date '%v' into ISO format with error: %v, defaulting to no start date", startDateTimeStr, errStart)
 				}
 			} else {
-				startDateTime = stStartInput
+				startDateTime = st
 			}
+		}
+	} else {
+		startDateTime = stStartInput
+	}
 
-			endDateTime := time.Now().UTC()
-			stEndInput, errEndInput := time.Parse(utils.ISOFormat, *params.EndDateTime)
-			if errEndInput != nil {
-				if len(*params.EndDateTime) > 0 {
-					if *verbose {
-						log.Printf("WPS: invalid input end date '%v' with error '%v'", *params.EndDateTime, errEndInput)
-					}
-				}
-				endDateTimeStr := strings.TrimSpace(dataSource.EndISODate)
-				if len(endDateTimeStr) > 0 && strings.ToLower(endDateTimeStr) != "now" {
-					dt, errEnd := time.Parse(utils.ISOFormat, endDateTimeStr)
-					if errEnd != nil {
-						if *verbose {
-							log.Printf("WPS: Failed to parse end date '%s' into ISO format with error: %v, defaulting to now()", endDateTimeStr, errEnd)
-						}
-					} else {
-						endDateTime = dt
-					}
+	endDateTime := time.Now().UTC()
+	stEndInput, errEndInput := time.Parse(utils.ISOFormat, *params.EndDateTime)
+	if errEndInput != nil {
+		if len(*params.EndDateTime) > 0 {
+			if *verbose {
+				log.Printf("WPS: invalid input end date '%v' with error '%v'", *params.EndDateTime, errEndInput)
+			}
+		}
+		endDateTimeStr := strings.TrimSpace(dataSource.EndISODate)
+		if len(endDateTimeStr) > 0 && strings.ToLower(endDateTimeStr) != "now" {
+			dt, errEnd := time.Parse(utils.ISOFormat, endDateTimeStr)
+			if errEnd != nil {
+				if *verbose {
+					log.Printf("WPS: Failed to parse end date '%s' into ISO format with error: %v, defaulting to now()", endDateTimeStr, errEnd)
 				}
 			} else {
-				if !time.Time.IsZero(stEndInput) {
-					endDateTime = stEndInput
-				}
+				endDateTime = dt
 			}
+		}
+	} else {
+		if !time.Time.IsZero(stEndInput) {
+			endDateTime = stEndInput
+		}
+	}
 
-			clipUpper := float32(math.MaxFloat32)
-			if cu, cuOk := params.ClipUppers[fmt.Sprintf("%s_clip_upper", dataSource.Name)]; cuOk {
-				clipUpper = cu
-			}
+	clipUpper := float32(math.MaxFloat32)
+	if cu, cuOk := params.ClipUppers[fmt.Sprintf("%s_clip_upper", dataSource.Name)]; cuOk {
+		clipUpper = cu
+	}
 
-			clipLower := float32(-math.MaxFloat32)
-			if cl, clOk := params.ClipLowers[fmt.Sprintf("%s_clip_lower", dataSource.Name)]; clOk {
-				clipLower = cl
-			}
+	clipLower := float32(-math.MaxFloat32)
+	if cl, clOk := params.ClipLowers[fmt.Sprintf("%s_clip_lower", dataSource.Name)]; clOk {
+		clipLower = cl
+	}
 
-			if clipLower > clipUpper {
-				metricsCollector.Info.HTTPStatus = 400
-				http.Error(w, "clipLower greater than clipUpper", 400)
-				return
-			}
+	if clipLower > clipUpper {
+		return proc.GeoDrillRequest{}, fmt.Errorf("clipLower greater than clipUpper")
+	}
 
-			geoReq := proc.GeoDrillRequest{Geometry: string(feat),
-				CRS:              "EPSG:4326",
-				Collection:       dataSource.DataSource,
-				NameSpaces:       dataSource.RGBExpressions.VarList,
-				BandExpr:         dataSource.RGBExpressions,
-				Mask:             dataSource.Mask,
-				VRTURL:           dataSource.VRTURL,
-				StartTime:        startDateTime,
-				EndTime:          endDateTime,
-				ClipUpper:        clipUpper,
-				ClipLower:        clipLower,
-				RasterXSize:      dataSource.RasterXSize,
-				RasterYSize:      dataSource.RasterYSize,
-				GrpcConcLimit:    dataSource.GrpcWpsConcPerNode,
-				IndexTileXSize:   dataSource.IndexTileXSize,
-				IndexTileYSize:   dataSource.IndexTileYSize,
-				MetricsCollector: metricsCollector,
-			}
+	geoReq := proc.GeoDrillRequest{Geometry: string(feat),
+		CRS:              "EPSG:4326",
+		Collection:       dataSource.DataSource,
+		NameSpaces:       dataSource.RGBExpressions.VarList,
+		BandExpr:         dataSource.RGBExpressions,
+		Mask:             dataSource.Mask,
+		VRTURL:           dataSource.VRTURL,
+		StartTime:        startDateTime,
+		EndTime:          endDateTime,
+		ClipUpper:        clipUpper,
+		ClipLower:        clipLower,
+		RasterXSize:      dataSource.RasterXSize,
+		RasterYSize:      dataSource.RasterYSize,
+		GrpcConcLimit:    dataSource.GrpcWpsConcPerNode,
+		IndexTileXSize:   dataSource.IndexTileXSize,
+		IndexTileYSize:   dataSource.IndexTileYSize,
+		Percentiles:      process.Percentiles,
+		HistogramBins:    process.HistogramBins,
+		MetricsCollector: metricsCollector,
+	}
 
-			dp := proc.InitDrillPipeline(ctx, conf.ServiceConfig.MASAddress, conf.ServiceConfig.WorkerNodes, process.IdentityTol, process.DpTol, errChan)
+	return geoReq, nil
+}
 
-			if dataSource.BandStrides <= 0 {
-				dataSource.BandStrides = 1
-			}
-			proc := dp.Process(geoReq, suffix, dataSource.MetadataURL, dataSource.BandStrides, *process.Approx, process.DrillAlgorithm, process.PixelStat, *verbose)
+// runWPSDrill drills a data source through the drill pipeline.
+// Without a template the merged CSV is returned as is.
+func runWPSDrill(ctx context.Context, timeoutCtx context.Context, conf *utils.Config, process *utils.Process, dataSource *utils.Layer, geoReq proc.GeoDrillRequest, suffix string, templateFileName string, approx bool, progress *proc.DrillProgress) (string, error) {
+	errChan := make(chan error, 100)
+	dp := proc.InitDrillPipeline(ctx, conf.ServiceConfig.MASAddress, conf.ServiceConfig.WorkerNodes, process.IdentityTol, process.DpTol, errChan)
+	dp.Progress = progress
 
-			select {
-			case res := <-proc:
-				result.WriteString(res)
-			case err := <-errChan:
-				Info.Printf("Error in the pipeline: %v\n", err)
-				metricsCollector.Info.HTTPStatus = 500
-				http.Error(w, err.Error(), 500)
-				return
-			case <-ctx.Done():
-				Error.Printf("Context cancelled with message: %v\n", ctx.Err())
-				metricsCollector.Info.HTTPStatus = 500
-				http.Error(w, ctx.Err().Error(), 500)
-				return
-			case <-timeoutCtx.Done():
-				Error.Printf("WPS pipeline timed out, threshold:%v seconds", process.WpsTimeout)
-				metricsCollector.Info.HTTPStatus = 500
-				http.Error(w, "WPS request timed out", 500)
-				return
-			}
+	bandStrides := dataSource.BandStrides
+	if bandStrides <= 0 {
+		bandStrides = 1
+	}
+	proc := dp.Process(geoReq, suffix, templateFileName, bandStrides, approx, process.DrillAlgorithm, process.PixelStat, *verbose)
+	return waitWPSPipeline(ctx, timeoutCtx, process, proc, errChan)
+}
+
+// waitWPSPipeline waits for the output of a drill pipeline.
+func waitWPSPipeline(ctx context.Context, timeoutCtx context.Context, process *utils.Process, out chan string, errChan chan error) (string, error) {
+	select {
+	case res := <-out:
+		return res, nil
+	case err := <-errChan:
+		Info.Printf("Error in the pipeline: %v\n", err)
+		return "", err
+	case <-ctx.Done():
+		Error.Printf("Context cancelled with message: %v\n", ctx.Err())
+		return "", ctx.Err()
+	case <-timeoutCtx.Done():
+		Error.Printf("WPS pipeline timed out, threshold:%v seconds", process.WpsTimeout)
+		return "", fmt.Errorf("WPS request timed out")
+	}
+}
+
+// writeWPSExecuteStatus writes the ExecuteResponse of an
+// asynchronous Execute request including the outputs if
+// the job has succeeded. WPS 2.0 requests get a StatusInfo
+// document instead whereas the outputs are retrieved by
+// GetResult.
+func writeWPSExecuteStatus(w http.ResponseWriter, conf *utils.Config, r *http.Request, process *utils.Process, job *proc.Job, isV2 bool, metricsCollector *metrics.MetricsCollector) {
+	newConf := conf.Copy(r)
+	serviceInstance := fmt.Sprintf("%s://%s/ows/%s", newConf.ServiceConfig.OWSProtocol, newConf.ServiceConfig.OWSHostname, newConf.ServiceConfig.NameSpace)
+
+	status := &utils.WPSExecuteStatus{Process: process,
+		JobID:            job.ID,
+		Status:           job.Status,
+		Message:          job.Message,
+		PercentCompleted: job.PercentCompleted(),
+		CreationTime:     job.Created.Format(time.RFC3339),
+		ServiceInstance:  serviceInstance,
+		StatusLocation:   fmt.Sprintf("%s?service=WPS&amp;request=GetExecuteStatus&amp;jobid=%s", serviceInstance, job.ID),
+	}
+	if !job.Expires.IsZero() {
+		status.ExpirationDate = job.Expires.Format(time.RFC3339)
+	}
+
+	if isV2 {
+		tpl, _ := fileResolver.Lookup("templates/WPS2_StatusInfo.tpl")
+		err := utils.ExecuteWriteTemplateFile(w, status, tpl)
+		if err != nil {
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, err.Error(), 500)
 		}
+		return
+	}
 
-		tpl, _ := fileResolver.Lookup("templates/WPS_Execute.tpl")
-		err = utils.ExecuteWriteTemplateFile(w, result.String(), tpl)
+	if job.Status == proc.JobSucceeded {
+		out, _, err := wpsJobs.Result(conf.ServiceConfig.NameSpace, job.ID)
 		if err != nil {
+			Error.Printf("WPS: %v\n", err)
 			metricsCollector.Info.HTTPStatus = 500
 			http.Error(w, err.Error(), 500)
+			return
 		}
+		status.Outputs = string(out)
+	}
 
-	default:
-		metricsCollector.Info.HTTPStatus = 400
-		http.Error(w, fmt.Sprintf("%s not recognised.", *params.Request), 400)
+	tpl, _ := fileResolver.Lookup("templates/WPS_ExecuteStatus.tpl")
+	err := utils.ExecuteWriteTemplateFile(w, status, tpl)
+	if err != nil {
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, err.Error(), 500)
+	}
+}
+
+// writeWPSResult writes the WPS 2.0 Result document of the
+// outputs of a process. Raw responses return the data of
+// the first output as is.
+func writeWPSResult(w http.ResponseWriter, result string, job *proc.Job, response *string, metricsCollector *metrics.MetricsCollector) {
+	outputs, err := utils.ParseWPSOutputs(result)
+	if err != nil {
+		Error.Printf("WPS: %v\n", err)
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	if response != nil && *response == "raw" {
+		if len(outputs) == 0 {
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, "WPS process returned no outputs", 500)
+			return
+		}
+		w.Header().Set("Content-Type", outputs[0].MimeType)
+		w.Write([]byte(outputs[0].Value))
+		return
+	}
+
+	status := &utils.WPSExecuteStatus{Outputs: utils.EncodeWPSOutputsV2(outputs)}
+	if job != nil {
+		status.JobID = job.ID
+		status.ExpirationDate = job.Expires.Format(time.RFC3339)
+	}
+
+	tpl, _ := fileResolver.Lookup("templates/WPS2_Result.tpl")
+	err = utils.ExecuteWriteTemplateFile(w, status, tpl)
+	if err != nil {
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, err.Error(), 500)
 	}
 }
 
@@ -1634,7 +2303,7 @@ func generalHandler(conf *utils.Config, w http.ResponseWriter, r *http.Request)
 		query, err = utils.ParsePost(r.Body)
 		if err != nil {
 			metricsCollector.Info.HTTPStatus = 400
-			http.Error(w, fmt.Sprintf("Error parsing WPS POST payload: %s", err), 400)
+			http.Error(w, fmt.Sprintf("Error parsing POST payload: %s", err), 400)
 			return
 		}
 
@@ -1665,10 +2334,16 @@ func generalHandler(conf *utils.Config, w http.ResponseWriter, r *http.Request)
 				"GetMap":           "WMS",
 				"DescribeLayer":    "WMS",
 				"GetLegendGraphic": "WMS",
+				"GetTile":          "WMTS",
 				"DescribeCoverage": "WCS",
 				"GetCoverage":      "WCS",
 				"DescribeProcess":  "WPS",
 				"Execute":          "WPS",
+				"GetExecuteStatus": "WPS",
+				"CancelExecute":    "WPS",
+				"GetStatus":        "WPS",
+				"GetResult":        "WPS",
+				"Dismiss":          "WPS",
 			}
 			if service, found := reqService[request[0]]; found {
 				query["service"] = []string{service}
@@ -1693,6 +2368,17 @@ func generalHandler(conf *utils.Config, w http.ResponseWriter, r *http.Request)
 		}
 		serveWMS(ctx, params, conf, r, w, metricsCollector)
 	case "WCS":
+		if utils.IsWCS2Request(query) {
+			params, err := utils.WCS2ParamsChecker(query, reWCSMap)
+			if err != nil {
+				metricsCollector.Info.HTTPStatus = 400
+				http.Error(w, fmt.Sprintf("Wrong WCS parameters on URL: %s", err), 400)
+				return
+			}
+			serveWCS2(ctx, params, conf, r, w, query, metricsCollector)
+			return
+		}
+
 		params, err := utils.WCSParamsChecker(query, reWCSMap)
 		if err != nil {
 			metricsCollector.Info.HTTPStatus = 400
@@ -1720,6 +2406,22 @@ func generalHandler(conf *utils.Config, w http.ResponseWriter, r *http.Request)
 			return
 		}
 		serveWPS(ctx, params, conf, r, w, metricsCollector)
+	case "WMTS":
+		params, err := utils.WMTSParamsChecker(query, reWMTSMap)
+		if err != nil {
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, fmt.Sprintf("Wrong WMTS parameters on URL: %s", err), 400)
+			return
+		}
+		serveWMTS(ctx, params, conf, r, w, query, metricsCollector)
+	case "EDR":
+		params, err := utils.EDRParamsChecker(query, reEDRMap)
+		if err != nil {
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, fmt.Sprintf("Wrong EDR parameters on URL: %s", err), 400)
+			return
+		}
+		serveEDR(ctx, params, conf, r, w, metricsCollector)
 	default:
 		metricsCollector.Info.HTTPStatus = 400
 		http.Error(w, fmt.Sprintf("Not a valid OWS request. URL %s does not contain a valid 'request' parameter.", r.URL.String()), 400)
@@ -1731,11 +2433,23 @@ func owsHandler(w http.ResponseWriter, r *http.Request) {
 	namespace := "."
 	if len(r.URL.Path) > len("/ows/") {
 		namespace = r.URL.Path[len("/ows/"):]
-		dapExt := ".dap"
-		if len(namespace) >= len(dapExt) && namespace[len(namespace)-len(dapExt):] == dapExt {
-			namespace = namespace[:len(namespace)-len(dapExt)]
+		for _, dapExt := range []string{".dap", ".zarr"} {
+			if len(namespace) >= len(dapExt) && namespace[len(namespace)-len(dapExt):] == dapExt {
+				namespace = namespace[:len(namespace)-len(dapExt)]
+			}
 		}
 	}
+	config := getNamespaceConfig(namespace, w, r)
+	if config == nil {
+		return
+	}
+	generalHandler(config, w, r)
+}
+
+// getNamespaceConfig returns the config of a dataset namespace
+// loading it on demand if necessary. A nil config is returned
+// once the error has been written to the response.
+func getNamespaceConfig(namespace string, w http.ResponseWriter, r *http.Request) *utils.Config {
 	confMap := getConfigMap()
 	config, ok := confMap[namespace]
 	if !ok || config == nil {
@@ -1751,7 +2465,7 @@ func owsHandler(w http.ResponseWriter, r *http.Request) {
 			masAddress, masErr := getMASAddress()
 			if masErr != nil {
 				namespaceErr(masErr)
-				return
+				return nil
 			}
 
 			confMap = getConfigMap()
@@ -1761,25 +2475,25 @@ func owsHandler(w http.ResponseWriter, r *http.Request) {
 					log.Printf("Invalid dataset namespace: root config not found in owsHandler")
 				}
 				http.Error(w, fmt.Sprintf("Invalid dataset namespace: %v\n", namespace), 404)
-				return
+				return nil
 			}
 			if !rootConfig.ServiceConfig.EnableAutoLayers {
 				if *verbose {
 					log.Printf("owsHandler: rootConfig.EnableAutoLayers is false, therefore invalid dataset namespace")
 				}
 				http.Error(w, fmt.Sprintf("Invalid dataset namespace: %v\n", namespace), 404)
-				return
+				return nil
 			}
 
 			conf, err = utils.LoadConfigFromMAS(masAddress, namespace, rootConfig, *verbose)
 			if err != nil {
 				namespaceErr(err)
-				return
+				return nil
 			}
 			for _, v := range conf {
 				if len(v.Layers) == 0 {
 					namespaceErr(fmt.Errorf("config returned from MAS has no layers"))
-					return
+					return nil
 				}
 			}
 		}
@@ -1789,8 +2503,12 @@ func owsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		configMap.Store("config", confMap)
 		config, _ = conf[namespace]
+		if config == nil {
+			namespaceErr(fmt.Errorf("namespace not found in loaded config"))
+			return nil
+		}
 	}
-	generalHandler(config, w, r)
+	return config
 }
 
 func fileHandler(w http.ResponseWriter, r *http.Request) {
@@ -1916,6 +2634,9 @@ func main() {
 	http.HandleFunc("/", fileHandler)
 	http.HandleFunc("/ows", owsHandler)
 	http.HandleFunc("/ows/", owsHandler)
+	http.HandleFunc("/wmts/", wmtsHandler)
+	http.HandleFunc("/tiles/", tilesHandler)
+	http.HandleFunc("/ogcapi/", ogcapiHandler)
 	http.HandleFunc(fmt.Sprintf("/%s", utils.CatalogueDirName), cataloguesHandler)
 	http.HandleFunc(fmt.Sprintf("/%s/", utils.CatalogueDirName), cataloguesHandler)
 
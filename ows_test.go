@@ -1,8 +1,37 @@
 package main
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/nci/gsky/utils"
+)
 
 // From little things, big things grow.
 func TestFirst(t *testing.T) {
 	// pass
 }
+
+func TestWPSDrillApprox(t *testing.T) {
+	approx := true
+	process := &utils.Process{Approx: &approx}
+	if !wpsDrillApprox(process) {
+		t.Errorf("expected approximated drill")
+	}
+
+	process.Percentiles = []float64{50}
+	if wpsDrillApprox(process) {
+		t.Errorf("expected exact drill for percentiles")
+	}
+
+	process.Percentiles = nil
+	process.HistogramBins = []float64{0, 1}
+	if wpsDrillApprox(process) {
+		t.Errorf("expected exact drill for histograms")
+	}
+
+	approx = false
+	process.HistogramBins = nil
+	if wpsDrillApprox(process) {
+		t.Errorf("expected exact drill when approx is disabled")
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	geo "github.com/nci/geometry"
+	"github.com/nci/gsky/metrics"
+	proc "github.com/nci/gsky/processor"
+	"github.com/nci/gsky/utils"
+)
+
+// pointDrillValues holds the values sampled at a point
+// keyed by date. The values are in the order of the
+// variables of all the data sources of the process.
+type pointDrillValues map[string][]*float64
+
+// executePointDrill samples every data source of the process
+// at all the points of the request at once and returns a CSV
+// with a row per point and date. On failure it returns the
+// HTTP status code of the error.
+func executePointDrill(ctx context.Context, timeoutCtx context.Context, conf *utils.Config, process *utils.Process, params utils.WPSParams, progress *proc.DrillProgress, metricsCollector *metrics.MetricsCollector) (string, int, error) {
+	var points []*geo.Point
+	for i, f := range params.FeatCol.Features {
+		point, isPoint := f.Geometry.(*geo.Point)
+		if !isPoint {
+			return "", 400, fmt.Errorf("Geometry of feature %d is not a Point", i)
+		}
+		points = append(points, point)
+	}
+
+	feat, err := encodeMultiPointFeature(points)
+	if err != nil {
+		return "", 400, err
+	}
+
+	var columns []string
+	for ids := range process.DataSources {
+		columns = append(columns, drillVariables(&process.DataSources[ids])...)
+	}
+
+	values := make([]pointDrillValues, len(points))
+	for ip := range values {
+		values[ip] = make(pointDrillValues)
+	}
+
+	offset := 0
+	for ids := range process.DataSources {
+		dataSource := &process.DataSources[ids]
+
+		geoReq, err := newWPSDrillRequest(process, dataSource, params, feat, metricsCollector)
+		if err != nil {
+			return "", 400, err
+		}
+
+		errChan := make(chan error, 100)
+		dp := proc.InitDrillPipeline(ctx, conf.ServiceConfig.MASAddress, conf.ServiceConfig.WorkerNodes, process.IdentityTol, process.DpTol, errChan)
+		dp.Progress = progress
+
+		out := dp.ProcessPoints(geoReq, len(points), process.Interpolation, *verbose)
+		res, err := waitWPSPipeline(ctx, timeoutCtx, process, out, errChan)
+		if err != nil {
+			return "", 500, err
+		}
+
+		nCols := len(drillVariables(dataSource))
+		for _, line := range strings.Split(res, "\n") {
+			if len(strings.TrimSpace(line)) == 0 {
+				continue
+			}
+			cols := strings.Split(line, ",")
+			if len(cols) != nCols+2 {
+				return "", 500, fmt.Errorf("unexpected point drill result: %s", line)
+			}
+
+			ip, err := strconv.Atoi(cols[0])
+			if err != nil || ip < 0 || ip >= len(points) {
+				return "", 500, fmt.Errorf("unexpected point drill result: %s", line)
+			}
+
+			row, found := values[ip][cols[1]]
+			if !found {
+				row = make([]*float64, len(columns))
+				values[ip][cols[1]] = row
+			}
+			for ic := 0; ic < nCols; ic++ {
+				if v, err := strconv.ParseFloat(cols[ic+2], 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+					row[offset+ic] = &v
+				}
+			}
+		}
+		offset += nCols
+	}
+
+	var ids []string
+	for i := range points {
+		id := strconv.Itoa(i + 1)
+		if i < len(params.FeatProps.Features) {
+			if val, found := params.FeatProps.Features[i].Properties["id"]; found && val != nil {
+				id = zonalPropString(val)
+			}
+		}
+		ids = append(ids, id)
+	}
+
+	csvOut, err := encodePointDrillCSV(columns, ids, points, values)
+	if err != nil {
+		return "", 500, err
+	}
+
+	outputs := []*utils.WPSOutput{
+		{Identifier: "point_drill_csv",
+			Title:    "Point Time Series",
+			Abstract: "Values of every point over time.",
+			MimeType: "text/csv",
+			Value:    csvOut,
+		},
+	}
+	return utils.EncodeWPSOutputs(outputs), 200, nil
+}
+
+// encodeMultiPointFeature returns the GeoJSON feature of the
+// points of a point drill. MultiPoint is not supported by
+// geo.Feature, hence the feature is written here.
+func encodeMultiPointFeature(points []*geo.Point) ([]byte, error) {
+	type geometry struct {
+		Type        string      `json:"type"`
+		Coordinates [][]float64 `json:"coordinates"`
+	}
+	feat := struct {
+		Type     string   `json:"type"`
+		Geometry geometry `json:"geometry"`
+	}{Type: "Feature", Geometry: geometry{Type: "MultiPoint"}}
+
+	for _, point := range points {
+		feat.Geometry.Coordinates = append(feat.Geometry.Coordinates, []float64{point.X, point.Y})
+	}
+	return json.Marshal(&feat)
+}
+
+// encodePointDrillCSV writes a row per point and date in the
+// order of the points. Points without data get a single row
+// without date.
+func encodePointDrillCSV(columns []string, ids []string, points []*geo.Point, values []pointDrillValues) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := append([]string{"point_id", "longitude", "latitude", "date"}, columns...)
+	w.Write(header)
+
+	for ip, point := range points {
+		record := []string{ids[ip], strconv.FormatFloat(point.X, 'f', -1, 64), strconv.FormatFloat(point.Y, 'f', -1, 64)}
+
+		var dates []string
+		for date := range values[ip] {
+			dates = append(dates, date)
+		}
+		sort.Strings(dates)
+
+		if len(dates) == 0 {
+			w.Write(append(record, make([]string, 1+len(columns))...))
+			continue
+		}
+
+		for _, date := range dates {
+			row := append(append([]string{}, record...), date)
+			for _, val := range values[ip][date] {
+				cell := ""
+				if val != nil {
+					cell = strconv.FormatFloat(*val, 'f', -1, 64)
+				}
+				row = append(row, cell)
+			}
+			w.Write(row)
+		}
+	}
+
+	w.Flush()
+	return buf.String(), w.Error()
+}
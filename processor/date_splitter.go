@@ -21,7 +21,25 @@ func (ts *TimeSplitter) Run() {
 	for geoReq := range ts.In {
 		if ts.YearStep > 0 {
 			for t := geoReq.StartTime; t.Before(geoReq.EndTime); t = t.AddDate(ts.YearStep, 0, 0) {
-				ts.Out <- &GeoDrillRequest{geoReq.Geometry, geoReq.CRS, geoReq.Collection, geoReq.NameSpaces, geoReq.BandExpr, geoReq.Mask, "", t, t.AddDate(ts.YearStep, 0, 0), geoReq.ClipUpper, geoReq.ClipLower, geoReq.RasterXSize, geoReq.RasterYSize, geoReq.GrpcConcLimit, geoReq.IndexTileXSize, geoReq.IndexTileYSize, geoReq.MetricsCollector}
+				ts.Out <- &GeoDrillRequest{Geometry: geoReq.Geometry,
+					CRS:              geoReq.CRS,
+					Collection:       geoReq.Collection,
+					NameSpaces:       geoReq.NameSpaces,
+					BandExpr:         geoReq.BandExpr,
+					Mask:             geoReq.Mask,
+					StartTime:        t,
+					EndTime:          t.AddDate(ts.YearStep, 0, 0),
+					ClipUpper:        geoReq.ClipUpper,
+					ClipLower:        geoReq.ClipLower,
+					RasterXSize:      geoReq.RasterXSize,
+					RasterYSize:      geoReq.RasterYSize,
+					GrpcConcLimit:    geoReq.GrpcConcLimit,
+					IndexTileXSize:   geoReq.IndexTileXSize,
+					IndexTileYSize:   geoReq.IndexTileYSize,
+					Percentiles:      geoReq.Percentiles,
+					HistogramBins:    geoReq.HistogramBins,
+					MetricsCollector: geoReq.MetricsCollector,
+				}
 			}
 		} else {
 			ts.Out <- geoReq
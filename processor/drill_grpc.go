@@ -12,20 +12,26 @@ import (
 )
 
 type GeoDrillGRPC struct {
-	Context context.Context
-	In      chan *GeoDrillGranule
-	Out     chan *DrillResult
-	Error   chan error
-	Clients []string
+	Context       context.Context
+	In            chan *GeoDrillGranule
+	Out           chan *DrillResult
+	Error         chan error
+	Clients       []string
+	Progress      *DrillProgress
+	Operation     string
+	Interpolation string
+	Percentiles   []float64
+	HistogramBins []float64
 }
 
 func NewDrillGRPC(ctx context.Context, serverAddress []string, errChan chan error) *GeoDrillGRPC {
 	return &GeoDrillGRPC{
-		Context: ctx,
-		In:      make(chan *GeoDrillGranule, 100),
-		Out:     make(chan *DrillResult, 100),
-		Error:   errChan,
-		Clients: serverAddress,
+		Context:   ctx,
+		In:        make(chan *GeoDrillGranule, 100),
+		Out:       make(chan *DrillResult, 100),
+		Error:     errChan,
+		Clients:   serverAddress,
+		Operation: "drill",
 	}
 }
 
@@ -87,6 +93,7 @@ func (gi *GeoDrillGRPC) Run(bandStrides int, decileCount int, pixelCount int, pi
 
 				if hasStats {
 					gi.Out <- &DrillResult{NameSpace: gran.NameSpace, Data: ts, Dates: gran.TimeStamps}
+					gi.Progress.AddProcessed(1)
 					continue
 				}
 			}
@@ -129,7 +136,7 @@ func (gi *GeoDrillGRPC) Run(bandStrides int, decileCount int, pixelCount int, pi
 				c := pb.NewGDALClient(conns[(iTile+workerStart)%len(conns)])
 				bands, err := getBands(g.TimeStamps)
 
-				granule := &pb.GeoRPCGranule{Operation: "drill", Path: g.Path, Geometry: g.Geometry, Bands: bands, Height: float32(gran.RasterYSize), Width: float32(gran.RasterXSize), BandStrides: int32(bandStrides), DrillDecileCount: int32(decileCount), ClipUpper: gran.ClipUpper, ClipLower: gran.ClipLower, PixelCount: int32(pixelCount), PixelStat: pixelStat, VRT: g.VRT}
+				granule := &pb.GeoRPCGranule{Operation: gi.Operation, Path: g.Path, Geometry: g.Geometry, Bands: bands, Height: float32(gran.RasterYSize), Width: float32(gran.RasterXSize), BandStrides: int32(bandStrides), DrillDecileCount: int32(decileCount), ClipUpper: gran.ClipUpper, ClipLower: gran.ClipLower, PixelCount: int32(pixelCount), PixelStat: pixelStat, VRT: g.VRT, Interpolation: gi.Interpolation, Percentiles: gi.Percentiles, HistogramBins: gi.HistogramBins}
 				r, err := c.Process(gi.Context, granule)
 				if err != nil {
 					gi.sendError(fmt.Errorf("Drill gRPC: %v", err))
@@ -137,11 +144,13 @@ func (gi *GeoDrillGRPC) Run(bandStrides int, decileCount int, pixelCount int, pi
 					return
 				}
 
+				// The columns of point drills are the points
+				// rather than the statistics of the namespace
 				nCols := int(r.Shape[1])
 				nRows := int(r.Shape[0])
 				for i := 0; i < nCols; i++ {
 					ns := g.NameSpace
-					if i > 0 {
+					if i > 0 && gi.Operation != "point_drill" {
 						ns = g.NameSpace + fmt.Sprintf(DecileNamespace, i)
 					}
 					tsRow := make([]*pb.TimeSeries, nRows)
@@ -151,8 +160,9 @@ func (gi *GeoDrillGRPC) Run(bandStrides int, decileCount int, pixelCount int, pi
 					if gi.checkCancellation() {
 						return
 					}
-					gi.Out <- &DrillResult{NameSpace: ns, Data: tsRow, NoData: r.Raster.NoData, Dates: g.TimeStamps}
+					gi.Out <- &DrillResult{NameSpace: ns, Column: i, Data: tsRow, NoData: r.Raster.NoData, Dates: g.TimeStamps}
 				}
+				gi.Progress.AddProcessed(1)
 
 				if geoReq.MetricsCollector != nil {
 					metrics[iTile-1] = r.Metrics
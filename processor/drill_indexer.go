@@ -42,6 +42,7 @@ type DrillIndexer struct {
 	IdentityTol float64
 	DpTol       float64
 	Approx      bool
+	Progress    *DrillProgress
 }
 
 func NewDrillIndexer(ctx context.Context, apiAddr string, identityTol float64, dpTol float64, approx bool, errChan chan error) *DrillIndexer {
@@ -72,8 +73,7 @@ func (p *DrillIndexer) Run(verbose bool) {
 
 	isInit := true
 	for geoReq := range p.In {
-		var feat geo.Feature
-		err := json.Unmarshal([]byte(geoReq.Geometry), &feat)
+		featWKT, err := drillGeometryWKT(geoReq.Geometry)
 		if err != nil {
 			p.sendError(fmt.Errorf("Drill Indexer: Problem unmarshalling GeoJSON object: %v", geoReq.Geometry))
 			return
@@ -88,7 +88,6 @@ func (p *DrillIndexer) Run(verbose bool) {
 		namespaces := strings.Join(ns, ",")
 
 		reqURL := p.getRequestURL(geoReq, namespaces)
-		featWKT := feat.Geometry.MarshalWKT()
 
 		if isInit {
 			if geoReq.MetricsCollector != nil {
@@ -112,7 +111,7 @@ func (p *DrillIndexer) Run(verbose bool) {
 			logRequestGeometry("Drill Indexer: original request: ", reqURL, featWKT)
 		}
 
-		tiledGeoms, err := getTiledGeometries(feat.Geometry.MarshalWKT(), geoReq, verbose)
+		tiledGeoms, err := getTiledGeometries(featWKT, geoReq, verbose)
 		if err != nil || len(tiledGeoms) == 0 {
 			tiledGeoms = append(tiledGeoms, featWKT)
 		}
@@ -241,6 +240,7 @@ func (p *DrillIndexer) processDatasets(res *TiledResponse, geoReq *GeoDrillReque
 				if p.checkCancellation() {
 					return
 				}
+				p.Progress.AddTotal(1)
 				p.Out <- gran
 			}
 
@@ -346,6 +346,7 @@ func (p *DrillIndexer) processDatasets(res *TiledResponse, geoReq *GeoDrillReque
 					if p.checkCancellation() {
 						return
 					}
+					p.Progress.AddTotal(1)
 					p.Out <- dg
 				}
 			}
@@ -353,6 +354,38 @@ func (p *DrillIndexer) processDatasets(res *TiledResponse, geoReq *GeoDrillReque
 	}
 }
 
+// drillGeometryWKT returns the WKT of the geometry of a GeoJSON
+// feature. The MultiPoint geometries of point drills are not
+// supported by geo.Feature, hence they are converted here.
+func drillGeometryWKT(geometry string) (string, error) {
+	var multiPoint struct {
+		Geometry struct {
+			Type        string      `json:"type"`
+			Coordinates [][]float64 `json:"coordinates"`
+		} `json:"geometry"`
+	}
+	if err := json.Unmarshal([]byte(geometry), &multiPoint); err == nil && multiPoint.Geometry.Type == "MultiPoint" {
+		var points []string
+		for _, coords := range multiPoint.Geometry.Coordinates {
+			if len(coords) < 2 {
+				return "", fmt.Errorf("invalid MultiPoint coordinates: %v", coords)
+			}
+			points = append(points, fmt.Sprintf("(%g %g)", coords[0], coords[1]))
+		}
+		if len(points) == 0 {
+			return "", fmt.Errorf("empty MultiPoint")
+		}
+		return fmt.Sprintf("MULTIPOINT (%s)", strings.Join(points, ",")), nil
+	}
+
+	var feat geo.Feature
+	err := json.Unmarshal([]byte(geometry), &feat)
+	if err != nil {
+		return "", err
+	}
+	return feat.Geometry.MarshalWKT(), nil
+}
+
 func (p *DrillIndexer) getRequestURL(geoReq *GeoDrillRequest, namespaces string) string {
 	startTimeStr := ""
 	if !time.Time.IsZero(geoReq.StartTime) {
@@ -28,13 +28,74 @@ func NewDrillMerger(ctx context.Context, errChan chan error) *DrillMerger {
 	}
 }
 
-func (dm *DrillMerger) Run(suffix string, namespaces []string, templateFileName string, bandExpr *utils.BandExpressions, decileCount int, verbose bool) {
+// DrillOutput is the data of the WPS output templates. Besides
+// the CSV of the drill, it holds the names of the columns so that
+// templates may render the percentiles and histogram bins set by
+// the process. Templates rendering {{ . }} get the CSV as is.
+type DrillOutput struct {
+	Header  string
+	Columns []string
+	Data    string
+}
+
+func (o *DrillOutput) String() string {
+	return o.Data
+}
+
+func (dm *DrillMerger) Run(suffix string, namespaces []string, templateFileName string, bandExpr *utils.BandExpressions, statNames []string, nPercentiles int, nBins int, pixelStat string, verbose bool) {
 	if verbose {
 		defer log.Printf("Drill Merger done")
 	}
 	defer close(dm.Out)
 	results := make(map[string]map[string][]*pb.TimeSeries)
 
+	nCols := len(statNames)
+
+	// Zonal statistics come in groups of columns per
+	// namespace. The mean and the mean of squares are
+	// weighted by the pixel counts of the granules while
+	// min and max are taken across granules. The mean
+	// of squares is turned into the standard deviation
+	// once merged.
+	zonalCols := make(map[string]int)
+	if pixelStat == "zonal" {
+		for i, ns := range namespaces {
+			zonalCols[ns] = i % nCols
+		}
+	}
+
+	// The pixel counts of the histogram bins
+	// are added up across granules
+	histCols := make(map[string]bool)
+	for i, ns := range namespaces {
+		if i%nCols >= nCols-nBins {
+			histCols[ns] = true
+		}
+	}
+
+	// Percentiles are only exact over the pixels of a single
+	// granule. They cannot be derived from those of several
+	// granules nor from those of the variables of an expression.
+	pctCols := make(map[string]bool)
+	for i, ns := range namespaces {
+		if iCol := i % nCols; iCol >= nCols-nBins-nPercentiles && iCol < nCols-nBins {
+			pctCols[ns] = true
+		}
+	}
+
+	var columns []string
+	if len(bandExpr.Expressions) == 0 {
+		for i := range namespaces {
+			columns = append(columns, drillColumnName(namespaces[i-i%nCols], statNames[i%nCols]))
+		}
+	} else {
+		for _, name := range bandExpr.ExprNames {
+			for _, stat := range statNames {
+				columns = append(columns, drillColumnName(name, stat))
+			}
+		}
+	}
+
 	var drillResult *DrillResult
 	for drillRes := range dm.In {
 		if _, ok := results[drillRes.NameSpace]; !ok {
@@ -68,16 +129,80 @@ func (dm *DrillMerger) Run(suffix string, namespaces []string, templateFileName
 		return
 	}
 
+	if nPercentiles > 0 {
+		for ix, varRefs := range bandExpr.ExprVarRef {
+			if len(varRefs) > 1 {
+				dm.sendError(fmt.Errorf("WPS: percentiles cannot be computed for the multi-variable expression '%v'", bandExpr.ExprText[ix]))
+				return
+			}
+		}
+	}
+
 	var dates []string
 	for ts := range results[namespaces[0]] {
 		dates = append(dates, ts)
 	}
 	sort.Strings(dates)
 
+	// The CSV is embedded into a JSON string by the
+	// output templates, hence the escaped new lines.
+	// Without a template the CSV is returned as is.
+	lineSep := "\\n"
+	if len(templateFileName) == 0 {
+		lineSep = "\n"
+	}
+
 	var csv strings.Builder
 	for _, key := range dates {
 		values := map[string]float64{}
 		for _, ns := range namespaces {
+			if iCol := zonalCols[ns]; iCol == 1 || iCol == 2 {
+				found := false
+				for _, data := range results[ns][key] {
+					if data.Count == 0 || math.IsNaN(data.Value) {
+						continue
+					}
+					if !found || (iCol == 1 && data.Value < values[ns]) || (iCol == 2 && data.Value > values[ns]) {
+						values[ns] = data.Value
+						found = true
+					}
+				}
+				continue
+			}
+
+			if pctCols[ns] {
+				var pct *pb.TimeSeries
+				for _, data := range results[ns][key] {
+					if data.Count == 0 || math.IsNaN(data.Value) {
+						continue
+					}
+					if pct != nil {
+						dm.sendError(fmt.Errorf("WPS: percentiles cannot be merged across the granules of %v at %v, the polygon must be covered by a single granule", ns, key))
+						return
+					}
+					pct = data
+				}
+				if pct != nil {
+					values[ns] = pct.Value
+				}
+				continue
+			}
+
+			if histCols[ns] {
+				total := 0.0
+				found := false
+				for _, data := range results[ns][key] {
+					if !math.IsNaN(data.Value) {
+						total += data.Value
+						found = true
+					}
+				}
+				if found {
+					values[ns] = total
+				}
+				continue
+			}
+
 			total := 0.0
 			count := 0
 			for _, data := range results[ns][key] {
@@ -91,6 +216,18 @@ func (dm *DrillMerger) Run(suffix string, namespaces []string, templateFileName
 			}
 		}
 
+		for ns, iCol := range zonalCols {
+			if iCol != 0 {
+				continue
+			}
+			sqNs := ns + fmt.Sprintf(DecileNamespace, 3)
+			mean, meanOk := values[ns]
+			meanSq, sqOk := values[sqNs]
+			if meanOk && sqOk {
+				values[sqNs] = math.Sqrt(math.Max(0, meanSq-mean*mean))
+			}
+		}
+
 		fmt.Fprintf(&csv, "%s", key)
 
 		if len(bandExpr.Expressions) == 0 {
@@ -101,13 +238,26 @@ func (dm *DrillMerger) Run(suffix string, namespaces []string, templateFileName
 				}
 			}
 
-			fmt.Fprint(&csv, "\\n")
+			fmt.Fprint(&csv, lineSep)
 			continue
 		}
 
-		nCols := 1 + decileCount
 		for ix, expr := range bandExpr.Expressions {
 			for ic := 0; ic < nCols; ic++ {
+				// The histograms of band expressions cannot be
+				// derived from those of the bands, only those of
+				// single variable expressions are reported
+				if ic >= nCols-nBins {
+					fmt.Fprint(&csv, ",")
+					if len(bandExpr.ExprVarRef[ix]) == 1 {
+						varCol := bandExpr.ExprVarRef[ix][0] + fmt.Sprintf(DecileNamespace, ic)
+						if val, ok := values[varCol]; ok {
+							fmt.Fprintf(&csv, "%f", val)
+						}
+					}
+					continue
+				}
+
 				noData := false
 				for _, variable := range bandExpr.ExprVarRef[ix] {
 					varCol := variable
@@ -153,12 +303,21 @@ func (dm *DrillMerger) Run(suffix string, namespaces []string, templateFileName
 			}
 		}
 
-		fmt.Fprint(&csv, "\\n")
+		fmt.Fprint(&csv, lineSep)
 
 	}
 
+	if len(templateFileName) == 0 {
+		if dm.checkCancellation() {
+			return
+		}
+		dm.Out <- csv.String()
+		return
+	}
+
 	var out strings.Builder
-	err := utils.ExecuteWriteTemplateFile(&out, csv.String(), templateFileName)
+	output := &DrillOutput{Header: strings.Join(append([]string{"date"}, columns...), ","), Columns: columns, Data: csv.String()}
+	err := utils.ExecuteWriteTemplateFile(&out, output, templateFileName)
 	if err != nil {
 		dm.sendError(fmt.Errorf("WPS: output template error: %v", err))
 		return
@@ -189,3 +348,10 @@ func (dm *DrillMerger) checkCancellation() bool {
 		return false
 	}
 }
+
+func drillColumnName(name string, stat string) string {
+	if len(stat) == 0 {
+		return name
+	}
+	return name + "_" + stat
+}
@@ -0,0 +1,73 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nci/gsky/utils"
+	pb "github.com/nci/gsky/worker/gdalservice"
+)
+
+func runDrillMerger(results []*DrillResult, statNames []string, nPercentiles int) (string, error) {
+	errChan := make(chan error, 10)
+	dm := NewDrillMerger(context.Background(), errChan)
+	go func() {
+		for _, res := range results {
+			dm.In <- res
+		}
+		close(dm.In)
+	}()
+
+	var namespaces []string
+	for i := range statNames {
+		ns := "ndvi"
+		if i > 0 {
+			ns += fmt.Sprintf(DecileNamespace, i)
+		}
+		namespaces = append(namespaces, ns)
+	}
+	go dm.Run("", namespaces, "", &utils.BandExpressions{}, statNames, nPercentiles, 0, "", false)
+
+	out := <-dm.Out
+	select {
+	case err := <-errChan:
+		return out, err
+	default:
+		return out, nil
+	}
+}
+
+func TestDrillMergerPercentiles(t *testing.T) {
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	granule := func(mean float64, pct float64, count int32) []*DrillResult {
+		return []*DrillResult{
+			{NameSpace: "ndvi", Dates: []time.Time{date}, Data: []*pb.TimeSeries{{Value: mean, Count: count}}},
+			{NameSpace: "ndvi" + fmt.Sprintf(DecileNamespace, 1), Dates: []time.Time{date}, Data: []*pb.TimeSeries{{Value: pct, Count: count}}},
+		}
+	}
+	statNames := DrillStatNames("", 0, []float64{50}, nil)
+
+	out, err := runDrillMerger(granule(2, 3, 4), statNames, 1)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if expected := "2020-01-01T00:00:00.000Z,2.000000,3.000000\n"; out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+
+	// A granule without valid pixels does not prevent
+	// the percentiles of the other one from being reported
+	out, err = runDrillMerger(append(granule(2, 3, 4), granule(0, 0, 0)...), statNames, 1)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if expected := "2020-01-01T00:00:00.000Z,2.000000,3.000000\n"; out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+
+	if _, err = runDrillMerger(append(granule(2, 3, 4), granule(6, 7, 4)...), statNames, 1); err == nil {
+		t.Errorf("expected percentiles of several granules to be rejected")
+	}
+}
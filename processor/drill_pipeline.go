@@ -3,10 +3,55 @@ package processor
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
 const DecileNamespace = "_d%d"
+const DefaultDecileAnchorPoints = 9
+
+// ZonalStatColumns are the columns drilled for each
+// namespace by the zonal pixel statistic, followed by
+// the deciles if requested.
+var ZonalStatColumns = []string{"mean", "min", "max", "stddev"}
+
+// DrillStatNames returns the names of the columns drilled for
+// each namespace in the order of the worker results, i.e. the
+// pixel statistics followed by the deciles, the percentiles and
+// the histogram bins. The name of the mean is empty.
+func DrillStatNames(pixelStat string, decileCount int, percentiles []float64, histogramBins []float64) []string {
+	var names []string
+	switch pixelStat {
+	case "minmaxmean":
+		names = append(names, "min", "max", "mean")
+	case "zonal":
+		names = append(names, ZonalStatColumns...)
+	default:
+		names = append(names, "")
+	}
+
+	for i := 1; i <= decileCount; i++ {
+		names = append(names, fmt.Sprintf("p%d", i*100/(decileCount+1)))
+	}
+
+	for _, pct := range percentiles {
+		names = append(names, "p"+strconv.FormatFloat(pct, 'f', -1, 64))
+	}
+
+	for i := 0; i < histogramBinCount(histogramBins); i++ {
+		lo := strconv.FormatFloat(histogramBins[i], 'f', -1, 64)
+		hi := strconv.FormatFloat(histogramBins[i+1], 'f', -1, 64)
+		names = append(names, "hist_"+lo+"_"+hi)
+	}
+	return names
+}
+
+func histogramBinCount(histogramBins []float64) int {
+	if len(histogramBins) < 2 {
+		return 0
+	}
+	return len(histogramBins) - 1
+}
 
 type DrillPipeline struct {
 	Context     context.Context
@@ -15,6 +60,7 @@ type DrillPipeline struct {
 	APIAddr     string
 	IdentityTol float64
 	DpTol       float64
+	Progress    *DrillProgress
 }
 
 func InitDrillPipeline(ctx context.Context, apiAddr string, rpcAddrs []string, identityTol float64, dpTol float64, errChan chan error) *DrillPipeline {
@@ -29,7 +75,6 @@ func InitDrillPipeline(ctx context.Context, apiAddr string, rpcAddrs []string, i
 }
 
 func (dp *DrillPipeline) Process(geoReq GeoDrillRequest, suffix string, templateFileName string, bandStrides int, approx bool, drillAlgorithm string, pixelStat string, verbose bool) chan string {
-	const DefaultDecileAnchorPoints = 9
 	decileCount := 0
 	pixelCount := 0
 	if len(drillAlgorithm) > 0 {
@@ -55,7 +100,12 @@ func (dp *DrillPipeline) Process(geoReq GeoDrillRequest, suffix string, template
 		dp.Error <- fmt.Errorf("Couldn't instantiate RPCDriller %s/n", dp.RPCAddrs)
 	}
 
+	grpcDriller.Progress = dp.Progress
+	grpcDriller.Percentiles = geoReq.Percentiles
+	grpcDriller.HistogramBins = geoReq.HistogramBins
+
 	i := NewDrillIndexer(dp.Context, dp.APIAddr, dp.IdentityTol, dp.DpTol, approx, dp.Error)
+	i.Progress = dp.Progress
 	go func() {
 		i.In <- &geoReq
 		close(i.In)
@@ -69,10 +119,10 @@ func (dp *DrillPipeline) Process(geoReq GeoDrillRequest, suffix string, template
 	go i.Run(verbose)
 	go grpcDriller.Run(bandStrides, decileCount, pixelCount, pixelStat, verbose)
 
-	nCols := decileCount + 1
+	statNames := DrillStatNames(pixelStat, decileCount, geoReq.Percentiles, geoReq.HistogramBins)
 	var namespaces []string
 	for _, ns := range geoReq.NameSpaces {
-		for i := 0; i < nCols; i++ {
+		for i := range statNames {
 			newNs := ns
 			if i > 0 {
 				newNs = ns + fmt.Sprintf(DecileNamespace, i)
@@ -80,7 +130,38 @@ func (dp *DrillPipeline) Process(geoReq GeoDrillRequest, suffix string, template
 			namespaces = append(namespaces, newNs)
 		}
 	}
-	go dm.Run(suffix, namespaces, templateFileName, geoReq.BandExpr, decileCount, verbose)
+	go dm.Run(suffix, namespaces, templateFileName, geoReq.BandExpr, statNames, len(geoReq.Percentiles), histogramBinCount(geoReq.HistogramBins), pixelStat, verbose)
 
 	return dm.Out
 }
+
+// ProcessPoints samples the values of the request at every
+// point of a Point or MultiPoint geometry. The granules are
+// looked up by the DrillIndexer as for the other drills and
+// the result is a CSV row per point and date.
+func (dp *DrillPipeline) ProcessPoints(geoReq GeoDrillRequest, nPoints int, interpolation string, verbose bool) chan string {
+	grpcDriller := NewDrillGRPC(dp.Context, dp.RPCAddrs, dp.Error)
+	grpcDriller.Progress = dp.Progress
+	grpcDriller.Operation = "point_drill"
+	grpcDriller.Interpolation = interpolation
+
+	// Sampled values are never approximated by the
+	// means of the index
+	i := NewDrillIndexer(dp.Context, dp.APIAddr, dp.IdentityTol, dp.DpTol, false, dp.Error)
+	i.Progress = dp.Progress
+	go func() {
+		i.In <- &geoReq
+		close(i.In)
+	}()
+
+	pm := NewPointDrillMerger(dp.Context, dp.Error)
+
+	grpcDriller.In = i.Out
+	pm.In = grpcDriller.Out
+
+	go i.Run(verbose)
+	go grpcDriller.Run(1, 0, 0, "", verbose)
+	go pm.Run(nPoints, geoReq.NameSpaces, geoReq.BandExpr, verbose)
+
+	return pm.Out
+}
@@ -2,6 +2,7 @@ package processor
 
 import (
 	"image"
+	"sync/atomic"
 	"time"
 
 	"github.com/nci/gsky/metrics"
@@ -26,6 +27,8 @@ type GeoDrillRequest struct {
 	GrpcConcLimit    int
 	IndexTileXSize   float64
 	IndexTileYSize   float64
+	Percentiles      []float64
+	HistogramBins    []float64
 	MetricsCollector *metrics.MetricsCollector
 }
 
@@ -51,6 +54,7 @@ type GeoDrillGranule struct {
 
 type DrillResult struct {
 	NameSpace string
+	Column    int
 	Dates     []time.Time
 	Data      []*pb.TimeSeries
 	NoData    float64
@@ -62,3 +66,32 @@ type DrillFileDescriptor struct {
 	NoData         float64
 	Mask           *image.Gray
 }
+
+// DrillProgress counts the granules found by the drill
+// indexer and the granules drilled so far. It is safe
+// for concurrent use and a nil DrillProgress is a no-op.
+type DrillProgress struct {
+	total     int64
+	processed int64
+}
+
+func (p *DrillProgress) AddTotal(n int) {
+	if p != nil {
+		atomic.AddInt64(&p.total, int64(n))
+	}
+}
+
+func (p *DrillProgress) AddProcessed(n int) {
+	if p != nil {
+		atomic.AddInt64(&p.processed, int64(n))
+	}
+}
+
+// Counts returns the number of granules drilled
+// so far and the number of granules found.
+func (p *DrillProgress) Counts() (int, int) {
+	if p == nil {
+		return 0, 0
+	}
+	return int(atomic.LoadInt64(&p.processed)), int(atomic.LoadInt64(&p.total))
+}
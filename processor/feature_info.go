@@ -3,6 +3,7 @@ package processor
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"time"
@@ -18,13 +19,16 @@ type featureInfo struct {
 	DsDates    []string
 }
 
-func GetFeatureInfo(ctx context.Context, params utils.WMSParams, conf *utils.Config, configMap map[string]*utils.Config, verbose bool, metricsCollector *metrics.MetricsCollector) (string, error) {
+// GetFeatureInfo returns the values of the bands at the pixel
+// of a GetFeatureInfo request together with the dates and files
+// of the data available at that pixel.
+func GetFeatureInfo(ctx context.Context, params utils.WMSParams, conf *utils.Config, configMap map[string]*utils.Config, verbose bool, metricsCollector *metrics.MetricsCollector) (*utils.FeatureInfo, error) {
 	ftInfo, err := getRaster(ctx, params, conf, configMap, verbose, metricsCollector)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	out := `"bands": {`
+	info := &utils.FeatureInfo{}
 
 	hasData := true
 	if len(ftInfo.Raster) == 1 {
@@ -40,13 +44,9 @@ func GetFeatureInfo(ctx context.Context, params utils.WMSParams, conf *utils.Con
 			}
 
 			if !hasData {
-				for i, ns := range ftInfo.Namespaces {
-					out += fmt.Sprintf(`"%s":"%s"`, ns, msg)
-					if i < len(ftInfo.Namespaces)-1 {
-						out += ","
-					}
+				for _, ns := range ftInfo.Namespaces {
+					info.Bands = append(info.Bands, utils.FeatureInfoBand{Name: ns, Value: msg})
 				}
-				out += `}`
 			}
 		}
 	}
@@ -54,7 +54,7 @@ func GetFeatureInfo(ctx context.Context, params utils.WMSParams, conf *utils.Con
 	if hasData {
 		width, height, _, err := utils.ValidateRasterSlice(ftInfo.Raster)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		x := *params.X
@@ -62,79 +62,59 @@ func GetFeatureInfo(ctx context.Context, params utils.WMSParams, conf *utils.Con
 
 		offset := y*width + x
 		if offset >= width*height {
-			return "", fmt.Errorf("x or y out of bound")
+			return nil, fmt.Errorf("x or y out of bound")
 		}
 
 		for i, ns := range ftInfo.Namespaces {
 			r := ftInfo.Raster[i]
-			var valueStr string
+			var value interface{}
+			isNoData := true
 
 			switch t := r.(type) {
 			case *utils.SignedByteRaster:
-				noData := int8(t.NoData)
-				value := t.Data[offset]
-				if value == noData {
-					valueStr = `"n/a"`
-				} else {
-					valueStr = fmt.Sprintf("%v", value)
-				}
+				value = t.Data[offset]
+				isNoData = t.Data[offset] == int8(t.NoData)
 
 			case *utils.ByteRaster:
-				noData := uint8(t.NoData)
-				value := t.Data[offset]
-				if value == noData {
-					valueStr = `"n/a"`
-				} else {
-					valueStr = fmt.Sprintf("%v", value)
-				}
+				value = t.Data[offset]
+				isNoData = t.Data[offset] == uint8(t.NoData)
 
 			case *utils.Int16Raster:
-				noData := int16(t.NoData)
-				value := t.Data[offset]
-				if value == noData {
-					valueStr = `"n/a"`
-				} else {
-					valueStr = fmt.Sprintf("%v", value)
-				}
+				value = t.Data[offset]
+				isNoData = t.Data[offset] == int16(t.NoData)
 
 			case *utils.UInt16Raster:
-				noData := uint16(t.NoData)
-				value := t.Data[offset]
-				if value == noData {
-					valueStr = `"n/a"`
-				} else {
-					valueStr = fmt.Sprintf("%v", value)
-				}
+				value = t.Data[offset]
+				isNoData = t.Data[offset] == uint16(t.NoData)
 
 			case *utils.Float32Raster:
-				noData := float32(t.NoData)
-				value := t.Data[offset]
-				if value == noData {
-					valueStr = `"n/a"`
-				} else {
-					valueStr = fmt.Sprintf("%v", value)
-				}
-			}
+				value = t.Data[offset]
+				isNoData = t.Data[offset] == float32(t.NoData) || math.IsNaN(float64(t.Data[offset]))
 
-			out += fmt.Sprintf(`"%s": %s`, ns, valueStr)
-			if i < len(ftInfo.Namespaces)-1 {
-				out += ","
+			case *utils.Int32Raster:
+				value = t.Data[offset]
+				isNoData = t.Data[offset] == int32(t.NoData)
+
+			case *utils.UInt32Raster:
+				value = t.Data[offset]
+				isNoData = t.Data[offset] == uint32(t.NoData)
+
+			case *utils.Float64Raster:
+				value = t.Data[offset]
+				isNoData = t.Data[offset] == t.NoData || math.IsNaN(t.Data[offset])
 			}
-		}
-		out += `}`
-	}
 
-	if len(ftInfo.DsDates) > 0 {
-		out += `, "data_available_for_dates":[`
-		for i, ts := range ftInfo.DsDates {
-			out += fmt.Sprintf(`"%s"`, ts)
-			if i < len(ftInfo.DsDates)-1 {
-				out += ","
+			band := utils.FeatureInfoBand{Name: ns, Value: "n/a"}
+			if !isNoData {
+				band.Value = fmt.Sprintf("%v", value)
+				band.HasData = true
 			}
+			info.Bands = append(info.Bands, band)
 		}
-		out += `]`
 	}
 
+	info.AvailableDates = ftInfo.DsDates
+
 	if len(ftInfo.DsFiles) > 0 {
 		prefix := ""
 		idx, _ := utils.GetLayerIndex(params, conf)
@@ -144,18 +124,12 @@ func GetFeatureInfo(ctx context.Context, params utils.WMSParams, conf *utils.Con
 				prefix += "/"
 			}
 		}
-		out += `, "data_links":[`
-		for i, file := range ftInfo.DsFiles {
-
-			out += fmt.Sprintf(`"%s%s"`, prefix, file)
-			if i < len(ftInfo.DsFiles)-1 {
-				out += ","
-			}
+		for _, file := range ftInfo.DsFiles {
+			info.DataLinks = append(info.DataLinks, prefix+file)
 		}
-		out += `]`
 	}
 
-	return out, nil
+	return info, nil
 }
 
 func getRaster(ctx context.Context, params utils.WMSParams, conf *utils.Config, configMap map[string]*utils.Config, verbose bool, metricsCollector *metrics.MetricsCollector) (*featureInfo, error) {
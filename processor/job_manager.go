@@ -0,0 +1,478 @@
+package processor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	JobAccepted  = "accepted"
+	JobRunning   = "running"
+	JobSucceeded = "successful"
+	JobFailed    = "failed"
+	JobDismissed = "dismissed"
+)
+
+const DefaultJobExpiry = 24 * time.Hour
+
+var reJobID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Job holds the state of an asynchronous job submitted to
+// the processes of a namespace. The job along with the result
+// of a successful job is kept by the JobStore of the JobManager
+// until the job expires.
+type Job struct {
+	ID          string         `json:"id"`
+	NameSpace   string         `json:"namespace"`
+	ProcessID   string         `json:"process_id"`
+	Status      string         `json:"status"`
+	Message     string         `json:"message,omitempty"`
+	Created     time.Time      `json:"created"`
+	Started     time.Time      `json:"started"`
+	Finished    time.Time      `json:"finished"`
+	Expires     time.Time      `json:"expires"`
+	ContentType string         `json:"content_type,omitempty"`
+	Progress    *DrillProgress `json:"-"`
+
+	cancel context.CancelFunc
+}
+
+// IsFinished reports whether the job is no longer
+// running, regardless of the outcome.
+func (j *Job) IsFinished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobDismissed
+}
+
+// PercentCompleted estimates the progress of the job from
+// the granules drilled so far. Running jobs never report
+// 100 since the merging of the results is still pending.
+func (j *Job) PercentCompleted() int {
+	switch j.Status {
+	case JobAccepted:
+		return 0
+	case JobRunning:
+		processed, total := j.Progress.Counts()
+		if total == 0 {
+			return 0
+		}
+		pct := processed * 100 / total
+		if pct > 99 {
+			pct = 99
+		}
+		return pct
+	default:
+		return 100
+	}
+}
+
+// JobFunc runs the work of a job and returns its result
+// along with the content type of the result.
+type JobFunc func(ctx context.Context, progress *DrillProgress) ([]byte, string, error)
+
+// JobStore persists asynchronous jobs and their results
+// so that they survive restarts of the server.
+type JobStore interface {
+	Put(id string, data []byte) error
+	Get(id string) ([]byte, error)
+	Delete(id string) error
+
+	PutJob(job *Job) error
+	LoadJobs() ([]*Job, error)
+	DeleteJob(id string) error
+}
+
+// FileJobStore is a JobStore keeping jobs and their
+// results as files in a local directory.
+type FileJobStore struct {
+	Dir string
+}
+
+func NewFileJobStore(dir string) (*FileJobStore, error) {
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("error creating job store directory: %v", err)
+	}
+	return &FileJobStore{Dir: dir}, nil
+}
+
+func (s *FileJobStore) path(id string, ext string) (string, error) {
+	if !reJobID.MatchString(id) {
+		return "", fmt.Errorf("invalid job id: %s", id)
+	}
+	return filepath.Join(s.Dir, id+ext), nil
+}
+
+func (s *FileJobStore) Put(id string, data []byte) error {
+	fileName, err := s.path(id, ".result")
+	if err != nil {
+		return err
+	}
+	return s.write(fileName, data)
+}
+
+// write writes to a temp file first so that readers
+// never see partially written jobs or results
+func (s *FileJobStore) write(fileName string, data []byte) error {
+	tmpFile, err := ioutil.TempFile(s.Dir, "job_")
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	return os.Rename(tmpFile.Name(), fileName)
+}
+
+func (s *FileJobStore) Get(id string) ([]byte, error) {
+	fileName, err := s.path(id, ".result")
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(fileName)
+}
+
+func (s *FileJobStore) Delete(id string) error {
+	return s.remove(id, ".result")
+}
+
+func (s *FileJobStore) PutJob(job *Job) error {
+	fileName, err := s.path(job.ID, ".job")
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return s.write(fileName, data)
+}
+
+// LoadJobs returns the jobs of the directory. Results
+// without a job, e.g. those left over by a failed job
+// submission, are deleted.
+func (s *FileJobStore) LoadJobs() ([]*Job, error) {
+	files, err := ioutil.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*Job
+	hasJob := make(map[string]bool)
+	for _, f := range files {
+		id := strings.TrimSuffix(f.Name(), ".job")
+		if id == f.Name() || !reJobID.MatchString(id) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(s.Dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		job := &Job{}
+		if err = json.Unmarshal(data, job); err != nil || job.ID != id {
+			log.Printf("Job store: invalid job file %s: %v", f.Name(), err)
+			continue
+		}
+		jobs = append(jobs, job)
+		hasJob[id] = true
+	}
+
+	for _, f := range files {
+		id := strings.TrimSuffix(f.Name(), ".result")
+		if id != f.Name() && reJobID.MatchString(id) && !hasJob[id] {
+			s.Delete(id)
+		}
+	}
+	return jobs, nil
+}
+
+func (s *FileJobStore) DeleteJob(id string) error {
+	return s.remove(id, ".job")
+}
+
+func (s *FileJobStore) remove(id string, ext string) error {
+	fileName, err := s.path(id, ext)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(fileName)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// JobManager runs asynchronous jobs in the background
+// and keeps track of their status. Finished jobs along
+// with their results are removed once they expire.
+// Jobs are only visible from the namespace they were
+// submitted to.
+type JobManager struct {
+	Store  JobStore
+	Expiry time.Duration
+
+	mutex sync.Mutex
+	jobs  map[string]*Job
+}
+
+func NewJobManager(store JobStore, expiry time.Duration) *JobManager {
+	if expiry <= 0 {
+		expiry = DefaultJobExpiry
+	}
+
+	m := &JobManager{
+		Store:  store,
+		Expiry: expiry,
+		jobs:   make(map[string]*Job),
+	}
+	m.load(time.Now().UTC())
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			m.expire(now)
+		}
+	}()
+
+	return m
+}
+
+// Submit starts running a job of a namespace in the
+// background and returns a snapshot of the accepted job.
+// The job is cancelled if it runs for longer than timeout.
+func (m *JobManager) Submit(nameSpace string, processID string, timeout time.Duration, run JobFunc) (*Job, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate job id: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	job := &Job{
+		ID:        hex.EncodeToString(idBytes),
+		NameSpace: nameSpace,
+		ProcessID: processID,
+		Status:    JobAccepted,
+		Created:   time.Now().UTC(),
+		Progress:  &DrillProgress{},
+		cancel:    cancel,
+	}
+
+	m.mutex.Lock()
+	m.jobs[job.ID] = job
+	m.persist(job)
+	snapshot := *job
+	m.mutex.Unlock()
+
+	go func() {
+		defer cancel()
+
+		m.update(job.ID, func(j *Job) {
+			if j.Status == JobAccepted {
+				j.Status = JobRunning
+				j.Started = time.Now().UTC()
+			}
+		})
+
+		out, contentType, err := run(ctx, job.Progress)
+		if err == nil {
+			err = m.Store.Put(job.ID, out)
+		}
+
+		m.update(job.ID, func(j *Job) {
+			if j.Status == JobDismissed {
+				m.Store.Delete(j.ID)
+				return
+			}
+
+			j.Finished = time.Now().UTC()
+			j.Expires = j.Finished.Add(m.Expiry)
+			if err != nil {
+				j.Status = JobFailed
+				j.Message = err.Error()
+				if ctx.Err() == context.DeadlineExceeded {
+					j.Message = fmt.Sprintf("job timed out, threshold: %v", timeout)
+				}
+				return
+			}
+			j.Status = JobSucceeded
+			j.ContentType = contentType
+		})
+	}()
+
+	return &snapshot, nil
+}
+
+// Get returns a snapshot of a job of a namespace.
+func (m *JobManager) Get(nameSpace string, id string) (*Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	job, err := m.find(nameSpace, id)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// List returns snapshots of all the jobs of a
+// namespace, the most recently created first.
+func (m *JobManager) List(nameSpace string) []*Job {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		if job.NameSpace != nameSpace {
+			continue
+		}
+		snapshot := *job
+		jobs = append(jobs, &snapshot)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Created.After(jobs[j].Created) })
+	return jobs
+}
+
+// Result returns the result of a successful job of a namespace.
+func (m *JobManager) Result(nameSpace string, id string) ([]byte, *Job, error) {
+	job, err := m.Get(nameSpace, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != JobSucceeded {
+		return nil, job, fmt.Errorf("job %s has no result, status: %s", id, job.Status)
+	}
+
+	out, err := m.Store.Get(id)
+	if err != nil {
+		return nil, job, fmt.Errorf("failed to retrieve result of job %s: %v", id, err)
+	}
+	return out, job, nil
+}
+
+// Cancel stops a running job of a namespace or discards
+// the result of a finished job. The job remains visible
+// with dismissed status until it expires.
+func (m *JobManager) Cancel(nameSpace string, id string) (*Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	job, err := m.find(nameSpace, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status != JobDismissed {
+		job.cancel()
+		if job.Status == JobSucceeded {
+			m.Store.Delete(id)
+		}
+
+		now := time.Now().UTC()
+		job.Status = JobDismissed
+		job.Message = "job dismissed"
+		if job.Finished.IsZero() {
+			job.Finished = now
+		}
+		job.Expires = now.Add(m.Expiry)
+		m.persist(job)
+	}
+
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// find returns the job of an id unless it belongs to
+// another namespace. The caller must hold the mutex.
+func (m *JobManager) find(nameSpace string, id string) (*Job, error) {
+	job, found := m.jobs[id]
+	if !found || job.NameSpace != nameSpace {
+		return nil, fmt.Errorf("job not found: %s", id)
+	}
+	return job, nil
+}
+
+func (m *JobManager) update(id string, fn func(*Job)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if job, found := m.jobs[id]; found {
+		fn(job)
+		m.persist(job)
+	}
+}
+
+// persist saves the status of a job to the store.
+// The caller must hold the mutex.
+func (m *JobManager) persist(job *Job) {
+	if err := m.Store.PutJob(job); err != nil {
+		log.Printf("Job manager: failed to save job %s: %v", job.ID, err)
+	}
+}
+
+// load restores the jobs of the store. Jobs still running
+// when the server stopped cannot be resumed, they fail.
+func (m *JobManager) load(now time.Time) {
+	jobs, err := m.Store.LoadJobs()
+	if err != nil {
+		log.Printf("Job manager: failed to load jobs: %v", err)
+		return
+	}
+
+	m.mutex.Lock()
+	for _, job := range jobs {
+		job.Progress = &DrillProgress{}
+		job.cancel = func() {}
+		if !job.IsFinished() {
+			job.Status = JobFailed
+			job.Message = "job interrupted by a server restart"
+			job.Finished = now
+			job.Expires = now.Add(m.Expiry)
+			m.persist(job)
+		}
+		m.jobs[job.ID] = job
+	}
+	m.mutex.Unlock()
+
+	m.expire(now)
+}
+
+func (m *JobManager) expire(now time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for id, job := range m.jobs {
+		if !job.IsFinished() || now.Before(job.Expires) {
+			continue
+		}
+
+		if job.Status == JobSucceeded {
+			err := m.Store.Delete(id)
+			if err != nil {
+				log.Printf("Job manager: failed to delete result of job %s: %v", id, err)
+			}
+		}
+		if err := m.Store.DeleteJob(id); err != nil {
+			log.Printf("Job manager: failed to delete job %s: %v", id, err)
+		}
+		delete(m.jobs, id)
+	}
+}
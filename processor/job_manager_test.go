@@ -0,0 +1,156 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func waitJob(t *testing.T, m *JobManager, id string) *Job {
+	for i := 0; i < 100; i++ {
+		job, err := m.Get("ns", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.IsFinished() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+func TestJobManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gsky_jobs_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileJobStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewJobManager(store, time.Hour)
+
+	job, err := m.Submit("ns", "drill", time.Minute, func(ctx context.Context, progress *DrillProgress) ([]byte, string, error) {
+		progress.AddTotal(2)
+		progress.AddProcessed(2)
+		return []byte("result"), "text/plain", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job = waitJob(t, m, job.ID)
+	if job.Status != JobSucceeded || job.PercentCompleted() != 100 {
+		t.Errorf("unexpected job status: %v", job.Status)
+	}
+	out, _, err := m.Result("ns", job.ID)
+	if err != nil || string(out) != "result" {
+		t.Errorf("unexpected job result: %s, %v", string(out), err)
+	}
+	if _, _, err = m.Result("other", job.ID); err == nil {
+		t.Errorf("job %s is visible from another namespace", job.ID)
+	}
+	if _, err = m.Cancel("other", job.ID); err == nil {
+		t.Errorf("job %s can be dismissed from another namespace", job.ID)
+	}
+	if len(m.List("ns")) != 1 || len(m.List("other")) != 0 {
+		t.Errorf("unexpected job lists")
+	}
+
+	failed, _ := m.Submit("ns", "drill", time.Minute, func(ctx context.Context, progress *DrillProgress) ([]byte, string, error) {
+		return nil, "", fmt.Errorf("drill error")
+	})
+	failed = waitJob(t, m, failed.ID)
+	if failed.Status != JobFailed || failed.Message != "drill error" {
+		t.Errorf("unexpected job status: %v, %v", failed.Status, failed.Message)
+	}
+
+	running, _ := m.Submit("ns", "drill", time.Minute, func(ctx context.Context, progress *DrillProgress) ([]byte, string, error) {
+		<-ctx.Done()
+		return nil, "", ctx.Err()
+	})
+	if _, err = m.Cancel("ns", running.ID); err != nil {
+		t.Fatal(err)
+	}
+	running = waitJob(t, m, running.ID)
+	if running.Status != JobDismissed {
+		t.Errorf("unexpected job status: %v", running.Status)
+	}
+
+	m.expire(time.Now().Add(2 * time.Hour))
+	if _, err = m.Get("ns", job.ID); err == nil {
+		t.Errorf("job %s has not expired", job.ID)
+	}
+	if _, err = store.Get(job.ID); err == nil {
+		t.Errorf("result of job %s has not been deleted", job.ID)
+	}
+}
+
+func TestJobManagerRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gsky_jobs_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileJobStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewJobManager(store, time.Hour)
+
+	job, err := m.Submit("ns", "drill", time.Minute, func(ctx context.Context, progress *DrillProgress) ([]byte, string, error) {
+		return []byte("result"), "text/plain", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job = waitJob(t, m, job.ID)
+
+	now := time.Now().UTC()
+	running := &Job{ID: "0123456789abcdef0123456789abcdef", NameSpace: "ns", ProcessID: "drill", Status: JobRunning, Created: now, Started: now}
+	expired := &Job{ID: "fedcba9876543210fedcba9876543210", NameSpace: "ns", ProcessID: "drill", Status: JobSucceeded, Created: now, Finished: now, Expires: now.Add(-time.Minute)}
+	for _, j := range []*Job{running, expired} {
+		if err = store.PutJob(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = store.Put(expired.ID, []byte("expired")); err != nil {
+		t.Fatal(err)
+	}
+	orphan := "00112233445566778899aabbccddeeff"
+	if err = store.Put(orphan, []byte("orphan")); err != nil {
+		t.Fatal(err)
+	}
+
+	m = NewJobManager(store, time.Hour)
+
+	out, reloaded, err := m.Result("ns", job.ID)
+	if err != nil || string(out) != "result" || reloaded.ContentType != "text/plain" {
+		t.Errorf("unexpected job result after restart: %s, %v", string(out), err)
+	}
+
+	interrupted, err := m.Get("ns", running.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interrupted.Status != JobFailed || interrupted.Expires.IsZero() {
+		t.Errorf("unexpected status of interrupted job: %v", interrupted.Status)
+	}
+
+	if _, err = m.Get("ns", expired.ID); err == nil {
+		t.Errorf("job %s has not expired", expired.ID)
+	}
+	for _, id := range []string{expired.ID, orphan} {
+		if _, err = store.Get(id); err == nil {
+			t.Errorf("result of job %s has not been deleted", id)
+		}
+	}
+}
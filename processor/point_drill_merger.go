@@ -0,0 +1,183 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nci/gsky/utils"
+)
+
+// PointDrillMerger gathers the values sampled at every point
+// by the point drills of the granules. The output is a CSV
+// with a row per point and date made of the index of the
+// point, the date and the values of the band expressions.
+type PointDrillMerger struct {
+	Context context.Context
+	In      chan *DrillResult
+	Out     chan string
+	Error   chan error
+}
+
+func NewPointDrillMerger(ctx context.Context, errChan chan error) *PointDrillMerger {
+	return &PointDrillMerger{
+		Context: ctx,
+		In:      make(chan *DrillResult, 100),
+		Out:     make(chan string),
+		Error:   errChan,
+	}
+}
+
+type pointValue struct {
+	sum   float64
+	count int
+}
+
+func (pm *PointDrillMerger) Run(nPoints int, namespaces []string, bandExpr *utils.BandExpressions, verbose bool) {
+	if verbose {
+		defer log.Printf("Point Drill Merger done")
+	}
+	defer close(pm.Out)
+
+	// Values of the same point and date sampled from several
+	// granules, e.g. overlapping tiles, are averaged
+	results := make([]map[string]map[string]*pointValue, nPoints)
+	for ip := range results {
+		results[ip] = make(map[string]map[string]*pointValue)
+	}
+
+	noData := math.NaN()
+	for drillRes := range pm.In {
+		if drillRes.Column < 0 || drillRes.Column >= nPoints {
+			pm.sendError(fmt.Errorf("Point Drill Merger: unexpected point index %d", drillRes.Column))
+			return
+		}
+		noData = drillRes.NoData
+
+		for i, date := range drillRes.Dates {
+			if i >= len(drillRes.Data) || drillRes.Data[i] == nil {
+				break
+			}
+			data := drillRes.Data[i]
+			if data.Count == 0 || math.IsNaN(data.Value) {
+				continue
+			}
+
+			isoDate := date.Format(ISOFormat)
+			values, ok := results[drillRes.Column][isoDate]
+			if !ok {
+				values = make(map[string]*pointValue)
+				results[drillRes.Column][isoDate] = values
+			}
+			val, ok := values[drillRes.NameSpace]
+			if !ok {
+				val = &pointValue{}
+				values[drillRes.NameSpace] = val
+			}
+			val.sum += data.Value
+			val.count++
+		}
+	}
+
+	var csv strings.Builder
+	for ip := range results {
+		var dates []string
+		for date := range results[ip] {
+			dates = append(dates, date)
+		}
+		sort.Strings(dates)
+
+		for _, date := range dates {
+			values := make(map[string]float64)
+			for ns, val := range results[ip][date] {
+				values[ns] = val.sum / float64(val.count)
+			}
+
+			cells, err := drillValueCells(namespaces, bandExpr, values, noData)
+			if err != nil {
+				pm.sendError(err)
+				return
+			}
+			fmt.Fprintf(&csv, "%d,%s,%s\n", ip, date, strings.Join(cells, ","))
+		}
+	}
+
+	if pm.checkCancellation() {
+		return
+	}
+	pm.Out <- csv.String()
+}
+
+func (pm *PointDrillMerger) sendError(err error) {
+	select {
+	case pm.Error <- err:
+	default:
+	}
+}
+
+func (pm *PointDrillMerger) checkCancellation() bool {
+	select {
+	case <-pm.Context.Done():
+		pm.sendError(fmt.Errorf("Point Drill Merger: context has been cancel: %v", pm.Context.Err()))
+		return true
+	case err := <-pm.Error:
+		pm.sendError(err)
+		return true
+	default:
+		return false
+	}
+}
+
+// drillValueCells returns the CSV cells of the values sampled
+// at a location, i.e. either the values of the namespaces or
+// those of the band expressions evaluated over the namespaces.
+func drillValueCells(namespaces []string, bandExpr *utils.BandExpressions, values map[string]float64, noData float64) ([]string, error) {
+	var cells []string
+	if len(bandExpr.Expressions) == 0 {
+		for _, ns := range namespaces {
+			cell := ""
+			if val, ok := values[ns]; ok {
+				cell = strconv.FormatFloat(val, 'f', -1, 64)
+			}
+			cells = append(cells, cell)
+		}
+		return cells, nil
+	}
+
+	for ix, expr := range bandExpr.Expressions {
+		parameters := make(map[string]interface{}, len(bandExpr.ExprVarRef[ix]))
+		for _, variable := range bandExpr.ExprVarRef[ix] {
+			val, ok := values[variable]
+			if !ok {
+				parameters = nil
+				break
+			}
+			parameters[variable] = val
+		}
+		if parameters == nil {
+			cells = append(cells, "")
+			continue
+		}
+
+		result, err := expr.Evaluate(parameters)
+		if err != nil {
+			return nil, fmt.Errorf("WPS: Eval '%v' error: %v", bandExpr.ExprText[ix], err)
+		}
+
+		val, ok := result.(float32)
+		if !ok {
+			return nil, fmt.Errorf("WPS: Failed to cast eval results '%v' to float32, %v", val, bandExpr.ExprText[ix])
+		}
+
+		cell := ""
+		if float32(noData) != val && !math.IsNaN(float64(val)) {
+			cell = strconv.FormatFloat(float64(val), 'f', -1, 32)
+		}
+		cells = append(cells, cell)
+	}
+	return cells, nil
+}
@@ -325,16 +325,14 @@ func getDataSize(dataType string) (int, error) {
 		return 2, nil
 	case "UInt16":
 		return 2, nil
-	case "Float32":
-		return 4, nil
-
-	// grpc workers convert any real data types other than the above to float32
-	case "Float64":
-		return 4, nil
 	case "Int32":
 		return 4, nil
 	case "UInt32":
 		return 4, nil
+	case "Float32":
+		return 4, nil
+	case "Float64":
+		return 8, nil
 	default:
 		return -1, fmt.Errorf("Unsupported raster type %s", dataType)
 	}
@@ -381,6 +379,10 @@ func getRPCRaster(ctx context.Context, g *GeoTileGranule, projWKT string, geot [
 		granule.SRSCf = int32(g.SRSCf)
 	}
 
+	if len(g.Resampling) > 0 {
+		granule.Resampling = g.Resampling
+	}
+
 	r, err := c.Process(ctx, granule)
 	if err != nil {
 		return nil, err
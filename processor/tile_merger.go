@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unsafe"
 
 	"github.com/nci/gsky/utils"
@@ -18,6 +19,9 @@ import (
 const SizeofUint16 = 2
 const SizeofInt16 = 2
 const SizeofFloat32 = 4
+const SizeofInt32 = 4
+const SizeofUint32 = 4
+const SizeofFloat64 = 8
 
 type RasterMerger struct {
 	Context context.Context
@@ -217,6 +221,119 @@ func MergeMaskedRaster(r *FlexRaster, canvasMap map[string]*FlexRaster, mask []b
 			}
 			canvasMap[r.NameSpace].TimeStamp = r.TimeStamp
 		}
+	case "Int32":
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&canvasMap[r.NameSpace].Data))
+		headr.Len /= SizeofInt32
+		headr.Cap /= SizeofInt32
+		canvas := *(*[]int32)(unsafe.Pointer(&headr))
+
+		header := *(*reflect.SliceHeader)(unsafe.Pointer(&r.Data))
+		header.Len /= SizeofInt32
+		header.Cap /= SizeofInt32
+		data := *(*[]int32)(unsafe.Pointer(&header))
+		nodata := int32(r.NoData)
+
+		if r.TimeStamp < canvasMap[r.NameSpace].TimeStamp {
+			iSrc := 0
+			for ir := 0; ir < r.DataHeight; ir++ {
+				for ic := 0; ic < r.DataWidth; ic++ {
+					val := data[iSrc]
+					iDst := (ir+r.OffY)*r.Width + ic + r.OffX
+					if val != nodata && !mask[iSrc] && canvas[iDst] == nodata {
+						canvas[iDst] = val
+					}
+					iSrc++
+				}
+			}
+		} else {
+			iSrc := 0
+			for ir := 0; ir < r.DataHeight; ir++ {
+				for ic := 0; ic < r.DataWidth; ic++ {
+					val := data[iSrc]
+					if val != nodata && !mask[iSrc] {
+						iDst := (ir+r.OffY)*r.Width + ic + r.OffX
+						canvas[iDst] = val
+					}
+					iSrc++
+				}
+			}
+			canvasMap[r.NameSpace].TimeStamp = r.TimeStamp
+		}
+	case "UInt32":
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&canvasMap[r.NameSpace].Data))
+		headr.Len /= SizeofUint32
+		headr.Cap /= SizeofUint32
+		canvas := *(*[]uint32)(unsafe.Pointer(&headr))
+
+		header := *(*reflect.SliceHeader)(unsafe.Pointer(&r.Data))
+		header.Len /= SizeofUint32
+		header.Cap /= SizeofUint32
+		data := *(*[]uint32)(unsafe.Pointer(&header))
+		nodata := uint32(r.NoData)
+
+		if r.TimeStamp < canvasMap[r.NameSpace].TimeStamp {
+			iSrc := 0
+			for ir := 0; ir < r.DataHeight; ir++ {
+				for ic := 0; ic < r.DataWidth; ic++ {
+					val := data[iSrc]
+					iDst := (ir+r.OffY)*r.Width + ic + r.OffX
+					if val != nodata && !mask[iSrc] && canvas[iDst] == nodata {
+						canvas[iDst] = val
+					}
+					iSrc++
+				}
+			}
+		} else {
+			iSrc := 0
+			for ir := 0; ir < r.DataHeight; ir++ {
+				for ic := 0; ic < r.DataWidth; ic++ {
+					val := data[iSrc]
+					if val != nodata && !mask[iSrc] {
+						iDst := (ir+r.OffY)*r.Width + ic + r.OffX
+						canvas[iDst] = val
+					}
+					iSrc++
+				}
+			}
+			canvasMap[r.NameSpace].TimeStamp = r.TimeStamp
+		}
+	case "Float64":
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&canvasMap[r.NameSpace].Data))
+		headr.Len /= SizeofFloat64
+		headr.Cap /= SizeofFloat64
+		canvas := *(*[]float64)(unsafe.Pointer(&headr))
+
+		header := *(*reflect.SliceHeader)(unsafe.Pointer(&r.Data))
+		header.Len /= SizeofFloat64
+		header.Cap /= SizeofFloat64
+		data := *(*[]float64)(unsafe.Pointer(&header))
+		nodata := float64(r.NoData)
+		if r.TimeStamp < canvasMap[r.NameSpace].TimeStamp {
+			iSrc := 0
+			for ir := 0; ir < r.DataHeight; ir++ {
+				for ic := 0; ic < r.DataWidth; ic++ {
+					val := data[iSrc]
+					iDst := (ir+r.OffY)*r.Width + ic + r.OffX
+					if val != nodata && !mask[iSrc] && canvas[iDst] == nodata {
+						canvas[iDst] = val
+					}
+					iSrc++
+				}
+			}
+		} else {
+			iSrc := 0
+			for ir := 0; ir < r.DataHeight; ir++ {
+				for ic := 0; ic < r.DataWidth; ic++ {
+					val := data[iSrc]
+					if val != nodata && !mask[iSrc] {
+						iDst := (ir+r.OffY)*r.Width + ic + r.OffX
+						canvas[iDst] = val
+					}
+					iSrc++
+				}
+			}
+			canvasMap[r.NameSpace].TimeStamp = r.TimeStamp
+		}
 	default:
 		err = fmt.Errorf("MergeMaskedRaster hasn't been implemented for Raster type %s", r.Type)
 	}
@@ -271,6 +388,36 @@ func initNoDataSlice(rType string, noDataValue float64, size int) []uint8 {
 		headr.Len *= SizeofFloat32
 		headr.Cap *= SizeofFloat32
 		return *(*[]uint8)(unsafe.Pointer(&headr))
+	case "Int32":
+		out := make([]int32, size)
+		fill := int32(noDataValue)
+		for i := 0; i < size; i++ {
+			out[i] = fill
+		}
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&out))
+		headr.Len *= SizeofInt32
+		headr.Cap *= SizeofInt32
+		return *(*[]uint8)(unsafe.Pointer(&headr))
+	case "UInt32":
+		out := make([]uint32, size)
+		fill := uint32(noDataValue)
+		for i := 0; i < size; i++ {
+			out[i] = fill
+		}
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&out))
+		headr.Len *= SizeofUint32
+		headr.Cap *= SizeofUint32
+		return *(*[]uint8)(unsafe.Pointer(&headr))
+	case "Float64":
+		out := make([]float64, size)
+		fill := float64(noDataValue)
+		for i := 0; i < size; i++ {
+			out[i] = fill
+		}
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&out))
+		headr.Len *= SizeofFloat64
+		headr.Cap *= SizeofFloat64
+		return *(*[]uint8)(unsafe.Pointer(&headr))
 	default:
 		return []uint8{}
 	}
@@ -310,6 +457,494 @@ func ProcessRasterStack(rasterStack map[float64][]*FlexRaster, maskMap map[float
 	return canvasMap, nil
 }
 
+// ProcessTemporalRasterStack mosaics the rasters of every date
+// of the stack onto the canvases of the date so that the dates
+// can be reduced per pixel once all the rasters are merged.
+func ProcessTemporalRasterStack(rasterStack map[float64][]*FlexRaster, maskMap map[float64][]bool, dateCanvasMap map[float64]map[string]*FlexRaster) error {
+	dateStacks := make(map[float64]map[float64][]*FlexRaster)
+	for geoStamp, rasters := range rasterStack {
+		for _, r := range rasters {
+			if _, ok := dateStacks[r.TimeStamp]; !ok {
+				dateStacks[r.TimeStamp] = make(map[float64][]*FlexRaster)
+			}
+			dateStacks[r.TimeStamp][geoStamp] = append(dateStacks[r.TimeStamp][geoStamp], r)
+		}
+		delete(rasterStack, geoStamp)
+	}
+
+	for timeStamp, stack := range dateStacks {
+		canvasMap, ok := dateCanvasMap[timeStamp]
+		if !ok {
+			canvasMap = make(map[string]*FlexRaster)
+			dateCanvasMap[timeStamp] = canvasMap
+		}
+		if _, err := ProcessRasterStack(stack, maskMap, canvasMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReduceTemporalRasterStack reduces per pixel the canvases of
+// every date with the aggregation of the request, i.e. one of
+// utils.TemporalAggregations. The resulting canvases are Float32
+// whose pixels without valid values across all the dates are set
+// to the nodata value of the namespace.
+func ReduceTemporalRasterStack(dateCanvasMap map[float64]map[string]*FlexRaster, agg string) (map[string]*FlexRaster, error) {
+	var timeStamps []float64
+	for timeStamp := range dateCanvasMap {
+		timeStamps = append(timeStamps, timeStamp)
+	}
+	sort.Float64s(timeStamps)
+
+	nsCanvases := make(map[string][]*FlexRaster)
+	var nameSpaces []string
+	for _, timeStamp := range timeStamps {
+		for ns, canvas := range dateCanvasMap[timeStamp] {
+			if _, ok := nsCanvases[ns]; !ok {
+				nameSpaces = append(nameSpaces, ns)
+			}
+			nsCanvases[ns] = append(nsCanvases[ns], canvas)
+		}
+	}
+
+	canvasMap := make(map[string]*FlexRaster)
+	for _, ns := range nameSpaces {
+		canvases := nsCanvases[ns]
+		first := canvases[0]
+		if ns == utils.EmptyTileNS {
+			canvasMap[ns] = first
+			continue
+		}
+
+		size := first.Width * first.Height
+		stack := make([][]float64, len(canvases))
+		for i, canvas := range canvases {
+			if canvas.Width*canvas.Height != size {
+				return nil, fmt.Errorf("temporal aggregation: canvas size mismatch for namespace %s", ns)
+			}
+			vals, err := flexRasterFloat64(canvas)
+			if err != nil {
+				return nil, err
+			}
+			stack[i] = vals
+		}
+
+		out := make([]float32, size)
+		noData := float32(first.NoData)
+		pixVals := make([]float64, 0, len(stack))
+		for i := 0; i < size; i++ {
+			pixVals = pixVals[:0]
+			for _, vals := range stack {
+				if !math.IsNaN(vals[i]) {
+					pixVals = append(pixVals, vals[i])
+				}
+			}
+
+			if len(pixVals) == 0 {
+				out[i] = noData
+				continue
+			}
+			out[i] = float32(reduceTemporalValues(pixVals, agg))
+		}
+
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&out))
+		headr.Len *= SizeofFloat32
+		headr.Cap *= SizeofFloat32
+		canvasMap[ns] = &FlexRaster{TimeStamp: first.TimeStamp, ConfigPayLoad: first.ConfigPayLoad,
+			NoData: first.NoData, Data: *(*[]uint8)(unsafe.Pointer(&headr)),
+			Height: first.Height, Width: first.Width, OffX: first.OffX, OffY: first.OffY,
+			Type: "Float32", NameSpace: ns}
+	}
+	return canvasMap, nil
+}
+
+// NewCompositingParams returns the best pixel compositing of
+// a layer for a request at targetTime, or nil if the layer
+// paints the latest pixels. The variables of the score that
+// aren't amongst the namespaces of the request are added to
+// ScoreVars.
+func NewCompositingParams(layer *utils.Layer, nameSpaces []string, targetTime *time.Time, dateBand bool) *CompositingParams {
+	if layer.Compositing != utils.CompositingBestPixel || layer.CompositingScoreExpr == nil {
+		return nil
+	}
+
+	params := &CompositingParams{Score: layer.CompositingScoreExpr}
+	if targetTime != nil {
+		params.TargetTime = float64(targetTime.Unix())
+	}
+	if dateBand {
+		params.DateBand = layer.CompositingDateBand
+	}
+
+	found := make(map[string]struct{})
+	for _, ns := range nameSpaces {
+		found[ns] = struct{}{}
+	}
+	for _, v := range layer.CompositingScoreExpr.VarList {
+		if _, ok := found[v]; !ok && v != utils.CompositingDateDistance {
+			params.ScoreVars = append(params.ScoreVars, v)
+		}
+	}
+	return params
+}
+
+// CompositeBestPixel selects per pixel the canvases of the date
+// of the highest compositing score amongst the dates where the
+// score and all the namespaces are valid. The canvases keep
+// their raster types and the date band, if any, is added as a
+// Float32 canvas.
+func CompositeBestPixel(dateCanvasMap map[float64]map[string]*FlexRaster, params *CompositingParams) (map[string]*FlexRaster, error) {
+	var timeStamps []float64
+	for timeStamp := range dateCanvasMap {
+		timeStamps = append(timeStamps, timeStamp)
+	}
+	sort.Float64s(timeStamps)
+
+	var nameSpaces []string
+	var first *FlexRaster
+	found := make(map[string]struct{})
+	for _, timeStamp := range timeStamps {
+		for ns, canvas := range dateCanvasMap[timeStamp] {
+			if _, ok := found[ns]; !ok {
+				found[ns] = struct{}{}
+				nameSpaces = append(nameSpaces, ns)
+				if ns != utils.EmptyTileNS {
+					first = canvas
+				}
+			}
+		}
+	}
+	sort.Strings(nameSpaces)
+
+	canvasMap := make(map[string]*FlexRaster)
+	if first == nil {
+		for _, canvas := range dateCanvasMap[timeStamps[0]] {
+			canvasMap[canvas.NameSpace] = canvas
+		}
+		return canvasMap, nil
+	}
+
+	size := first.Width * first.Height
+	bestDate := make([]int, size)
+	bestScore := make([]float64, size)
+	for i := range bestDate {
+		bestDate[i] = -1
+	}
+
+	for it, timeStamp := range timeStamps {
+		dateCanvases := dateCanvasMap[timeStamp]
+
+		valid := make([]bool, size)
+		for i := range valid {
+			valid[i] = true
+		}
+		parameters := make(map[string]interface{})
+		for _, ns := range nameSpaces {
+			if ns == utils.EmptyTileNS {
+				continue
+			}
+			canvas, ok := dateCanvases[ns]
+			if !ok || canvas.Width*canvas.Height != size {
+				valid = nil
+				break
+			}
+			vals, err := flexRasterFloat64(canvas)
+			if err != nil {
+				return nil, err
+			}
+			varData := make([]float32, size)
+			for i, v := range vals {
+				if math.IsNaN(v) {
+					valid[i] = false
+				}
+				varData[i] = float32(v)
+			}
+			parameters[ns] = varData
+		}
+		if valid == nil {
+			continue
+		}
+
+		dateDistance := make([]float32, size)
+		for i := range dateDistance {
+			dateDistance[i] = float32(math.Abs(timeStamp-params.TargetTime) / 86400)
+		}
+		parameters[utils.CompositingDateDistance] = dateDistance
+
+		score, err := evalCompositingScore(params.Score, parameters, size)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < size; i++ {
+			if !valid[i] || math.IsNaN(float64(score[i])) || math.IsInf(float64(score[i]), 0) {
+				continue
+			}
+			if bestDate[i] < 0 || float64(score[i]) > bestScore[i] {
+				bestDate[i] = it
+				bestScore[i] = float64(score[i])
+			}
+		}
+	}
+
+	for _, ns := range nameSpaces {
+		if ns == utils.EmptyTileNS {
+			continue
+		}
+
+		var nsCanvas *FlexRaster
+		for _, timeStamp := range timeStamps {
+			if canvas, ok := dateCanvasMap[timeStamp][ns]; ok {
+				nsCanvas = canvas
+				break
+			}
+		}
+
+		elemSize, err := rasterTypeSize(nsCanvas.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		out := initNoDataSlice(nsCanvas.Type, nsCanvas.NoData, size)
+		for i := 0; i < size; i++ {
+			if bestDate[i] < 0 {
+				continue
+			}
+			src := dateCanvasMap[timeStamps[bestDate[i]]][ns]
+			if src.Type != nsCanvas.Type {
+				return nil, fmt.Errorf("best pixel compositing: raster type mismatch for namespace %s", ns)
+			}
+			copy(out[i*elemSize:(i+1)*elemSize], src.Data[i*elemSize:(i+1)*elemSize])
+		}
+
+		canvasMap[ns] = &FlexRaster{TimeStamp: nsCanvas.TimeStamp, ConfigPayLoad: nsCanvas.ConfigPayLoad,
+			NoData: nsCanvas.NoData, Data: out,
+			Height: nsCanvas.Height, Width: nsCanvas.Width, OffX: nsCanvas.OffX, OffY: nsCanvas.OffY,
+			Type: nsCanvas.Type, NameSpace: ns}
+	}
+
+	if len(params.DateBand) > 0 {
+		dates := make([]float32, size)
+		for i := range dates {
+			if bestDate[i] < 0 {
+				dates[i] = float32(CompositingDateNoData)
+			} else {
+				dates[i] = float32(math.Floor(timeStamps[bestDate[i]] / 86400))
+			}
+		}
+
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&dates))
+		headr.Len *= SizeofFloat32
+		headr.Cap *= SizeofFloat32
+		canvasMap[params.DateBand] = &FlexRaster{ConfigPayLoad: first.ConfigPayLoad,
+			NoData: CompositingDateNoData, Data: *(*[]uint8)(unsafe.Pointer(&headr)),
+			Height: first.Height, Width: first.Width, OffX: first.OffX, OffY: first.OffY,
+			Type: "Float32", NameSpace: params.DateBand}
+	}
+	return canvasMap, nil
+}
+
+// CompositingDateNoData is the nodata value of the date band
+// of best pixel composites.
+const CompositingDateNoData = -9999.0
+
+// evalCompositingScore evaluates the score of the pixels of a
+// date. A score made of a single variable is its values.
+func evalCompositingScore(score *utils.BandExpressions, parameters map[string]interface{}, size int) ([]float32, error) {
+	if len(score.Expressions) == 0 {
+		if len(score.VarList) != 1 {
+			return nil, fmt.Errorf("invalid compositing score: %v", score.ExprText)
+		}
+		vals, ok := parameters[score.VarList[0]].([]float32)
+		if !ok {
+			return nil, fmt.Errorf("compositing score variable not found: %v", score.VarList[0])
+		}
+		return vals, nil
+	}
+
+	for _, v := range score.VarList {
+		if _, ok := parameters[v]; !ok {
+			return nil, fmt.Errorf("compositing score variable not found: %v", v)
+		}
+	}
+
+	result, err := score.Expressions[0].Evaluate(parameters)
+	if err != nil {
+		return nil, fmt.Errorf("compositing score '%v' error: %v", score.ExprText[0], err)
+	}
+
+	switch res := result.(type) {
+	case []float32:
+		return res, nil
+	case float32:
+		out := make([]float32, size)
+		for i := range out {
+			out[i] = res
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unknown data type for returned value '%v' for compositing score '%v'", result, score.ExprText[0])
+	}
+}
+
+// rasterTypeSize returns the size in bytes of the
+// pixels of a raster type.
+func rasterTypeSize(rType string) (int, error) {
+	switch rType {
+	case "SignedByte", "Byte":
+		return 1, nil
+	case "Int16":
+		return SizeofInt16, nil
+	case "UInt16":
+		return SizeofUint16, nil
+	case "Int32":
+		return SizeofInt32, nil
+	case "UInt32":
+		return SizeofUint32, nil
+	case "Float32":
+		return SizeofFloat32, nil
+	case "Float64":
+		return SizeofFloat64, nil
+	default:
+		return 0, fmt.Errorf("best pixel compositing hasn't been implemented for Raster type %s", rType)
+	}
+}
+
+// reduceTemporalValues returns the aggregation of the
+// valid values of a pixel. The values are reordered by
+// the median.
+func reduceTemporalValues(vals []float64, agg string) float64 {
+	switch agg {
+	case "min":
+		res := vals[0]
+		for _, v := range vals[1:] {
+			res = math.Min(res, v)
+		}
+		return res
+	case "max":
+		res := vals[0]
+		for _, v := range vals[1:] {
+			res = math.Max(res, v)
+		}
+		return res
+	case "median":
+		sort.Float64s(vals)
+		mid := len(vals) / 2
+		if len(vals)%2 == 0 {
+			return (vals[mid-1] + vals[mid]) / 2
+		}
+		return vals[mid]
+	case "count":
+		return float64(len(vals))
+	}
+
+	sum := 0.0
+	for _, v := range vals {
+		sum += v
+	}
+	switch agg {
+	case "sum":
+		return sum
+	case "stddev":
+		mean := sum / float64(len(vals))
+		sqSum := 0.0
+		for _, v := range vals {
+			sqSum += (v - mean) * (v - mean)
+		}
+		return math.Sqrt(sqSum / float64(len(vals)))
+	default:
+		return sum / float64(len(vals))
+	}
+}
+
+// flexRasterFloat64 returns the pixels of a canvas as float64,
+// the nodata pixels being NaN.
+func flexRasterFloat64(r *FlexRaster) ([]float64, error) {
+	size := r.Width * r.Height
+	out := make([]float64, size)
+	header := *(*reflect.SliceHeader)(unsafe.Pointer(&r.Data))
+	switch r.Type {
+	case "SignedByte":
+		data := *(*[]int8)(unsafe.Pointer(&header))
+		for i := 0; i < size; i++ {
+			out[i] = float64(data[i])
+		}
+	case "Byte":
+		for i := 0; i < size; i++ {
+			out[i] = float64(r.Data[i])
+		}
+	case "Int16":
+		header.Len /= SizeofInt16
+		header.Cap /= SizeofInt16
+		data := *(*[]int16)(unsafe.Pointer(&header))
+		for i := 0; i < size; i++ {
+			out[i] = float64(data[i])
+		}
+	case "UInt16":
+		header.Len /= SizeofUint16
+		header.Cap /= SizeofUint16
+		data := *(*[]uint16)(unsafe.Pointer(&header))
+		for i := 0; i < size; i++ {
+			out[i] = float64(data[i])
+		}
+	case "Float32":
+		header.Len /= SizeofFloat32
+		header.Cap /= SizeofFloat32
+		data := *(*[]float32)(unsafe.Pointer(&header))
+		for i := 0; i < size; i++ {
+			out[i] = float64(data[i])
+		}
+	case "Int32":
+		header.Len /= SizeofInt32
+		header.Cap /= SizeofInt32
+		data := *(*[]int32)(unsafe.Pointer(&header))
+		for i := 0; i < size; i++ {
+			out[i] = float64(data[i])
+		}
+	case "UInt32":
+		header.Len /= SizeofUint32
+		header.Cap /= SizeofUint32
+		data := *(*[]uint32)(unsafe.Pointer(&header))
+		for i := 0; i < size; i++ {
+			out[i] = float64(data[i])
+		}
+	case "Float64":
+		header.Len /= SizeofFloat64
+		header.Cap /= SizeofFloat64
+		data := *(*[]float64)(unsafe.Pointer(&header))
+		for i := 0; i < size; i++ {
+			out[i] = float64(data[i])
+		}
+	default:
+		return nil, fmt.Errorf("temporal aggregation hasn't been implemented for Raster type %s", r.Type)
+	}
+
+	noData := r.NoData
+	switch r.Type {
+	case "SignedByte":
+		noData = float64(int8(r.NoData))
+	case "Byte":
+		noData = float64(uint8(r.NoData))
+	case "Int16":
+		noData = float64(int16(r.NoData))
+	case "UInt16":
+		noData = float64(uint16(r.NoData))
+	case "Int32":
+		noData = float64(int32(r.NoData))
+	case "UInt32":
+		noData = float64(uint32(r.NoData))
+	case "Float32":
+		noData = float64(float32(r.NoData))
+	}
+	for i, v := range out {
+		if v == noData || math.IsInf(v, 0) {
+			out[i] = math.NaN()
+		}
+	}
+	return out, nil
+}
+
 func ComputeMask(mask *utils.Mask, data []byte, rType string) (out []bool, err error) {
 	if len(mask.Value) == 0 {
 		if len(mask.BitTests) == 0 {
@@ -429,6 +1064,64 @@ func ComputeMask(mask *utils.Mask, data []byte, rType string) (out []bool, err e
 					maskValue64, _ := strconv.ParseInt(mask.BitTests[j+1], 2, 16)
 					maskValue := uint16(maskValue64)
 
+					if (val & maskFilter) == maskValue {
+						out[i] = true
+						break
+					}
+				}
+			}
+		}
+	case "Int32":
+		header.Len /= SizeofInt32
+		header.Cap /= SizeofInt32
+		data := *(*[]int32)(unsafe.Pointer(&header))
+		out = make([]bool, len(data))
+		if len(mask.Value) > 0 {
+			maskValue64, _ := strconv.ParseInt(mask.Value, 2, 32)
+			maskValue := int32(maskValue64)
+			for i, val := range data {
+				if (val & maskValue) > 0 {
+					out[i] = true
+				}
+			}
+		} else {
+			for i, val := range data {
+				for j := 0; j < len(mask.BitTests); j += 2 {
+					maskFilter64, _ := strconv.ParseInt(mask.BitTests[j], 2, 32)
+					maskFilter := int32(maskFilter64)
+
+					maskValue64, _ := strconv.ParseInt(mask.BitTests[j+1], 2, 32)
+					maskValue := int32(maskValue64)
+
+					if (val & maskFilter) == maskValue {
+						out[i] = true
+						break
+					}
+				}
+			}
+		}
+	case "UInt32":
+		header.Len /= SizeofUint32
+		header.Cap /= SizeofUint32
+		data := *(*[]uint32)(unsafe.Pointer(&header))
+		out = make([]bool, len(data))
+		if len(mask.Value) > 0 {
+			maskValue64, _ := strconv.ParseUint(mask.Value, 2, 32)
+			maskValue := uint32(maskValue64)
+			for i, val := range data {
+				if (val & maskValue) > 0 {
+					out[i] = true
+				}
+			}
+		} else {
+			for i, val := range data {
+				for j := 0; j < len(mask.BitTests); j += 2 {
+					maskFilter64, _ := strconv.ParseInt(mask.BitTests[j], 2, 32)
+					maskFilter := uint32(maskFilter64)
+
+					maskValue64, _ := strconv.ParseInt(mask.BitTests[j+1], 2, 32)
+					maskValue := uint32(maskValue64)
+
 					if (val & maskFilter) == maskValue {
 						out[i] = true
 						break
@@ -450,6 +1143,13 @@ func (enc *RasterMerger) Run(bandExpr *utils.BandExpressions, verbose bool) {
 	defer close(enc.Out)
 
 	canvasMap := map[string]*FlexRaster{}
+
+	// The rasters of a temporal aggregation or of a best
+	// pixel composite are mosaicked by date before being
+	// reduced across the dates
+	var temporalAgg string
+	var compositing *CompositingParams
+	dateCanvasMap := map[float64]map[string]*FlexRaster{}
 	for inRasters := range enc.In {
 		select {
 		case <-enc.Context.Done():
@@ -499,9 +1199,21 @@ func (enc *RasterMerger) Run(bandExpr *utils.BandExpressions, verbose bool) {
 			}
 
 			rasterStack[geoStamp] = append(rasterStack[geoStamp], r)
+			if len(r.TemporalAgg) > 0 && r.NameSpace != utils.EmptyTileNS {
+				temporalAgg = r.TemporalAgg
+			}
+			if r.Compositing != nil && r.NameSpace != utils.EmptyTileNS {
+				compositing = r.Compositing
+			}
 		}
 
-		if len(rasterStack) > 0 {
+		if len(rasterStack) > 0 && (len(temporalAgg) > 0 || compositing != nil) {
+			err := ProcessTemporalRasterStack(rasterStack, maskMap, dateCanvasMap)
+			if err != nil {
+				enc.sendError(err)
+				return
+			}
+		} else if len(rasterStack) > 0 {
 			tmpMap, err := ProcessRasterStack(rasterStack, maskMap, canvasMap)
 			if err != nil {
 				enc.sendError(err)
@@ -515,6 +1227,24 @@ func (enc *RasterMerger) Run(bandExpr *utils.BandExpressions, verbose bool) {
 		return
 	}
 
+	// Empty tiles don't carry the aggregation and are only
+	// rendered if none of the dates have any data
+	if len(dateCanvasMap) > 0 && len(temporalAgg) > 0 {
+		tmpMap, err := ReduceTemporalRasterStack(dateCanvasMap, temporalAgg)
+		if err != nil {
+			enc.sendError(err)
+			return
+		}
+		canvasMap = tmpMap
+	} else if len(dateCanvasMap) > 0 {
+		tmpMap, err := CompositeBestPixel(dateCanvasMap, compositing)
+		if err != nil {
+			enc.sendError(err)
+			return
+		}
+		canvasMap = tmpMap
+	}
+
 	var nameSpaces []string
 	if _, found := canvasMap[utils.EmptyTileNS]; found {
 		nameSpaces = append(nameSpaces, utils.EmptyTileNS)
@@ -525,6 +1255,22 @@ func (enc *RasterMerger) Run(bandExpr *utils.BandExpressions, verbose bool) {
 		}
 	}
 
+	// Variables only fetched for the compositing
+	// score aren't rendered
+	if compositing != nil && len(compositing.ScoreVars) > 0 {
+		scoreVars := make(map[string]struct{})
+		for _, v := range compositing.ScoreVars {
+			scoreVars[v] = struct{}{}
+		}
+		var renderedNameSpaces []string
+		for _, ns := range nameSpaces {
+			if _, found := scoreVars[ns]; !found {
+				renderedNameSpaces = append(renderedNameSpaces, ns)
+			}
+		}
+		nameSpaces = renderedNameSpaces
+	}
+
 	if len(nameSpaces) == 0 {
 		enc.Out <- []utils.Raster{&utils.ByteRaster{Data: make([]uint8, 0), NameSpace: utils.EmptyTileNS, Height: 0, Width: 0}}
 		return
@@ -655,6 +1401,51 @@ func (enc *RasterMerger) Run(bandExpr *utils.BandExpressions, verbose bool) {
 				bandVars[i] = &utils.Float32Raster{NoData: float64(canvas.NoData), Data: varData}
 			}
 
+		case "Int32":
+			headr.Len /= SizeofInt32
+			headr.Cap /= SizeofInt32
+			data := *(*[]int32)(unsafe.Pointer(&headr))
+			if !hasExpr {
+				out[i] = &utils.Int32Raster{NoData: canvas.NoData, Data: data,
+					Width: canvas.Width, Height: canvas.Height, NameSpace: ns}
+			} else {
+				varData := make([]float32, len(data))
+				for i, val := range data {
+					varData[i] = float32(val)
+				}
+				bandVars[i] = &utils.Float32Raster{NoData: float64(canvas.NoData), Data: varData}
+			}
+
+		case "UInt32":
+			headr.Len /= SizeofUint32
+			headr.Cap /= SizeofUint32
+			data := *(*[]uint32)(unsafe.Pointer(&headr))
+			if !hasExpr {
+				out[i] = &utils.UInt32Raster{NoData: canvas.NoData, Data: data,
+					Width: canvas.Width, Height: canvas.Height, NameSpace: ns}
+			} else {
+				varData := make([]float32, len(data))
+				for i, val := range data {
+					varData[i] = float32(val)
+				}
+				bandVars[i] = &utils.Float32Raster{NoData: float64(canvas.NoData), Data: varData}
+			}
+
+		case "Float64":
+			headr.Len /= SizeofFloat64
+			headr.Cap /= SizeofFloat64
+			data := *(*[]float64)(unsafe.Pointer(&headr))
+			if !hasExpr {
+				out[i] = &utils.Float64Raster{NoData: canvas.NoData, Data: data,
+					Width: canvas.Width, Height: canvas.Height, NameSpace: ns}
+			} else {
+				varData := make([]float32, len(data))
+				for i, val := range data {
+					varData[i] = float32(val)
+				}
+				bandVars[i] = &utils.Float32Raster{NoData: float64(canvas.NoData), Data: varData}
+			}
+
 		default:
 			enc.sendError(fmt.Errorf("raster type %s not recognised", canvas.Type))
 			return
@@ -740,6 +1531,16 @@ func (enc *RasterMerger) Run(bandExpr *utils.BandExpressions, verbose bool) {
 		}
 	}
 
+	if compositing != nil && len(compositing.DateBand) > 0 && nameSpaces[0] != utils.EmptyTileNS {
+		if canvas, found := canvasMap[compositing.DateBand]; found {
+			headr := *(*reflect.SliceHeader)(unsafe.Pointer(&canvas.Data))
+			headr.Len /= SizeofFloat32
+			headr.Cap /= SizeofFloat32
+			out = append(out, &utils.Float32Raster{NoData: canvas.NoData, Data: *(*[]float32)(unsafe.Pointer(&headr)),
+				Width: canvas.Width, Height: canvas.Height, NameSpace: compositing.DateBand})
+		}
+	}
+
 	if enc.checkCancellation() {
 		return
 	}
@@ -0,0 +1,171 @@
+package processor
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/nci/gsky/utils"
+)
+
+func newFloat32Canvas(data []float32, noData float64) *FlexRaster {
+	headr := *(*reflect.SliceHeader)(unsafe.Pointer(&data))
+	headr.Len *= SizeofFloat32
+	headr.Cap *= SizeofFloat32
+	return &FlexRaster{Data: *(*[]uint8)(unsafe.Pointer(&headr)), Width: len(data), Height: 1, Type: "Float32", NoData: noData, NameSpace: "ndvi"}
+}
+
+func TestReduceTemporalRasterStack(t *testing.T) {
+	noData := -999.0
+	dates := [][]float32{
+		{1, -999, 4, -999},
+		{3, 2, -999, -999},
+		{8, 6, -999, -999},
+	}
+
+	expected := map[string][]float32{
+		"mean":   {4, 4, 4, -999},
+		"min":    {1, 2, 4, -999},
+		"max":    {8, 6, 4, -999},
+		"median": {3, 4, 4, -999},
+		"sum":    {12, 8, 4, -999},
+		"count":  {3, 2, 1, -999},
+		"stddev": {float32(math.Sqrt(26.0 / 3)), 2, 0, -999},
+	}
+
+	for agg, exp := range expected {
+		dateCanvasMap := make(map[float64]map[string]*FlexRaster)
+		for i, data := range dates {
+			dateCanvasMap[float64(i)] = map[string]*FlexRaster{"ndvi": newFloat32Canvas(append([]float32{}, data...), noData)}
+		}
+
+		canvasMap, err := ReduceTemporalRasterStack(dateCanvasMap, agg)
+		if err != nil {
+			t.Fatalf("%s: %v", agg, err)
+		}
+
+		canvas := canvasMap["ndvi"]
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&canvas.Data))
+		headr.Len /= SizeofFloat32
+		headr.Cap /= SizeofFloat32
+		out := *(*[]float32)(unsafe.Pointer(&headr))
+
+		for i := range exp {
+			if math.Abs(float64(out[i]-exp[i])) > 1e-5 {
+				t.Errorf("%s: pixel %d, expected %v, got %v", agg, i, exp[i], out[i])
+			}
+		}
+	}
+}
+
+func TestCompositeBestPixel(t *testing.T) {
+	noData := -999.0
+	red := [][]float32{
+		{10, 11, -999},
+		{20, 21, -999},
+		{30, -999, -999},
+	}
+	cloud := [][]float32{
+		{5, 1, 0},
+		{2, 3, 0},
+		{9, 0, 0},
+	}
+
+	score, err := utils.ParseBandExpressions([]string{"0 - cloud"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := &CompositingParams{Score: score, ScoreVars: []string{"cloud"}, DateBand: "date"}
+
+	dateCanvasMap := make(map[float64]map[string]*FlexRaster)
+	for i := range red {
+		redCanvas := newFloat32Canvas(red[i], noData)
+		redCanvas.NameSpace = "red"
+		cloudCanvas := newFloat32Canvas(cloud[i], noData)
+		cloudCanvas.NameSpace = "cloud"
+		dateCanvasMap[float64(i*86400)] = map[string]*FlexRaster{"red": redCanvas, "cloud": cloudCanvas}
+	}
+
+	canvasMap, err := CompositeBestPixel(dateCanvasMap, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for ns, exp := range map[string][]float32{"red": {20, 11, -999}, "date": {1, 0, CompositingDateNoData}} {
+		canvas, found := canvasMap[ns]
+		if !found {
+			t.Fatalf("missing canvas %s", ns)
+		}
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&canvas.Data))
+		headr.Len /= SizeofFloat32
+		headr.Cap /= SizeofFloat32
+		out := *(*[]float32)(unsafe.Pointer(&headr))
+		for i := range exp {
+			if out[i] != exp[i] {
+				t.Errorf("%s: pixel %d, expected %v, got %v", ns, i, exp[i], out[i])
+			}
+		}
+	}
+}
+
+func TestMergeMaskedRasterWideTypes(t *testing.T) {
+	src := map[string][]float64{
+		"Int32":   {-100000, 5, 70000, -1},
+		"UInt32":  {4000000000, 5, 70000, 0},
+		"Float64": {1e-300, 5.25, 1e300, -1},
+	}
+	noData := map[string]float64{"Int32": -1, "UInt32": 0, "Float64": -1}
+
+	for rType, vals := range src {
+		var data []uint8
+		switch rType {
+		case "Int32":
+			buf := make([]int32, len(vals))
+			for i, v := range vals {
+				buf[i] = int32(v)
+			}
+			headr := *(*reflect.SliceHeader)(unsafe.Pointer(&buf))
+			headr.Len *= SizeofInt32
+			headr.Cap *= SizeofInt32
+			data = *(*[]uint8)(unsafe.Pointer(&headr))
+		case "UInt32":
+			buf := make([]uint32, len(vals))
+			for i, v := range vals {
+				buf[i] = uint32(v)
+			}
+			headr := *(*reflect.SliceHeader)(unsafe.Pointer(&buf))
+			headr.Len *= SizeofUint32
+			headr.Cap *= SizeofUint32
+			data = *(*[]uint8)(unsafe.Pointer(&headr))
+		case "Float64":
+			buf := append([]float64{}, vals...)
+			headr := *(*reflect.SliceHeader)(unsafe.Pointer(&buf))
+			headr.Len *= SizeofFloat64
+			headr.Cap *= SizeofFloat64
+			data = *(*[]uint8)(unsafe.Pointer(&headr))
+		}
+
+		r := &FlexRaster{Data: data, Width: len(vals), Height: 1, DataWidth: len(vals), DataHeight: 1,
+			Type: rType, NoData: noData[rType], NameSpace: "band", TimeStamp: 1}
+		canvasMap := map[string]*FlexRaster{"band": &FlexRaster{Data: initNoDataSlice(rType, noData[rType], len(vals)),
+			Width: len(vals), Height: 1, Type: rType, NoData: noData[rType], NameSpace: "band"}}
+
+		if err := MergeMaskedRaster(r, canvasMap, make([]bool, len(vals))); err != nil {
+			t.Fatalf("%s: %v", rType, err)
+		}
+
+		out, err := flexRasterFloat64(canvasMap["band"])
+		if err != nil {
+			t.Fatalf("%s: %v", rType, err)
+		}
+		for i, v := range vals[:3] {
+			if out[i] != v {
+				t.Errorf("%s: expected %v at %d, got %v", rType, v, i, out[i])
+			}
+		}
+		if !math.IsNaN(out[3]) {
+			t.Errorf("%s: expected nodata at 3, got %v", rType, out[3])
+		}
+	}
+}
@@ -57,12 +57,16 @@ func (dp *TilePipeline) Process(geoReq *GeoTileRequest, verbose bool) chan []uti
 
 		geoReq.Collection = geoReq.Overview.DataSource
 		geoReq.SpatialExtent = geoReq.Overview.SpatialExtent
-		if !geoReq.Overview.Accum {
-			geoReq.EndTime = nil
-		} else {
-			step := time.Minute * time.Duration(60*24*geoReq.Overview.StepDays+60*geoReq.Overview.StepHours+geoReq.Overview.StepMinutes)
-			et := geoReq.StartTime.Add(step)
-			geoReq.EndTime = &et
+		// Temporal aggregations reduce the overviews over
+		// the time interval of the request
+		if len(geoReq.TemporalAgg) == 0 {
+			if !geoReq.Overview.Accum {
+				geoReq.EndTime = nil
+			} else {
+				step := time.Minute * time.Duration(60*24*geoReq.Overview.StepDays+60*geoReq.Overview.StepHours+geoReq.Overview.StepMinutes)
+				et := geoReq.StartTime.Add(step)
+				geoReq.EndTime = &et
+			}
 		}
 
 		dp.MASAddress = geoReq.Overview.MASAddress
@@ -699,6 +703,89 @@ func getFlexRaster(idx int, timestamp time.Time, req *GeoTileRequest, raster uti
 			}
 		}
 
+	case *utils.Int32Raster:
+		flex.Type = "Int32"
+		flex.NoData = t.NoData
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&t.Data))
+		headr.Len *= SizeofInt32
+		headr.Cap *= SizeofInt32
+		flex.Data = *(*[]uint8)(unsafe.Pointer(&headr))
+		noData := int32(t.NoData)
+		if normRaster != nil {
+			if r, ok := normRaster.(*utils.Int32Raster); ok {
+				normNoData = r.NoData
+				if int32(normNoData) != noData {
+					normalise = true
+					flex.NoData = normNoData
+				}
+			}
+		}
+		for i := range t.Data {
+			if t.Data[i] == noData {
+				allFilled = false
+				if !normalise {
+					break
+				} else {
+					t.Data[i] = int32(normNoData)
+				}
+			}
+		}
+
+	case *utils.UInt32Raster:
+		flex.Type = "UInt32"
+		flex.NoData = t.NoData
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&t.Data))
+		headr.Len *= SizeofUint32
+		headr.Cap *= SizeofUint32
+		flex.Data = *(*[]uint8)(unsafe.Pointer(&headr))
+		noData := uint32(t.NoData)
+		if normRaster != nil {
+			if r, ok := normRaster.(*utils.UInt32Raster); ok {
+				normNoData = r.NoData
+				if uint32(normNoData) != noData {
+					normalise = true
+					flex.NoData = normNoData
+				}
+			}
+		}
+		for i := range t.Data {
+			if t.Data[i] == noData {
+				allFilled = false
+				if !normalise {
+					break
+				} else {
+					t.Data[i] = uint32(normNoData)
+				}
+			}
+		}
+
+	case *utils.Float64Raster:
+		flex.Type = "Float64"
+		flex.NoData = t.NoData
+		headr := *(*reflect.SliceHeader)(unsafe.Pointer(&t.Data))
+		headr.Len *= SizeofFloat64
+		headr.Cap *= SizeofFloat64
+		flex.Data = *(*[]uint8)(unsafe.Pointer(&headr))
+		noData := t.NoData
+		if normRaster != nil {
+			if r, ok := normRaster.(*utils.Float64Raster); ok {
+				normNoData = r.NoData
+				if normNoData != noData {
+					normalise = true
+					flex.NoData = normNoData
+				}
+			}
+		}
+		for i := range t.Data {
+			if t.Data[i] == noData {
+				allFilled = false
+				if !normalise {
+					break
+				} else {
+					t.Data[i] = normNoData
+				}
+			}
+		}
 	}
 
 	return flex, allFilled
@@ -40,9 +40,24 @@ type ConfigPayLoad struct {
 	ReqRes                float64
 	SRSCf                 int
 	FusionUnscale         int
+	TemporalAgg           string
+	Compositing           *CompositingParams
+	Resampling            string
 	MetricsCollector      *metrics.MetricsCollector
 }
 
+// CompositingParams selects per pixel the date of the highest
+// score across the rasters of a time interval. The variables
+// of the score that aren't rendered are listed in ScoreVars.
+// The date of every pixel in days since the Unix epoch is
+// returned as an extra band if DateBand is set.
+type CompositingParams struct {
+	Score      *utils.BandExpressions
+	ScoreVars  []string
+	DateBand   string
+	TargetTime float64
+}
+
 type GeoTileIdxSelector struct {
 	Start   *int
 	End     *int
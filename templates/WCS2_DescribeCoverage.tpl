@@ -0,0 +1,72 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<wcs:CoverageDescriptions xmlns:wcs="http://www.opengis.net/wcs/2.0" xmlns:gml="http://www.opengis.net/gml/3.2" xmlns:gmlcov="http://www.opengis.net/gmlcov/1.0" xmlns:swe="http://www.opengis.net/swe/2.0" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.opengis.net/wcs/2.0 http://schemas.opengis.net/wcs/2.0/wcsDescribeCoverage.xsd">
+	{{ range $index, $value := . }}
+  <wcs:CoverageDescription gml:id="{{ .ID }}">
+    <gml:description>{{ .Abstract }}</gml:description>
+    <gml:name>{{ .Title }}</gml:name>
+    <gml:boundedBy>
+      {{ if .EffectiveStartDate }}
+      <gml:EnvelopeWithTimePeriod srsName="http://www.opengis.net/def/crs/EPSG/0/4326" axisLabels="Lat Long" uomLabels="deg deg" srsDimension="2">
+        <gml:lowerCorner>{{ index .BBox 1 }} {{ index .BBox 0 }}</gml:lowerCorner>
+        <gml:upperCorner>{{ index .BBox 3 }} {{ index .BBox 2 }}</gml:upperCorner>
+        <gml:beginPosition>{{ .EffectiveStartDate }}</gml:beginPosition>
+        <gml:endPosition>{{ .EffectiveEndDate }}</gml:endPosition>
+      </gml:EnvelopeWithTimePeriod>
+      {{ else }}
+      <gml:Envelope srsName="http://www.opengis.net/def/crs/EPSG/0/4326" axisLabels="Lat Long" uomLabels="deg deg" srsDimension="2">
+        <gml:lowerCorner>{{ index .BBox 1 }} {{ index .BBox 0 }}</gml:lowerCorner>
+        <gml:upperCorner>{{ index .BBox 3 }} {{ index .BBox 2 }}</gml:upperCorner>
+      </gml:Envelope>
+      {{ end }}
+    </gml:boundedBy>
+    <wcs:CoverageId>{{ .Name }}</wcs:CoverageId>
+    <gmlcov:metadata>
+      <gmlcov:Extension>
+        <TimePositions>
+		{{ range $i, $date := .Dates }}
+          <gml:timePosition>{{ $date }}</gml:timePosition>
+		{{ end }}
+        </TimePositions>
+      </gmlcov:Extension>
+    </gmlcov:metadata>
+    <gml:domainSet>
+      <gml:RectifiedGrid gml:id="grid_{{ .ID }}" dimension="2">
+        <gml:limits>
+          <gml:GridEnvelope>
+            <gml:low>0 0</gml:low>
+            <gml:high>{{ index .GridHigh 0 }} {{ index .GridHigh 1 }}</gml:high>
+          </gml:GridEnvelope>
+        </gml:limits>
+        <gml:axisLabels>i j</gml:axisLabels>
+        <gml:origin>
+          <gml:Point gml:id="origin_{{ .ID }}" srsName="http://www.opengis.net/def/crs/EPSG/0/4326">
+            <gml:pos>{{ index .Origin 0 }} {{ index .Origin 1 }}</gml:pos>
+          </gml:Point>
+        </gml:origin>
+        <gml:offsetVector srsName="http://www.opengis.net/def/crs/EPSG/0/4326">-{{ .YRes }} 0</gml:offsetVector>
+        <gml:offsetVector srsName="http://www.opengis.net/def/crs/EPSG/0/4326">0 {{ .XRes }}</gml:offsetVector>
+      </gml:RectifiedGrid>
+    </gml:domainSet>
+    <gmlcov:rangeType>
+      <swe:DataRecord>
+		{{ range $i, $band := .Bands }}
+        <swe:field name="{{ $band }}">
+          <swe:Quantity>
+            <swe:nilValues>
+              <swe:NilValues>
+                <swe:nilValue reason="http://www.opengis.net/def/nil/OGC/0/unknown">NaN</swe:nilValue>
+              </swe:NilValues>
+            </swe:nilValues>
+            <swe:uom code="1"/>
+          </swe:Quantity>
+        </swe:field>
+		{{ end }}
+      </swe:DataRecord>
+    </gmlcov:rangeType>
+    <wcs:ServiceParameters>
+      <wcs:CoverageSubtype>RectifiedGridCoverage</wcs:CoverageSubtype>
+      <wcs:nativeFormat>image/tiff</wcs:nativeFormat>
+    </wcs:ServiceParameters>
+  </wcs:CoverageDescription>
+	{{ end }}
+</wcs:CoverageDescriptions>
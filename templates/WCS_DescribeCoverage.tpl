@@ -64,9 +64,17 @@
     </supportedCRSs>
     <supportedFormats>
       <formats>GeoTIFF</formats>
+      <formats>COG</formats>
       <formats>NetCDF</formats>
+      <formats>Zarr</formats>
+      <formats>CoverageJSON</formats>
+      <formats>CSV</formats>
+      {{ if .Contour }}<formats>GeoJSON</formats>{{ end }}
     </supportedFormats>
-    <supportedInterpolations>
+    <supportedInterpolations default="nearest neighbor">
+      <interpolationMethod>nearest neighbor</interpolationMethod>
+      <interpolationMethod>bilinear</interpolationMethod>
+      <interpolationMethod>bicubic</interpolationMethod>
       <interpolationMethod>none</interpolationMethod>
     </supportedInterpolations>
   </CoverageOffering>
@@ -42,6 +42,7 @@
 			</GetCapabilities>
 			<GetMap>
 				<Format>image/png</Format>
+				<Format>application/vnd.mapbox-vector-tile</Format>
 				<DCPType>
 				  <HTTP>
 				    <Get>
@@ -52,6 +53,9 @@
 			</GetMap>
 			<GetFeatureInfo>
 				<Format>application/json</Format>
+				<Format>text/html</Format>
+				<Format>text/plain</Format>
+				<Format>application/vnd.ogc.gml</Format>
 				<DCPType>
 				  <HTTP>
 				    <Get>
@@ -126,7 +130,7 @@
 						<Name>{{ .Name }}</Name>
 						<Title>{{ .Title }}</Title>
 						<Abstract>{{ .Abstract }}</Abstract>
-						{{if .LegendPath }}
+						{{if or .LegendPath (eq (len .RGBProducts) 1) }}
 						<LegendURL width="{{ .LegendWidth }}" height="{{ .LegendHeight }}">
 							<Format>image/png</Format>
 							<OnlineResource xlink:type="simple" xlink:href="{{ $layer.OWSProtocol }}://{{ $layer.OWSHostname }}/ows/{{ .NameSpace }}?service=WMS&amp;request=GetLegendGraphic&amp;version=1.3.0&amp;layers={{ $layer.Name }}&amp;styles={{ .Name }}"/>
@@ -0,0 +1,31 @@
+<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<title>{{ .Layer }}</title>
+</head>
+<body>
+	<table>
+		<caption>{{ .Layer }}</caption>
+		<tr><th>x</th><td>{{ .X }}</td></tr>
+		<tr><th>y</th><td>{{ .Y }}</td></tr>
+		{{ if .Times }}
+		<tr><th>times</th><td>{{ range $i, $t := .Times }}{{ if $i }}, {{ end }}{{ $t }}{{ end }}</td></tr>
+		{{ else if .Time }}
+		<tr><th>time</th><td>{{ .Time }}</td></tr>
+		{{ end }}
+		{{ range .Bands }}
+		<tr><th>{{ .Name }}</th><td>{{ .Value }}</td></tr>
+		{{ end }}
+		{{ if .AvailableDates }}
+		<tr><th>data available for dates</th><td>{{ range $i, $d := .AvailableDates }}{{ if $i }}, {{ end }}{{ $d }}{{ end }}</td></tr>
+		{{ end }}
+		{{ if .DataLinks }}
+		<tr><th>data links</th><td>{{ range .DataLinks }}<a href="{{ . }}">{{ . }}</a><br>{{ end }}</td></tr>
+		{{ end }}
+		{{ if .Error }}
+		<tr><th>error</th><td>{{ .Error }}</td></tr>
+		{{ end }}
+	</table>
+</body>
+</html>
@@ -0,0 +1,29 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<msGMLOutput xmlns:gml="http://www.opengis.net/gml" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+	<layer name="{{ html .Layer }}">
+		<feature>
+			<gml:pointProperty>
+				<gml:Point srsName="{{ html .CRS }}">
+					<gml:coordinates>{{ .X }},{{ .Y }}</gml:coordinates>
+				</gml:Point>
+			</gml:pointProperty>
+			{{ range .Times }}
+			<time>{{ html . }}</time>
+			{{ else }}
+			{{ if .Time }}<time>{{ html .Time }}</time>{{ end }}
+			{{ end }}
+			{{ range .Bands }}
+			<band name="{{ html .Name }}" has_data="{{ .HasData }}">{{ html .Value }}</band>
+			{{ end }}
+			{{ range .AvailableDates }}
+			<data_available_for_date>{{ html . }}</data_available_for_date>
+			{{ end }}
+			{{ range .DataLinks }}
+			<data_link>{{ html . }}</data_link>
+			{{ end }}
+			{{ if .Error }}
+			<error>{{ html .Error }}</error>
+			{{ end }}
+		</feature>
+	</layer>
+</msGMLOutput>
@@ -0,0 +1,155 @@
+<?xml version="1.0" encoding="UTF-8"?><Capabilities version="1.0.0" xmlns="http://www.opengis.net/wmts/1.0" xmlns:ows="http://www.opengis.net/ows/1.1" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:gml="http://www.opengis.net/gml" xsi:schemaLocation="http://www.opengis.net/wmts/1.0 http://schemas.opengis.net/wmts/1.0/wmtsGetCapabilities_response.xsd">
+	<ows:ServiceIdentification>
+		<ows:Title>GSKY Web Map Tile Service</ows:Title>
+		<ows:Abstract>This service relies on GSKY - A Scalable, Distributed Geospatial Data Service. https://geonetwork.nci.org.au/geonetwork/srv/eng/catalog.search#/metadata/dc9fb2db-8d6f-4b76-a734-93ac7fbc9201</ows:Abstract>
+		<ows:Keywords>
+			<ows:Keyword>WMTS</ows:Keyword>
+			<ows:Keyword>WMS</ows:Keyword>
+			<ows:Keyword>GSKY</ows:Keyword>
+		</ows:Keywords>
+		<ows:ServiceType>OGC WMTS</ows:ServiceType>
+		<ows:ServiceTypeVersion>1.0.0</ows:ServiceTypeVersion>
+		<ows:Fees>NONE</ows:Fees>
+		<ows:AccessConstraints>NONE</ows:AccessConstraints>
+	</ows:ServiceIdentification>
+	<ows:ServiceProvider>
+		<ows:ProviderName>National Computational Infrastructure</ows:ProviderName>
+		<ows:ServiceContact>
+			<ows:IndividualName>GSKY Developers</ows:IndividualName>
+			<ows:ContactInfo>
+				<ows:Address>
+					<ows:DeliveryPoint>143 Ward Road</ows:DeliveryPoint>
+					<ows:City>Acton</ows:City>
+					<ows:AdministrativeArea>ACT</ows:AdministrativeArea>
+					<ows:PostalCode>2601</ows:PostalCode>
+					<ows:Country>Australia</ows:Country>
+					<ows:ElectronicMailAddress>help@nci.org.au</ows:ElectronicMailAddress>
+				</ows:Address>
+			</ows:ContactInfo>
+		</ows:ServiceContact>
+	</ows:ServiceProvider>
+	<ows:OperationsMetadata>
+		<ows:Operation name="GetCapabilities">
+			<ows:DCP>
+				<ows:HTTP>
+					<ows:Get xlink:href="{{ .ServiceConfig.OWSProtocol }}://{{ .ServiceConfig.OWSHostname }}/ows/{{ .ServiceConfig.NameSpace }}?">
+						<ows:Constraint name="GetEncoding">
+							<ows:AllowedValues>
+								<ows:Value>KVP</ows:Value>
+							</ows:AllowedValues>
+						</ows:Constraint>
+					</ows:Get>
+					<ows:Get xlink:href="{{ .ServiceConfig.OWSProtocol }}://{{ .ServiceConfig.OWSHostname }}/wmts/{{ .ServiceConfig.NameSpace }}/1.0.0/WMTSCapabilities.xml">
+						<ows:Constraint name="GetEncoding">
+							<ows:AllowedValues>
+								<ows:Value>RESTful</ows:Value>
+							</ows:AllowedValues>
+						</ows:Constraint>
+					</ows:Get>
+				</ows:HTTP>
+			</ows:DCP>
+		</ows:Operation>
+		<ows:Operation name="GetTile">
+			<ows:DCP>
+				<ows:HTTP>
+					<ows:Get xlink:href="{{ .ServiceConfig.OWSProtocol }}://{{ .ServiceConfig.OWSHostname }}/ows/{{ .ServiceConfig.NameSpace }}?">
+						<ows:Constraint name="GetEncoding">
+							<ows:AllowedValues>
+								<ows:Value>KVP</ows:Value>
+							</ows:AllowedValues>
+						</ows:Constraint>
+					</ows:Get>
+				</ows:HTTP>
+			</ows:DCP>
+		</ows:Operation>
+		<ows:Operation name="GetFeatureInfo">
+			<ows:DCP>
+				<ows:HTTP>
+					<ows:Get xlink:href="{{ .ServiceConfig.OWSProtocol }}://{{ .ServiceConfig.OWSHostname }}/ows/{{ .ServiceConfig.NameSpace }}?">
+						<ows:Constraint name="GetEncoding">
+							<ows:AllowedValues>
+								<ows:Value>KVP</ows:Value>
+							</ows:AllowedValues>
+						</ows:Constraint>
+					</ows:Get>
+				</ows:HTTP>
+			</ows:DCP>
+		</ows:Operation>
+	</ows:OperationsMetadata>
+	<Contents>
+		{{ $tileMatrixSets := .TileMatrixSets }}
+		{{ range $index, $layer := .Layers }}
+		<Layer>
+			<ows:Title>{{ .Title }}</ows:Title>
+			<ows:Abstract>{{ .Abstract }}</ows:Abstract>
+			<ows:WGS84BoundingBox>
+				<ows:LowerCorner>-180.0 -90.0</ows:LowerCorner>
+				<ows:UpperCorner>180.0 90.0</ows:UpperCorner>
+			</ows:WGS84BoundingBox>
+			<ows:Identifier>{{ .Name }}</ows:Identifier>
+			{{ if $layer.Styles }}
+			{{ range $styleIdx, $style := $layer.Styles }}
+			{{ if .Visibility }}
+			<Style{{ if eq $styleIdx 0 }} isDefault="true"{{ end }}>
+				<ows:Title>{{ .Title }}</ows:Title>
+				<ows:Identifier>{{ .Name }}</ows:Identifier>
+				{{ if or .LegendPath (eq (len .RGBProducts) 1) }}
+				<LegendURL format="image/png" width="{{ .LegendWidth }}" height="{{ .LegendHeight }}" xlink:href="{{ $layer.OWSProtocol }}://{{ $layer.OWSHostname }}/ows/{{ .NameSpace }}?service=WMS&amp;request=GetLegendGraphic&amp;version=1.3.0&amp;layers={{ $layer.Name }}&amp;styles={{ .Name }}"/>
+				{{ end }}
+			</Style>
+			{{ end }}
+			{{ end }}
+			{{ else }}
+			<Style isDefault="true">
+				<ows:Identifier>default</ows:Identifier>
+			</Style>
+			{{ end }}
+			<Format>image/png</Format>
+			<InfoFormat>application/json</InfoFormat>
+			<InfoFormat>text/html</InfoFormat>
+			<InfoFormat>text/plain</InfoFormat>
+			<InfoFormat>application/vnd.ogc.gml</InfoFormat>
+			<Dimension>
+				<ows:Identifier>time</ows:Identifier>
+				<UOM>ISO8601</UOM>
+				<Default>current</Default>
+				<Current>true</Current>
+				{{ range $iv, $value := .Dates }}<Value>{{ $value }}</Value>{{ end }}
+			</Dimension>
+			{{ range $ia, $axis := .AxesInfo }}
+			<Dimension>
+				<ows:Identifier>{{ $axis.Name }}</ows:Identifier>
+				<Default>{{ $axis.Default }}</Default>
+				{{ range $iv, $value := $axis.Values }}<Value>{{ $value }}</Value>{{ end }}
+			</Dimension>
+			{{ end }}
+			<ResourceURL format="image/png" resourceType="tile" template="{{ $.ServiceConfig.OWSProtocol }}://{{ $.ServiceConfig.OWSHostname }}/wmts/{{ $.ServiceConfig.NameSpace }}/1.0.0/{{ $layer.Name }}/{Style}/{TileMatrixSet}/{TileMatrix}/{TileRow}/{TileCol}.png?time={time}"/>
+			<ResourceURL format="application/json" resourceType="FeatureInfo" template="{{ $.ServiceConfig.OWSProtocol }}://{{ $.ServiceConfig.OWSHostname }}/wmts/{{ $.ServiceConfig.NameSpace }}/1.0.0/{{ $layer.Name }}/{Style}/{TileMatrixSet}/{TileMatrix}/{TileRow}/{TileCol}/{I}/{J}.json?time={time}"/>
+			{{ range $it, $tms := $tileMatrixSets }}
+			<TileMatrixSetLink>
+				<TileMatrixSet>{{ $tms.Identifier }}</TileMatrixSet>
+			</TileMatrixSetLink>
+			{{ end }}
+		</Layer>
+		{{ end }}
+		{{ range $it, $tms := .TileMatrixSets }}
+		<TileMatrixSet>
+			<ows:Title>{{ $tms.Title }}</ows:Title>
+			<ows:Identifier>{{ $tms.Identifier }}</ows:Identifier>
+			<ows:SupportedCRS>{{ $tms.SupportedCRS }}</ows:SupportedCRS>
+			<WellKnownScaleSet>{{ $tms.WellKnownScaleSet }}</WellKnownScaleSet>
+			{{ range $im, $tm := $tms.TileMatrices }}
+			<TileMatrix>
+				<ows:Identifier>{{ $tm.Identifier }}</ows:Identifier>
+				<ScaleDenominator>{{ $tm.ScaleDenominator }}</ScaleDenominator>
+				<TopLeftCorner>{{ index $tm.TopLeftCorner 0 }} {{ index $tm.TopLeftCorner 1 }}</TopLeftCorner>
+				<TileWidth>{{ $tm.TileWidth }}</TileWidth>
+				<TileHeight>{{ $tm.TileHeight }}</TileHeight>
+				<MatrixWidth>{{ $tm.MatrixWidth }}</MatrixWidth>
+				<MatrixHeight>{{ $tm.MatrixHeight }}</MatrixHeight>
+			</TileMatrix>
+			{{ end }}
+		</TileMatrixSet>
+		{{ end }}
+	</Contents>
+</Capabilities>
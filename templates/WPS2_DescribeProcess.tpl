@@ -0,0 +1,51 @@
+<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<wps:ProcessOfferings xmlns:ows="http://www.opengis.net/ows/2.0" xmlns:wps="http://www.opengis.net/wps/2.0" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.opengis.net/wps/2.0 http://schemas.opengis.net/wps/2.0/wps.xsd">
+<wps:ProcessOffering processVersion="1.0.0" jobControlOptions="sync-execute async-execute dismiss" outputTransmission="value">
+<wps:Process>
+	<ows:Title>{{ .Title }}</ows:Title>
+	<ows:Abstract>{{ .Abstract }}</ows:Abstract>
+	<ows:Identifier>{{ .Identifier }}</ows:Identifier>
+	<ows:Metadata xlink:title="TimeSeries Extractor"/>
+	{{ range $index, $value := .LiteralData }}
+	<wps:Input minOccurs="{{ .MinOccurs }}" maxOccurs="1">
+		<ows:Title>{{ .Title }}</ows:Title>
+		<ows:Abstract>{{ .Abstract }}</ows:Abstract>
+		<ows:Identifier>{{ .Identifier }}</ows:Identifier>
+		<ns:LiteralData xmlns:ns="http://www.opengis.net/wps/2.0">
+			<ns:Format default="true" mimeType="text/plain"/>
+			<LiteralDataDomain>
+				{{ if .AllowedValues }}
+				<ows:AllowedValues>
+					{{ range $index, $value := .AllowedValues }}
+					<ows:Value>{{ . }}</ows:Value>
+					{{ end }}
+				</ows:AllowedValues>
+				{{ else }}
+				<ows:AnyValue/>
+				{{ end }}
+				<ows:DataType ows:reference="{{ .DataTypeRef }}">{{ .DataType }}</ows:DataType>
+			</LiteralDataDomain>
+		</ns:LiteralData>
+	</wps:Input>
+	{{ end }}
+	{{ range $index, $value := .ComplexData }}
+	<wps:Input minOccurs="{{ .MinOccurs }}" maxOccurs="1">
+		<ows:Title>{{ .Title }}</ows:Title>
+		<ows:Abstract>{{ .Abstract }}</ows:Abstract>
+		<ows:Identifier>{{ .Identifier }}</ows:Identifier>
+		<ns:ComplexData xmlns:ns="http://www.opengis.net/wps/2.0">
+			<ns:Format default="true" mimeType="{{ .MimeType }}" schema="{{ .Schema }}"/>
+		</ns:ComplexData>
+	</wps:Input>
+	{{ end }}
+	<wps:Output>
+		<ows:Title>Time Series Output</ows:Title>
+		<ows:Abstract>Time series data for location.</ows:Abstract>
+		<ows:Identifier>Result</ows:Identifier>
+		<ns:ComplexData xmlns:ns="http://www.opengis.net/wps/2.0">
+			<ns:Format default="true" mimeType="application/vnd.terriajs.catalog-member+json" schema="https://tools.ietf.org/html/rfc7159"/>
+		</ns:ComplexData>
+	</wps:Output>
+</wps:Process>
+</wps:ProcessOffering>
+</wps:ProcessOfferings>
@@ -0,0 +1,5 @@
+<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<wps:Result xmlns:wps="http://www.opengis.net/wps/2.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.opengis.net/wps/2.0 http://schemas.opengis.net/wps/2.0/wps.xsd">
+{{ if .JobID }}<wps:JobID>{{ .JobID }}</wps:JobID>
+{{ end }}{{ if .ExpirationDate }}<wps:ExpirationDate>{{ .ExpirationDate }}</wps:ExpirationDate>
+{{ end }}{{ .Outputs }}</wps:Result>
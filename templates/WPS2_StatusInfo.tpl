@@ -0,0 +1,7 @@
+<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<wps:StatusInfo xmlns:wps="http://www.opengis.net/wps/2.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.opengis.net/wps/2.0 http://schemas.opengis.net/wps/2.0/wps.xsd">
+<wps:JobID>{{ .JobID }}</wps:JobID>
+<wps:Status>{{ if eq .Status "accepted" }}Accepted{{ else if eq .Status "running" }}Running{{ else if eq .Status "successful" }}Succeeded{{ else if eq .Status "dismissed" }}Dismissed{{ else }}Failed{{ end }}</wps:Status>
+{{ if .ExpirationDate }}<wps:ExpirationDate>{{ .ExpirationDate }}</wps:ExpirationDate>
+{{ end }}{{ if eq .Status "running" }}<wps:PercentCompleted>{{ .PercentCompleted }}</wps:PercentCompleted>
+{{ end }}</wps:StatusInfo>
@@ -0,0 +1,22 @@
+<wps:ExecuteResponse xmlns:ows="http://www.opengis.net/ows/1.1" xmlns:wps="http://www.opengis.net/wps/1.0.0" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.opengis.net/wps/1.0.0 http://schemas.opengis.net/wps/1.0.0/wpsExecute_response.xsd" service="WPS" version="1.0.0" xml:lang="en-US" serviceInstance="{{ .ServiceInstance }}" statusLocation="{{ .StatusLocation }}">
+<wps:Process wps:processVersion="1.0.0">
+<ows:Identifier>{{ .Process.Identifier }}</ows:Identifier>
+<ows:Title>{{ .Process.Title }}</ows:Title>
+<ows:Abstract>{{ .Process.Abstract }}</ows:Abstract>
+</wps:Process>
+<wps:Status creationTime="{{ .CreationTime }}">
+{{ if eq .Status "accepted" }}<wps:ProcessAccepted>The job {{ .JobID }} has been accepted.</wps:ProcessAccepted>
+{{ else if eq .Status "running" }}<wps:ProcessStarted percentCompleted="{{ .PercentCompleted }}">The job {{ .JobID }} is running.</wps:ProcessStarted>
+{{ else if eq .Status "successful" }}<wps:ProcessSucceeded>The service "{{ .Process.Identifier }}" ran successfully.</wps:ProcessSucceeded>
+{{ else }}<wps:ProcessFailed>
+<ows:ExceptionReport version="1.0.0">
+<ows:Exception exceptionCode="NoApplicableCode">
+<ows:ExceptionText>{{ html .Message }}</ows:ExceptionText>
+</ows:Exception>
+</ows:ExceptionReport>
+</wps:ProcessFailed>
+{{ end }}</wps:Status>
+{{ if eq .Status "successful" }}<wps:ProcessOutputs>
+{{ .Outputs }}
+</wps:ProcessOutputs>
+{{ end }}</wps:ExecuteResponse>
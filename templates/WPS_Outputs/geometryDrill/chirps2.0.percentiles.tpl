@@ -0,0 +1,10 @@
+<wps:Output>
+<ows:Identifier>precipitation</ows:Identifier>
+<ows:Title>Accumulated Precipitation</ows:Title>
+<ows:Abstract>Time series data for CHIRPS2.0 accumulated precipitation with the percentiles and histogram of the pixels.</ows:Abstract>
+<wps:Data>
+<wps:ComplexData mimeType="application/vnd.terriajs.catalog-member+json" schema="https://tools.ietf.org/html/rfc7159">
+<![CDATA[{ "data": "{{ .Header }}\n{{ .Data }}", "isEnabled": true, "type": "csv", "name": "%s", "tableStyle": { "columns": { {{ range $i, $col := .Columns }}{{ if $i }},{{ end }}"{{ $col }}": { "units": "mm", "yAxisMin": 0, "active": {{ if eq $i 0 }}true{{ else }}false{{ end }} }{{ end }} } } }]]>
+</wps:ComplexData>
+</wps:Data>
+</wps:Output>
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	geo "github.com/nci/geometry"
+	"github.com/nci/gsky/metrics"
+	proc "github.com/nci/gsky/processor"
+	"github.com/nci/gsky/utils"
+)
+
+// transectSample holds the values of a sample along
+// the line at a date. The values are in the order of
+// the variables of all the data sources of the process.
+type transectSample struct {
+	Date     string
+	Distance float64
+	Lon      float64
+	Lat      float64
+	Values   []*float64
+}
+
+const earthRadius = 6371008.8
+
+// executeTransect samples every data source of the process
+// along the line of the request and returns the profiles as
+// CSV and GeoJSON outputs. On failure it returns the HTTP
+// status code of the error.
+func executeTransect(ctx context.Context, timeoutCtx context.Context, conf *utils.Config, process *utils.Process, params utils.WPSParams, progress *proc.DrillProgress, metricsCollector *metrics.MetricsCollector) (string, int, error) {
+	line, isLine := params.FeatCol.Features[0].Geometry.(*geo.LineString)
+	if !isLine {
+		return "", 400, fmt.Errorf("Geometry not supported. Only Features containing LineString are available.")
+	}
+
+	// The line is densified once so that the granules of
+	// every data source are sampled at the same locations,
+	// the samples being drilled as the points of a MultiPoint
+	points, distances, err := densifyTransect(*line, process.TransectSamples)
+	if err != nil {
+		return "", 400, err
+	}
+
+	feat, err := encodeMultiPointFeature(points)
+	if err != nil {
+		return "", 400, err
+	}
+
+	var columns []string
+	for ids := range process.DataSources {
+		columns = append(columns, drillVariables(&process.DataSources[ids])...)
+	}
+
+	// Samples are keyed by date and index along the line
+	samples := make(map[string]*transectSample)
+
+	offset := 0
+	for ids := range process.DataSources {
+		dataSource := &process.DataSources[ids]
+
+		geoReq, err := newWPSDrillRequest(process, dataSource, params, feat, metricsCollector)
+		if err != nil {
+			return "", 400, err
+		}
+
+		errChan := make(chan error, 100)
+		dp := proc.InitDrillPipeline(ctx, conf.ServiceConfig.MASAddress, conf.ServiceConfig.WorkerNodes, process.IdentityTol, process.DpTol, errChan)
+		dp.Progress = progress
+
+		out := dp.ProcessPoints(geoReq, len(points), process.Interpolation, *verbose)
+		res, err := waitWPSPipeline(ctx, timeoutCtx, process, out, errChan)
+		if err != nil {
+			return "", 500, err
+		}
+
+		nCols := len(drillVariables(dataSource))
+		for _, line := range strings.Split(res, "\n") {
+			if len(strings.TrimSpace(line)) == 0 {
+				continue
+			}
+			cols := strings.Split(line, ",")
+			if len(cols) != nCols+2 {
+				return "", 500, fmt.Errorf("unexpected transect result: %s", line)
+			}
+
+			ip, err := strconv.Atoi(cols[0])
+			if err != nil || ip < 0 || ip >= len(points) {
+				return "", 500, fmt.Errorf("unexpected transect result: %s", line)
+			}
+
+			key := cols[1] + "," + cols[0]
+			sample, found := samples[key]
+			if !found {
+				sample = &transectSample{Date: cols[1], Distance: distances[ip], Lon: points[ip].X, Lat: points[ip].Y, Values: make([]*float64, len(columns))}
+				samples[key] = sample
+			}
+			for ic := 0; ic < nCols; ic++ {
+				if v, err := strconv.ParseFloat(cols[ic+2], 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+					sample.Values[offset+ic] = &v
+				}
+			}
+		}
+		offset += nCols
+	}
+
+	var sorted []*transectSample
+	for _, sample := range samples {
+		sorted = append(sorted, sample)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Date != sorted[j].Date {
+			return sorted[i].Date < sorted[j].Date
+		}
+		return sorted[i].Distance < sorted[j].Distance
+	})
+
+	csvOut, err := encodeTransectCSV(columns, sorted)
+	if err != nil {
+		return "", 500, err
+	}
+
+	geojsonOut, err := encodeTransectGeoJSON(columns, sorted)
+	if err != nil {
+		return "", 500, err
+	}
+
+	outputs := []*utils.WPSOutput{
+		{Identifier: "transect_csv",
+			Title:    "Transect Profile",
+			Abstract: "Values along the line over time.",
+			MimeType: "text/csv",
+			Value:    csvOut,
+		},
+		{Identifier: "transect_geojson",
+			Title:    "Transect Profile",
+			Abstract: "Samples along the line with their values over time.",
+			MimeType: "application/geo+json",
+			Schema:   "https://tools.ietf.org/html/rfc7946",
+			Value:    geojsonOut,
+		},
+	}
+	return utils.EncodeWPSOutputs(outputs), 200, nil
+}
+
+// encodeTransectCSV writes a row per date and sample, the
+// distance from the start of the line being in metres.
+func encodeTransectCSV(columns []string, samples []*transectSample) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := append([]string{"date", "distance", "longitude", "latitude"}, columns...)
+	w.Write(header)
+
+	for _, sample := range samples {
+		row := []string{sample.Date,
+			strconv.FormatFloat(sample.Distance, 'f', -1, 64),
+			strconv.FormatFloat(sample.Lon, 'f', -1, 64),
+			strconv.FormatFloat(sample.Lat, 'f', -1, 64),
+		}
+		for _, val := range sample.Values {
+			cell := ""
+			if val != nil {
+				cell = strconv.FormatFloat(*val, 'f', -1, 64)
+			}
+			row = append(row, cell)
+		}
+		w.Write(row)
+	}
+
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// encodeTransectGeoJSON writes a Point feature per sample
+// along the line ordered by distance, the values of every
+// date being added to the properties as a list of records.
+func encodeTransectGeoJSON(columns []string, samples []*transectSample) (string, error) {
+	type geometry struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	}
+	type feature struct {
+		Type       string                 `json:"type"`
+		Geometry   geometry               `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	}
+	featCol := struct {
+		Type     string     `json:"type"`
+		Features []*feature `json:"features"`
+	}{Type: "FeatureCollection", Features: []*feature{}}
+
+	features := make(map[float64]*feature)
+	for _, sample := range samples {
+		feat, found := features[sample.Distance]
+		if !found {
+			feat = &feature{Type: "Feature",
+				Geometry:   geometry{Type: "Point", Coordinates: []float64{sample.Lon, sample.Lat}},
+				Properties: map[string]interface{}{"distance": sample.Distance, "values": []map[string]interface{}{}},
+			}
+			features[sample.Distance] = feat
+			featCol.Features = append(featCol.Features, feat)
+		}
+
+		record := map[string]interface{}{"date": sample.Date}
+		for ic, val := range sample.Values {
+			record[columns[ic]] = val
+		}
+		feat.Properties["values"] = append(feat.Properties["values"].([]map[string]interface{}), record)
+	}
+
+	sort.SliceStable(featCol.Features, func(i, j int) bool {
+		return featCol.Features[i].Properties["distance"].(float64) < featCol.Features[j].Properties["distance"].(float64)
+	})
+
+	out, err := json.Marshal(&featCol)
+	return string(out), err
+}
+
+// densifyTransect returns nSamples points evenly spaced along a
+// WGS84 line from its first vertex to its last one, along with
+// their great circle distances in metres from the first vertex.
+func densifyTransect(line geo.LineString, nSamples int) ([]*geo.Point, []float64, error) {
+	if len(line) < 2 {
+		return nil, nil, fmt.Errorf("LineString has less than 2 points")
+	}
+	if nSamples < 2 {
+		nSamples = 2
+	}
+
+	cumDist := make([]float64, len(line))
+	for i := 1; i < len(line); i++ {
+		cumDist[i] = cumDist[i-1] + haversine(line[i-1].X, line[i-1].Y, line[i].X, line[i].Y)
+	}
+	length := cumDist[len(line)-1]
+	if length <= 0 {
+		return nil, nil, fmt.Errorf("LineString has a null length")
+	}
+
+	points := make([]*geo.Point, nSamples)
+	distances := make([]float64, nSamples)
+	step := length / float64(nSamples-1)
+	iSeg := 0
+	for i := range points {
+		dist := float64(i) * step
+		if i == nSamples-1 {
+			dist = length
+		}
+		for iSeg < len(line)-2 && cumDist[iSeg+1] < dist {
+			iSeg++
+		}
+
+		t := 0.0
+		if segLen := cumDist[iSeg+1] - cumDist[iSeg]; segLen > 0 {
+			t = math.Min(1, math.Max(0, (dist-cumDist[iSeg])/segLen))
+		}
+		p0, p1 := line[iSeg], line[iSeg+1]
+		points[i] = &geo.Point{X: p0.X + t*(p1.X-p0.X), Y: p0.Y + t*(p1.Y-p0.Y)}
+		distances[i] = dist
+	}
+	return points, distances, nil
+}
+
+// haversine returns the great circle distance in metres
+// between two WGS84 locations.
+func haversine(lon1 float64, lat1 float64, lon2 float64, lat2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	geo "github.com/nci/geometry"
+)
+
+func TestDensifyTransect(t *testing.T) {
+	// Two degrees east along the equator then one degree north
+	line := geo.LineString{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}}
+	points, distances, err := densifyTransect(line, 4)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expectedX := []float64{0, 1, 2, 2}
+	expectedY := []float64{0, 0, 0, 1}
+	if len(points) != len(expectedX) || len(distances) != len(expectedX) {
+		t.Fatalf("unexpected samples %v, %v", points, distances)
+	}
+	for i := range expectedX {
+		if math.Abs(points[i].X-expectedX[i]) > 1e-6 || math.Abs(points[i].Y-expectedY[i]) > 1e-6 {
+			t.Errorf("sample %d: expected (%v %v), got (%v %v)", i, expectedX[i], expectedY[i], points[i].X, points[i].Y)
+		}
+		if math.Abs(distances[i]-float64(i)*111195) > 1 {
+			t.Errorf("sample %d: unexpected distance %v", i, distances[i])
+		}
+	}
+
+	if _, _, err = densifyTransect(geo.LineString{{X: 1, Y: 1}, {X: 1, Y: 1}}, 10); err == nil {
+		t.Errorf("expected an error for a line of null length")
+	}
+}
@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CFConventions is the version of the CF conventions
+// of the NetCDF and Zarr outputs of WCS and DAP.
+const CFConventions = "CF-1.8"
+
+// CFTimeUnits are the units of CF time coordinates
+const CFTimeUnits = "seconds since 1970-01-01 00:00:00"
+
+// BandAxis is a non-spatial axis of the
+// bands of a merged raster, e.g. time.
+type BandAxis struct {
+	Name   string
+	Values []float64
+}
+
+// BandLocation locates a band of a merged raster in
+// a data variable along with its index on each axis.
+// Empty tiles have a negative variable index.
+type BandLocation struct {
+	Variable int
+	Index    []int
+}
+
+// BandLayout describes the data variables and axes
+// of the bands of a merged raster.
+type BandLayout struct {
+	Variables []string
+	LongNames []string
+	Axes      []*BandAxis
+	Bands     []*BandLocation
+}
+
+// NewBandLayout parses the namespaces of the bands of a merged
+// raster, e.g. ndvi#time=2020-01-01T00:00:00.000Z,depth=5, into
+// data variables of dimensions (axes..., y, x). Axis values are
+// sorted in ascending order and times are in unix seconds.
+func NewBandLayout(bandNames []string) (*BandLayout, error) {
+	ds := &BandLayout{}
+
+	varNameRegex := regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	varIdx := make(map[string]int)
+	axisIdx := make(map[string]int)
+	valsLookup := make(map[string]map[float64]struct{})
+
+	type bandAxes struct {
+		variable int
+		axes     map[string]float64
+	}
+	parsed := make([]*bandAxes, len(bandNames))
+	for ib, ns := range bandNames {
+		parts := strings.Split(ns, "#")
+		if len(parts) > 2 {
+			return nil, fmt.Errorf("invalid band namespace: %v", ns)
+		}
+
+		band := &bandAxes{variable: -1, axes: make(map[string]float64)}
+		parsed[ib] = band
+		if parts[0] == EmptyTileNS || len(parts[0]) == 0 {
+			continue
+		}
+
+		iv, found := varIdx[parts[0]]
+		if !found {
+			iv = len(ds.Variables)
+			varIdx[parts[0]] = iv
+			varName := parts[0]
+			if !varNameRegex.MatchString(varName) {
+				varName = fmt.Sprintf("var%d", iv+1)
+			}
+			ds.Variables = append(ds.Variables, varName)
+			ds.LongNames = append(ds.LongNames, parts[0])
+		}
+		band.variable = iv
+
+		if len(parts) == 1 {
+			continue
+		}
+
+		for _, axis := range strings.Split(parts[1], ",") {
+			kv := strings.Split(axis, "=")
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid axis format: %v", ns)
+			}
+
+			val, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				timeVal, tErr := time.Parse(ISOFormat, kv[1])
+				if tErr != nil {
+					return nil, fmt.Errorf("unknown data type: %v", ns)
+				}
+				val = float64(timeVal.Unix())
+			}
+
+			if _, found := axisIdx[kv[0]]; !found {
+				axisIdx[kv[0]] = len(ds.Axes)
+				ds.Axes = append(ds.Axes, &BandAxis{Name: kv[0]})
+				valsLookup[kv[0]] = make(map[float64]struct{})
+			}
+			if _, found := valsLookup[kv[0]][val]; !found {
+				valsLookup[kv[0]][val] = struct{}{}
+				ax := ds.Axes[axisIdx[kv[0]]]
+				ax.Values = append(ax.Values, val)
+			}
+			band.axes[kv[0]] = val
+		}
+	}
+
+	for _, ax := range ds.Axes {
+		sort.Float64s(ax.Values)
+	}
+
+	for _, band := range parsed {
+		zb := &BandLocation{Variable: band.variable, Index: make([]int, len(ds.Axes))}
+		if band.variable >= 0 {
+			for ia, ax := range ds.Axes {
+				val, found := band.axes[ax.Name]
+				if !found {
+					return nil, fmt.Errorf("band of %v has no %v axis", ds.LongNames[band.variable], ax.Name)
+				}
+				zb.Index[ia] = sort.SearchFloat64s(ax.Values, val)
+			}
+		}
+		ds.Bands = append(ds.Bands, zb)
+	}
+
+	return ds, nil
+}
+
+// CFSpatialAttrs returns the CF attributes of the
+// x and y coordinates of geographic or projected CRSs.
+func CFSpatialAttrs(isGeographic bool) (map[string]interface{}, map[string]interface{}) {
+	if isGeographic {
+		return map[string]interface{}{"standard_name": "longitude", "long_name": "longitude", "units": "degrees_east", "axis": "X"},
+			map[string]interface{}{"standard_name": "latitude", "long_name": "latitude", "units": "degrees_north", "axis": "Y"}
+	}
+	return map[string]interface{}{"standard_name": "projection_x_coordinate", "long_name": "x coordinate of projection", "units": "m", "axis": "X"},
+		map[string]interface{}{"standard_name": "projection_y_coordinate", "long_name": "y coordinate of projection", "units": "m", "axis": "Y"}
+}
+
+// cfGridMappingParam maps an attribute of a CF grid mapping to
+// the normalised OGC WKT projection parameters of its values.
+type cfGridMappingParam struct {
+	attr   string
+	params []string
+}
+
+// cfGridMapping is a CF grid mapping along with
+// the attributes of its projection parameters.
+type cfGridMapping struct {
+	name   string
+	params []cfGridMappingParam
+}
+
+// cfGridMappings maps the OGC WKT projections
+// to the grid mappings of the CF conventions.
+var cfGridMappings = map[string]cfGridMapping{
+	"Transverse_Mercator": {"transverse_mercator", []cfGridMappingParam{
+		{"scale_factor_at_central_meridian", []string{"scale_factor"}},
+		{"longitude_of_central_meridian", []string{"central_meridian"}},
+		{"latitude_of_projection_origin", []string{"latitude_of_origin"}},
+	}},
+	"Lambert_Conformal_Conic_2SP": {"lambert_conformal_conic", []cfGridMappingParam{
+		{"standard_parallel", []string{"standard_parallel_1", "standard_parallel_2"}},
+		{"longitude_of_central_meridian", []string{"central_meridian"}},
+		{"latitude_of_projection_origin", []string{"latitude_of_origin"}},
+	}},
+	"Albers_Conic_Equal_Area": {"albers_conical_equal_area", []cfGridMappingParam{
+		{"standard_parallel", []string{"standard_parallel_1", "standard_parallel_2"}},
+		{"longitude_of_central_meridian", []string{"longitude_of_center"}},
+		{"latitude_of_projection_origin", []string{"latitude_of_center"}},
+	}},
+	"Mercator_1SP": {"mercator", []cfGridMappingParam{
+		{"longitude_of_projection_origin", []string{"central_meridian"}},
+		{"scale_factor_at_projection_origin", []string{"scale_factor"}},
+	}},
+	"Mercator_2SP": {"mercator", []cfGridMappingParam{
+		{"longitude_of_projection_origin", []string{"central_meridian"}},
+		{"standard_parallel", []string{"standard_parallel_1"}},
+	}},
+	"Lambert_Azimuthal_Equal_Area": {"lambert_azimuthal_equal_area", []cfGridMappingParam{
+		{"longitude_of_projection_origin", []string{"longitude_of_center"}},
+		{"latitude_of_projection_origin", []string{"latitude_of_center"}},
+	}},
+	"Sinusoidal": {"sinusoidal", []cfGridMappingParam{
+		{"longitude_of_projection_origin", []string{"longitude_of_center"}},
+	}},
+}
+
+// CFGridMappingAttrs returns the CF grid mapping attributes of an
+// OGC WKT projection given the values of its normalised parameters,
+// or nil if the projection has no CF grid mapping.
+func CFGridMappingAttrs(projection string, projParam func(name string) float64) map[string]interface{} {
+	mapping, found := cfGridMappings[projection]
+	if !found {
+		return nil
+	}
+
+	attrs := map[string]interface{}{"grid_mapping_name": mapping.name,
+		"false_easting":  projParam("false_easting"),
+		"false_northing": projParam("false_northing"),
+	}
+	for _, param := range mapping.params {
+		if len(param.params) == 1 {
+			attrs[param.attr] = projParam(param.params[0])
+			continue
+		}
+		values := make([]float64, len(param.params))
+		for i, name := range param.params {
+			values[i] = projParam(name)
+		}
+		attrs[param.attr] = values
+	}
+	return attrs
+}
+
+// CFTimeAttrs returns the CF attributes of time coordinates
+// in unix seconds.
+func CFTimeAttrs() map[string]interface{} {
+	return map[string]interface{}{"standard_name": "time", "long_name": "time", "units": CFTimeUnits, "calendar": "proleptic_gregorian", "axis": "T"}
+}
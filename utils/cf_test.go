@@ -0,0 +1,73 @@
+package utils
+
+import "testing"
+
+func TestNewBandLayout(t *testing.T) {
+	bandNames := []string{"ndvi#time=2020-01-02T00:00:00.000Z,depth=10",
+		"ndvi#time=2020-01-01T00:00:00.000Z,depth=10",
+		EmptyTileNS,
+		"ndvi#time=2020-01-01T00:00:00.000Z,depth=5",
+		"red+nir#time=2020-01-01T00:00:00.000Z,depth=5",
+	}
+
+	ds, err := NewBandLayout(bandNames)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(ds.Variables) != 2 || ds.Variables[0] != "ndvi" || ds.Variables[1] != "var2" || ds.LongNames[1] != "red+nir" {
+		t.Errorf("unexpected variables: %v %v", ds.Variables, ds.LongNames)
+	}
+	if len(ds.Axes) != 2 || ds.Axes[0].Name != "time" || ds.Axes[0].Values[0] != 1577836800 || ds.Axes[1].Values[0] != 5 {
+		t.Errorf("unexpected axes: %v %v", *ds.Axes[0], *ds.Axes[1])
+	}
+
+	expected := [][]int{{0, 1, 1}, {0, 0, 1}, {-1}, {0, 0, 0}, {1, 0, 0}}
+	for ib, band := range ds.Bands {
+		if band.Variable != expected[ib][0] {
+			t.Errorf("band %d: unexpected variable %d", ib, band.Variable)
+			continue
+		}
+		if band.Variable >= 0 && (band.Index[0] != expected[ib][1] || band.Index[1] != expected[ib][2]) {
+			t.Errorf("band %d: unexpected index %v", ib, band.Index)
+		}
+	}
+
+	if _, err := NewBandLayout([]string{"a#time=2020-01-01T00:00:00.000Z", "b"}); err == nil {
+		t.Errorf("expected an error for variables of different axes")
+	}
+}
+
+func TestCFGridMappingAttrs(t *testing.T) {
+	// Parameters of GDA94 / MGA zone 55
+	params := map[string]float64{"scale_factor": 0.9996, "central_meridian": 147, "latitude_of_origin": 0, "false_easting": 500000, "false_northing": 10000000}
+	attrs := CFGridMappingAttrs("Transverse_Mercator", func(name string) float64 { return params[name] })
+	expected := map[string]interface{}{"grid_mapping_name": "transverse_mercator",
+		"scale_factor_at_central_meridian": 0.9996,
+		"longitude_of_central_meridian":    147.0,
+		"latitude_of_projection_origin":    0.0,
+		"false_easting":                    500000.0,
+		"false_northing":                   10000000.0,
+	}
+	if len(attrs) != len(expected) {
+		t.Fatalf("unexpected attributes: %v", attrs)
+	}
+	for key, val := range expected {
+		if attrs[key] != val {
+			t.Errorf("%s: expected %v, got %v", key, val, attrs[key])
+		}
+	}
+
+	// Parameters of GDA94 / Australian Albers
+	params = map[string]float64{"standard_parallel_1": -18, "standard_parallel_2": -36, "longitude_of_center": 132}
+	attrs = CFGridMappingAttrs("Albers_Conic_Equal_Area", func(name string) float64 { return params[name] })
+	if parallels, ok := attrs["standard_parallel"].([]float64); !ok || len(parallels) != 2 || parallels[0] != -18 || parallels[1] != -36 {
+		t.Errorf("unexpected standard parallels: %v", attrs["standard_parallel"])
+	}
+	if attrs["grid_mapping_name"] != "albers_conical_equal_area" || attrs["longitude_of_central_meridian"] != 132.0 {
+		t.Errorf("unexpected attributes: %v", attrs)
+	}
+
+	if attrs = CFGridMappingAttrs("Popular_Visualisation_Pseudo_Mercator", func(name string) float64 { return 0 }); attrs != nil {
+		t.Errorf("expected no grid mapping, got %v", attrs)
+	}
+}
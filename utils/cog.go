@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Defaults of the Cloud Optimized GeoTIFFs of WCS GetCoverage
+const DefaultCOGCompression = "deflate"
+const DefaultCOGBlockSize = 512
+const DefaultCOGOverviewResampling = "nearest"
+
+// COGCompressions lists the compression methods of COGs
+var COGCompressions = []string{"deflate", "zstd", "lzw"}
+
+// COGOverviewResamplings lists the resampling methods
+// used to compute the overviews of COGs.
+var COGOverviewResamplings = []string{"nearest", "average", "bilinear", "cubic", "cubicspline", "lanczos", "mode", "gauss"}
+
+// COGParams configures the Cloud Optimized GeoTIFFs
+// returned by WCS GetCoverage requests with format=COG.
+type COGParams struct {
+	Compression        string `json:"compression"`
+	Level              int    `json:"level"`
+	BlockSize          int    `json:"block_size"`
+	OverviewLevels     int    `json:"overview_levels"`
+	OverviewResampling string `json:"overview_resampling"`
+}
+
+// parseCOG checks the COG parameters of a layer
+// and sets the defaults of the missing ones.
+func parseCOG(layer *Layer) error {
+	if layer.COG == nil {
+		layer.COG = &COGParams{}
+	}
+	cog := layer.COG
+
+	cog.Compression = strings.ToLower(strings.TrimSpace(cog.Compression))
+	if len(cog.Compression) == 0 {
+		cog.Compression = DefaultCOGCompression
+	}
+
+	switch cog.Compression {
+	case "deflate":
+		if cog.Level < 0 || cog.Level > 9 {
+			return fmt.Errorf("COG deflate level must be between 1 and 9: %v", cog.Level)
+		}
+	case "zstd":
+		if cog.Level < 0 || cog.Level > 22 {
+			return fmt.Errorf("COG zstd level must be between 1 and 22: %v", cog.Level)
+		}
+	case "lzw":
+		if cog.Level != 0 {
+			return fmt.Errorf("COG lzw compression has no level")
+		}
+	default:
+		return fmt.Errorf("COG compression must be one of %s", strings.Join(COGCompressions, ", "))
+	}
+
+	if cog.BlockSize == 0 {
+		cog.BlockSize = DefaultCOGBlockSize
+	}
+	if cog.BlockSize < 64 || cog.BlockSize > 4096 || cog.BlockSize%16 != 0 {
+		return fmt.Errorf("COG block size must be a multiple of 16 between 64 and 4096: %v", cog.BlockSize)
+	}
+
+	cog.OverviewResampling = strings.ToLower(strings.TrimSpace(cog.OverviewResampling))
+	if len(cog.OverviewResampling) == 0 {
+		cog.OverviewResampling = DefaultCOGOverviewResampling
+	}
+	for _, r := range COGOverviewResamplings {
+		if cog.OverviewResampling == r {
+			return nil
+		}
+	}
+	return fmt.Errorf("COG overview resampling must be one of %s", strings.Join(COGOverviewResamplings, ", "))
+}
+
+// COGOverviewFactors returns the decimation factors of the
+// overviews of a COG. Without explicit overview levels, the
+// overviews go on until the image fits in a single block,
+// while negative levels disable them.
+func COGOverviewFactors(params *COGParams, width int, height int) []int {
+	var factors []int
+	if params.OverviewLevels < 0 {
+		return factors
+	}
+
+	for factor := 2; ; factor *= 2 {
+		if params.OverviewLevels > 0 {
+			if len(factors) >= params.OverviewLevels {
+				break
+			}
+		} else if width <= params.BlockSize*factor/2 && height <= params.BlockSize*factor/2 {
+			break
+		}
+		if width < factor || height < factor {
+			break
+		}
+		factors = append(factors, factor)
+	}
+	return factors
+}
+
+// COGCreationOptions returns the creation options of the GDAL
+// GTiff driver copying a GeoTIFF with its overviews into a COG.
+// Floating point rasters use the floating point predictor.
+func COGCreationOptions(params *COGParams, isFloat bool) []string {
+	opts := []string{"TILED=YES",
+		"COPY_SRC_OVERVIEWS=YES",
+		"BIGTIFF=IF_SAFER",
+		fmt.Sprintf("BLOCKXSIZE=%d", params.BlockSize),
+		fmt.Sprintf("BLOCKYSIZE=%d", params.BlockSize),
+		fmt.Sprintf("COMPRESS=%s", strings.ToUpper(params.Compression)),
+	}
+
+	if isFloat {
+		opts = append(opts, "PREDICTOR=3")
+	} else {
+		opts = append(opts, "PREDICTOR=2")
+	}
+
+	if params.Level > 0 {
+		switch params.Compression {
+		case "deflate":
+			opts = append(opts, fmt.Sprintf("ZLEVEL=%d", params.Level))
+		case "zstd":
+			opts = append(opts, fmt.Sprintf("ZSTD_LEVEL=%d", params.Level))
+		}
+	}
+	return opts
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseCOG(t *testing.T) {
+	layer := &Layer{}
+	if err := parseCOG(layer); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if layer.COG.Compression != DefaultCOGCompression || layer.COG.BlockSize != DefaultCOGBlockSize || layer.COG.OverviewResampling != DefaultCOGOverviewResampling {
+		t.Errorf("unexpected defaults: %v", *layer.COG)
+	}
+
+	layer = &Layer{COG: &COGParams{Compression: " ZSTD ", Level: 15, OverviewResampling: "Average"}}
+	if err := parseCOG(layer); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if layer.COG.Compression != "zstd" || layer.COG.OverviewResampling != "average" {
+		t.Errorf("unexpected parameters: %v", *layer.COG)
+	}
+
+	invalid := []*COGParams{
+		{Compression: "jpeg"},
+		{Compression: "deflate", Level: 15},
+		{Compression: "lzw", Level: 1},
+		{BlockSize: 100},
+		{OverviewResampling: "max"},
+	}
+	for _, cog := range invalid {
+		if err := parseCOG(&Layer{COG: cog}); err == nil {
+			t.Errorf("expected an error: %v", *cog)
+		}
+	}
+}
+
+func TestCOGOverviewFactors(t *testing.T) {
+	tests := []struct {
+		levels, width, height int
+		expected              string
+	}{
+		{0, 500, 400, ""},
+		{0, 1000, 400, "2"},
+		{0, 5000, 3000, "2,4,8,16"},
+		{2, 5000, 3000, "2,4"},
+		{3, 10, 6, "2,4"},
+		{-1, 5000, 3000, ""},
+	}
+
+	for _, test := range tests {
+		params := &COGParams{BlockSize: 512, OverviewLevels: test.levels}
+		var factors []string
+		for _, f := range COGOverviewFactors(params, test.width, test.height) {
+			factors = append(factors, strconv.Itoa(f))
+		}
+		if res := strings.Join(factors, ","); res != test.expected {
+			t.Errorf("COGOverviewFactors(%v, %v, %v): expected %v, got %v", test.levels, test.width, test.height, test.expected, res)
+		}
+	}
+}
+
+func TestCOGCreationOptions(t *testing.T) {
+	params := &COGParams{Compression: "zstd", Level: 9, BlockSize: 256}
+	opts := strings.Join(COGCreationOptions(params, true), " ")
+	for _, opt := range []string{"TILED=YES", "COPY_SRC_OVERVIEWS=YES", "BLOCKXSIZE=256", "BLOCKYSIZE=256", "COMPRESS=ZSTD", "PREDICTOR=3", "ZSTD_LEVEL=9"} {
+		if !strings.Contains(opts, opt) {
+			t.Errorf("%v not found in %v", opt, opts)
+		}
+	}
+
+	params = &COGParams{Compression: "lzw", BlockSize: 512}
+	opts = strings.Join(COGCreationOptions(params, false), " ")
+	if !strings.Contains(opts, "PREDICTOR=2") || strings.Contains(opts, "LEVEL") {
+		t.Errorf("unexpected options: %v", opts)
+	}
+}
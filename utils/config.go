@@ -43,6 +43,12 @@ const DefaultWcsPolygonSegments = 10
 const DefaultWmsTimeout = 20
 const DefaultWcsTimeout = 30
 const DefaultWpsTimeout = 300
+const DefaultWpsAsyncTimeout = 21600
+const DefaultZonalConcLimit = 4
+const DefaultZonalMaxFeatures = 1000
+const DefaultPointMaxPoints = 1000
+const DefaultTransectSamples = 1000
+const DefaultEdrTimeout = 300
 
 const DefaultGrpcWmsConcPerNode = 16
 const DefaultGrpcWcsConcPerNode = 16
@@ -57,6 +63,19 @@ const DefaultWcsMaxWidth = 50000
 const DefaultWcsMaxHeight = 30000
 const DefaultWcsMaxTileWidth = 1024
 const DefaultWcsMaxTileHeight = 1024
+const DefaultWcsJSONMaxCells = 250000
+
+const DefaultEdrMaxArea = 10000
+const DefaultEdrMaxCells = 1000000
+
+// Compositing strategies of the rasters of a time interval
+const CompositingLatest = "latest"
+const CompositingBestPixel = "best_pixel"
+
+// CompositingDateDistance is the variable of the compositing
+// scores holding the number of days between the date of a
+// pixel and the time of the request.
+const CompositingDateDistance = "date_distance"
 
 const DefaultLegendWidth = 160
 const DefaultLegendHeight = 320
@@ -152,38 +171,49 @@ type Layer struct {
 	Dates                        []string `json:"dates"`
 	RGBProducts                  []string `json:"rgb_products"`
 	RGBExpressions               *BandExpressions
-	Mask                         *Mask      `json:"mask"`
-	OffsetValue                  float64    `json:"offset_value"`
-	ClipValue                    float64    `json:"clip_value"`
-	ScaleValue                   float64    `json:"scale_value"`
-	Palette                      *Palette   `json:"palette"`
-	Palettes                     []*Palette `json:"palettes"`
-	LegendPath                   string     `json:"legend_path"`
-	LegendHeight                 int        `json:"legend_height"`
-	LegendWidth                  int        `json:"legend_width"`
-	Styles                       []Layer    `json:"styles"`
-	ZoomLimit                    float64    `json:"zoom_limit"`
-	MaxGrpcRecvMsgSize           int        `json:"max_grpc_recv_msg_size"`
-	WmsPolygonSegments           int        `json:"wms_polygon_segments"`
-	WcsPolygonSegments           int        `json:"wcs_polygon_segments"`
-	WmsTimeout                   int        `json:"wms_timeout"`
-	WcsTimeout                   int        `json:"wcs_timeout"`
-	GrpcWmsConcPerNode           int        `json:"grpc_wms_conc_per_node"`
-	GrpcWcsConcPerNode           int        `json:"grpc_wcs_conc_per_node"`
-	GrpcWpsConcPerNode           int        `json:"grpc_wps_conc_per_node"`
-	WmsPolygonShardConcLimit     int        `json:"wms_polygon_shard_conc_limit"`
-	WcsPolygonShardConcLimit     int        `json:"wcs_polygon_shard_conc_limit"`
-	BandStrides                  int        `json:"band_strides"`
-	WmsMaxWidth                  int        `json:"wms_max_width"`
-	WmsMaxHeight                 int        `json:"wms_max_height"`
-	WcsMaxWidth                  int        `json:"wcs_max_width"`
-	WcsMaxHeight                 int        `json:"wcs_max_height"`
-	WcsMaxTileWidth              int        `json:"wcs_max_tile_width"`
-	WcsMaxTileHeight             int        `json:"wcs_max_tile_height"`
-	FeatureInfoMaxAvailableDates int        `json:"feature_info_max_dates"`
-	FeatureInfoMaxDataLinks      int        `json:"feature_info_max_data_links"`
-	FeatureInfoDataLinkUrl       string     `json:"feature_info_data_link_url"`
-	FeatureInfoBands             []string   `json:"feature_info_bands"`
+	Mask                         *Mask         `json:"mask"`
+	OffsetValue                  float64       `json:"offset_value"`
+	ClipValue                    float64       `json:"clip_value"`
+	ScaleValue                   float64       `json:"scale_value"`
+	Palette                      *Palette      `json:"palette"`
+	Palettes                     []*Palette    `json:"palettes"`
+	LegendPath                   string        `json:"legend_path"`
+	LegendHeight                 int           `json:"legend_height"`
+	LegendWidth                  int           `json:"legend_width"`
+	LegendOrientation            string        `json:"legend_orientation"`
+	LegendTitle                  string        `json:"legend_title"`
+	LegendUnits                  string        `json:"legend_units"`
+	LegendTicks                  []float64     `json:"legend_ticks"`
+	LegendTickLabels             []string      `json:"legend_tick_labels"`
+	LegendTickCount              int           `json:"legend_tick_count"`
+	Styles                       []Layer       `json:"styles"`
+	ZoomLimit                    float64       `json:"zoom_limit"`
+	MaxGrpcRecvMsgSize           int           `json:"max_grpc_recv_msg_size"`
+	WmsPolygonSegments           int           `json:"wms_polygon_segments"`
+	WcsPolygonSegments           int           `json:"wcs_polygon_segments"`
+	WmsTimeout                   int           `json:"wms_timeout"`
+	WcsTimeout                   int           `json:"wcs_timeout"`
+	GrpcWmsConcPerNode           int           `json:"grpc_wms_conc_per_node"`
+	GrpcWcsConcPerNode           int           `json:"grpc_wcs_conc_per_node"`
+	GrpcWpsConcPerNode           int           `json:"grpc_wps_conc_per_node"`
+	WmsPolygonShardConcLimit     int           `json:"wms_polygon_shard_conc_limit"`
+	WcsPolygonShardConcLimit     int           `json:"wcs_polygon_shard_conc_limit"`
+	BandStrides                  int           `json:"band_strides"`
+	WmsMaxWidth                  int           `json:"wms_max_width"`
+	WmsMaxHeight                 int           `json:"wms_max_height"`
+	WcsMaxWidth                  int           `json:"wcs_max_width"`
+	WcsMaxHeight                 int           `json:"wcs_max_height"`
+	WcsMaxTileWidth              int           `json:"wcs_max_tile_width"`
+	WcsMaxTileHeight             int           `json:"wcs_max_tile_height"`
+	WcsJSONMaxCells              int           `json:"wcs_json_max_cells"`
+	COG                          *COGParams    `json:"wcs_cog"`
+	Zarr                         *ZarrParams   `json:"wcs_zarr"`
+	NetCDF                       *NetCDFParams `json:"wcs_netcdf"`
+	FeatureInfoMaxAvailableDates int           `json:"feature_info_max_dates"`
+	FeatureInfoMaxDataLinks      int           `json:"feature_info_max_data_links"`
+	FeatureInfoDataLinkUrl       string        `json:"feature_info_data_link_url"`
+	FeatureInfoBands             []string      `json:"feature_info_bands"`
+	FeatureInfoTemplate          string        `json:"feature_info_template"`
 	FeatureInfoExpressions       *BandExpressions
 	NoDataLegendPath             string                            `json:"nodata_legend_path"`
 	AxesInfo                     []*LayerAxis                      `json:"axes"`
@@ -207,24 +237,57 @@ type Layer struct {
 	RasterYSize                  float64                           `json:"raster_y_size"`
 	WmsBandExpressionCriteria    *BandExpressionComplexityCriteria `json:"wms_band_expr_criteria"`
 	WcsBandExpressionCriteria    *BandExpressionComplexityCriteria `json:"wcs_band_expr_criteria"`
+	EdrMaxArea                   float64                           `json:"edr_max_area"`
+	EdrMaxCells                  int                               `json:"edr_max_cells"`
+	EdrTimeout                   int                               `json:"edr_timeout"`
+	Compositing                  string                            `json:"compositing"`
+	CompositingScore             string                            `json:"compositing_score"`
+	CompositingDateBand          string                            `json:"compositing_date_band"`
+	Resampling                   string                            `json:"resampling"`
+	Terrain                      *TerrainConfig                    `json:"terrain"`
+	Contour                      *ContourConfig                    `json:"contour"`
+	CompositingScoreExpr         *BandExpressions
 }
 
 // Process contains all the details that a WPS needs
 // to be published and processed
 type Process struct {
-	DataSources    []Layer    `json:"data_sources"`
-	Identifier     string     `json:"identifier"`
-	Title          string     `json:"title"`
-	Abstract       string     `json:"abstract"`
-	MaxArea        float64    `json:"max_area"`
-	LiteralData    []LitData  `json:"literal_data"`
-	ComplexData    []CompData `json:"complex_data"`
-	IdentityTol    float64    `json:"identity_tol"`
-	DpTol          float64    `json:"dp_tol"`
-	Approx         *bool      `json:"approx,omitempty"`
-	DrillAlgorithm string     `json:"drill_algo,omitempty"`
-	PixelStat      string     `json:"pixel_stat,omitempty"`
-	WpsTimeout     int        `json:"wps_timeout"`
+	DataSources     []Layer    `json:"data_sources"`
+	Identifier      string     `json:"identifier"`
+	Title           string     `json:"title"`
+	Abstract        string     `json:"abstract"`
+	MaxArea         float64    `json:"max_area"`
+	LiteralData     []LitData  `json:"literal_data"`
+	ComplexData     []CompData `json:"complex_data"`
+	IdentityTol     float64    `json:"identity_tol"`
+	DpTol           float64    `json:"dp_tol"`
+	Approx          *bool      `json:"approx,omitempty"`
+	DrillAlgorithm  string     `json:"drill_algo,omitempty"`
+	PixelStat       string     `json:"pixel_stat,omitempty"`
+	WpsTimeout      int        `json:"wps_timeout"`
+	WpsAsyncTimeout int        `json:"wps_async_timeout"`
+
+	// Percentiles from 0 to 100 and the edges of the
+	// histogram bins drilled besides the pixel statistic.
+	// Percentiles are exact over a single granule, drills
+	// spanning several granules per date are rejected.
+	Percentiles   []float64 `json:"percentiles,omitempty"`
+	HistogramBins []float64 `json:"histogram_bins,omitempty"`
+
+	// The zonal pixel statistic drills every feature
+	// of the request, up to ZonalMaxFeatures features
+	// with ZonalConcLimit features at a time.
+	ZonalConcLimit   int `json:"zonal_conc_limit"`
+	ZonalMaxFeatures int `json:"zonal_max_features"`
+
+	// The point pixel statistic samples the values at
+	// up to PointMaxPoints points of the request whereas
+	// the transect pixel statistic samples the values at
+	// TransectSamples points evenly spaced along a line,
+	// both with either nearest or bilinear Interpolation.
+	Interpolation   string `json:"interpolation,omitempty"`
+	PointMaxPoints  int    `json:"point_max_points"`
+	TransectSamples int    `json:"transect_samples"`
 }
 
 // LitData contains the description of a variable used to compute a
@@ -993,6 +1056,22 @@ func (config *Config) postprocessConfig(ns string) error {
 			if config.Layers[i].Styles[j].LegendHeight <= 0 {
 				config.Layers[i].Styles[j].LegendHeight = DefaultLegendHeight
 			}
+			if len(config.Layers[i].Styles[j].LegendOrientation) == 0 {
+				config.Layers[i].Styles[j].LegendOrientation = config.Layers[i].LegendOrientation
+			}
+			if len(config.Layers[i].Styles[j].LegendUnits) == 0 {
+				config.Layers[i].Styles[j].LegendUnits = config.Layers[i].LegendUnits
+			}
+			if len(config.Layers[i].Styles[j].LegendTicks) == 0 {
+				config.Layers[i].Styles[j].LegendTicks = config.Layers[i].LegendTicks
+				config.Layers[i].Styles[j].LegendTickLabels = config.Layers[i].LegendTickLabels
+			}
+			if config.Layers[i].Styles[j].LegendTickCount == 0 {
+				config.Layers[i].Styles[j].LegendTickCount = config.Layers[i].LegendTickCount
+			}
+			if err := parseLegend(&config.Layers[i].Styles[j]); err != nil {
+				return fmt.Errorf("Layer %v, style %v, %v", config.Layers[i].Name, config.Layers[i].Styles[j].Name, err)
+			}
 
 			bandExpr, err := ParseBandExpressions(config.Layers[i].Styles[j].RGBProducts)
 			if err != nil {
@@ -1000,6 +1079,10 @@ func (config *Config) postprocessConfig(ns string) error {
 			}
 			config.Layers[i].Styles[j].RGBExpressions = bandExpr
 
+			if len(config.Layers[i].Styles[j].FeatureInfoTemplate) == 0 {
+				config.Layers[i].Styles[j].FeatureInfoTemplate = config.Layers[i].FeatureInfoTemplate
+			}
+
 			if len(config.Layers[i].Styles[j].FeatureInfoBands) > 0 {
 				featureInfoExpr, err := ParseBandExpressions(config.Layers[i].Styles[j].FeatureInfoBands)
 				if err != nil {
@@ -1020,6 +1103,37 @@ func (config *Config) postprocessConfig(ns string) error {
 				}
 			}
 
+			if len(config.Layers[i].Styles[j].Compositing) == 0 {
+				config.Layers[i].Styles[j].Compositing = config.Layers[i].Compositing
+				config.Layers[i].Styles[j].CompositingScore = config.Layers[i].CompositingScore
+				config.Layers[i].Styles[j].CompositingDateBand = config.Layers[i].CompositingDateBand
+			}
+			if err := parseCompositing(&config.Layers[i].Styles[j]); err != nil {
+				return fmt.Errorf("Layer %v, style %v, %v", config.Layers[i].Name, config.Layers[i].Styles[j].Name, err)
+			}
+
+			if config.Layers[i].Styles[j].Terrain == nil {
+				config.Layers[i].Styles[j].Terrain = config.Layers[i].Terrain
+			} else if err := parseTerrain(config.Layers[i].Styles[j].Terrain); err != nil {
+				return fmt.Errorf("Layer %v, style %v, %v", config.Layers[i].Name, config.Layers[i].Styles[j].Name, err)
+			}
+
+			if config.Layers[i].Styles[j].Contour == nil {
+				config.Layers[i].Styles[j].Contour = config.Layers[i].Contour
+			} else if err := parseContour(config.Layers[i].Styles[j].Contour); err != nil {
+				return fmt.Errorf("Layer %v, style %v, %v", config.Layers[i].Name, config.Layers[i].Styles[j].Name, err)
+			}
+
+			if len(strings.TrimSpace(config.Layers[i].Styles[j].Resampling)) == 0 {
+				config.Layers[i].Styles[j].Resampling = config.Layers[i].Resampling
+			} else {
+				resampling, err := CheckResampling(config.Layers[i].Styles[j].Resampling)
+				if err != nil {
+					return fmt.Errorf("Layer %v, style %v, %v", config.Layers[i].Name, config.Layers[i].Styles[j].Name, err)
+				}
+				config.Layers[i].Styles[j].Resampling = resampling
+			}
+
 			if len(config.Layers[i].Styles[j].DisableServices) == 0 && len(config.Layers[i].DisableServices) > 0 {
 				config.Layers[i].Styles[j].DisableServices = config.Layers[i].DisableServices
 			}
@@ -1258,6 +1372,8 @@ func (config *Config) Copy(r *http.Request) *Config {
 			Dates:              layer.Dates,
 			EffectiveStartDate: layer.EffectiveStartDate,
 			EffectiveEndDate:   layer.EffectiveEndDate,
+			DefaultGeoBbox:     layer.DefaultGeoBbox,
+			DefaultGeoSize:     layer.DefaultGeoSize,
 		}
 		if !hasOWSHostname {
 			newConf.Layers[i].OWSHostname = r.Host
@@ -1551,6 +1667,31 @@ func ParseBandExpressions(bands []string) (*BandExpressions, error) {
 	return bandExpr, nil
 }
 
+// parseCompositing checks the compositing strategy of a layer
+// and parses the score of the best pixel compositing.
+func parseCompositing(layer *Layer) error {
+	layer.Compositing = strings.ToLower(strings.TrimSpace(layer.Compositing))
+	switch layer.Compositing {
+	case "", CompositingLatest:
+		return nil
+	case CompositingBestPixel:
+		if len(strings.TrimSpace(layer.CompositingScore)) == 0 {
+			return fmt.Errorf("best_pixel compositing requires a compositing_score")
+		}
+		scoreExpr, err := ParseBandExpressions([]string{layer.CompositingScore})
+		if err != nil {
+			return fmt.Errorf("compositing score parsing error: %v", err)
+		}
+		if len(scoreExpr.Expressions) == 0 && len(scoreExpr.VarList) != 1 {
+			return fmt.Errorf("invalid compositing score: %v", layer.CompositingScore)
+		}
+		layer.CompositingScoreExpr = scoreExpr
+		return nil
+	default:
+		return fmt.Errorf("unknown compositing: %v", layer.Compositing)
+	}
+}
+
 // LoadConfigFileTemplate parses the config as a Jet
 // template and escapes any GSKY here docs (i.e. $gdoc$)
 // into valid one-line JSON strings.
@@ -1771,6 +1912,46 @@ func (config *Config) LoadConfigString(cfg []byte, verbose bool) error {
 		}
 		config.Layers[i].FeatureInfoExpressions = featureInfoExpr
 
+		if err := parseCompositing(&config.Layers[i]); err != nil {
+			return fmt.Errorf("Layer %v %v", layer.Name, err)
+		}
+
+		if config.Layers[i].Terrain != nil {
+			if err := parseTerrain(config.Layers[i].Terrain); err != nil {
+				return fmt.Errorf("Layer %v %v", layer.Name, err)
+			}
+		}
+
+		if config.Layers[i].Contour != nil {
+			if err := parseContour(config.Layers[i].Contour); err != nil {
+				return fmt.Errorf("Layer %v %v", layer.Name, err)
+			}
+		}
+
+		if err := parseLegend(&config.Layers[i]); err != nil {
+			return fmt.Errorf("Layer %v %v", layer.Name, err)
+		}
+
+		if err := parseCOG(&config.Layers[i]); err != nil {
+			return fmt.Errorf("Layer %v %v", layer.Name, err)
+		}
+
+		if err := parseZarr(&config.Layers[i]); err != nil {
+			return fmt.Errorf("Layer %v %v", layer.Name, err)
+		}
+
+		if err := parseNetCDF(&config.Layers[i]); err != nil {
+			return fmt.Errorf("Layer %v %v", layer.Name, err)
+		}
+
+		if len(strings.TrimSpace(config.Layers[i].Resampling)) > 0 {
+			resampling, err := CheckResampling(config.Layers[i].Resampling)
+			if err != nil {
+				return fmt.Errorf("Layer %v %v", layer.Name, err)
+			}
+			config.Layers[i].Resampling = resampling
+		}
+
 		if len(strings.TrimSpace(config.Layers[i].TimestampsLoadStrategy)) == 0 {
 			config.Layers[i].TimestampsLoadStrategy = "on_demand"
 		}
@@ -1816,6 +1997,14 @@ func (config *Config) LoadConfigString(cfg []byte, verbose bool) error {
 			config.Layers[i].GrpcWcsConcPerNode = conc
 		}
 
+		if config.Layers[i].GrpcWpsConcPerNode <= 0 {
+			conc := grpcPoolSize
+			if conc < DefaultGrpcWpsConcPerNode {
+				conc = DefaultGrpcWpsConcPerNode
+			}
+			config.Layers[i].GrpcWpsConcPerNode = conc
+		}
+
 		if config.Layers[i].WmsPolygonShardConcLimit <= 0 {
 			config.Layers[i].WmsPolygonShardConcLimit = DefaultWmsPolygonShardConcLimit
 		}
@@ -1852,6 +2041,22 @@ func (config *Config) LoadConfigString(cfg []byte, verbose bool) error {
 			config.Layers[i].WcsMaxTileHeight = DefaultWcsMaxTileHeight
 		}
 
+		if config.Layers[i].WcsJSONMaxCells <= 0 {
+			config.Layers[i].WcsJSONMaxCells = DefaultWcsJSONMaxCells
+		}
+
+		if config.Layers[i].EdrMaxArea <= 0 {
+			config.Layers[i].EdrMaxArea = DefaultEdrMaxArea
+		}
+
+		if config.Layers[i].EdrMaxCells <= 0 {
+			config.Layers[i].EdrMaxCells = DefaultEdrMaxCells
+		}
+
+		if config.Layers[i].EdrTimeout <= 0 {
+			config.Layers[i].EdrTimeout = DefaultEdrTimeout
+		}
+
 		if config.Layers[i].WmsBandExpressionCriteria == nil {
 			config.Layers[i].WmsBandExpressionCriteria = &BandExpressionComplexityCriteria{}
 		}
@@ -1886,10 +2091,16 @@ func (config *Config) LoadConfigString(cfg []byte, verbose bool) error {
 		if len(config.Layers[i].NoDataLegendPath) > 0 {
 			config.Layers[i].NoDataLegendPath = resolveFilePath(config.Layers[i].NoDataLegendPath, config.Layers[i].Name)
 		}
+		if len(config.Layers[i].FeatureInfoTemplate) > 0 {
+			config.Layers[i].FeatureInfoTemplate = resolveFilePath(config.Layers[i].FeatureInfoTemplate, config.Layers[i].Name)
+		}
 		for iStyle := range config.Layers[i].Styles {
 			if len(config.Layers[i].Styles[iStyle].LegendPath) > 0 {
 				config.Layers[i].Styles[iStyle].LegendPath = resolveFilePath(config.Layers[i].Styles[iStyle].LegendPath, fmt.Sprintf("%s/%s", config.Layers[i].Name, config.Layers[i].Styles[iStyle].Name))
 			}
+			if len(config.Layers[i].Styles[iStyle].FeatureInfoTemplate) > 0 {
+				config.Layers[i].Styles[iStyle].FeatureInfoTemplate = resolveFilePath(config.Layers[i].Styles[iStyle].FeatureInfoTemplate, fmt.Sprintf("%s/%s", config.Layers[i].Name, config.Layers[i].Styles[iStyle].Name))
+			}
 		}
 
 	}
@@ -1912,6 +2123,49 @@ func (config *Config) LoadConfigString(cfg []byte, verbose bool) error {
 			config.Processes[i].WpsTimeout = DefaultWpsTimeout
 		}
 
+		if proc.WpsAsyncTimeout <= 0 {
+			config.Processes[i].WpsAsyncTimeout = DefaultWpsAsyncTimeout
+		}
+
+		if proc.ZonalConcLimit <= 0 {
+			config.Processes[i].ZonalConcLimit = DefaultZonalConcLimit
+		}
+
+		if proc.ZonalMaxFeatures <= 0 {
+			config.Processes[i].ZonalMaxFeatures = DefaultZonalMaxFeatures
+		}
+
+		if proc.PointMaxPoints <= 0 {
+			config.Processes[i].PointMaxPoints = DefaultPointMaxPoints
+		}
+
+		if proc.TransectSamples < 2 {
+			config.Processes[i].TransectSamples = DefaultTransectSamples
+		}
+
+		for _, pct := range proc.Percentiles {
+			if pct < 0 || pct > 100 {
+				return fmt.Errorf("Process %v, invalid percentile: %v", proc.Identifier, pct)
+			}
+		}
+
+		if len(proc.HistogramBins) == 1 {
+			return fmt.Errorf("Process %v, histogram bins need at least 2 edges", proc.Identifier)
+		}
+		for ib := 1; ib < len(proc.HistogramBins); ib++ {
+			if proc.HistogramBins[ib] <= proc.HistogramBins[ib-1] {
+				return fmt.Errorf("Process %v, histogram bin edges must be increasing: %v", proc.Identifier, proc.HistogramBins)
+			}
+		}
+
+		switch proc.Interpolation {
+		case "":
+			config.Processes[i].Interpolation = "nearest"
+		case "nearest", "bilinear":
+		default:
+			return fmt.Errorf("Process %v, invalid interpolation: %v", proc.Identifier, proc.Interpolation)
+		}
+
 		for ids, ds := range proc.DataSources {
 			bandExpr, err := ParseBandExpressions(ds.RGBProducts)
 			if err != nil {
@@ -0,0 +1,586 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxContourLevels is the maximum number of contour
+// levels computed for a single raster.
+const MaxContourLevels = 1000
+
+// ContourMVTFormat is the format of WMS GetMap
+// requests for the contour lines as vector tiles.
+const ContourMVTFormat = "application/vnd.mapbox-vector-tile"
+
+// ContourMVTExtent is the extent of the vector tiles
+// holding contour lines.
+const ContourMVTExtent = 4096
+
+// ContourConfig draws the isolines of the values of a layer
+// either at every Interval from Base or at the explicit Levels.
+// LineColour and LineWidth set the appearance of the lines drawn
+// onto WMS GetMap images. If LinesOnly is set, the lines are drawn
+// onto a transparent image instead of the rendered layer.
+type ContourConfig struct {
+	Interval   float64    `json:"interval"`
+	Base       float64    `json:"base"`
+	Levels     []float64  `json:"levels"`
+	LineColour color.RGBA `json:"line_colour"`
+	LineWidth  int        `json:"line_width"`
+	LinesOnly  bool       `json:"lines_only"`
+}
+
+// ContourLine is an isoline of a raster. The points are in
+// pixel coordinates, i.e. (0, 0) is the top-left corner of
+// the top-left pixel of the raster.
+type ContourLine struct {
+	Level  float64
+	Points [][2]float64
+}
+
+// parseContour checks the contour lines of a layer and sets
+// the defaults of their appearance.
+func parseContour(contour *ContourConfig) error {
+	if contour.Interval < 0 {
+		return fmt.Errorf("contour interval must be positive: %v", contour.Interval)
+	}
+	if contour.Interval == 0 && len(contour.Levels) == 0 {
+		return fmt.Errorf("contour requires either an interval or levels")
+	}
+	if len(contour.Levels) > MaxContourLevels {
+		return fmt.Errorf("contour levels exceed %d", MaxContourLevels)
+	}
+	sort.Float64s(contour.Levels)
+
+	if contour.LineWidth < 0 {
+		return fmt.Errorf("contour line width must be positive: %v", contour.LineWidth)
+	}
+	if contour.LineWidth == 0 {
+		contour.LineWidth = 1
+	}
+	if contour.LineColour == (color.RGBA{}) {
+		contour.LineColour = color.RGBA{R: 0, G: 0, B: 0, A: 0xFF}
+	}
+	return nil
+}
+
+// NewContourConfig returns the contour lines of a request, i.e.
+// those of the layer with the interval or the levels of the
+// request if any. It returns nil if neither the layer nor the
+// request ask for contour lines.
+func NewContourConfig(layerContour *ContourConfig, interval *float64, levels []float64) (*ContourConfig, error) {
+	if interval == nil && len(levels) == 0 {
+		return layerContour, nil
+	}
+
+	contour := &ContourConfig{}
+	if layerContour != nil {
+		*contour = *layerContour
+	}
+	contour.Interval = 0
+	contour.Levels = nil
+	if interval != nil {
+		contour.Interval = *interval
+	}
+	contour.Levels = append(contour.Levels, levels...)
+
+	if err := parseContour(contour); err != nil {
+		return nil, err
+	}
+	return contour, nil
+}
+
+// parseContourParams returns the JSON fields of the contour
+// interval and levels of a WMS or WCS request.
+func parseContourParams(params map[string][]string) ([]string, error) {
+	var jsonFields []string
+	if interval, intervalOK := params["contour_interval"]; intervalOK {
+		val, err := strconv.ParseFloat(strings.TrimSpace(interval[0]), 64)
+		if err != nil || val <= 0 || math.IsInf(val, 0) {
+			return nil, fmt.Errorf("contour_interval must be a positive number: %v", interval[0])
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"contour_interval":%s`, strconv.FormatFloat(val, 'g', -1, 64)))
+	}
+
+	if levels, levelsOK := params["contour_levels"]; levelsOK {
+		var vals []string
+		for _, part := range strings.Split(levels[0], ",") {
+			val, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+				return nil, fmt.Errorf("contour_levels must be a list of numbers: %v", levels[0])
+			}
+			vals = append(vals, strconv.FormatFloat(val, 'g', -1, 64))
+		}
+		if len(vals) > MaxContourLevels {
+			return nil, fmt.Errorf("contour_levels exceed %d levels", MaxContourLevels)
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"contour_levels":[%s]`, strings.Join(vals, ",")))
+	}
+	return jsonFields, nil
+}
+
+// ContourLevels returns the levels of the contour lines
+// within the range of values [minVal, maxVal].
+func ContourLevels(contour *ContourConfig, minVal float64, maxVal float64) ([]float64, error) {
+	var levels []float64
+	if len(contour.Levels) > 0 {
+		for _, level := range contour.Levels {
+			if level >= minVal && level <= maxVal {
+				levels = append(levels, level)
+			}
+		}
+		return levels, nil
+	}
+
+	first := math.Ceil((minVal - contour.Base) / contour.Interval)
+	last := math.Floor((maxVal - contour.Base) / contour.Interval)
+	if last-first+1 > MaxContourLevels {
+		return nil, fmt.Errorf("contour interval %v yields more than %d levels", contour.Interval, MaxContourLevels)
+	}
+	for k := first; k <= last; k++ {
+		levels = append(levels, contour.Base+k*contour.Interval)
+	}
+	return levels, nil
+}
+
+// contourCellSegments lists the edges joined by the segments
+// of every configuration of the corners of a marching squares
+// cell. The corners are top-left, top-right, bottom-right and
+// bottom-left from the most significant bit and the edges are
+// top, right, bottom and left. The saddles, 5 and 10, hold
+// the segments of a centre below the level.
+var contourCellSegments = [16][][2]int{
+	{},
+	{{3, 2}},
+	{{2, 1}},
+	{{3, 1}},
+	{{0, 1}},
+	{{0, 1}, {3, 2}},
+	{{0, 2}},
+	{{3, 0}},
+	{{3, 0}},
+	{{0, 2}},
+	{{3, 0}, {2, 1}},
+	{{0, 1}},
+	{{3, 1}},
+	{{2, 1}},
+	{{3, 2}},
+	{},
+}
+
+// ComputeContours returns the contour lines of the values of a
+// raster at the given levels using marching squares over the
+// centres of the pixels. Values without data are NaN and the
+// cells touching them have no contour lines.
+func ComputeContours(values []float64, width int, height int, levels []float64) []ContourLine {
+	var lines []ContourLine
+	if width < 2 || height < 2 || len(values) != width*height {
+		return lines
+	}
+
+	for _, level := range levels {
+		// Every edge of the grid is crossed at most once by a
+		// level, hence segments are joined through their edges
+		type segment struct {
+			edges  [2]int
+			points [2][2]float64
+		}
+		var segments []segment
+		edgeSegments := make(map[int][]int)
+
+		for iy := 0; iy < height-1; iy++ {
+			for ix := 0; ix < width-1; ix++ {
+				corners := [4]float64{values[iy*width+ix], values[iy*width+ix+1], values[(iy+1)*width+ix+1], values[(iy+1)*width+ix]}
+				config := 0
+				hasNaN := false
+				for ic, v := range corners {
+					if math.IsNaN(v) {
+						hasNaN = true
+						break
+					}
+					if v >= level {
+						config |= 8 >> uint(ic)
+					}
+				}
+				if hasNaN || config == 0 || config == 15 {
+					continue
+				}
+
+				cellSegments := contourCellSegments[config]
+				if config == 5 || config == 10 {
+					centre := (corners[0] + corners[1] + corners[2] + corners[3]) / 4
+					if centre >= level {
+						cellSegments = contourCellSegments[15-config]
+					}
+				}
+
+				for _, cellSeg := range cellSegments {
+					var seg segment
+					for ie, edge := range cellSeg {
+						seg.edges[ie], seg.points[ie] = contourEdgePoint(corners, edge, ix, iy, width, level)
+					}
+					for _, edge := range seg.edges {
+						edgeSegments[edge] = append(edgeSegments[edge], len(segments))
+					}
+					segments = append(segments, seg)
+				}
+			}
+		}
+
+		used := make([]bool, len(segments))
+		next := func(edge int) int {
+			for _, is := range edgeSegments[edge] {
+				if !used[is] {
+					return is
+				}
+			}
+			return -1
+		}
+
+		for is := range segments {
+			if used[is] {
+				continue
+			}
+			used[is] = true
+			points := [][2]float64{segments[is].points[0], segments[is].points[1]}
+
+			// Extends the line forward from its last edge then
+			// backward from its first edge
+			for dir := 0; dir < 2; dir++ {
+				edge := segments[is].edges[1-dir]
+				for {
+					in := next(edge)
+					if in < 0 {
+						break
+					}
+					used[in] = true
+					ie := 0
+					if segments[in].edges[1] == edge {
+						ie = 1
+					}
+					if dir == 0 {
+						points = append(points, segments[in].points[1-ie])
+					} else {
+						points = append([][2]float64{segments[in].points[1-ie]}, points...)
+					}
+					edge = segments[in].edges[1-ie]
+				}
+			}
+			lines = append(lines, ContourLine{Level: level, Points: points})
+		}
+	}
+	return lines
+}
+
+// contourEdgePoint returns the identifier of an edge of a cell
+// and the point crossed by a level along it. Horizontal edges
+// have even identifiers and vertical edges odd ones.
+func contourEdgePoint(corners [4]float64, edge int, ix int, iy int, width int, level float64) (int, [2]float64) {
+	var v0, v1 float64
+	var x0, y0, dx, dy float64
+	var id int
+	switch edge {
+	case 0:
+		v0, v1 = corners[0], corners[1]
+		x0, y0, dx = float64(ix), float64(iy), 1
+		id = 2 * (iy*width + ix)
+	case 1:
+		v0, v1 = corners[1], corners[2]
+		x0, y0, dy = float64(ix+1), float64(iy), 1
+		id = 2*(iy*width+ix+1) + 1
+	case 2:
+		v0, v1 = corners[3], corners[2]
+		x0, y0, dx = float64(ix), float64(iy+1), 1
+		id = 2 * ((iy+1)*width + ix)
+	default:
+		v0, v1 = corners[0], corners[3]
+		x0, y0, dy = float64(ix), float64(iy), 1
+		id = 2*(iy*width+ix) + 1
+	}
+
+	t := (level - v0) / (v1 - v0)
+	return id, [2]float64{x0 + t*dx + 0.5, y0 + t*dy + 0.5}
+}
+
+// RasterContours returns the contour lines of a single band
+// raster. The pixels of the padding around the raster are
+// used to compute the lines but the coordinates of the lines
+// are relative to the raster without the padding.
+func RasterContours(r Raster, contour *ContourConfig, padding int) ([]ContourLine, error) {
+	if br, ok := r.(*ByteRaster); ok && (br.Width == 0 || br.Height == 0 || isEmptyTile(br.NameSpace)) {
+		return nil, nil
+	}
+	width, height, _, err := ValidateRasterSlice([]Raster{r})
+	if err != nil {
+		return nil, err
+	}
+
+	values, _, err := RasterFloat64(r)
+	if err != nil {
+		return nil, err
+	}
+
+	minVal := math.Inf(1)
+	maxVal := math.Inf(-1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			minVal = math.Min(minVal, v)
+			maxVal = math.Max(maxVal, v)
+		}
+	}
+	if minVal > maxVal {
+		return nil, nil
+	}
+
+	levels, err := ContourLevels(contour, minVal, maxVal)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := ComputeContours(values, width, height, levels)
+	if padding != 0 {
+		for _, line := range lines {
+			for ip := range line.Points {
+				line.Points[ip][0] -= float64(padding)
+				line.Points[ip][1] -= float64(padding)
+			}
+		}
+	}
+	return lines, nil
+}
+
+// CropRaster removes the padding pixels around a raster
+// of any type. Empty tiles without pixels are returned as such.
+func CropRaster(r Raster, padding int) (Raster, error) {
+	if br, ok := r.(*ByteRaster); padding == 0 || ok && (br.Width == 0 || br.Height == 0) {
+		return r, nil
+	}
+	width, height, _, err := ValidateRasterSlice([]Raster{r})
+	if err != nil {
+		return nil, err
+	}
+
+	outWidth := width - 2*padding
+	outHeight := height - 2*padding
+	if outWidth <= 0 || outHeight <= 0 {
+		return nil, fmt.Errorf("raster too small to crop: %dx%d", width, height)
+	}
+
+	val := reflect.ValueOf(r).Elem()
+	data := val.FieldByName("Data")
+	if data.Len() != width*height {
+		return nil, fmt.Errorf("raster size mismatch: %d pixels for %dx%d", data.Len(), width, height)
+	}
+
+	outData := reflect.MakeSlice(data.Type(), outWidth*outHeight, outWidth*outHeight)
+	for iy := 0; iy < outHeight; iy++ {
+		iRow := (iy+padding)*width + padding
+		reflect.Copy(outData.Slice(iy*outWidth, (iy+1)*outWidth), data.Slice(iRow, iRow+outWidth))
+	}
+
+	out := reflect.New(val.Type())
+	out.Elem().Set(val)
+	out.Elem().FieldByName("Data").Set(outData)
+	out.Elem().FieldByName("Width").SetInt(int64(outWidth))
+	out.Elem().FieldByName("Height").SetInt(int64(outHeight))
+	return out.Interface().(Raster), nil
+}
+
+// EncodeContourGeoJSON writes a LineString feature per contour
+// line with the level as property. The coordinates are those
+// of the geotransform of the raster.
+func EncodeContourGeoJSON(lines []ContourLine, geot []float64) ([]byte, error) {
+	type geometry struct {
+		Type        string       `json:"type"`
+		Coordinates [][2]float64 `json:"coordinates"`
+	}
+	type feature struct {
+		Type       string                 `json:"type"`
+		Geometry   geometry               `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	}
+	featCol := struct {
+		Type     string     `json:"type"`
+		Features []*feature `json:"features"`
+	}{Type: "FeatureCollection", Features: []*feature{}}
+
+	for _, line := range lines {
+		coords := make([][2]float64, len(line.Points))
+		for ip, pt := range line.Points {
+			coords[ip][0] = geot[0] + pt[0]*geot[1] + pt[1]*geot[2]
+			coords[ip][1] = geot[3] + pt[0]*geot[4] + pt[1]*geot[5]
+		}
+		featCol.Features = append(featCol.Features, &feature{Type: "Feature",
+			Geometry:   geometry{Type: "LineString", Coordinates: coords},
+			Properties: map[string]interface{}{"level": line.Level},
+		})
+	}
+
+	return json.Marshal(&featCol)
+}
+
+// EncodeContourMVT writes the contour lines of a raster of the
+// given size as a Mapbox Vector Tile with a single layer whose
+// features are the lines with the level as attribute.
+func EncodeContourMVT(lines []ContourLine, width int, height int, layerName string) ([]byte, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid vector tile size: %dx%d", width, height)
+	}
+	xScale := float64(ContourMVTExtent) / float64(width)
+	yScale := float64(ContourMVTExtent) / float64(height)
+
+	var layer bytes.Buffer
+	mvtWriteString(&layer, 1, layerName)
+
+	levelValues := make(map[float64]uint64)
+	var values []float64
+	for _, line := range lines {
+		var geom []uint64
+		var x, y, nPoints int64
+		for _, pt := range line.Points {
+			px := int64(math.Round(pt[0] * xScale))
+			py := int64(math.Round(pt[1] * yScale))
+			if nPoints > 0 && px == x && py == y {
+				continue
+			}
+			geom = append(geom, mvtZigZag(px-x), mvtZigZag(py-y))
+			x, y = px, py
+			nPoints++
+		}
+		if nPoints < 2 {
+			continue
+		}
+
+		// MoveTo the first point then LineTo the others
+		cmds := append([]uint64{1 | 1<<3}, geom[:2]...)
+		cmds = append(cmds, 2|uint64(nPoints-1)<<3)
+		cmds = append(cmds, geom[2:]...)
+
+		iv, found := levelValues[line.Level]
+		if !found {
+			iv = uint64(len(values))
+			levelValues[line.Level] = iv
+			values = append(values, line.Level)
+		}
+
+		var feat bytes.Buffer
+		mvtWritePacked(&feat, 2, []uint64{0, iv})
+		mvtWriteVarint(&feat, 3<<3)
+		mvtWriteVarint(&feat, 2)
+		mvtWritePacked(&feat, 4, cmds)
+		mvtWriteBytes(&layer, 2, feat.Bytes())
+	}
+
+	mvtWriteString(&layer, 3, "level")
+	for _, v := range values {
+		var value bytes.Buffer
+		mvtWriteVarint(&value, 3<<3|1)
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+		value.Write(b[:])
+		mvtWriteBytes(&layer, 4, value.Bytes())
+	}
+	mvtWriteVarint(&layer, 5<<3)
+	mvtWriteVarint(&layer, ContourMVTExtent)
+	mvtWriteVarint(&layer, 15<<3)
+	mvtWriteVarint(&layer, 2)
+
+	var tile bytes.Buffer
+	mvtWriteBytes(&tile, 3, layer.Bytes())
+	return tile.Bytes(), nil
+}
+
+func mvtZigZag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func mvtWriteVarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	buf.Write(b[:n])
+}
+
+func mvtWriteBytes(buf *bytes.Buffer, field int, data []byte) {
+	mvtWriteVarint(buf, uint64(field)<<3|2)
+	mvtWriteVarint(buf, uint64(len(data)))
+	buf.Write(data)
+}
+
+func mvtWriteString(buf *bytes.Buffer, field int, s string) {
+	mvtWriteBytes(buf, field, []byte(s))
+}
+
+func mvtWritePacked(buf *bytes.Buffer, field int, values []uint64) {
+	var packed bytes.Buffer
+	for _, v := range values {
+		mvtWriteVarint(&packed, v)
+	}
+	mvtWriteBytes(buf, field, packed.Bytes())
+}
+
+// DrawContours draws the contour lines onto an image with the
+// colour and the width of the lines of the contour.
+func DrawContours(canvas *image.RGBA, lines []ContourLine, contour *ContourConfig) {
+	bounds := canvas.Bounds()
+	half := float64(contour.LineWidth) / 2
+
+	plot := func(x float64, y float64) {
+		x0 := int(math.Floor(x - half + 0.5))
+		y0 := int(math.Floor(y - half + 0.5))
+		for py := y0; py < y0+contour.LineWidth; py++ {
+			for px := x0; px < x0+contour.LineWidth; px++ {
+				if image.Pt(px, py).In(bounds) {
+					canvas.SetRGBA(px, py, contour.LineColour)
+				}
+			}
+		}
+	}
+
+	for _, line := range lines {
+		for ip := 1; ip < len(line.Points); ip++ {
+			p0 := line.Points[ip-1]
+			p1 := line.Points[ip]
+			steps := int(math.Ceil(2 * math.Max(math.Abs(p1[0]-p0[0]), math.Abs(p1[1]-p0[1]))))
+			if steps == 0 {
+				steps = 1
+			}
+			for is := 0; is <= steps; is++ {
+				t := float64(is) / float64(steps)
+				plot(p0[0]+t*(p1[0]-p0[0]), p0[1]+t*(p1[1]-p0[1]))
+			}
+		}
+	}
+}
+
+// EncodeContourPNG draws the contour lines onto the rendering
+// of the scaled rasters, or onto a transparent image if only
+// the lines are requested, and encodes the result as PNG.
+func EncodeContourPNG(br []*ByteRaster, palette *Palette, lines []ContourLine, contour *ContourConfig) ([]byte, error) {
+	var canvas *image.RGBA
+	if contour.LinesOnly {
+		canvas = image.NewRGBA(image.Rect(0, 0, br[0].Width, br[0].Height))
+	} else {
+		var err error
+		canvas, err = RenderRGBA(br, palette)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	DrawContours(canvas, lines, contour)
+
+	buf := new(bytes.Buffer)
+	err := png.Encode(buf, canvas)
+	return buf.Bytes(), err
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestComputeContours(t *testing.T) {
+	plane := newTerrainPlane(5, 4, 10, 0)
+	values, _, err := RasterFloat64(plane)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	// The level lies halfway between the centres
+	// of the second and third columns
+	lines := ComputeContours(values, 5, 4, []float64{115})
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d", len(lines))
+	}
+	if len(lines[0].Points) != 4 {
+		t.Fatalf("expected 4 points, got %v", lines[0].Points)
+	}
+	for _, pt := range lines[0].Points {
+		if math.Abs(pt[0]-2) > 1e-9 {
+			t.Errorf("expected x 2, got %v", pt[0])
+		}
+	}
+	if math.Abs(math.Abs(lines[0].Points[3][1]-lines[0].Points[0][1])-3) > 1e-9 {
+		t.Errorf("expected the line to span the rows, got %v", lines[0].Points)
+	}
+
+	// Nodata splits the line
+	values, _, _ = RasterFloat64(newTerrainPlane(5, 6, 10, 0))
+	values[2*5+1] = math.NaN()
+	lines = ComputeContours(values, 5, 6, []float64{115})
+	if len(lines) != 2 {
+		t.Errorf("expected 2 lines, got %d", len(lines))
+	}
+
+	// A peak yields a closed line around it
+	peak := make([]float64, 7*7)
+	for iy := 0; iy < 7; iy++ {
+		for ix := 0; ix < 7; ix++ {
+			peak[iy*7+ix] = 10 - math.Hypot(float64(ix-3), float64(iy-3))
+		}
+	}
+	lines = ComputeContours(peak, 7, 7, []float64{8})
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d", len(lines))
+	}
+	pts := lines[0].Points
+	if pts[0] != pts[len(pts)-1] {
+		t.Errorf("expected a closed line, got %v", pts)
+	}
+	for _, pt := range pts {
+		if d := math.Hypot(pt[0]-3.5, pt[1]-3.5); d < 1.5 || d > 2.5 {
+			t.Errorf("unexpected distance from the peak: %v", d)
+		}
+	}
+}
+
+func TestContourLevels(t *testing.T) {
+	contour := &ContourConfig{Interval: 10, Base: 5}
+	if err := parseContour(contour); err != nil {
+		t.Fatalf("%v", err)
+	}
+	levels, err := ContourLevels(contour, 0, 40)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := []float64{5, 15, 25, 35}
+	if len(levels) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, levels)
+	}
+	for i := range expected {
+		if levels[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, levels)
+		}
+	}
+
+	contour = &ContourConfig{Interval: 0.001}
+	parseContour(contour)
+	if _, err := ContourLevels(contour, 0, 100); err == nil {
+		t.Errorf("expected too many levels to fail")
+	}
+
+	if err := parseContour(&ContourConfig{}); err == nil {
+		t.Errorf("expected contour without interval or levels to fail")
+	}
+
+	interval := 20.0
+	reqContour, err := NewContourConfig(contour, &interval, nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if reqContour.Interval != 20 || contour.Interval != 0.001 {
+		t.Errorf("unexpected intervals: %v, %v", reqContour.Interval, contour.Interval)
+	}
+}
+
+func TestEncodeContourMVT(t *testing.T) {
+	lines := []ContourLine{{Level: 10, Points: [][2]float64{{0, 0}, {256, 256}}}}
+	out, err := EncodeContourMVT(lines, 256, 256, "contours")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	// MoveTo(0, 0) and LineTo(4096, 4096) packed
+	// into the geometry of the feature
+	geom := []byte{0x22, 0x08, 0x09, 0x00, 0x00, 0x0A, 0x80, 0x40, 0x80, 0x40}
+	if !bytes.Contains(out, geom) {
+		t.Errorf("geometry not found in %v", out)
+	}
+	if !bytes.Contains(out, []byte("contours")) || !bytes.Contains(out, []byte("level")) {
+		t.Errorf("layer name or key not found in %v", out)
+	}
+}
+
+func TestCropRaster(t *testing.T) {
+	r := &Int16Raster{NoData: -1, Data: make([]int16, 16), Width: 4, Height: 4, NameSpace: "ns"}
+	for i := range r.Data {
+		r.Data[i] = int16(i)
+	}
+
+	cropped, err := CropRaster(r, 1)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	c, ok := cropped.(*Int16Raster)
+	if !ok {
+		t.Fatalf("unexpected raster type: %T", cropped)
+	}
+	if c.Width != 2 || c.Height != 2 || c.NoData != -1 || c.NameSpace != "ns" {
+		t.Errorf("unexpected raster: %+v", c)
+	}
+	expected := []int16{5, 6, 9, 10}
+	for i := range expected {
+		if c.Data[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, c.Data)
+			break
+		}
+	}
+	if r.Width != 4 || len(r.Data) != 16 {
+		t.Errorf("the original raster was modified")
+	}
+}
@@ -0,0 +1,443 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const CovJSONMediaType = "application/prs.coverage+json"
+
+// CovJSONAxis is either a regular axis given by start,
+// stop and num or an axis with explicit values.
+type CovJSONAxis struct {
+	Start       *float64      `json:"start,omitempty"`
+	Stop        *float64      `json:"stop,omitempty"`
+	Num         int           `json:"num,omitempty"`
+	DataType    string        `json:"dataType,omitempty"`
+	Coordinates []string      `json:"coordinates,omitempty"`
+	Values      []interface{} `json:"values,omitempty"`
+}
+
+type CovJSONReferenceSystem struct {
+	Type     string `json:"type"`
+	ID       string `json:"id,omitempty"`
+	Calendar string `json:"calendar,omitempty"`
+}
+
+type CovJSONReferencing struct {
+	Coordinates []string               `json:"coordinates"`
+	System      CovJSONReferenceSystem `json:"system"`
+}
+
+type CovJSONDomain struct {
+	Type        string                  `json:"type"`
+	DomainType  string                  `json:"domainType"`
+	Axes        map[string]*CovJSONAxis `json:"axes"`
+	Referencing []CovJSONReferencing    `json:"referencing,omitempty"`
+}
+
+type CovJSONObservedProperty struct {
+	Label map[string]string `json:"label"`
+}
+
+type CovJSONParameter struct {
+	Type             string                  `json:"type"`
+	ObservedProperty CovJSONObservedProperty `json:"observedProperty"`
+}
+
+// CovJSONNdArray holds the values of a parameter.
+// Missing values are encoded as null.
+type CovJSONNdArray struct {
+	Type      string     `json:"type"`
+	DataType  string     `json:"dataType"`
+	AxisNames []string   `json:"axisNames,omitempty"`
+	Shape     []int      `json:"shape,omitempty"`
+	Values    []*float64 `json:"values"`
+}
+
+type CovJSONCoverage struct {
+	Type       string                       `json:"type"`
+	Domain     *CovJSONDomain               `json:"domain"`
+	Parameters map[string]*CovJSONParameter `json:"parameters,omitempty"`
+	Ranges     map[string]*CovJSONNdArray   `json:"ranges"`
+}
+
+type CovJSONCoverageCollection struct {
+	Type       string                       `json:"type"`
+	DomainType string                       `json:"domainType,omitempty"`
+	Parameters map[string]*CovJSONParameter `json:"parameters,omitempty"`
+	Coverages  []*CovJSONCoverage           `json:"coverages"`
+}
+
+// CovJSONSeries holds a time series per parameter,
+// i.e. Values[iParam][iTime]
+type CovJSONSeries struct {
+	Times      []string
+	Parameters []string
+	Values     [][]*float64
+}
+
+// NewCovJSONParameters returns the parameter
+// descriptions for the given parameter names.
+func NewCovJSONParameters(names []string) map[string]*CovJSONParameter {
+	params := make(map[string]*CovJSONParameter)
+	for _, name := range names {
+		params[name] = &CovJSONParameter{Type: "Parameter", ObservedProperty: CovJSONObservedProperty{Label: map[string]string{"en": name}}}
+	}
+	return params
+}
+
+func covJSONReferencing(crs string, hasTime bool) []CovJSONReferencing {
+	var ref []CovJSONReferencing
+	crs = strings.ToUpper(strings.TrimSpace(crs))
+	if len(crs) == 0 || crs == "EPSG:4326" {
+		ref = append(ref, CovJSONReferencing{Coordinates: []string{"x", "y"}, System: CovJSONReferenceSystem{Type: "GeographicCRS", ID: OGCAPICRS84}})
+	} else {
+		id := crs
+		if strings.HasPrefix(crs, "EPSG:") {
+			id = "http://www.opengis.net/def/crs/EPSG/0/" + crs[len("EPSG:"):]
+		}
+		ref = append(ref, CovJSONReferencing{Coordinates: []string{"x", "y"}, System: CovJSONReferenceSystem{Type: "ProjectedCRS", ID: id}})
+	}
+	if hasTime {
+		ref = append(ref, CovJSONReferencing{Coordinates: []string{"t"}, System: CovJSONReferenceSystem{Type: "TemporalRS", Calendar: "Gregorian"}})
+	}
+	return ref
+}
+
+func covJSONTimes(times []string) []interface{} {
+	values := make([]interface{}, len(times))
+	for i, t := range times {
+		values[i] = t
+	}
+	return values
+}
+
+// NewCovJSONPointSeries returns a PointSeries
+// coverage for the time series at x, y.
+func NewCovJSONPointSeries(x float64, y float64, series *CovJSONSeries) *CovJSONCoverage {
+	cov := &CovJSONCoverage{Type: "Coverage",
+		Domain: &CovJSONDomain{Type: "Domain",
+			DomainType: "PointSeries",
+			Axes: map[string]*CovJSONAxis{
+				"x": {Values: []interface{}{x}},
+				"y": {Values: []interface{}{y}},
+				"t": {Values: covJSONTimes(series.Times)},
+			},
+			Referencing: covJSONReferencing("", true),
+		},
+		Parameters: NewCovJSONParameters(series.Parameters),
+		Ranges:     make(map[string]*CovJSONNdArray),
+	}
+
+	for ip, name := range series.Parameters {
+		cov.Ranges[name] = &CovJSONNdArray{Type: "NdArray", DataType: "float", AxisNames: []string{"t"}, Shape: []int{len(series.Times)}, Values: series.Values[ip]}
+	}
+	return cov
+}
+
+// NewCovJSONPolygonSeries returns a PolygonSeries coverage
+// for the time series aggregated over the polygon.
+func NewCovJSONPolygonSeries(polygon [][][]float64, series *CovJSONSeries) *CovJSONCoverage {
+	rings := make([]interface{}, len(polygon))
+	for i, r := range polygon {
+		ring := make([][]float64, len(r))
+		for j, p := range r {
+			ring[j] = p[:2]
+		}
+		rings[i] = ring
+	}
+
+	cov := &CovJSONCoverage{Type: "Coverage",
+		Domain: &CovJSONDomain{Type: "Domain",
+			DomainType: "PolygonSeries",
+			Axes: map[string]*CovJSONAxis{
+				"composite": {DataType: "polygon", Coordinates: []string{"x", "y"}, Values: []interface{}{rings}},
+				"t":         {Values: covJSONTimes(series.Times)},
+			},
+			Referencing: covJSONReferencing("", true),
+		},
+		Parameters: NewCovJSONParameters(series.Parameters),
+		Ranges:     make(map[string]*CovJSONNdArray),
+	}
+
+	for ip, name := range series.Parameters {
+		cov.Ranges[name] = &CovJSONNdArray{Type: "NdArray", DataType: "float", AxisNames: []string{"t"}, Shape: []int{len(series.Times)}, Values: series.Values[ip]}
+	}
+	return cov
+}
+
+// NewCovJSONTrajectory returns a Trajectory coverage.
+// The positions are given as t, x, y tuples and
+// Values[iParam][iPosition] holds the values.
+func NewCovJSONTrajectory(times []string, xs []float64, ys []float64, parameters []string, values [][]*float64) *CovJSONCoverage {
+	tuples := make([]interface{}, len(times))
+	for i := range times {
+		tuples[i] = []interface{}{times[i], xs[i], ys[i]}
+	}
+
+	cov := &CovJSONCoverage{Type: "Coverage",
+		Domain: &CovJSONDomain{Type: "Domain",
+			DomainType: "Trajectory",
+			Axes: map[string]*CovJSONAxis{
+				"composite": {DataType: "tuple", Coordinates: []string{"t", "x", "y"}, Values: tuples},
+			},
+			Referencing: covJSONReferencing("", true),
+		},
+		Parameters: NewCovJSONParameters(parameters),
+		Ranges:     make(map[string]*CovJSONNdArray),
+	}
+
+	for ip, name := range parameters {
+		cov.Ranges[name] = &CovJSONNdArray{Type: "NdArray", DataType: "float", AxisNames: []string{"composite"}, Shape: []int{len(times)}, Values: values[ip]}
+	}
+	return cov
+}
+
+// RasterFloat64 returns the values of a raster as
+// float64 with nodata values mapped to NaN.
+func RasterFloat64(r Raster) ([]float64, string, error) {
+	var values []float64
+	var ns string
+	noData := r.GetNoData()
+	switch t := r.(type) {
+	case *SignedByteRaster:
+		ns = t.NameSpace
+		values = make([]float64, len(t.Data))
+		for i, v := range t.Data {
+			values[i] = float64(v)
+		}
+	case *ByteRaster:
+		ns = t.NameSpace
+		values = make([]float64, len(t.Data))
+		for i, v := range t.Data {
+			values[i] = float64(v)
+		}
+	case *Int16Raster:
+		ns = t.NameSpace
+		values = make([]float64, len(t.Data))
+		for i, v := range t.Data {
+			values[i] = float64(v)
+		}
+	case *UInt16Raster:
+		ns = t.NameSpace
+		values = make([]float64, len(t.Data))
+		for i, v := range t.Data {
+			values[i] = float64(v)
+		}
+	case *Int32Raster:
+		ns = t.NameSpace
+		values = make([]float64, len(t.Data))
+		for i, v := range t.Data {
+			values[i] = float64(v)
+		}
+	case *UInt32Raster:
+		ns = t.NameSpace
+		values = make([]float64, len(t.Data))
+		for i, v := range t.Data {
+			values[i] = float64(v)
+		}
+	case *Float32Raster:
+		ns = t.NameSpace
+		values = make([]float64, len(t.Data))
+		for i, v := range t.Data {
+			values[i] = float64(v)
+		}
+		noData = float64(float32(noData))
+	case *Float64Raster:
+		ns = t.NameSpace
+		values = make([]float64, len(t.Data))
+		copy(values, t.Data)
+	default:
+		return nil, "", fmt.Errorf("raster type not supported: %T", r)
+	}
+
+	for i, v := range values {
+		if v == noData || math.IsInf(v, 0) {
+			values[i] = math.NaN()
+		}
+	}
+	return values, ns, nil
+}
+
+// RasterGrid is a stack of rasters of the same
+// grid organised by parameter and time.
+type RasterGrid struct {
+	Width, Height int
+	Times         []string
+	Parameters    []string
+	// Values[iParam][iTime] holds height*width
+	// values or nil if there is no data
+	Values [][][]float64
+}
+
+// NewRasterGrid organises the output rasters of the tile
+// pipeline by parameter and time using their namespaces,
+// e.g. ndvi#time=2020-01-01T00:00:00.000Z. Axes other
+// than time remain part of the parameter name.
+func NewRasterGrid(rs []Raster, width int, height int) (*RasterGrid, error) {
+	grid := &RasterGrid{Width: width, Height: height}
+	type band struct {
+		param, time string
+		values      []float64
+	}
+
+	var bands []*band
+	paramIdx := make(map[string]int)
+	timeIdx := make(map[string]int)
+	for _, r := range rs {
+		values, ns, err := RasterFloat64(r)
+		if err != nil {
+			return nil, err
+		}
+		if ns == EmptyTileNS {
+			continue
+		}
+		if len(values) != width*height {
+			return nil, fmt.Errorf("raster %s size mismatch: %d != %d x %d", ns, len(values), width, height)
+		}
+
+		param := ns
+		ts := ""
+		if parts := strings.SplitN(ns, "#", 2); len(parts) == 2 {
+			param = parts[0]
+			var others []string
+			for _, axis := range strings.Split(parts[1], ",") {
+				if strings.HasPrefix(axis, "time=") {
+					ts = axis[len("time="):]
+				} else {
+					others = append(others, axis)
+				}
+			}
+			if len(others) > 0 {
+				param += "#" + strings.Join(others, ",")
+			}
+		}
+
+		if _, found := paramIdx[param]; !found {
+			paramIdx[param] = len(grid.Parameters)
+			grid.Parameters = append(grid.Parameters, param)
+		}
+		if _, found := timeIdx[ts]; !found {
+			timeIdx[ts] = 0
+			grid.Times = append(grid.Times, ts)
+		}
+		bands = append(bands, &band{param: param, time: ts, values: values})
+	}
+
+	sort.Strings(grid.Times)
+	for it, ts := range grid.Times {
+		timeIdx[ts] = it
+	}
+
+	grid.Values = make([][][]float64, len(grid.Parameters))
+	for ip := range grid.Values {
+		grid.Values[ip] = make([][]float64, len(grid.Times))
+	}
+	for _, b := range bands {
+		grid.Values[paramIdx[b.param]][timeIdx[b.time]] = b.values
+	}
+
+	return grid, nil
+}
+
+// NewCovJSONGrid returns a Grid coverage of the raster
+// grid with the given bounding box in crs.
+func NewCovJSONGrid(grid *RasterGrid, bbox []float64, crs string) *CovJSONCoverage {
+	dx := (bbox[2] - bbox[0]) / float64(grid.Width)
+	dy := (bbox[3] - bbox[1]) / float64(grid.Height)
+
+	// Rows are ordered from north to south
+	xStart, xStop := bbox[0]+dx/2, bbox[2]-dx/2
+	yStart, yStop := bbox[3]-dy/2, bbox[1]+dy/2
+
+	hasTime := len(grid.Times) > 1 || (len(grid.Times) == 1 && len(grid.Times[0]) > 0)
+	axes := map[string]*CovJSONAxis{
+		"x": {Start: &xStart, Stop: &xStop, Num: grid.Width},
+		"y": {Start: &yStart, Stop: &yStop, Num: grid.Height},
+	}
+	axisNames := []string{"y", "x"}
+	shape := []int{grid.Height, grid.Width}
+	if hasTime {
+		axes["t"] = &CovJSONAxis{Values: covJSONTimes(grid.Times)}
+		axisNames = []string{"t", "y", "x"}
+		shape = []int{len(grid.Times), grid.Height, grid.Width}
+	}
+
+	cov := &CovJSONCoverage{Type: "Coverage",
+		Domain: &CovJSONDomain{Type: "Domain",
+			DomainType:  "Grid",
+			Axes:        axes,
+			Referencing: covJSONReferencing(crs, hasTime),
+		},
+		Parameters: NewCovJSONParameters(grid.Parameters),
+		Ranges:     make(map[string]*CovJSONNdArray),
+	}
+
+	nCells := grid.Width * grid.Height
+	for ip, name := range grid.Parameters {
+		values := make([]*float64, len(grid.Times)*nCells)
+		for it := range grid.Times {
+			data := grid.Values[ip][it]
+			if data == nil {
+				continue
+			}
+			for i := range data {
+				if !math.IsNaN(data[i]) {
+					values[it*nCells+i] = &data[i]
+				}
+			}
+		}
+		cov.Ranges[name] = &CovJSONNdArray{Type: "NdArray", DataType: "float", AxisNames: axisNames, Shape: shape, Values: values}
+	}
+
+	return cov
+}
+
+// EncodeRasterGridCSV encodes the raster grid with the given
+// bounding box as CSV rows of the x, y coordinates of the
+// centre of each cell, the time if the grid has one, and the
+// values of the parameters. Missing values are left empty.
+func EncodeRasterGridCSV(grid *RasterGrid, bbox []float64) ([]byte, error) {
+	dx := (bbox[2] - bbox[0]) / float64(grid.Width)
+	dy := (bbox[3] - bbox[1]) / float64(grid.Height)
+	hasTime := len(grid.Times) > 1 || (len(grid.Times) == 1 && len(grid.Times[0]) > 0)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := []string{"x", "y"}
+	if hasTime {
+		header = append(header, "time")
+	}
+	w.Write(append(header, grid.Parameters...))
+
+	for it, ts := range grid.Times {
+		for iy := 0; iy < grid.Height; iy++ {
+			y := bbox[3] - (float64(iy)+0.5)*dy
+			for ix := 0; ix < grid.Width; ix++ {
+				x := bbox[0] + (float64(ix)+0.5)*dx
+				row := []string{strconv.FormatFloat(x, 'f', -1, 64), strconv.FormatFloat(y, 'f', -1, 64)}
+				if hasTime {
+					row = append(row, ts)
+				}
+				for ip := range grid.Parameters {
+					cell := ""
+					if data := grid.Values[ip][it]; data != nil && !math.IsNaN(data[iy*grid.Width+ix]) {
+						cell = strconv.FormatFloat(data[iy*grid.Width+ix], 'f', -1, 64)
+					}
+					row = append(row, cell)
+				}
+				w.Write(row)
+			}
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
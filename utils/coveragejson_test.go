@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewCovJSONGrid(t *testing.T) {
+	rs := []Raster{
+		&Float32Raster{NameSpace: "ndvi", Data: []float32{0.1, -999, 0.3, 0.4}, Width: 2, Height: 2, NoData: -999},
+		&Int16Raster{NameSpace: "red", Data: []int16{10, 20, 30, 0}, Width: 2, Height: 2, NoData: 0},
+	}
+	grid, err := NewRasterGrid(rs, 2, 2)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	grid.Times[0] = "2020-01-01T00:00:00.000Z"
+
+	cov := NewCovJSONGrid(grid, []float64{0, 0, 2, 2}, "EPSG:4326")
+	axes := cov.Domain.Axes
+	if *axes["x"].Start != 0.5 || *axes["x"].Stop != 1.5 || *axes["y"].Start != 1.5 || axes["y"].Num != 2 {
+		t.Errorf("unexpected spatial axes: %v %v", *axes["x"], *axes["y"])
+	}
+	if axes["t"] == nil || len(axes["t"].Values) != 1 {
+		t.Errorf("unexpected time axis: %v", axes["t"])
+	}
+
+	ndvi := cov.Ranges["ndvi"]
+	if ndvi == nil || len(ndvi.Values) != 4 || ndvi.Values[1] != nil || *ndvi.Values[3] != float64(float32(0.4)) {
+		t.Errorf("unexpected ndvi range: %v", ndvi)
+	}
+	if red := cov.Ranges["red"]; red == nil || red.Values[3] != nil || *red.Values[0] != 10 {
+		t.Errorf("unexpected red range: %v", red)
+	}
+}
+
+func TestEncodeRasterGridCSV(t *testing.T) {
+	grid := &RasterGrid{Width: 2,
+		Height:     1,
+		Times:      []string{"2020-01-01T00:00:00.000Z"},
+		Parameters: []string{"a", "b,c"},
+		Values:     [][][]float64{{{1, 2.5}}, {nil}},
+	}
+
+	out, err := EncodeRasterGridCSV(grid, []float64{100, -10, 102, -9})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected := []string{`x,y,time,a,"b,c"`,
+		"100.5,-9.5,2020-01-01T00:00:00.000Z,1,",
+		"101.5,-9.5,2020-01-01T00:00:00.000Z,2.5,",
+	}
+	if res := strings.TrimSpace(string(out)); res != strings.Join(expected, "\n") {
+		t.Errorf("unexpected CSV:\n%s", res)
+	}
+}
@@ -0,0 +1,446 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EDRMaxPoints is the maximum number of positions
+// drilled by a single MultiPoint or trajectory query.
+const EDRMaxPoints = 100
+
+// EDRParams contains the serialised version
+// of the parameters contained in an EDR request.
+type EDRParams struct {
+	Service       *string      `json:"service,omitempty"`
+	Request       *string      `json:"request,omitempty"`
+	Collection    *string      `json:"collection,omitempty"`
+	Coords        *string      `json:"coords,omitempty"`
+	BBox          []float64    `json:"bbox,omitempty"`
+	DateTime      *string      `json:"datetime,omitempty"`
+	ParameterName []string     `json:"parameter_name,omitempty"`
+	Format        *string      `json:"format,omitempty"`
+	Geometry      *EDRGeometry `json:"-"`
+	StartTime     *time.Time   `json:"-"`
+	EndTime       *time.Time   `json:"-"`
+}
+
+// EDRRegexpMap maps EDR request parameters to
+// regular expressions for doing validation
+// when parsing.
+var EDRRegexpMap = map[string]string{"service": `^EDR$`,
+	"request":        `^position$|^area$|^cube$|^trajectory$`,
+	"collection":     `^[^"]+$`,
+	"coords":         `^(?i)[A-Z]+\s*(ZM|Z|M)?\s*\([-+0-9.eE,()\s]+\)$`,
+	"bbox":           `^[-+]?[0-9]*\.?[0-9]*([eE][-+]?[0-9]+)?(,[-+]?[0-9]*\.?[0-9]*([eE][-+]?[0-9]+)?){3}$`,
+	"datetime":       `^[0-9TZ:.+\-/]+$`,
+	"parameter-name": `^[A-Za-z0-9_,\s]+$`,
+	"f":              `^(?i)(CoverageJSON|CSV|application/prs\.coverage\+json|text/csv)$`}
+
+// EDRGeometry is a geometry parsed from the WKT
+// coords parameter of an EDR query. Points holds the
+// positions of POINT, MULTIPOINT and LINESTRING while
+// Polygons holds the rings of POLYGON and MULTIPOLYGON.
+// Each position is x y followed by z and m if present.
+type EDRGeometry struct {
+	Type     string
+	HasZ     bool
+	HasM     bool
+	Points   [][]float64
+	Polygons [][][][]float64
+}
+
+type wktNode struct {
+	coord    []float64
+	children []*wktNode
+}
+
+func CompileEDRRegexMap() map[string]*regexp.Regexp {
+	REMap := make(map[string]*regexp.Regexp)
+	for key, re := range EDRRegexpMap {
+		REMap[key] = regexp.MustCompile(re)
+	}
+
+	return REMap
+}
+
+// EDRParamsChecker checks and marshals the content
+// of the parameters of an EDR request into an
+// EDRParams struct.
+func EDRParamsChecker(params map[string][]string, compREMap map[string]*regexp.Regexp) (EDRParams, error) {
+	var edrParams EDRParams
+
+	jsonFields := []string{}
+
+	if service, serviceOK := params["service"]; serviceOK {
+		if compREMap["service"].MatchString(service[0]) {
+			jsonFields = append(jsonFields, fmt.Sprintf(`"service":"%s"`, service[0]))
+		}
+	}
+
+	if request, requestOK := params["request"]; requestOK {
+		if !compREMap["request"].MatchString(request[0]) {
+			return edrParams, fmt.Errorf("invalid request: %s", request[0])
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"request":"%s"`, request[0]))
+	}
+
+	if collection, collectionOK := params["collection"]; collectionOK {
+		if compREMap["collection"].MatchString(collection[0]) {
+			jsonFields = append(jsonFields, fmt.Sprintf(`"collection":"%s"`, collection[0]))
+		}
+	}
+
+	if coords, coordsOK := params["coords"]; coordsOK {
+		if !compREMap["coords"].MatchString(coords[0]) {
+			return edrParams, fmt.Errorf("invalid coords: %s", coords[0])
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"coords":"%s"`, coords[0]))
+	}
+
+	if bbox, bboxOK := params["bbox"]; bboxOK {
+		if !compREMap["bbox"].MatchString(bbox[0]) {
+			return edrParams, fmt.Errorf("invalid bbox: %s", bbox[0])
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"bbox":[%s]`, bbox[0]))
+	}
+
+	if datetime, datetimeOK := params["datetime"]; datetimeOK {
+		if !compREMap["datetime"].MatchString(datetime[0]) {
+			return edrParams, fmt.Errorf("invalid datetime: %s", datetime[0])
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"datetime":"%s"`, datetime[0]))
+	}
+
+	if paramNames, paramNamesOK := params["parameter-name"]; paramNamesOK {
+		if !compREMap["parameter-name"].MatchString(paramNames[0]) {
+			return edrParams, fmt.Errorf("invalid parameter-name: %s", paramNames[0])
+		}
+		var names []string
+		for _, name := range strings.Split(paramNames[0], ",") {
+			name = strings.TrimSpace(name)
+			if len(name) > 0 {
+				names = append(names, fmt.Sprintf(`"%s"`, name))
+			}
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"parameter_name":[%s]`, strings.Join(names, ",")))
+	}
+
+	if format, formatOK := params["f"]; formatOK {
+		if !compREMap["f"].MatchString(format[0]) {
+			return edrParams, fmt.Errorf("unsupported format: %s", format[0])
+		}
+		f := "coveragejson"
+		switch strings.ToLower(format[0]) {
+		case "csv", "text/csv":
+			f = "csv"
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"format":"%s"`, f))
+	}
+
+	jsonParams := fmt.Sprintf("{%s}", strings.Join(jsonFields, ","))
+	err := json.Unmarshal([]byte(jsonParams), &edrParams)
+	if err != nil {
+		return edrParams, err
+	}
+
+	if edrParams.Coords != nil {
+		geom, err := ParseWKT(*edrParams.Coords)
+		if err != nil {
+			return edrParams, err
+		}
+		edrParams.Geometry = geom
+	}
+
+	if edrParams.DateTime != nil {
+		start, end, err := ParseEDRDateTime(*edrParams.DateTime)
+		if err != nil {
+			return edrParams, err
+		}
+		edrParams.StartTime = start
+		edrParams.EndTime = end
+	}
+
+	return edrParams, nil
+}
+
+// ParseEDRDateTime parses an instant or an interval
+// with optional open ends denoted by "..".
+func ParseEDRDateTime(datetime string) (*time.Time, *time.Time, error) {
+	interval := strings.Split(datetime, "/")
+	if len(interval) > 2 {
+		return nil, nil, fmt.Errorf("invalid datetime: %s", datetime)
+	}
+
+	var times []*time.Time
+	for _, t := range interval {
+		ts, err := parseOGCAPITime(t)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ts == "*" {
+			times = append(times, nil)
+			continue
+		}
+		tm, err := time.Parse(ISOFormat, ts)
+		if err != nil {
+			return nil, nil, err
+		}
+		times = append(times, &tm)
+	}
+
+	if len(times) == 1 {
+		return times[0], times[0], nil
+	}
+	if times[0] != nil && times[1] != nil && times[1].Before(*times[0]) {
+		return nil, nil, fmt.Errorf("invalid datetime interval: %s", datetime)
+	}
+	return times[0], times[1], nil
+}
+
+// ParseWKT parses the WKT geometries used by EDR queries,
+// i.e. POINT, MULTIPOINT, LINESTRING, POLYGON and
+// MULTIPOLYGON with optional Z and M dimensions.
+func ParseWKT(wkt string) (*EDRGeometry, error) {
+	wkt = strings.TrimSpace(wkt)
+	iOpen := strings.Index(wkt, "(")
+	if iOpen < 0 {
+		return nil, fmt.Errorf("invalid WKT: %s", wkt)
+	}
+
+	header := strings.Fields(strings.ToUpper(wkt[:iOpen]))
+	if len(header) == 0 || len(header) > 2 {
+		return nil, fmt.Errorf("invalid WKT: %s", wkt)
+	}
+
+	geomType := header[0]
+	dims := ""
+	if len(header) == 2 {
+		dims = header[1]
+	} else {
+		for _, suffix := range []string{"ZM", "Z", "M"} {
+			if strings.HasSuffix(geomType, suffix) {
+				geomType = geomType[:len(geomType)-len(suffix)]
+				dims = suffix
+				break
+			}
+		}
+	}
+	if dims != "" && dims != "Z" && dims != "M" && dims != "ZM" {
+		return nil, fmt.Errorf("invalid WKT dimensions: %s", dims)
+	}
+
+	pos := iOpen
+	root, err := parseWKTNode(wkt, &pos)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(wkt[pos:]) != "" {
+		return nil, fmt.Errorf("invalid WKT, trailing characters: %s", wkt)
+	}
+
+	geom := &EDRGeometry{Type: geomType, HasZ: strings.Contains(dims, "Z"), HasM: strings.Contains(dims, "M")}
+	nDims := 2
+	if geom.HasZ {
+		nDims++
+	}
+	if geom.HasM {
+		nDims++
+	}
+
+	getCoords := func(node *wktNode) ([][]float64, error) {
+		var coords [][]float64
+		for _, c := range node.children {
+			// MULTIPOINT allows both (x y, x y) and ((x y), (x y))
+			if c.coord == nil && len(c.children) == 1 {
+				c = c.children[0]
+			}
+			if c.coord == nil {
+				return nil, fmt.Errorf("invalid WKT coordinates: %s", wkt)
+			}
+			if len(c.coord) == 3 && dims == "" {
+				geom.HasZ = true
+				nDims = 3
+			}
+			if len(c.coord) != nDims {
+				return nil, fmt.Errorf("invalid WKT coordinate dimension: %s", wkt)
+			}
+			coords = append(coords, c.coord)
+		}
+		return coords, nil
+	}
+
+	getPolygon := func(node *wktNode) ([][][]float64, error) {
+		var rings [][][]float64
+		for _, r := range node.children {
+			ring, err := getCoords(r)
+			if err != nil {
+				return nil, err
+			}
+			if len(ring) < 4 {
+				return nil, fmt.Errorf("invalid WKT, polygon rings need at least 4 positions: %s", wkt)
+			}
+			rings = append(rings, ring)
+		}
+		return rings, nil
+	}
+
+	switch geomType {
+	case "POINT":
+		geom.Points, err = getCoords(root)
+		if err == nil && len(geom.Points) != 1 {
+			err = fmt.Errorf("invalid WKT point: %s", wkt)
+		}
+	case "MULTIPOINT", "LINESTRING":
+		geom.Points, err = getCoords(root)
+		if err == nil && geomType == "LINESTRING" && len(geom.Points) < 2 {
+			err = fmt.Errorf("invalid WKT linestring: %s", wkt)
+		}
+	case "POLYGON":
+		var poly [][][]float64
+		poly, err = getPolygon(root)
+		geom.Polygons = [][][][]float64{poly}
+	case "MULTIPOLYGON":
+		for _, p := range root.children {
+			var poly [][][]float64
+			poly, err = getPolygon(p)
+			if err != nil {
+				break
+			}
+			geom.Polygons = append(geom.Polygons, poly)
+		}
+	default:
+		err = fmt.Errorf("unsupported WKT geometry: %s", geomType)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return geom, nil
+}
+
+func parseWKTNode(wkt string, pos *int) (*wktNode, error) {
+	skipSpaces := func() {
+		for *pos < len(wkt) && (wkt[*pos] == ' ' || wkt[*pos] == '\t' || wkt[*pos] == '\n') {
+			*pos++
+		}
+	}
+
+	skipSpaces()
+	if *pos >= len(wkt) || wkt[*pos] != '(' {
+		return nil, fmt.Errorf("invalid WKT, expecting '(' at %d: %s", *pos, wkt)
+	}
+	*pos++
+
+	node := &wktNode{}
+	for {
+		skipSpaces()
+		if *pos >= len(wkt) {
+			return nil, fmt.Errorf("invalid WKT, missing ')': %s", wkt)
+		}
+
+		if wkt[*pos] == '(' {
+			child, err := parseWKTNode(wkt, pos)
+			if err != nil {
+				return nil, err
+			}
+			node.children = append(node.children, child)
+		} else {
+			end := *pos
+			for end < len(wkt) && wkt[end] != ',' && wkt[end] != ')' {
+				end++
+			}
+			var coord []float64
+			for _, f := range strings.Fields(wkt[*pos:end]) {
+				val, err := strconv.ParseFloat(f, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid WKT coordinate '%s': %s", f, wkt)
+				}
+				coord = append(coord, val)
+			}
+			if len(coord) < 2 || len(coord) > 4 {
+				return nil, fmt.Errorf("invalid WKT coordinate: %s", wkt)
+			}
+			node.children = append(node.children, &wktNode{coord: coord})
+			*pos = end
+		}
+
+		skipSpaces()
+		if *pos >= len(wkt) {
+			return nil, fmt.Errorf("invalid WKT, missing ')': %s", wkt)
+		}
+		if wkt[*pos] == ',' {
+			*pos++
+			continue
+		}
+		if wkt[*pos] == ')' {
+			*pos++
+			return node, nil
+		}
+		return nil, fmt.Errorf("invalid WKT, unexpected '%c': %s", wkt[*pos], wkt)
+	}
+}
+
+// Time returns the M value of a position as a time
+// assuming it is given in seconds since the epoch.
+func (g *EDRGeometry) Time(pos []float64) *time.Time {
+	if !g.HasM {
+		return nil
+	}
+	t := time.Unix(int64(pos[len(pos)-1]), 0).UTC()
+	return &t
+}
+
+// GeoJSONFeature returns the 2D GeoJSON feature of the
+// geometry as expected by the drill pipeline.
+func (g *EDRGeometry) GeoJSONFeature() string {
+	xy := func(coords [][]float64) [][]float64 {
+		out := make([][]float64, len(coords))
+		for i, c := range coords {
+			out[i] = c[:2]
+		}
+		return out
+	}
+	polygon := func(rings [][][]float64) [][][]float64 {
+		out := make([][][]float64, len(rings))
+		for i, r := range rings {
+			out[i] = xy(r)
+		}
+		return out
+	}
+
+	geom := map[string]interface{}{}
+	switch g.Type {
+	case "POINT":
+		geom["type"] = "Point"
+		geom["coordinates"] = g.Points[0][:2]
+	case "MULTIPOINT":
+		geom["type"] = "MultiPoint"
+		geom["coordinates"] = xy(g.Points)
+	case "LINESTRING":
+		geom["type"] = "LineString"
+		geom["coordinates"] = xy(g.Points)
+	case "POLYGON":
+		geom["type"] = "Polygon"
+		geom["coordinates"] = polygon(g.Polygons[0])
+	case "MULTIPOLYGON":
+		var polys [][][][]float64
+		for _, p := range g.Polygons {
+			polys = append(polys, polygon(p))
+		}
+		geom["type"] = "MultiPolygon"
+		geom["coordinates"] = polys
+	}
+
+	feat, _ := json.Marshal(map[string]interface{}{"type": "Feature", "geometry": geom})
+	return string(feat)
+}
+
+// PointFeature returns the GeoJSON point feature of a position.
+func PointFeature(pos []float64) string {
+	return fmt.Sprintf(`{"type":"Feature","geometry":{"type":"Point","coordinates":[%v,%v]}}`, pos[0], pos[1])
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestParseWKT(t *testing.T) {
+	geom, err := ParseWKT("POINT(149.1 -35.3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if geom.Type != "POINT" || len(geom.Points) != 1 || geom.Points[0][0] != 149.1 || geom.Points[0][1] != -35.3 {
+		t.Errorf("unexpected point: %+v", geom)
+	}
+
+	geom, err = ParseWKT("MULTIPOINT((149 -35),(150 -36))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(geom.Points) != 2 || geom.Points[1][0] != 150 {
+		t.Errorf("unexpected multipoint: %+v", geom)
+	}
+
+	geom, err = ParseWKT("LINESTRINGM(149 -35 1577836800, 150 -36 1577923200)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !geom.HasM || geom.HasZ || len(geom.Points) != 2 {
+		t.Errorf("unexpected linestring: %+v", geom)
+	}
+	if ts := geom.Time(geom.Points[1]); ts == nil || ts.Format(ISOFormat) != "2020-01-02T00:00:00.000Z" {
+		t.Errorf("unexpected linestring time: %v", ts)
+	}
+
+	geom, err = ParseWKT("MULTIPOLYGON(((0 0,1 0,1 1,0 0)),((2 2,3 2,3 3,2 2)))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if geom.Type != "MULTIPOLYGON" || len(geom.Polygons) != 2 || len(geom.Polygons[1][0]) != 4 {
+		t.Errorf("unexpected multipolygon: %+v", geom)
+	}
+
+	for _, wkt := range []string{"POINT(1)", "POLYGON((0 0,1 1,0 0))", "POINT(1 2", "CIRCLE(1 2)"} {
+		if _, err := ParseWKT(wkt); err == nil {
+			t.Errorf("expected error for %s", wkt)
+		}
+	}
+}
+
+func TestEDRParamsChecker(t *testing.T) {
+	reMap := CompileEDRRegexMap()
+	params, err := EDRParamsChecker(map[string][]string{"service": {"EDR"},
+		"request":        {"area"},
+		"collection":     {"ndvi"},
+		"coords":         {"POLYGON((0 0,1 0,1 1,0 0))"},
+		"datetime":       {"2020-01-01/.."},
+		"parameter-name": {"ndvi,evi"},
+		"f":              {"CSV"}}, reMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *params.Request != "area" || *params.Format != "csv" || len(params.ParameterName) != 2 {
+		t.Errorf("unexpected params: %+v", params)
+	}
+	if params.StartTime == nil || params.StartTime.Format(ISOFormat) != "2020-01-01T00:00:00.000Z" || params.EndTime != nil {
+		t.Errorf("unexpected datetime: %v %v", params.StartTime, params.EndTime)
+	}
+
+	_, err = EDRParamsChecker(map[string][]string{"request": {"radius"}}, reMap)
+	if err == nil {
+		t.Errorf("expected error for unsupported query")
+	}
+}
@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Output formats of WMS GetFeatureInfo requests
+const FeatureInfoJSON = "application/json"
+const FeatureInfoHTML = "text/html"
+const FeatureInfoText = "text/plain"
+const FeatureInfoGML = "application/vnd.ogc.gml"
+
+// FeatureInfoFormats lists the info_format values accepted
+// by GetFeatureInfo, the first one being the default.
+var FeatureInfoFormats = []string{FeatureInfoJSON, FeatureInfoHTML, FeatureInfoText, FeatureInfoGML}
+
+// CheckFeatureInfoFormat returns the info format in lower
+// case if it is one of FeatureInfoFormats.
+func CheckFeatureInfoFormat(format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	for _, f := range FeatureInfoFormats {
+		if format == f {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("info_format must be one of %s", strings.Join(FeatureInfoFormats, ", "))
+}
+
+// FeatureInfoBand holds the value of a band at the
+// queried pixel or the reason why there is none.
+type FeatureInfoBand struct {
+	Name    string
+	Value   string
+	HasData bool
+}
+
+// FeatureInfo is the result of a GetFeatureInfo request
+// independent of the format it is encoded to.
+type FeatureInfo struct {
+	Layer          string
+	CRS            string
+	X              float64
+	Y              float64
+	Time           string
+	Times          []string
+	Bands          []FeatureInfoBand
+	AvailableDates []string
+	DataLinks      []string
+	Error          string
+}
+
+// featureInfoBands encodes the bands as a JSON object
+// keeping the order of the band expressions.
+type featureInfoBands []FeatureInfoBand
+
+func (bands featureInfoBands) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, band := range bands {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(band.Name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		val, err := strconv.ParseFloat(band.Value, 64)
+		if band.HasData && err == nil && !math.IsNaN(val) && !math.IsInf(val, 0) {
+			buf.WriteString(band.Value)
+			continue
+		}
+		str, err := json.Marshal(band.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(str)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type featureInfoProperties struct {
+	X              float64          `json:"x"`
+	Y              float64          `json:"y"`
+	Time           string           `json:"time,omitempty"`
+	Times          []string         `json:"times,omitempty"`
+	Bands          featureInfoBands `json:"bands,omitempty"`
+	AvailableDates []string         `json:"data_available_for_dates,omitempty"`
+	DataLinks      []string         `json:"data_links,omitempty"`
+	Error          string           `json:"error,omitempty"`
+}
+
+type featureInfoFeature struct {
+	Type       string                `json:"type"`
+	Geometry   interface{}           `json:"geometry"`
+	Properties featureInfoProperties `json:"properties"`
+}
+
+type featureInfoCollection struct {
+	Type     string               `json:"type"`
+	Features []featureInfoFeature `json:"features"`
+}
+
+// EncodeFeatureInfoJSON encodes a GetFeatureInfo result as a GeoJSON
+// FeatureCollection of one feature whose properties hold the
+// coordinates and time of the request and the values of the bands.
+func EncodeFeatureInfoJSON(info *FeatureInfo) ([]byte, error) {
+	feat := featureInfoFeature{Type: "Feature",
+		Properties: featureInfoProperties{X: info.X,
+			Y:              info.Y,
+			Time:           info.Time,
+			Times:          info.Times,
+			Bands:          featureInfoBands(info.Bands),
+			AvailableDates: info.AvailableDates,
+			DataLinks:      info.DataLinks,
+			Error:          info.Error,
+		},
+	}
+	return json.Marshal(featureInfoCollection{Type: "FeatureCollection", Features: []featureInfoFeature{feat}})
+}
+
+// EncodeFeatureInfoText encodes a GetFeatureInfo result
+// as lines of key = value pairs.
+func EncodeFeatureInfoText(info *FeatureInfo) []byte {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "layer = %s\n", info.Layer)
+	fmt.Fprintf(buf, "x = %v\n", info.X)
+	fmt.Fprintf(buf, "y = %v\n", info.Y)
+	if len(info.Times) > 0 {
+		fmt.Fprintf(buf, "times = %s\n", strings.Join(info.Times, ", "))
+	} else if len(info.Time) > 0 {
+		fmt.Fprintf(buf, "time = %s\n", info.Time)
+	}
+	for _, band := range info.Bands {
+		fmt.Fprintf(buf, "%s = %s\n", band.Name, band.Value)
+	}
+	if len(info.AvailableDates) > 0 {
+		fmt.Fprintf(buf, "data_available_for_dates = %s\n", strings.Join(info.AvailableDates, ", "))
+	}
+	if len(info.DataLinks) > 0 {
+		fmt.Fprintf(buf, "data_links = %s\n", strings.Join(info.DataLinks, ", "))
+	}
+	if len(info.Error) > 0 {
+		fmt.Fprintf(buf, "error = %s\n", info.Error)
+	}
+	return buf.Bytes()
+}
+
+// EncodeFeatureInfoTemplate executes the template file of an
+// HTML or GML GetFeatureInfo response. HTML templates escape
+// their values automatically whereas GML templates have to
+// escape them with the html function.
+func EncodeFeatureInfoTemplate(info *FeatureInfo, filePath string, format string) ([]byte, error) {
+	tplStr, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Error trying to read %s file: %v", filePath, err)
+	}
+
+	buf := new(bytes.Buffer)
+	if format == FeatureInfoHTML {
+		tpl, err := htmltemplate.New("template").Parse(string(tplStr))
+		if err != nil {
+			return nil, fmt.Errorf("Error trying to parse template document: %v", err)
+		}
+		err = tpl.Execute(buf, info)
+		if err != nil {
+			return nil, fmt.Errorf("Error executing template: %v", err)
+		}
+	} else {
+		tpl, err := template.New("template").Parse(string(tplStr))
+		if err != nil {
+			return nil, fmt.Errorf("Error trying to parse template document: %v", err)
+		}
+		err = tpl.Execute(buf, info)
+		if err != nil {
+			return nil, fmt.Errorf("Error executing template: %v", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
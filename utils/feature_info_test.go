@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func newTestFeatureInfo() *FeatureInfo {
+	return &FeatureInfo{Layer: "rain <daily>",
+		CRS:  "EPSG:4326",
+		X:    150.5,
+		Y:    -35.25,
+		Time: "2020-01-01T00:00:00.000Z",
+		Bands: []FeatureInfoBand{{Name: "precip", Value: "12.5", HasData: true},
+			{Name: "quality", Value: "n/a"},
+			{Name: "anomaly", Value: "NaN", HasData: true},
+		},
+		DataLinks: []string{"http://example.com/a.nc?x=1&y=2"},
+	}
+}
+
+func TestEncodeFeatureInfoJSON(t *testing.T) {
+	out, err := EncodeFeatureInfoJSON(newTestFeatureInfo())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected := `"bands":{"precip":12.5,"quality":"n/a","anomaly":"NaN"}`
+	if !strings.Contains(string(out), expected) {
+		t.Errorf("expected %s in %s", expected, out)
+	}
+
+	var fc struct {
+		Type     string
+		Features []struct {
+			Type       string
+			Properties map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(out, &fc); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 1 {
+		t.Fatalf("unexpected feature collection: %s", out)
+	}
+	props := fc.Features[0].Properties
+	if props["x"] != 150.5 || props["time"] != "2020-01-01T00:00:00.000Z" {
+		t.Errorf("unexpected properties: %v", props)
+	}
+	if _, found := props["error"]; found {
+		t.Errorf("unexpected error property: %v", props)
+	}
+}
+
+func TestEncodeFeatureInfoText(t *testing.T) {
+	out := string(EncodeFeatureInfoText(newTestFeatureInfo()))
+	for _, line := range []string{"x = 150.5\n", "time = 2020-01-01T00:00:00.000Z\n", "precip = 12.5\n", "quality = n/a\n"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected %q in %q", line, out)
+		}
+	}
+}
+
+func TestEncodeFeatureInfoTemplate(t *testing.T) {
+	info := newTestFeatureInfo()
+
+	out, err := EncodeFeatureInfoTemplate(info, "../templates/WMS_GetFeatureInfo.tpl", FeatureInfoHTML)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !strings.Contains(string(out), "<th>precip</th><td>12.5</td>") || !strings.Contains(string(out), "rain &lt;daily&gt;") {
+		t.Errorf("unexpected HTML: %s", out)
+	}
+
+	out, err = EncodeFeatureInfoTemplate(info, "../templates/WMS_GetFeatureInfo_GML.tpl", FeatureInfoGML)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !strings.Contains(string(out), `<band name="precip" has_data="true">12.5</band>`) || !strings.Contains(string(out), "a.nc?x=1&amp;y=2") {
+		t.Errorf("unexpected GML: %s", out)
+	}
+}
@@ -0,0 +1,269 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Orientations of the colour bar of generated legends
+const LegendVertical = "vertical"
+const LegendHorizontal = "horizontal"
+
+// DefaultLegendTickCount is the number of tick labels
+// of generated legends without explicit ticks.
+const DefaultLegendTickCount = 5
+
+// legendMargin is the margin in pixels around the
+// content of generated legends.
+const legendMargin = 4
+
+// LegendParams describes a legend generated from the
+// palette and the scaling of a style.
+type LegendParams struct {
+	Width       int
+	Height      int
+	Orientation string
+	Title       string
+	Units       string
+	Ticks       []float64
+	TickLabels  []string
+	TickCount   int
+	Palette     *Palette
+	Offset      float64
+	Scale       float64
+	Clip        float64
+	ColourScale int
+}
+
+// parseLegend checks the generated legend of a layer
+// and sets the defaults of its size and orientation.
+func parseLegend(layer *Layer) error {
+	if layer.LegendWidth <= 0 {
+		layer.LegendWidth = DefaultLegendWidth
+	}
+	if layer.LegendHeight <= 0 {
+		layer.LegendHeight = DefaultLegendHeight
+	}
+
+	layer.LegendOrientation = strings.ToLower(strings.TrimSpace(layer.LegendOrientation))
+	switch layer.LegendOrientation {
+	case "":
+		layer.LegendOrientation = LegendVertical
+	case LegendVertical, LegendHorizontal:
+	default:
+		return fmt.Errorf("unknown legend orientation: %v", layer.LegendOrientation)
+	}
+
+	if layer.LegendTickCount < 0 {
+		return fmt.Errorf("legend tick count must be positive: %v", layer.LegendTickCount)
+	}
+	if len(layer.LegendTickLabels) > 0 && len(layer.LegendTickLabels) != len(layer.LegendTicks) {
+		return fmt.Errorf("legend tick labels must match the legend ticks")
+	}
+	return nil
+}
+
+// LegendRange returns the range of values covered by the colours
+// of a palette scaled by the offset, scale and clip of a style,
+// i.e. the inverse of the scaling of the rasters. The values
+// are log10 of the data for logarithmic colour scales. Styles
+// scaled to the range of every tile have no fixed range.
+func LegendRange(offset float64, scale float64, clip float64) (float64, float64, bool) {
+	if scale == 0 && clip == 0 && offset == 0 {
+		return 0, 0, false
+	}
+
+	if scale <= 0 {
+		if clip <= 0 {
+			scale = 1
+		} else {
+			scale = 254 / clip
+		}
+	}
+
+	top := 254 / scale
+	if clip < top {
+		top = clip
+	}
+	if top <= 0 {
+		return 0, 0, false
+	}
+	return -offset, top - offset, true
+}
+
+// formatLegendValue formats the label of a tick with up
+// to 4 significant digits.
+func formatLegendValue(val float64) string {
+	abs := math.Abs(val)
+	if abs != 0 && (abs >= 1e5 || abs < 1e-3) {
+		return strconv.FormatFloat(val, 'g', 3, 64)
+	}
+	if abs != 0 {
+		exp := math.Pow(10, 3-math.Floor(math.Log10(abs)))
+		val = math.Round(val*exp) / exp
+	}
+	return strconv.FormatFloat(val, 'f', -1, 64)
+}
+
+// EncodeLegend renders a colour bar of the palette of a style
+// with its tick labels, title and units as PNG. The ticks are
+// either the explicit ticks within the range of the palette
+// or evenly spread along it.
+func EncodeLegend(params *LegendParams) ([]byte, error) {
+	width, height := params.Width, params.Height
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid legend size: %dx%d", width, height)
+	}
+
+	plt, err := GradientRGBAPalette(params.Palette)
+	if err != nil {
+		return nil, err
+	}
+	colourAt := func(idx uint8) color.RGBA {
+		if plt != nil {
+			return plt[idx]
+		}
+		return color.RGBA{R: idx, G: idx, B: idx, A: 0xFF}
+	}
+
+	// The colour bar spans the colours of the range of values
+	// which may end before the top of the palette if clipped
+	lo, hi, hasRange := LegendRange(params.Offset, params.Scale, params.Clip)
+	topIdx := 254.0
+	if hasRange && params.Scale > 0 {
+		topIdx = math.Min(254, (hi+params.Offset)*params.Scale)
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	white := color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	black := color.RGBA{A: 0xFF}
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{white}, image.Point{}, draw.Src)
+
+	y := legendMargin
+	for _, text := range []string{params.Title, params.Units} {
+		if len(strings.TrimSpace(text)) > 0 {
+			drawLegendText(canvas, legendMargin, y, text, black)
+			y += legendLineHeight
+		}
+	}
+
+	type tick struct {
+		pos   float64
+		label string
+	}
+	var ticks []tick
+	if hasRange && hi > lo {
+		toPos := func(val float64) (float64, bool) {
+			if params.ColourScale == ColourLogScale {
+				if val <= 0 {
+					return 0, false
+				}
+				val = math.Log10(val)
+			}
+			pos := (val - lo) / (hi - lo)
+			return pos, pos >= -1e-9 && pos <= 1+1e-9
+		}
+		if len(params.Ticks) > 0 {
+			for it, val := range params.Ticks {
+				if pos, ok := toPos(val); ok {
+					label := formatLegendValue(val)
+					if it < len(params.TickLabels) {
+						label = params.TickLabels[it]
+					}
+					ticks = append(ticks, tick{pos: pos, label: label})
+				}
+			}
+		} else {
+			count := params.TickCount
+			if count <= 0 {
+				count = DefaultLegendTickCount
+			}
+			for it := 0; it < count; it++ {
+				pos := 0.0
+				if count > 1 {
+					pos = float64(it) / float64(count-1)
+				}
+				val := lo + pos*(hi-lo)
+				if params.ColourScale == ColourLogScale {
+					val = math.Pow(10, val)
+				}
+				ticks = append(ticks, tick{pos: pos, label: formatLegendValue(val)})
+			}
+		}
+	}
+
+	var x0, y0, x1, y1 int
+	if params.Orientation == LegendHorizontal {
+		x0 = legendMargin
+		x1 = width - legendMargin - 1
+		y0 = y + 2
+		y1 = height - legendMargin - legendLineHeight - 4
+		if y1-y0 > 30 {
+			y1 = y0 + 30
+		}
+	} else {
+		barWidth := width / 5
+		if barWidth < 8 {
+			barWidth = 8
+		} else if barWidth > 30 {
+			barWidth = 30
+		}
+		x0 = legendMargin
+		x1 = x0 + barWidth
+		y0 = y + legendGlyphHeight/2
+		y1 = height - legendMargin - legendGlyphHeight/2 - 1
+	}
+	if x1-x0 < 2 || y1-y0 < 2 {
+		return nil, fmt.Errorf("legend too small: %dx%d", width, height)
+	}
+
+	for py := y0; py <= y1; py++ {
+		for px := x0; px <= x1; px++ {
+			var pos float64
+			if params.Orientation == LegendHorizontal {
+				pos = float64(px-x0) / float64(x1-x0)
+			} else {
+				pos = float64(y1-py) / float64(y1-y0)
+			}
+			colour := colourAt(uint8(pos*topIdx + 0.5))
+			if px == x0 || px == x1 || py == y0 || py == y1 {
+				colour = black
+			}
+			canvas.SetRGBA(px, py, colour)
+		}
+	}
+
+	for _, t := range ticks {
+		if params.Orientation == LegendHorizontal {
+			px := x0 + int(t.pos*float64(x1-x0)+0.5)
+			for py := y1; py <= y1+3; py++ {
+				canvas.SetRGBA(px, py, black)
+			}
+			lx := px - legendTextWidth(t.label)/2
+			if lx+legendTextWidth(t.label) > width {
+				lx = width - legendTextWidth(t.label)
+			}
+			if lx < 0 {
+				lx = 0
+			}
+			drawLegendText(canvas, lx, y1+5, t.label, black)
+		} else {
+			py := y1 - int(t.pos*float64(y1-y0)+0.5)
+			for px := x1; px <= x1+3; px++ {
+				canvas.SetRGBA(px, py, black)
+			}
+			drawLegendText(canvas, x1+6, py-legendGlyphHeight/2, t.label, black)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	err = png.Encode(buf, canvas)
+	return buf.Bytes(), err
+}
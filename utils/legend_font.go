@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+)
+
+// Size in pixels of the glyphs of the legend font and
+// of the cells holding them, spacing included.
+const legendGlyphWidth = 5
+const legendGlyphHeight = 7
+const legendCharWidth = 6
+const legendLineHeight = 10
+
+// legendGlyphs is a 5x7 bitmap font for the labels of the
+// generated legends. Every row holds the 5 columns of the
+// glyph from the most significant bit.
+var legendGlyphs = map[rune][legendGlyphHeight]uint8{
+	' ':  {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+	'!':  {0x04, 0x04, 0x04, 0x04, 0x00, 0x00, 0x04},
+	'%':  {0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03},
+	'\'': {0x0C, 0x04, 0x08, 0x00, 0x00, 0x00, 0x00},
+	'(':  {0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02},
+	')':  {0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08},
+	'*':  {0x00, 0x04, 0x15, 0x0E, 0x15, 0x04, 0x00},
+	'+':  {0x00, 0x04, 0x04, 0x1F, 0x04, 0x04, 0x00},
+	',':  {0x00, 0x00, 0x00, 0x00, 0x0C, 0x04, 0x08},
+	'-':  {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	'.':  {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	'/':  {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	'0':  {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1':  {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2':  {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3':  {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4':  {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5':  {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6':  {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7':  {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8':  {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9':  {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	':':  {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	'<':  {0x02, 0x04, 0x08, 0x10, 0x08, 0x04, 0x02},
+	'=':  {0x00, 0x00, 0x1F, 0x00, 0x1F, 0x00, 0x00},
+	'>':  {0x08, 0x04, 0x02, 0x01, 0x02, 0x04, 0x08},
+	'?':  {0x0E, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04},
+	'A':  {0x0E, 0x11, 0x11, 0x11, 0x1F, 0x11, 0x11},
+	'B':  {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C':  {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
+	'D':  {0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C},
+	'E':  {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
+	'F':  {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G':  {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
+	'H':  {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I':  {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'J':  {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K':  {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L':  {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M':  {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N':  {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O':  {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'P':  {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q':  {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R':  {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S':  {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E},
+	'T':  {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U':  {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'V':  {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W':  {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
+	'X':  {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y':  {0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04},
+	'Z':  {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+	'[':  {0x0E, 0x08, 0x08, 0x08, 0x08, 0x08, 0x0E},
+	']':  {0x0E, 0x02, 0x02, 0x02, 0x02, 0x02, 0x0E},
+	'^':  {0x04, 0x0A, 0x11, 0x00, 0x00, 0x00, 0x00},
+	'_':  {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1F},
+	'a':  {0x00, 0x00, 0x0E, 0x01, 0x0F, 0x11, 0x0F},
+	'b':  {0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x1E},
+	'c':  {0x00, 0x00, 0x0E, 0x10, 0x10, 0x11, 0x0E},
+	'd':  {0x01, 0x01, 0x0D, 0x13, 0x11, 0x11, 0x0F},
+	'e':  {0x00, 0x00, 0x0E, 0x11, 0x1F, 0x10, 0x0E},
+	'f':  {0x06, 0x09, 0x08, 0x1C, 0x08, 0x08, 0x08},
+	'g':  {0x00, 0x0F, 0x11, 0x11, 0x0F, 0x01, 0x0E},
+	'h':  {0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x11},
+	'i':  {0x04, 0x00, 0x0C, 0x04, 0x04, 0x04, 0x0E},
+	'j':  {0x02, 0x00, 0x06, 0x02, 0x02, 0x12, 0x0C},
+	'k':  {0x10, 0x10, 0x12, 0x14, 0x18, 0x14, 0x12},
+	'l':  {0x0C, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'm':  {0x00, 0x00, 0x1A, 0x15, 0x15, 0x11, 0x11},
+	'n':  {0x00, 0x00, 0x16, 0x19, 0x11, 0x11, 0x11},
+	'o':  {0x00, 0x00, 0x0E, 0x11, 0x11, 0x11, 0x0E},
+	'p':  {0x00, 0x00, 0x1E, 0x11, 0x1E, 0x10, 0x10},
+	'q':  {0x00, 0x00, 0x0D, 0x13, 0x0F, 0x01, 0x01},
+	'r':  {0x00, 0x00, 0x16, 0x19, 0x10, 0x10, 0x10},
+	's':  {0x00, 0x00, 0x0E, 0x10, 0x0E, 0x01, 0x1E},
+	't':  {0x08, 0x08, 0x1C, 0x08, 0x08, 0x09, 0x06},
+	'u':  {0x00, 0x00, 0x11, 0x11, 0x11, 0x13, 0x0D},
+	'v':  {0x00, 0x00, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'w':  {0x00, 0x00, 0x11, 0x11, 0x15, 0x15, 0x0A},
+	'x':  {0x00, 0x00, 0x11, 0x0A, 0x04, 0x0A, 0x11},
+	'y':  {0x00, 0x00, 0x11, 0x11, 0x0F, 0x01, 0x0E},
+	'z':  {0x00, 0x00, 0x1F, 0x02, 0x04, 0x08, 0x1F},
+	'°':  {0x0C, 0x12, 0x12, 0x0C, 0x00, 0x00, 0x00},
+	'²':  {0x0C, 0x02, 0x04, 0x0E, 0x00, 0x00, 0x00},
+	'³':  {0x0E, 0x02, 0x06, 0x02, 0x0E, 0x00, 0x00},
+	'µ':  {0x00, 0x00, 0x11, 0x11, 0x13, 0x1D, 0x10},
+}
+
+// legendTextWidth returns the width in pixels of a
+// line of text drawn with the legend font.
+func legendTextWidth(text string) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return n*legendCharWidth - (legendCharWidth - legendGlyphWidth)
+}
+
+// drawLegendText draws a line of text whose top-left corner
+// is at (x, y). Characters missing from the font are drawn
+// as question marks and pixels outside the image are skipped.
+func drawLegendText(canvas *image.RGBA, x int, y int, text string, colour color.RGBA) {
+	bounds := canvas.Bounds()
+	for _, c := range text {
+		glyph, found := legendGlyphs[c]
+		if !found {
+			glyph = legendGlyphs['?']
+		}
+		for row, bits := range glyph {
+			for col := 0; col < legendGlyphWidth; col++ {
+				if bits&(0x10>>uint(col)) == 0 {
+					continue
+				}
+				pt := image.Pt(x+col, y+row)
+				if pt.In(bounds) {
+					canvas.SetRGBA(pt.X, pt.Y, colour)
+				}
+			}
+		}
+		x += legendCharWidth
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestLegendRange(t *testing.T) {
+	tests := []struct {
+		offset, scale, clip float64
+		lo, hi              float64
+		ok                  bool
+	}{
+		{0, 0, 0, 0, 0, false},
+		{0, 0, 100, 0, 100, true},
+		{10, 0, 100, -10, 90, true},
+		{0, 2.54, 1000, 0, 100, true},
+		{-5, 1, 10, 5, 15, true},
+	}
+
+	for _, test := range tests {
+		lo, hi, ok := LegendRange(test.offset, test.scale, test.clip)
+		if ok != test.ok || lo != test.lo || hi != test.hi {
+			t.Errorf("LegendRange(%v, %v, %v): expected %v %v %v, got %v %v %v", test.offset, test.scale, test.clip, test.lo, test.hi, test.ok, lo, hi, ok)
+		}
+	}
+}
+
+func TestFormatLegendValue(t *testing.T) {
+	tests := map[float64]string{
+		0:         "0",
+		12.5:      "12.5",
+		1.23456:   "1.235",
+		-2500:     "-2500",
+		123456789: "1.23e+08",
+		0.0001:    "0.0001",
+	}
+	for val, expected := range tests {
+		if label := formatLegendValue(val); label != expected {
+			t.Errorf("formatLegendValue(%v): expected %v, got %v", val, expected, label)
+		}
+	}
+}
+
+func TestEncodeLegend(t *testing.T) {
+	palette := &Palette{Interpolate: true, Colours: []color.RGBA{{0, 0, 255, 255}, {255, 0, 0, 255}}}
+	params := &LegendParams{Width: 80,
+		Height:      200,
+		Orientation: LegendVertical,
+		Title:       "Rainfall",
+		Units:       "mm",
+		Palette:     palette,
+		Clip:        100,
+	}
+
+	out, err := EncodeLegend(params)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if img.Bounds().Dx() != 80 || img.Bounds().Dy() != 200 {
+		t.Fatalf("unexpected legend size: %v", img.Bounds())
+	}
+
+	// Low values at the bottom and high values at the top
+	bottom := color.RGBAModel.Convert(img.At(legendMargin+4, 200-legendMargin-legendGlyphHeight/2-3)).(color.RGBA)
+	top := color.RGBAModel.Convert(img.At(legendMargin+4, legendMargin+2*legendLineHeight+legendGlyphHeight/2+3)).(color.RGBA)
+	if bottom.B < 200 || bottom.R > 50 {
+		t.Errorf("expected blue at the bottom, got %v", bottom)
+	}
+	if top.R < 200 || top.B > 50 {
+		t.Errorf("expected red at the top, got %v", top)
+	}
+
+	params.Orientation = LegendHorizontal
+	params.Width, params.Height = 200, 60
+	if _, err := EncodeLegend(params); err != nil {
+		t.Errorf("%v", err)
+	}
+
+	params.Height = 5
+	if _, err := EncodeLegend(params); err == nil {
+		t.Errorf("expected a legend too small to fail")
+	}
+}
+
+func TestParseLegend(t *testing.T) {
+	layer := &Layer{LegendOrientation: " Horizontal "}
+	if err := parseLegend(layer); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if layer.LegendOrientation != LegendHorizontal || layer.LegendWidth != DefaultLegendWidth || layer.LegendHeight != DefaultLegendHeight {
+		t.Errorf("unexpected legend: %v %v %v", layer.LegendOrientation, layer.LegendWidth, layer.LegendHeight)
+	}
+
+	if err := parseLegend(&Layer{LegendOrientation: "diagonal"}); err == nil {
+		t.Errorf("expected unknown orientation to fail")
+	}
+	if err := parseLegend(&Layer{LegendTicks: []float64{1, 2}, LegendTickLabels: []string{"low"}}); err == nil {
+		t.Errorf("expected mismatched tick labels to fail")
+	}
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Defaults of the NetCDF files of WCS GetCoverage requests
+const DefaultNetCDFChunkSize = 512
+const DefaultNetCDFCompression = "deflate"
+const DefaultNetCDFLevel = 4
+
+// NetCDFCompressions lists the compression methods of NetCDF variables
+var NetCDFCompressions = []string{"deflate", "none"}
+
+// netCDFTypedAttrs lists the attributes of data variables
+// which must be of the data type of the variable.
+var netCDFTypedAttrs = map[string]struct{}{"valid_min": struct{}{},
+	"valid_max":     struct{}{},
+	"valid_range":   struct{}{},
+	"missing_value": struct{}{},
+}
+
+// NetCDFParams configures the CF NetCDF4 files returned
+// by WCS GetCoverage requests with format=NetCDF.
+// VariableAttrs are the attributes of the data
+// variables keyed by band expression name, e.g.
+// units, standard_name or valid_range.
+type NetCDFParams struct {
+	ChunkSize     int                               `json:"chunk_size"`
+	Compression   string                            `json:"compression"`
+	Level         int                               `json:"level"`
+	Shuffle       bool                              `json:"shuffle"`
+	GlobalAttrs   map[string]interface{}            `json:"global_attributes"`
+	VariableAttrs map[string]map[string]interface{} `json:"variable_attributes"`
+}
+
+// parseNetCDF checks the NetCDF parameters of a layer
+// and sets the defaults of the missing ones.
+func parseNetCDF(layer *Layer) error {
+	if layer.NetCDF == nil {
+		layer.NetCDF = &NetCDFParams{}
+	}
+	nc := layer.NetCDF
+
+	if nc.ChunkSize == 0 {
+		nc.ChunkSize = DefaultNetCDFChunkSize
+	}
+	if nc.ChunkSize < 16 || nc.ChunkSize > 8192 {
+		return fmt.Errorf("NetCDF chunk size must be between 16 and 8192: %v", nc.ChunkSize)
+	}
+
+	nc.Compression = strings.ToLower(strings.TrimSpace(nc.Compression))
+	if len(nc.Compression) == 0 {
+		nc.Compression = DefaultNetCDFCompression
+	}
+
+	switch nc.Compression {
+	case "deflate":
+		if nc.Level == 0 {
+			nc.Level = DefaultNetCDFLevel
+		}
+		if nc.Level < 1 || nc.Level > 9 {
+			return fmt.Errorf("NetCDF deflate level must be between 1 and 9: %v", nc.Level)
+		}
+	case "none":
+		if nc.Level != 0 || nc.Shuffle {
+			return fmt.Errorf("NetCDF variables without compression have no level or shuffle")
+		}
+	default:
+		return fmt.Errorf("NetCDF compression must be one of %s", strings.Join(NetCDFCompressions, ", "))
+	}
+
+	if err := checkNetCDFAttrs(nc.GlobalAttrs); err != nil {
+		return fmt.Errorf("NetCDF global attributes: %v", err)
+	}
+	for varName, attrs := range nc.VariableAttrs {
+		if err := checkNetCDFAttrs(attrs); err != nil {
+			return fmt.Errorf("NetCDF attributes of %s: %v", varName, err)
+		}
+	}
+	return nil
+}
+
+// checkNetCDFAttrs checks that the attribute values are
+// strings, numbers or arrays of numbers. The arrays are
+// converted into float64 slices.
+func checkNetCDFAttrs(attrs map[string]interface{}) error {
+	for name, val := range attrs {
+		if len(strings.TrimSpace(name)) == 0 {
+			return fmt.Errorf("empty attribute name")
+		}
+		if name == "_FillValue" {
+			return fmt.Errorf("_FillValue is set from the nodata of the bands")
+		}
+
+		switch v := val.(type) {
+		case string, float64:
+		case []float64:
+			if len(v) == 0 {
+				return fmt.Errorf("%s is an empty array", name)
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				return fmt.Errorf("%s is an empty array", name)
+			}
+			nums := make([]float64, len(v))
+			for i, iv := range v {
+				num, ok := iv.(float64)
+				if !ok {
+					return fmt.Errorf("%s must be an array of numbers", name)
+				}
+				nums[i] = num
+			}
+			attrs[name] = nums
+		default:
+			return fmt.Errorf("%s must be a string, number or array of numbers", name)
+		}
+	}
+	return nil
+}
+
+// NetCDFAttr is a NetCDF attribute of either text or
+// numeric values. Typed numeric attributes take the
+// data type of their variable.
+type NetCDFAttr struct {
+	Name    string
+	Text    *string
+	Values  []float64
+	IsTyped bool
+}
+
+// NetCDFAttrs merges attribute maps, the latter ones overriding
+// the former, into attributes sorted by name.
+func NetCDFAttrs(attrMaps ...map[string]interface{}) []*NetCDFAttr {
+	merged := make(map[string]interface{})
+	for _, attrs := range attrMaps {
+		for name, val := range attrs {
+			merged[name] = val
+		}
+	}
+
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ncAttrs := make([]*NetCDFAttr, 0, len(names))
+	for _, name := range names {
+		attr := &NetCDFAttr{Name: name}
+		switch v := merged[name].(type) {
+		case string:
+			attr.Text = &v
+		case float64:
+			attr.Values = []float64{v}
+		case []float64:
+			attr.Values = v
+		case int:
+			attr.Values = []float64{float64(v)}
+		default:
+			text := fmt.Sprintf("%v", v)
+			attr.Text = &text
+		}
+		_, attr.IsTyped = netCDFTypedAttrs[name]
+		ncAttrs = append(ncAttrs, attr)
+	}
+	return ncAttrs
+}
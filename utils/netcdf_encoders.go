@@ -0,0 +1,353 @@
+package utils
+
+// #include "gdal.h"
+// #include "ogr_srs_api.h"
+// #include "netcdf.h"
+// #cgo pkg-config: gdal netcdf
+import "C"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"unsafe"
+)
+
+// netCDFTypes maps GDAL data types to NetCDF4 types
+var netCDFTypes = map[C.GDALDataType]C.nc_type{C.GDT_Byte: C.NC_UBYTE,
+	C.GDT_UInt16:  C.NC_USHORT,
+	C.GDT_Int16:   C.NC_SHORT,
+	C.GDT_UInt32:  C.NC_UINT,
+	C.GDT_Int32:   C.NC_INT,
+	C.GDT_Float32: C.NC_FLOAT,
+	C.GDT_Float64: C.NC_DOUBLE,
+}
+
+func ncError(status C.int) error {
+	if status == C.NC_NOERR {
+		return nil
+	}
+	return fmt.Errorf("NetCDF error: %s", C.GoString(C.nc_strerror(status)))
+}
+
+// EncodeNetCDF copies the bands of a merged GeoTIFF into a CF NetCDF4
+// file in a new temp file. The bands of each band expression become
+// a single data variable of dimensions (axes..., y, x), e.g. time and
+// depth, with coordinate variables and a grid mapping.
+func EncodeNetCDF(tempDir string, srcFile string, bandNames []string, params *NetCDFParams, attrs map[string]interface{}) (string, error) {
+	if params == nil {
+		params = &NetCDFParams{ChunkSize: DefaultNetCDFChunkSize, Compression: DefaultNetCDFCompression, Level: DefaultNetCDFLevel}
+	}
+
+	layout, err := NewBandLayout(bandNames)
+	if err != nil {
+		return "", err
+	}
+
+	srcFileC := C.CString(srcFile)
+	defer C.free(unsafe.Pointer(srcFileC))
+
+	hSrcDS := C.GDALOpen(srcFileC, C.GA_ReadOnly)
+	if hSrcDS == nil {
+		return "", fmt.Errorf("Failed to open data file: %v", srcFile)
+	}
+	defer C.GDALClose(hSrcDS)
+
+	tempFileHandle, err := ioutil.TempFile(tempDir, "netcdf_")
+	if err != nil {
+		return "", fmt.Errorf("failed to create NetCDF temp file: %v", err)
+	}
+	tempFileHandle.Close()
+
+	tempFile := tempFileHandle.Name()
+	tempFileC := C.CString(tempFile)
+	defer C.free(unsafe.Pointer(tempFileC))
+
+	var ncid C.int
+	if err = ncError(C.nc_create(tempFileC, C.NC_NETCDF4|C.NC_CLOBBER, &ncid)); err != nil {
+		os.Remove(tempFile)
+		return "", err
+	}
+
+	err = writeNetCDF(ncid, hSrcDS, layout, params, attrs)
+	closeErr := ncError(C.nc_close(ncid))
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempFile)
+		return "", err
+	}
+
+	return tempFile, nil
+}
+
+func writeNetCDF(ncid C.int, hSrcDS C.GDALDatasetH, layout *BandLayout, params *NetCDFParams, attrs map[string]interface{}) error {
+	width := int(C.GDALGetRasterXSize(hSrcDS))
+	height := int(C.GDALGetRasterYSize(hSrcDS))
+	nBands := int(C.GDALGetRasterCount(hSrcDS))
+	if nBands != len(layout.Bands) {
+		return fmt.Errorf("band count mismatch: %d != %d", nBands, len(layout.Bands))
+	}
+
+	hBand := C.GDALGetRasterBand(hSrcDS, C.int(1))
+	gdalType := C.GDALGetRasterDataType(hBand)
+	ncType, found := netCDFTypes[gdalType]
+	if !found {
+		return fmt.Errorf("unsupported NetCDF data type: %v", int(gdalType))
+	}
+	dataSize := int(C.GDALGetDataTypeSizeBytes(gdalType))
+	noData := C.double(C.GDALGetRasterNoDataValue(hBand, nil))
+
+	geot := make([]float64, 6)
+	C.GDALGetGeoTransform(hSrcDS, (*C.double)(&geot[0]))
+
+	projWKT := C.GoString(C.GDALGetProjectionRef(hSrcDS))
+	projWKTC := C.CString(projWKT)
+	defer C.free(unsafe.Pointer(projWKTC))
+	hSRS := C.OSRNewSpatialReference(projWKTC)
+	defer C.OSRDestroySpatialReference(hSRS)
+	isGeographic := C.OSRIsGeographic(hSRS) != 0
+
+	if err := putNetCDFAttrs(ncid, C.NC_GLOBAL, ncType, NetCDFAttrs(attrs, params.GlobalAttrs)); err != nil {
+		return err
+	}
+
+	dimIDs := make([]C.int, 0, len(layout.Axes)+2)
+	for _, ax := range layout.Axes {
+		dimID, err := defNetCDFDim(ncid, ax.Name, len(ax.Values))
+		if err != nil {
+			return err
+		}
+		dimIDs = append(dimIDs, dimID)
+	}
+	yDimID, err := defNetCDFDim(ncid, "y", height)
+	if err != nil {
+		return err
+	}
+	xDimID, err := defNetCDFDim(ncid, "x", width)
+	if err != nil {
+		return err
+	}
+	dimIDs = append(dimIDs, yDimID, xDimID)
+
+	xs := make([]float64, width)
+	for i := range xs {
+		xs[i] = geot[0] + (float64(i)+0.5)*geot[1]
+	}
+	ys := make([]float64, height)
+	for i := range ys {
+		ys[i] = geot[3] + (float64(i)+0.5)*geot[5]
+	}
+
+	type coordVar struct {
+		varID  C.int
+		values []float64
+	}
+	var coords []*coordVar
+
+	for ia, ax := range layout.Axes {
+		axisAttrs := map[string]interface{}{"long_name": ax.Name}
+		if ax.Name == "time" {
+			axisAttrs = CFTimeAttrs()
+		}
+		varID, err := defNetCDFVar(ncid, ax.Name, C.NC_DOUBLE, dimIDs[ia:ia+1], axisAttrs)
+		if err != nil {
+			return err
+		}
+		coords = append(coords, &coordVar{varID: varID, values: ax.Values})
+	}
+
+	xAttrs, yAttrs := CFSpatialAttrs(isGeographic)
+	yVarID, err := defNetCDFVar(ncid, "y", C.NC_DOUBLE, []C.int{yDimID}, yAttrs)
+	if err != nil {
+		return err
+	}
+	xVarID, err := defNetCDFVar(ncid, "x", C.NC_DOUBLE, []C.int{xDimID}, xAttrs)
+	if err != nil {
+		return err
+	}
+	coords = append(coords, &coordVar{varID: yVarID, values: ys}, &coordVar{varID: xVarID, values: xs})
+
+	crsAttrs := map[string]interface{}{"crs_wkt": projWKT,
+		"spatial_ref":  projWKT,
+		"GeoTransform": fmt.Sprintf("%v %v %v %v %v %v", geot[0], geot[1], geot[2], geot[3], geot[4], geot[5]),
+	}
+	var cfAttrs map[string]interface{}
+	if isGeographic {
+		cfAttrs = map[string]interface{}{"grid_mapping_name": "latitude_longitude"}
+	} else {
+		projC := C.CString("PROJECTION")
+		defer C.free(unsafe.Pointer(projC))
+		projection := C.GoString(C.OSRGetAttrValue(hSRS, projC, 0))
+		cfAttrs = CFGridMappingAttrs(projection, func(name string) float64 {
+			nameC := C.CString(name)
+			defer C.free(unsafe.Pointer(nameC))
+			return float64(C.OSRGetNormProjParm(hSRS, nameC, 0, nil))
+		})
+	}
+
+	// The data variables only refer to the CRS variable if it
+	// is a complete CF grid mapping, projections unknown to CF
+	// are left to its crs_wkt.
+	gridMapping := ""
+	if cfAttrs != nil {
+		gridMapping = "spatial_ref"
+		for key, val := range cfAttrs {
+			crsAttrs[key] = val
+		}
+		crsAttrs["semi_major_axis"] = float64(C.OSRGetSemiMajor(hSRS, nil))
+		crsAttrs["inverse_flattening"] = float64(C.OSRGetInvFlattening(hSRS, nil))
+	}
+	if _, err = defNetCDFVar(ncid, "spatial_ref", C.NC_INT, nil, crsAttrs); err != nil {
+		return err
+	}
+
+	chunks := make([]C.size_t, len(dimIDs))
+	for i := range layout.Axes {
+		chunks[i] = 1
+	}
+	chunkWidth, chunkHeight := params.ChunkSize, params.ChunkSize
+	if chunkWidth > width {
+		chunkWidth = width
+	}
+	if chunkHeight > height {
+		chunkHeight = height
+	}
+	chunks[len(chunks)-2] = C.size_t(chunkHeight)
+	chunks[len(chunks)-1] = C.size_t(chunkWidth)
+
+	// _FillValue is the nodata of the bands in the variable type
+	fillValue := make([]byte, dataSize)
+	C.GDALCopyWords(unsafe.Pointer(&noData), C.GDT_Float64, 0, unsafe.Pointer(&fillValue[0]), gdalType, 0, 1)
+
+	varIDs := make([]C.int, len(layout.Variables))
+	for iv, varName := range layout.Variables {
+		varAttrs := map[string]interface{}{"long_name": layout.LongNames[iv]}
+		if len(gridMapping) > 0 {
+			varAttrs["grid_mapping"] = gridMapping
+		}
+		varID, err := defNetCDFVar(ncid, varName, ncType, dimIDs, varAttrs, params.VariableAttrs[layout.LongNames[iv]])
+		if err != nil {
+			return err
+		}
+		varIDs[iv] = varID
+
+		if err = ncError(C.nc_def_var_chunking(ncid, varID, C.NC_CHUNKED, &chunks[0])); err != nil {
+			return err
+		}
+		if params.Compression == "deflate" {
+			shuffle := C.int(0)
+			if params.Shuffle {
+				shuffle = 1
+			}
+			if err = ncError(C.nc_def_var_deflate(ncid, varID, shuffle, 1, C.int(params.Level))); err != nil {
+				return err
+			}
+		}
+		if err = ncError(C.nc_def_var_fill(ncid, varID, 0, unsafe.Pointer(&fillValue[0]))); err != nil {
+			return err
+		}
+	}
+
+	if err = ncError(C.nc_enddef(ncid)); err != nil {
+		return err
+	}
+
+	for _, coord := range coords {
+		if err = ncError(C.nc_put_var_double(ncid, coord.varID, (*C.double)(&coord.values[0]))); err != nil {
+			return err
+		}
+	}
+
+	start := make([]C.size_t, len(dimIDs))
+	count := make([]C.size_t, len(dimIDs))
+	for i := range layout.Axes {
+		count[i] = 1
+	}
+	count[len(count)-1] = C.size_t(width)
+
+	dataBuf := make([]byte, chunkHeight*width*dataSize)
+	for ib, band := range layout.Bands {
+		if band.Variable < 0 {
+			continue
+		}
+		for i, idx := range band.Index {
+			start[i] = C.size_t(idx)
+		}
+
+		hBand := C.GDALGetRasterBand(hSrcDS, C.int(ib+1))
+		for yOff := 0; yOff < height; yOff += chunkHeight {
+			ySize := chunkHeight
+			if yOff+ySize > height {
+				ySize = height - yOff
+			}
+
+			gerr := C.GDALRasterIO(hBand, C.GF_Read, 0, C.int(yOff), C.int(width), C.int(ySize), unsafe.Pointer(&dataBuf[0]), C.int(width), C.int(ySize), gdalType, 0, 0)
+			if gerr != 0 {
+				return fmt.Errorf("Error reading raster band: %d, yOff:%d", ib, yOff)
+			}
+
+			start[len(start)-2] = C.size_t(yOff)
+			count[len(count)-2] = C.size_t(ySize)
+			if err = ncError(C.nc_put_vara(ncid, varIDs[band.Variable], &start[0], &count[0], unsafe.Pointer(&dataBuf[0]))); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func defNetCDFDim(ncid C.int, name string, size int) (C.int, error) {
+	nameC := C.CString(name)
+	defer C.free(unsafe.Pointer(nameC))
+
+	var dimID C.int
+	err := ncError(C.nc_def_dim(ncid, nameC, C.size_t(size), &dimID))
+	return dimID, err
+}
+
+func defNetCDFVar(ncid C.int, name string, ncType C.nc_type, dimIDs []C.int, attrMaps ...map[string]interface{}) (C.int, error) {
+	nameC := C.CString(name)
+	defer C.free(unsafe.Pointer(nameC))
+
+	var dimIDsC *C.int
+	if len(dimIDs) > 0 {
+		dimIDsC = &dimIDs[0]
+	}
+
+	var varID C.int
+	if err := ncError(C.nc_def_var(ncid, nameC, ncType, C.int(len(dimIDs)), dimIDsC, &varID)); err != nil {
+		return varID, fmt.Errorf("variable %s: %v", name, err)
+	}
+	return varID, putNetCDFAttrs(ncid, varID, ncType, NetCDFAttrs(attrMaps...))
+}
+
+// putNetCDFAttrs writes the attributes of a variable where the
+// typed numeric attributes take the type of the variable and
+// the other numeric attributes are doubles.
+func putNetCDFAttrs(ncid C.int, varID C.int, varType C.nc_type, attrs []*NetCDFAttr) error {
+	for _, attr := range attrs {
+		nameC := C.CString(attr.Name)
+
+		var status C.int
+		if attr.Text != nil {
+			textC := C.CString(*attr.Text)
+			status = C.nc_put_att_text(ncid, varID, nameC, C.size_t(len(*attr.Text)), textC)
+			C.free(unsafe.Pointer(textC))
+		} else {
+			attrType := C.nc_type(C.NC_DOUBLE)
+			if attr.IsTyped {
+				attrType = varType
+			}
+			status = C.nc_put_att_double(ncid, varID, nameC, attrType, C.size_t(len(attr.Values)), (*C.double)(&attr.Values[0]))
+		}
+		C.free(unsafe.Pointer(nameC))
+
+		if err := ncError(status); err != nil {
+			return fmt.Errorf("attribute %s: %v", attr.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseNetCDF(t *testing.T) {
+	layer := &Layer{}
+	if err := parseNetCDF(layer); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if layer.NetCDF.ChunkSize != DefaultNetCDFChunkSize || layer.NetCDF.Compression != DefaultNetCDFCompression || layer.NetCDF.Level != DefaultNetCDFLevel {
+		t.Errorf("unexpected defaults: %v", *layer.NetCDF)
+	}
+
+	var nc NetCDFParams
+	err := json.Unmarshal([]byte(`{"variable_attributes": {"ndvi": {"units": "1", "valid_range": [-1, 1]}}}`), &nc)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err = parseNetCDF(&Layer{NetCDF: &nc}); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if vr, ok := nc.VariableAttrs["ndvi"]["valid_range"].([]float64); !ok || len(vr) != 2 || vr[0] != -1 {
+		t.Errorf("unexpected valid_range: %v", nc.VariableAttrs["ndvi"]["valid_range"])
+	}
+
+	invalid := []*NetCDFParams{
+		{ChunkSize: 8},
+		{Compression: "zstd"},
+		{Compression: "deflate", Level: 10},
+		{Compression: "none", Shuffle: true},
+		{GlobalAttrs: map[string]interface{}{"flags": []interface{}{"a"}}},
+		{VariableAttrs: map[string]map[string]interface{}{"ndvi": {"_FillValue": 0.0}}},
+		{VariableAttrs: map[string]map[string]interface{}{"ndvi": {"valid": true}}},
+	}
+	for _, nc := range invalid {
+		if err := parseNetCDF(&Layer{NetCDF: nc}); err == nil {
+			t.Errorf("expected an error: %v", *nc)
+		}
+	}
+}
+
+func TestNetCDFAttrs(t *testing.T) {
+	attrs := NetCDFAttrs(map[string]interface{}{"long_name": "ndvi", "grid_mapping": "spatial_ref"},
+		map[string]interface{}{"long_name": "NDVI", "valid_range": []float64{-1, 1}, "scale_factor": 0.01})
+
+	expected := []string{"grid_mapping", "long_name", "scale_factor", "valid_range"}
+	if len(attrs) != len(expected) {
+		t.Fatalf("unexpected attributes: %v", attrs)
+	}
+	for i, attr := range attrs {
+		if attr.Name != expected[i] {
+			t.Errorf("attribute %d: expected %s, got %s", i, expected[i], attr.Name)
+		}
+	}
+
+	if *attrs[1].Text != "NDVI" {
+		t.Errorf("long_name not overridden: %v", *attrs[1].Text)
+	}
+	if attrs[2].IsTyped || len(attrs[2].Values) != 1 || attrs[2].Values[0] != 0.01 {
+		t.Errorf("unexpected scale_factor: %v", *attrs[2])
+	}
+	if !attrs[3].IsTyped || len(attrs[3].Values) != 2 {
+		t.Errorf("unexpected valid_range: %v", *attrs[3])
+	}
+}
@@ -77,10 +77,56 @@ func (r *Float32Raster) GetNoData() float64 {
 	return r.NoData
 }
 
+type Int32Raster struct {
+	NameSpace     string
+	Data          []int32
+	Height, Width int
+	NoData        float64
+}
+
+func (r *Int32Raster) GetNoData() float64 {
+	return r.NoData
+}
+
+type UInt32Raster struct {
+	NameSpace     string
+	Data          []uint32
+	Height, Width int
+	NoData        float64
+}
+
+func (r *UInt32Raster) GetNoData() float64 {
+	return r.NoData
+}
+
+type Float64Raster struct {
+	NameSpace     string
+	Data          []float64
+	Height, Width int
+	NoData        float64
+}
+
+func (r *Float64Raster) GetNoData() float64 {
+	return r.NoData
+}
+
 const EmptyTileNS = "EmptyTile"
 
 func EncodePNG(br []*ByteRaster, palette *Palette) ([]byte, error) {
 	buf := new(bytes.Buffer)
+	canvas, err := RenderRGBA(br, palette)
+	if err != nil {
+		return buf.Bytes(), err
+	}
+
+	err = png.Encode(buf, canvas)
+
+	return buf.Bytes(), err
+}
+
+// RenderRGBA renders either a single band with a palette
+// or the red, green and blue bands as an image.
+func RenderRGBA(br []*ByteRaster, palette *Palette) (*image.RGBA, error) {
 	canvas := image.NewRGBA(image.Rect(0, 0, br[0].Width, br[0].Height))
 
 	switch len(br) {
@@ -88,7 +134,7 @@ func EncodePNG(br []*ByteRaster, palette *Palette) ([]byte, error) {
 		if palette != nil {
 			plt, err := GradientRGBAPalette(palette)
 			if err != nil {
-				return buf.Bytes(), err
+				return nil, err
 			}
 
 			for x := 0; x < br[0].Width; x++ {
@@ -118,7 +164,7 @@ func EncodePNG(br []*ByteRaster, palette *Palette) ([]byte, error) {
 		rasterB := br[2]
 
 		if rasterR == nil || rasterG == nil || rasterB == nil {
-			return []byte{}, fmt.Errorf("At least one of the bands is nil")
+			return nil, fmt.Errorf("At least one of the bands is nil")
 		}
 
 		var start int
@@ -133,12 +179,25 @@ func EncodePNG(br []*ByteRaster, palette *Palette) ([]byte, error) {
 		}
 
 	default:
-		return []byte{}, fmt.Errorf("Cannot encode other than 1 or 3 namespaces into a PNG: Received %d", len(br))
+		return nil, fmt.Errorf("Cannot encode other than 1 or 3 namespaces into a PNG: Received %d", len(br))
 	}
 
-	err := png.Encode(buf, canvas)
+	return canvas, nil
+}
 
-	return buf.Bytes(), err
+// promoteRasterType returns the raster type able to hold the
+// values of rasters of both types, i.e. the type itself if both
+// rasters share it or a floating point type otherwise.
+func promoteRasterType(rasterType string, rType string) string {
+	if rasterType == "" || rasterType == rType {
+		return rType
+	}
+	for _, t := range []string{rasterType, rType} {
+		if t == "Int32" || t == "UInt32" || t == "Float64" {
+			return "Float64"
+		}
+	}
+	return "Float32"
 }
 
 func ValidateRasterSlice(rs []Raster) (int, int, string, error) {
@@ -149,11 +208,7 @@ func ValidateRasterSlice(rs []Raster) (int, int, string, error) {
 	for _, r := range rs {
 		switch t := r.(type) {
 		case *SignedByteRaster:
-			if rasterType == "" {
-				rasterType = "SignedByte"
-			} else if rasterType != "SignedByte" {
-				err = fmt.Errorf("Mixed types")
-			}
+			rasterType = promoteRasterType(rasterType, "SignedByte")
 
 			if width == 0 {
 				width = t.Width
@@ -167,11 +222,7 @@ func ValidateRasterSlice(rs []Raster) (int, int, string, error) {
 				err = fmt.Errorf("Mixed height sizes")
 			}
 		case *ByteRaster:
-			if rasterType == "" {
-				rasterType = "Byte"
-			} else if rasterType != "Byte" {
-				err = fmt.Errorf("Mixed types")
-			}
+			rasterType = promoteRasterType(rasterType, "Byte")
 
 			if width == 0 {
 				width = t.Width
@@ -185,11 +236,7 @@ func ValidateRasterSlice(rs []Raster) (int, int, string, error) {
 				err = fmt.Errorf("Mixed height sizes")
 			}
 		case *Int16Raster:
-			if rasterType == "" {
-				rasterType = "Int16"
-			} else if rasterType != "Int16" {
-				err = fmt.Errorf("Mixed types")
-			}
+			rasterType = promoteRasterType(rasterType, "Int16")
 
 			if width == 0 {
 				width = t.Width
@@ -203,11 +250,7 @@ func ValidateRasterSlice(rs []Raster) (int, int, string, error) {
 				err = fmt.Errorf("Mixed height sizes")
 			}
 		case *UInt16Raster:
-			if rasterType == "" {
-				rasterType = "UInt16"
-			} else if rasterType != "UInt16" {
-				err = fmt.Errorf("Mixed types")
-			}
+			rasterType = promoteRasterType(rasterType, "UInt16")
 
 			if width == 0 {
 				width = t.Width
@@ -221,11 +264,49 @@ func ValidateRasterSlice(rs []Raster) (int, int, string, error) {
 				err = fmt.Errorf("Mixed height sizes")
 			}
 		case *Float32Raster:
-			if rasterType == "" {
-				rasterType = "Float32"
-			} else if rasterType != "Float32" {
-				err = fmt.Errorf("Mixed types")
+			rasterType = promoteRasterType(rasterType, "Float32")
+
+			if width == 0 {
+				width = t.Width
+			} else if width != t.Width {
+				err = fmt.Errorf("Mixed width sizes")
+			}
+
+			if height == 0 {
+				height = t.Height
+			} else if height != t.Height {
+				err = fmt.Errorf("Mixed height sizes")
+			}
+		case *Int32Raster:
+			rasterType = promoteRasterType(rasterType, "Int32")
+
+			if width == 0 {
+				width = t.Width
+			} else if width != t.Width {
+				err = fmt.Errorf("Mixed width sizes")
+			}
+
+			if height == 0 {
+				height = t.Height
+			} else if height != t.Height {
+				err = fmt.Errorf("Mixed height sizes")
+			}
+		case *UInt32Raster:
+			rasterType = promoteRasterType(rasterType, "UInt32")
+
+			if width == 0 {
+				width = t.Width
+			} else if width != t.Width {
+				err = fmt.Errorf("Mixed width sizes")
+			}
+
+			if height == 0 {
+				height = t.Height
+			} else if height != t.Height {
+				err = fmt.Errorf("Mixed height sizes")
 			}
+		case *Float64Raster:
+			rasterType = promoteRasterType(rasterType, "Float64")
 
 			if width == 0 {
 				width = t.Width
@@ -435,6 +516,51 @@ func EncodeGdal(hDstDS C.GDALDatasetH, rs []Raster, xOff int, yOff int) ([]strin
 
 			gerr = C.GDALRasterIO(hBand, C.GF_Write, C.int(xOff), C.int(yOff), C.int(t.Width), C.int(t.Height), unsafe.Pointer(&t.Data[0]), C.int(t.Width), C.int(t.Height), C.GDT_Float32, 0, 0)
 
+		case *Int32Raster:
+			bandNames[i] = t.NameSpace
+			if isEmptyTile(t.NameSpace) {
+				continue
+			}
+			C.GDALSetRasterNoDataValue(hBand, C.double(t.NoData))
+			varNameC := C.CString(t.NameSpace)
+			gerr = C.GDALSetMetadataItem(C.GDALMajorObjectH(hBand), resNameSpaceC, varNameC, nil)
+			C.free(unsafe.Pointer(varNameC))
+			if gerr != 0 {
+				break
+			}
+
+			gerr = C.GDALRasterIO(hBand, C.GF_Write, C.int(xOff), C.int(yOff), C.int(t.Width), C.int(t.Height), unsafe.Pointer(&t.Data[0]), C.int(t.Width), C.int(t.Height), C.GDT_Int32, 0, 0)
+
+		case *UInt32Raster:
+			bandNames[i] = t.NameSpace
+			if isEmptyTile(t.NameSpace) {
+				continue
+			}
+			C.GDALSetRasterNoDataValue(hBand, C.double(t.NoData))
+			varNameC := C.CString(t.NameSpace)
+			gerr = C.GDALSetMetadataItem(C.GDALMajorObjectH(hBand), resNameSpaceC, varNameC, nil)
+			C.free(unsafe.Pointer(varNameC))
+			if gerr != 0 {
+				break
+			}
+
+			gerr = C.GDALRasterIO(hBand, C.GF_Write, C.int(xOff), C.int(yOff), C.int(t.Width), C.int(t.Height), unsafe.Pointer(&t.Data[0]), C.int(t.Width), C.int(t.Height), C.GDT_UInt32, 0, 0)
+
+		case *Float64Raster:
+			bandNames[i] = t.NameSpace
+			if isEmptyTile(t.NameSpace) {
+				continue
+			}
+			C.GDALSetRasterNoDataValue(hBand, C.double(t.NoData))
+			varNameC := C.CString(t.NameSpace)
+			gerr = C.GDALSetMetadataItem(C.GDALMajorObjectH(hBand), resNameSpaceC, varNameC, nil)
+			C.free(unsafe.Pointer(varNameC))
+			if gerr != 0 {
+				break
+			}
+
+			gerr = C.GDALRasterIO(hBand, C.GF_Write, C.int(xOff), C.int(yOff), C.int(t.Width), C.int(t.Height), unsafe.Pointer(&t.Data[0]), C.int(t.Width), C.int(t.Height), C.GDT_Float64, 0, 0)
+
 		default:
 			C.GDALClose(hDstDS)
 			return []string{}, fmt.Errorf("Unsupported gdal data type")
@@ -522,6 +648,79 @@ func EncodeGdalMerge(ctx context.Context, hDstDS C.GDALDatasetH, format string,
 	return nil
 }
 
+// EncodeGdalCOG copies a GeoTIFF into a Cloud Optimized GeoTIFF
+// in a new temp file. The overviews are built in the source file
+// then copied along with the tiled and compressed full resolution
+// image by the GTiff driver, which works with GDAL versions
+// predating the COG driver.
+func EncodeGdalCOG(tempDir string, srcFile string, params *COGParams) (string, error) {
+	if params == nil {
+		params = &COGParams{Compression: DefaultCOGCompression, BlockSize: DefaultCOGBlockSize, OverviewResampling: DefaultCOGOverviewResampling}
+	}
+
+	srcFileC := C.CString(srcFile)
+	defer C.free(unsafe.Pointer(srcFileC))
+
+	hSrcDS := C.GDALOpen(srcFileC, C.GA_Update)
+	if hSrcDS == nil {
+		return "", fmt.Errorf("Failed to reopen existing dataset: %v", srcFile)
+	}
+	defer C.GDALClose(hSrcDS)
+
+	width := int(C.GDALGetRasterXSize(hSrcDS))
+	height := int(C.GDALGetRasterYSize(hSrcDS))
+	factors := COGOverviewFactors(params, width, height)
+	if len(factors) > 0 {
+		overviewList := make([]C.int, len(factors))
+		for i, factor := range factors {
+			overviewList[i] = C.int(factor)
+		}
+
+		resamplingC := C.CString(strings.ToUpper(params.OverviewResampling))
+		defer C.free(unsafe.Pointer(resamplingC))
+
+		gerr := C.GDALBuildOverviews(hSrcDS, resamplingC, C.int(len(overviewList)), &overviewList[0], 0, nil, nil, nil)
+		if gerr != 0 {
+			return "", fmt.Errorf("Error building overviews of %v", srcFile)
+		}
+	}
+
+	dataType := C.GDALGetRasterDataType(C.GDALGetRasterBand(hSrcDS, 1))
+	isFloat := dataType == C.GDT_Float32 || dataType == C.GDT_Float64
+
+	var driverOptions []*C.char
+	for _, opt := range COGCreationOptions(params, isFloat) {
+		driverOptions = append(driverOptions, C.CString(opt))
+	}
+	for _, opt := range driverOptions {
+		defer C.free(unsafe.Pointer(opt))
+	}
+	driverOptions = append(driverOptions, nil)
+
+	driverNameC := C.CString("GTiff")
+	defer C.free(unsafe.Pointer(driverNameC))
+	hDriver := C.GDALGetDriverByName(driverNameC)
+
+	tempFileHandle, err := ioutil.TempFile(tempDir, "cog_")
+	if err != nil {
+		return "", fmt.Errorf("failed to create COG temp file: %v", err)
+	}
+	tempFileHandle.Close()
+
+	tempFile := tempFileHandle.Name()
+	tempFileC := C.CString(tempFile)
+	defer C.free(unsafe.Pointer(tempFileC))
+
+	hDstDS := C.GDALCreateCopy(hDriver, tempFileC, hSrcDS, 0, &driverOptions[0], nil, nil)
+	if hDstDS == nil {
+		os.Remove(tempFile)
+		return "", fmt.Errorf("Error creating COG")
+	}
+	C.GDALClose(hDstDS)
+
+	return tempFile, nil
+}
+
 func EncodeGdalFlush(hDstDS C.GDALDatasetH) {
 	C.GDALFlushCache(hDstDS)
 }
@@ -565,6 +764,18 @@ func CheckEmptyTile(rs []Raster) (bool, error) {
 			if isEmptyTile(t.NameSpace) {
 				return true, nil
 			}
+		case *Int32Raster:
+			if isEmptyTile(t.NameSpace) {
+				return true, nil
+			}
+		case *UInt32Raster:
+			if isEmptyTile(t.NameSpace) {
+				return true, nil
+			}
+		case *Float64Raster:
+			if isEmptyTile(t.NameSpace) {
+				return true, nil
+			}
 		default:
 			return false, fmt.Errorf("Raster type not implemented")
 		}
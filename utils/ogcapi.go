@@ -0,0 +1,808 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const OGCAPICRS84 = "http://www.opengis.net/def/crs/OGC/1.3/CRS84"
+const OGCAPIGregorianTRS = "http://www.opengis.net/def/uom/ISO-8601/0/Gregorian"
+
+// OGCAPILink is a web link as used by
+// all the OGC API resources.
+type OGCAPILink struct {
+	Href      string `json:"href"`
+	Rel       string `json:"rel"`
+	Type      string `json:"type,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Templated bool   `json:"templated,omitempty"`
+}
+
+type OGCAPISpatialExtent struct {
+	Bbox [][]float64 `json:"bbox"`
+	Crs  string      `json:"crs"`
+}
+
+type OGCAPITemporalExtent struct {
+	Interval [][]*string `json:"interval"`
+	Trs      string      `json:"trs"`
+}
+
+type OGCAPIExtent struct {
+	Spatial  *OGCAPISpatialExtent  `json:"spatial,omitempty"`
+	Temporal *OGCAPITemporalExtent `json:"temporal,omitempty"`
+}
+
+// OGCAPICollection describes a layer
+// published as an OGC API collection.
+type OGCAPICollection struct {
+	ID          string        `json:"id"`
+	Title       string        `json:"title,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Extent      *OGCAPIExtent `json:"extent,omitempty"`
+	Crs         []string      `json:"crs,omitempty"`
+	Links       []OGCAPILink  `json:"links"`
+
+	DataQueries   map[string]*OGCAPIDataQuery `json:"data_queries,omitempty"`
+	OutputFormats []string                    `json:"output_formats,omitempty"`
+}
+
+type OGCAPIDataQuery struct {
+	Link OGCAPILink `json:"link"`
+}
+
+type OGCAPICollections struct {
+	Links       []OGCAPILink        `json:"links"`
+	Collections []*OGCAPICollection `json:"collections"`
+}
+
+// OGCAPIAxis is either a RegularAxis with bounds
+// or an IrregularAxis with explicit coordinates.
+type OGCAPIAxis struct {
+	Type       string   `json:"type"`
+	AxisLabel  string   `json:"axisLabel"`
+	LowerBound *float64 `json:"lowerBound,omitempty"`
+	UpperBound *float64 `json:"upperBound,omitempty"`
+	UomLabel   string   `json:"uomLabel,omitempty"`
+	Coordinate []string `json:"coordinate,omitempty"`
+}
+
+type OGCAPIGeneralGrid struct {
+	Type       string        `json:"type"`
+	SrsName    string        `json:"srsName"`
+	AxisLabels []string      `json:"axisLabels"`
+	Axis       []*OGCAPIAxis `json:"axis"`
+}
+
+type OGCAPIDomainSet struct {
+	Type        string             `json:"type"`
+	GeneralGrid *OGCAPIGeneralGrid `json:"generalGrid"`
+}
+
+type OGCAPIRangeField struct {
+	Type       string `json:"type"`
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Definition string `json:"definition,omitempty"`
+}
+
+type OGCAPIRangeType struct {
+	Type  string              `json:"type"`
+	Field []*OGCAPIRangeField `json:"field"`
+}
+
+// NewOGCAPICollection returns the collection description
+// of a layer. baseURL is the URL of the collection itself.
+// Links to the coverage and map resources are only added
+// if the layer is published through them.
+func NewOGCAPICollection(layer *Layer, baseURL string, hasCoverage bool, hasMap bool, hasEDR bool) *OGCAPICollection {
+	coll := &OGCAPICollection{ID: layer.Name,
+		Title:       layer.Title,
+		Description: layer.Abstract,
+		Crs:         []string{OGCAPICRS84},
+		Extent: &OGCAPIExtent{
+			Spatial: &OGCAPISpatialExtent{Bbox: [][]float64{ogcapiGeoBbox(layer)}, Crs: OGCAPICRS84},
+		},
+	}
+
+	var start, end *string
+	if len(layer.Dates) > 0 {
+		start = &layer.Dates[0]
+		end = &layer.Dates[len(layer.Dates)-1]
+	} else {
+		if len(strings.TrimSpace(layer.EffectiveStartDate)) > 0 {
+			start = &layer.EffectiveStartDate
+		}
+		if len(strings.TrimSpace(layer.EffectiveEndDate)) > 0 {
+			end = &layer.EffectiveEndDate
+		}
+	}
+	if start != nil || end != nil {
+		coll.Extent.Temporal = &OGCAPITemporalExtent{Interval: [][]*string{{start, end}}, Trs: OGCAPIGregorianTRS}
+	}
+
+	coll.Links = []OGCAPILink{{Href: baseURL, Rel: "self", Type: "application/json", Title: "This collection"}}
+	if hasCoverage {
+		coll.Links = append(coll.Links,
+			OGCAPILink{Href: baseURL + "/coverage?f=geotiff", Rel: "http://www.opengis.net/def/rel/ogc/1.0/coverage", Type: "image/tiff; application=geotiff", Title: "Coverage as GeoTIFF"},
+			OGCAPILink{Href: baseURL + "/coverage?f=netcdf", Rel: "http://www.opengis.net/def/rel/ogc/1.0/coverage", Type: "application/x-netcdf", Title: "Coverage as NetCDF"},
+			OGCAPILink{Href: baseURL + "/coverage/domainset", Rel: "http://www.opengis.net/def/rel/ogc/1.0/coverage-domainset", Type: "application/json", Title: "Coverage domain set"},
+			OGCAPILink{Href: baseURL + "/coverage/rangetype", Rel: "http://www.opengis.net/def/rel/ogc/1.0/coverage-rangetype", Type: "application/json", Title: "Coverage range type"})
+	}
+	if hasMap {
+		coll.Links = append(coll.Links,
+			OGCAPILink{Href: baseURL + "/map?f=png", Rel: "http://www.opengis.net/def/rel/ogc/1.0/map", Type: "image/png", Title: "Default map"},
+			OGCAPILink{Href: baseURL + "/map/tiles", Rel: "http://www.opengis.net/def/rel/ogc/1.0/tilesets-map", Type: "application/json", Title: "Map tilesets"})
+		for _, style := range layer.Styles {
+			if len(style.Visibility) == 0 {
+				continue
+			}
+			coll.Links = append(coll.Links, OGCAPILink{Href: baseURL + "/styles/" + style.Name + "/map?f=png", Rel: "http://www.opengis.net/def/rel/ogc/1.0/map", Type: "image/png", Title: fmt.Sprintf("Map styled as %s", style.Title)})
+		}
+	}
+	if hasEDR {
+		coll.DataQueries = make(map[string]*OGCAPIDataQuery)
+		for _, query := range []string{"position", "area", "cube", "trajectory"} {
+			link := OGCAPILink{Href: baseURL + "/" + query, Rel: "data", Type: CovJSONMediaType, Title: fmt.Sprintf("EDR %s query", query)}
+			coll.Links = append(coll.Links, link)
+			coll.DataQueries[query] = &OGCAPIDataQuery{Link: link}
+		}
+		coll.OutputFormats = []string{"CoverageJSON", "CSV"}
+	}
+
+	return coll
+}
+
+// ogcapiGeoBbox returns the WGS84 extent of a layer, i.e.
+// its default_geo_bbox or the whole globe if unset.
+func ogcapiGeoBbox(layer *Layer) []float64 {
+	if len(layer.DefaultGeoBbox) == 4 {
+		return append([]float64{}, layer.DefaultGeoBbox...)
+	}
+	return []float64{-180, -90, 180, 90}
+}
+
+// ogcapiLayerBbox returns the extent of a layer in crs, the
+// default bbox of requests without bbox. The whole globe is
+// the full extent of the projection in Web Mercator.
+func ogcapiLayerBbox(layer *Layer, crs string) ([]float64, error) {
+	if crs == "EPSG:3857" && len(layer.DefaultGeoBbox) != 4 {
+		return []float64{-WebMercatorHalfExtent, -WebMercatorHalfExtent, WebMercatorHalfExtent, WebMercatorHalfExtent}, nil
+	}
+
+	bbox, err := TransformBbox("EPSG:4326", crs, ogcapiGeoBbox(layer))
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform the layer extent to %s: %v", crs, err)
+	}
+	return bbox, nil
+}
+
+// NewOGCAPIDomainSet returns the domain set of the
+// coverage of a layer, i.e. the spatial axes plus
+// time and any additional axes of the layer.
+func NewOGCAPIDomainSet(layer *Layer) *OGCAPIDomainSet {
+	bbox := ogcapiGeoBbox(layer)
+	lonMin, lonMax := bbox[0], bbox[2]
+	latMin, latMax := bbox[1], bbox[3]
+
+	grid := &OGCAPIGeneralGrid{Type: "GeneralGridCoverage",
+		SrsName:    OGCAPICRS84,
+		AxisLabels: []string{"Lon", "Lat"},
+		Axis: []*OGCAPIAxis{
+			{Type: "RegularAxis", AxisLabel: "Lon", LowerBound: &lonMin, UpperBound: &lonMax, UomLabel: "deg"},
+			{Type: "RegularAxis", AxisLabel: "Lat", LowerBound: &latMin, UpperBound: &latMax, UomLabel: "deg"},
+		},
+	}
+
+	if len(layer.Dates) > 0 {
+		grid.AxisLabels = append(grid.AxisLabels, "time")
+		grid.Axis = append(grid.Axis, &OGCAPIAxis{Type: "IrregularAxis", AxisLabel: "time", Coordinate: layer.Dates})
+	}
+
+	for _, axis := range layer.AxesInfo {
+		grid.AxisLabels = append(grid.AxisLabels, axis.Name)
+		grid.Axis = append(grid.Axis, &OGCAPIAxis{Type: "IrregularAxis", AxisLabel: axis.Name, Coordinate: axis.Values})
+	}
+
+	return &OGCAPIDomainSet{Type: "DomainSet", GeneralGrid: grid}
+}
+
+// NewOGCAPIRangeType returns the range type of the
+// coverage of a layer. Each band expression of the
+// layer or of its default style is a field.
+func NewOGCAPIRangeType(layer *Layer) *OGCAPIRangeType {
+	rangeType := &OGCAPIRangeType{Type: "DataRecord"}
+
+	bandExpr := layer.RGBExpressions
+	if (bandExpr == nil || len(bandExpr.ExprNames) == 0) && len(layer.Styles) > 0 {
+		bandExpr = layer.Styles[0].RGBExpressions
+	}
+	if bandExpr == nil {
+		return rangeType
+	}
+
+	for _, name := range bandExpr.ExprNames {
+		rangeType.Field = append(rangeType.Field, &OGCAPIRangeField{Type: "Quantity", ID: name, Name: name})
+	}
+
+	return rangeType
+}
+
+// OGCAPICoverageToWCS translates the query parameters of an
+// OGC API Coverages request into the equivalent WCS 1.0.0
+// GetCoverage key-value pairs. bbox, bbox-crs, datetime,
+// subset, scale-size, properties and f are supported.
+func OGCAPICoverageToWCS(layer *Layer, params map[string][]string) (url.Values, error) {
+	kvp := url.Values{}
+	kvp.Set("service", "WCS")
+	kvp.Set("version", "1.0.0")
+	kvp.Set("request", "GetCoverage")
+	kvp.Set("coverage", layer.Name)
+
+	getParam := func(key string) (string, bool) {
+		val, found := params[key]
+		if !found || len(val) == 0 {
+			return "", false
+		}
+		return strings.TrimSpace(val[0]), true
+	}
+
+	crs := "EPSG:4326"
+	if bboxCRS, found := getParam("bbox-crs"); found {
+		var err error
+		crs, err = parseOGCAPICRS(bboxCRS)
+		if err != nil {
+			return kvp, err
+		}
+	}
+	kvp.Set("crs", crs)
+
+	bbox, err := ogcapiLayerBbox(layer, crs)
+	if err != nil {
+		return kvp, err
+	}
+	if bboxStr, found := getParam("bbox"); found {
+		parts := strings.Split(bboxStr, ",")
+		if len(parts) != 4 {
+			return kvp, fmt.Errorf("bbox must contain 4 numbers: %s", bboxStr)
+		}
+		for i, p := range parts {
+			val, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return kvp, fmt.Errorf("invalid bbox: %s", bboxStr)
+			}
+			bbox[i] = val
+		}
+	}
+
+	var timeSubset []string
+	if datetime, found := getParam("datetime"); found {
+		interval := strings.Split(datetime, "/")
+		if len(interval) > 2 {
+			return kvp, fmt.Errorf("invalid datetime: %s", datetime)
+		}
+		for _, t := range interval {
+			ts, err := parseOGCAPITime(t)
+			if err != nil {
+				return kvp, err
+			}
+			timeSubset = append(timeSubset, ts)
+		}
+	}
+
+	var axisSubsets []string
+	if subsets, found := params["subset"]; found {
+		for _, clause := range splitOGCAPIList(strings.Join(subsets, ",")) {
+			iOpen := strings.Index(clause, "(")
+			if iOpen < 1 || !strings.HasSuffix(clause, ")") {
+				return kvp, fmt.Errorf("invalid subset: %s", clause)
+			}
+			axisName := strings.TrimSpace(clause[:iOpen])
+			endpoints := splitOGCAPIInterval(clause[iOpen+1 : len(clause)-1])
+			if len(endpoints) > 2 || len(endpoints[0]) == 0 {
+				return kvp, fmt.Errorf("invalid subset: %s", clause)
+			}
+
+			switch strings.ToLower(axisName) {
+			case "lon", "long", "x", "e":
+				if len(endpoints) != 2 {
+					return kvp, fmt.Errorf("spatial subset must be a range: %s", clause)
+				}
+				if err := parseOGCAPIRange(endpoints, bbox, 0, 2); err != nil {
+					return kvp, err
+				}
+			case "lat", "y", "n":
+				if len(endpoints) != 2 {
+					return kvp, fmt.Errorf("spatial subset must be a range: %s", clause)
+				}
+				if err := parseOGCAPIRange(endpoints, bbox, 1, 3); err != nil {
+					return kvp, err
+				}
+			case "time", "t", "date":
+				if timeSubset != nil {
+					return kvp, fmt.Errorf("time is subset by both datetime and subset")
+				}
+				for _, t := range endpoints {
+					ts, err := parseOGCAPITime(t)
+					if err != nil {
+						return kvp, err
+					}
+					timeSubset = append(timeSubset, ts)
+				}
+			default:
+				if len(endpoints) == 1 {
+					kvp.Set("dim_"+axisName, endpoints[0])
+				} else {
+					for i, p := range endpoints {
+						if p == ".." {
+							endpoints[i] = "*"
+						}
+					}
+					axisSubsets = append(axisSubsets, fmt.Sprintf("%s(%s,%s)", axisName, endpoints[0], endpoints[1]))
+				}
+			}
+		}
+	}
+
+	if bbox[0] >= bbox[2] || bbox[1] >= bbox[3] {
+		return kvp, fmt.Errorf("empty bbox: %v", bbox)
+	}
+	kvp.Set("bbox", fmt.Sprintf("%f,%f,%f,%f", bbox[0], bbox[1], bbox[2], bbox[3]))
+
+	if len(timeSubset) == 1 && timeSubset[0] != "*" {
+		kvp.Set("time", timeSubset[0])
+	} else if len(timeSubset) == 2 {
+		axisSubsets = append(axisSubsets, fmt.Sprintf("time(%s,%s)", timeSubset[0], timeSubset[1]))
+	}
+	if len(axisSubsets) > 0 {
+		kvp.Set("subset", strings.Join(axisSubsets, ";"))
+	}
+
+	// A negative width or height lets GetCoverage compute
+	// the native resolution of the requested extent
+	width, height := "-1", "-1"
+	if scaleSize, found := getParam("scale-size"); found {
+		for _, clause := range splitOGCAPIList(scaleSize) {
+			iOpen := strings.Index(clause, "(")
+			if iOpen < 1 || !strings.HasSuffix(clause, ")") {
+				return kvp, fmt.Errorf("invalid scale-size: %s", clause)
+			}
+			size, err := strconv.Atoi(strings.TrimSpace(clause[iOpen+1 : len(clause)-1]))
+			if err != nil || size <= 0 {
+				return kvp, fmt.Errorf("invalid scale-size: %s", clause)
+			}
+			switch strings.ToLower(strings.TrimSpace(clause[:iOpen])) {
+			case "lon", "long", "x", "e":
+				width = strconv.Itoa(size)
+			case "lat", "y", "n":
+				height = strconv.Itoa(size)
+			default:
+				return kvp, fmt.Errorf("scale-size is only supported on the spatial axes: %s", clause)
+			}
+		}
+	}
+	kvp.Set("width", width)
+	kvp.Set("height", height)
+
+	// properties is either the name of a style or a
+	// list of band expressions
+	if properties, found := getParam("properties"); found && len(properties) > 0 {
+		isStyle := false
+		for _, style := range layer.Styles {
+			if style.Name == properties {
+				isStyle = true
+				break
+			}
+		}
+		if isStyle {
+			kvp.Set("styles", properties)
+		} else {
+			kvp.Set("rangesubset", strings.Join(splitOGCAPIList(properties), ";"))
+		}
+	}
+
+	format := "GeoTIFF"
+	if f, found := getParam("f"); found {
+		switch strings.ToLower(f) {
+		case "geotiff", "tif", "tiff", "image/tiff", "image/tiff; application=geotiff":
+			format = "GeoTIFF"
+		case "netcdf", "nc", "application/x-netcdf":
+			format = "NetCDF"
+		default:
+			return kvp, fmt.Errorf("unsupported format: %s", f)
+		}
+	}
+	kvp.Set("format", format)
+
+	return kvp, nil
+}
+
+// splitOGCAPIList splits a comma separated list
+// ignoring the commas inside brackets.
+func splitOGCAPIList(list string) []string {
+	var items []string
+	depth := 0
+	start := 0
+	for i := 0; i <= len(list); i++ {
+		if i < len(list) {
+			switch list[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+
+		item := strings.TrimSpace(list[start:i])
+		if len(item) > 0 {
+			items = append(items, item)
+		}
+		start = i + 1
+	}
+	return items
+}
+
+// splitOGCAPIInterval splits the low:high endpoints of a
+// subset. Timestamps containing colons must be quoted.
+func splitOGCAPIInterval(interval string) []string {
+	var endpoints []string
+	quoted := false
+	start := 0
+	for i := 0; i <= len(interval); i++ {
+		if i < len(interval) {
+			if interval[i] == '"' {
+				quoted = !quoted
+			}
+			if interval[i] != ':' || quoted {
+				continue
+			}
+		}
+		endpoints = append(endpoints, strings.Trim(strings.TrimSpace(interval[start:i]), `"`))
+		start = i + 1
+	}
+	return endpoints
+}
+
+func parseOGCAPIRange(endpoints []string, bbox []float64, iLow int, iHigh int) error {
+	for i, p := range endpoints {
+		if p == ".." || p == "*" {
+			continue
+		}
+		val, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return fmt.Errorf("invalid subset endpoint: %s", p)
+		}
+		if i == 0 {
+			bbox[iLow] = val
+		} else {
+			bbox[iHigh] = val
+		}
+	}
+	return nil
+}
+
+// parseOGCAPITime converts RFC 3339 timestamps or dates
+// to the ISO format used by the WCS parameters.
+// Open interval ends ".." are mapped to "*".
+func parseOGCAPITime(ts string) (string, error) {
+	ts = strings.TrimSpace(ts)
+	if ts == ".." || ts == "*" || len(ts) == 0 {
+		return "*", nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		t, err := time.Parse(layout, ts)
+		if err == nil {
+			return t.UTC().Format(ISOFormat), nil
+		}
+	}
+	return "", fmt.Errorf("invalid datetime: %s", ts)
+}
+
+// parseOGCAPICRS converts a CRS URI into the EPSG code
+// notation understood by the WCS parameters.
+func parseOGCAPICRS(crs string) (string, error) {
+	switch {
+	case crs == OGCAPICRS84 || strings.EqualFold(crs, "CRS84"):
+		return "EPSG:4326", nil
+	case strings.HasPrefix(crs, "http://www.opengis.net/def/crs/EPSG/0/"):
+		code := crs[len("http://www.opengis.net/def/crs/EPSG/0/"):]
+		if _, err := strconv.Atoi(code); err != nil {
+			return "", fmt.Errorf("invalid crs: %s", crs)
+		}
+		return "EPSG:" + code, nil
+	case strings.HasPrefix(strings.ToUpper(crs), "EPSG:"):
+		if _, err := strconv.Atoi(crs[len("EPSG:"):]); err != nil {
+			return "", fmt.Errorf("invalid crs: %s", crs)
+		}
+		return strings.ToUpper(crs), nil
+	default:
+		return "", fmt.Errorf("unsupported crs: %s", crs)
+	}
+}
+
+// OGCAPIConformance lists the conformance classes
+// implemented by the OGC API endpoints.
+var OGCAPIConformance = []string{
+	"http://www.opengis.net/spec/ogcapi-common-1/1.0/conf/core",
+	"http://www.opengis.net/spec/ogcapi-common-1/1.0/conf/landing-page",
+	"http://www.opengis.net/spec/ogcapi-common-1/1.0/conf/json",
+	"http://www.opengis.net/spec/ogcapi-common-2/1.0/conf/collections",
+	"http://www.opengis.net/spec/ogcapi-coverages-1/1.0/conf/core",
+	"http://www.opengis.net/spec/ogcapi-coverages-1/1.0/conf/subsetting",
+	"http://www.opengis.net/spec/ogcapi-coverages-1/1.0/conf/scaling",
+	"http://www.opengis.net/spec/ogcapi-coverages-1/1.0/conf/fieldselection",
+	"http://www.opengis.net/spec/ogcapi-coverages-1/1.0/conf/geotiff",
+	"http://www.opengis.net/spec/ogcapi-coverages-1/1.0/conf/netcdf",
+	"http://www.opengis.net/spec/ogcapi-maps-1/1.0/conf/core",
+	"http://www.opengis.net/spec/ogcapi-maps-1/1.0/conf/spatial-subsetting",
+	"http://www.opengis.net/spec/ogcapi-maps-1/1.0/conf/datetime",
+	"http://www.opengis.net/spec/ogcapi-maps-1/1.0/conf/scaling",
+	"http://www.opengis.net/spec/ogcapi-maps-1/1.0/conf/styled-map",
+	"http://www.opengis.net/spec/ogcapi-maps-1/1.0/conf/png",
+	"http://www.opengis.net/spec/ogcapi-tiles-1/1.0/conf/core",
+	"http://www.opengis.net/spec/ogcapi-tiles-1/1.0/conf/tileset",
+	"http://www.opengis.net/spec/ogcapi-tiles-1/1.0/conf/tilesets-list",
+	"http://www.opengis.net/spec/ogcapi-tiles-1/1.0/conf/geodata-tilesets",
+	"http://www.opengis.net/spec/ogcapi-tiles-1/1.0/conf/png",
+	"http://www.opengis.net/spec/tms/2.0/conf/json-tilematrixset",
+	"http://www.opengis.net/spec/ogcapi-edr-1/1.0/conf/core",
+	"http://www.opengis.net/spec/ogcapi-edr-1/1.0/conf/collections",
+	"http://www.opengis.net/spec/ogcapi-edr-1/1.0/conf/covjson",
+	"http://www.opengis.net/spec/ogcapi-processes-1/1.0/conf/core",
+	"http://www.opengis.net/spec/ogcapi-processes-1/1.0/conf/ogc-process-description",
+	"http://www.opengis.net/spec/ogcapi-processes-1/1.0/conf/json",
+	"http://www.opengis.net/spec/ogcapi-processes-1/1.0/conf/job-list",
+	"http://www.opengis.net/spec/ogcapi-processes-1/1.0/conf/dismiss",
+}
+
+type OGCAPILandingPage struct {
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Links       []OGCAPILink `json:"links"`
+}
+
+type OGCAPIConformanceDoc struct {
+	ConformsTo []string `json:"conformsTo"`
+}
+
+type OGCAPITileMatrix struct {
+	ID               string     `json:"id"`
+	ScaleDenominator float64    `json:"scaleDenominator"`
+	CellSize         float64    `json:"cellSize"`
+	CornerOfOrigin   string     `json:"cornerOfOrigin"`
+	PointOfOrigin    [2]float64 `json:"pointOfOrigin"`
+	TileWidth        int        `json:"tileWidth"`
+	TileHeight       int        `json:"tileHeight"`
+	MatrixWidth      int        `json:"matrixWidth"`
+	MatrixHeight     int        `json:"matrixHeight"`
+}
+
+// OGCAPITileMatrixSet is the JSON encoding of
+// a tile matrix set as per OGC TMS 2.0.
+type OGCAPITileMatrixSet struct {
+	ID                string              `json:"id"`
+	Title             string              `json:"title"`
+	URI               string              `json:"uri"`
+	Crs               string              `json:"crs"`
+	OrderedAxes       []string            `json:"orderedAxes"`
+	WellKnownScaleSet string              `json:"wellKnownScaleSet"`
+	TileMatrices      []*OGCAPITileMatrix `json:"tileMatrices"`
+	Links             []OGCAPILink        `json:"links,omitempty"`
+}
+
+type OGCAPITileMatrixSets struct {
+	TileMatrixSets []*OGCAPITileMatrixSet `json:"tileMatrixSets"`
+}
+
+// OGCAPITileSet describes the tiles of a collection
+// for a given tile matrix set.
+type OGCAPITileSet struct {
+	Title            string       `json:"title,omitempty"`
+	DataType         string       `json:"dataType"`
+	Crs              string       `json:"crs"`
+	TileMatrixSetURI string       `json:"tileMatrixSetURI"`
+	Links            []OGCAPILink `json:"links"`
+}
+
+type OGCAPITileSets struct {
+	Links    []OGCAPILink     `json:"links"`
+	TileSets []*OGCAPITileSet `json:"tilesets"`
+}
+
+// OGCAPITileMatrixSetID returns the identifier of a tile
+// matrix set in the OGC API registry, e.g. WebMercatorQuad
+// for the WMTS GoogleMapsCompatible well known scale set.
+func OGCAPITileMatrixSetID(tms *TileMatrixSet) string {
+	if tms.Identifier == "GoogleMapsCompatible" {
+		return "WebMercatorQuad"
+	}
+	return tms.Identifier
+}
+
+// NewOGCAPITileMatrixSet converts a tile matrix
+// set into its OGC TMS 2.0 JSON encoding.
+func NewOGCAPITileMatrixSet(tms *TileMatrixSet) *OGCAPITileMatrixSet {
+	id := OGCAPITileMatrixSetID(tms)
+	doc := &OGCAPITileMatrixSet{ID: id,
+		Title:             tms.Title,
+		URI:               "http://www.opengis.net/def/tilematrixset/OGC/1.0/" + id,
+		Crs:               tms.SupportedCRS,
+		OrderedAxes:       []string{"E", "N"},
+		WellKnownScaleSet: tms.WellKnownScaleSet,
+	}
+	if tms.SRS == "EPSG:4326" {
+		doc.Crs = OGCAPICRS84
+		doc.OrderedAxes = []string{"Lon", "Lat"}
+	} else if strings.HasPrefix(tms.SRS, "EPSG:") {
+		doc.Crs = "http://www.opengis.net/def/crs/EPSG/0/" + tms.SRS[len("EPSG:"):]
+	}
+
+	for _, tm := range tms.TileMatrices {
+		doc.TileMatrices = append(doc.TileMatrices, &OGCAPITileMatrix{ID: tm.Identifier,
+			ScaleDenominator: tm.ScaleDenominator,
+			CellSize:         tm.TileSpanX / float64(tm.TileWidth),
+			CornerOfOrigin:   "topLeft",
+			PointOfOrigin:    tm.TopLeftCorner,
+			TileWidth:        tm.TileWidth,
+			TileHeight:       tm.TileHeight,
+			MatrixWidth:      tm.MatrixWidth,
+			MatrixHeight:     tm.MatrixHeight,
+		})
+	}
+
+	return doc
+}
+
+// OGCAPIMapParams lists the OGC API Maps query parameters
+// consumed by OGCAPIMapToWMS. These are removed from the
+// query before the remaining WMS parameters, e.g. palette
+// or colorscalerange, are passed through.
+var OGCAPIMapParams = []string{"bbox", "bbox-crs", "crs", "datetime", "width", "height", "style", "f"}
+
+// OGCAPIMapToWMS translates the query parameters of an OGC API
+// Maps request into the equivalent WMS 1.1.1 GetMap key-value
+// pairs. style is the style in the request path, if any.
+func OGCAPIMapToWMS(layer *Layer, style string, params map[string][]string) (url.Values, error) {
+	kvp := url.Values{}
+	kvp.Set("service", "WMS")
+	kvp.Set("version", "1.1.1")
+	kvp.Set("request", "GetMap")
+	kvp.Set("layers", layer.Name)
+	kvp.Set("format", "image/png")
+
+	getParam := func(key string) (string, bool) {
+		val, found := params[key]
+		if !found || len(val) == 0 {
+			return "", false
+		}
+		return strings.TrimSpace(val[0]), true
+	}
+
+	if s, found := getParam("style"); found && len(style) == 0 {
+		style = s
+	}
+	if len(style) > 0 && strings.ToLower(style) != "default" {
+		kvp.Set("styles", style)
+	}
+
+	crs := "EPSG:4326"
+	if bboxCRS, found := getParam("bbox-crs"); found {
+		var err error
+		crs, err = parseOGCAPICRS(bboxCRS)
+		if err != nil {
+			return kvp, err
+		}
+	}
+	if outCRS, found := getParam("crs"); found {
+		c, err := parseOGCAPICRS(outCRS)
+		if err != nil {
+			return kvp, err
+		}
+		if _, hasBboxCRS := getParam("bbox-crs"); hasBboxCRS && c != crs {
+			return kvp, fmt.Errorf("crs and bbox-crs must be the same")
+		}
+		crs = c
+	}
+	kvp.Set("srs", crs)
+
+	bbox, err := ogcapiLayerBbox(layer, crs)
+	if err != nil {
+		return kvp, err
+	}
+
+	if bboxStr, found := getParam("bbox"); found {
+		parts := strings.Split(bboxStr, ",")
+		if len(parts) != 4 {
+			return kvp, fmt.Errorf("bbox must contain 4 numbers: %s", bboxStr)
+		}
+		for i, p := range parts {
+			val, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return kvp, fmt.Errorf("invalid bbox: %s", bboxStr)
+			}
+			bbox[i] = val
+		}
+	}
+	if bbox[0] >= bbox[2] || bbox[1] >= bbox[3] {
+		return kvp, fmt.Errorf("empty bbox: %v", bbox)
+	}
+	kvp.Set("bbox", fmt.Sprintf("%f,%f,%f,%f", bbox[0], bbox[1], bbox[2], bbox[3]))
+
+	// Only one of width and height is needed,
+	// the other follows the aspect ratio of bbox
+	aspect := (bbox[3] - bbox[1]) / (bbox[2] - bbox[0])
+	width, height := 0, 0
+	for _, dim := range []struct {
+		key string
+		val *int
+	}{{"width", &width}, {"height", &height}} {
+		if s, found := getParam(dim.key); found {
+			v, err := strconv.Atoi(s)
+			if err != nil || v <= 0 {
+				return kvp, fmt.Errorf("invalid %s: %s", dim.key, s)
+			}
+			*dim.val = v
+		}
+	}
+	if width == 0 && height == 0 {
+		width = 1024
+	}
+	if width == 0 {
+		width = int(float64(height)/aspect + 0.5)
+	}
+	if height == 0 {
+		height = int(float64(width)*aspect + 0.5)
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	kvp.Set("width", strconv.Itoa(width))
+	kvp.Set("height", strconv.Itoa(height))
+
+	if datetime, found := getParam("datetime"); found {
+		if strings.Contains(datetime, "/") {
+			return kvp, fmt.Errorf("datetime intervals are not supported for maps: %s", datetime)
+		}
+		ts, err := parseOGCAPITime(datetime)
+		if err != nil {
+			return kvp, err
+		}
+		if ts != "*" {
+			kvp.Set("time", ts)
+		}
+	}
+
+	if f, found := getParam("f"); found {
+		switch strings.ToLower(f) {
+		case "png", "image/png":
+		default:
+			return kvp, fmt.Errorf("unsupported format: %s", f)
+		}
+	}
+
+	for _, axis := range layer.AxesInfo {
+		name := strings.ToLower(axis.Name)
+		if val, found := getParam(name); found {
+			if _, hasDim := params["dim_"+name]; !hasDim {
+				kvp.Set("dim_"+name, val)
+			}
+		}
+	}
+
+	return kvp, nil
+}
@@ -0,0 +1,360 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OGCAPIProcessSummary describes a WPS process
+// published as an OGC API process.
+type OGCAPIProcessSummary struct {
+	ID                 string       `json:"id"`
+	Title              string       `json:"title,omitempty"`
+	Description        string       `json:"description,omitempty"`
+	Version            string       `json:"version"`
+	JobControlOptions  []string     `json:"jobControlOptions"`
+	OutputTransmission []string     `json:"outputTransmission"`
+	Links              []OGCAPILink `json:"links"`
+}
+
+type OGCAPIProcess struct {
+	OGCAPIProcessSummary
+	Inputs  map[string]*OGCAPIProcessInput  `json:"inputs"`
+	Outputs map[string]*OGCAPIProcessOutput `json:"outputs"`
+}
+
+type OGCAPIProcessList struct {
+	Processes []*OGCAPIProcessSummary `json:"processes"`
+	Links     []OGCAPILink            `json:"links"`
+}
+
+type OGCAPIProcessInput struct {
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	MinOccurs   int                    `json:"minOccurs"`
+	MaxOccurs   int                    `json:"maxOccurs"`
+	Schema      map[string]interface{} `json:"schema"`
+}
+
+type OGCAPIProcessOutput struct {
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema"`
+}
+
+// OGCAPIStatusInfo holds the status of an OGC API job.
+type OGCAPIStatusInfo struct {
+	ProcessID string       `json:"processID,omitempty"`
+	Type      string       `json:"type"`
+	JobID     string       `json:"jobID"`
+	Status    string       `json:"status"`
+	Message   string       `json:"message,omitempty"`
+	Created   string       `json:"created,omitempty"`
+	Started   string       `json:"started,omitempty"`
+	Finished  string       `json:"finished,omitempty"`
+	Progress  int          `json:"progress"`
+	Links     []OGCAPILink `json:"links,omitempty"`
+}
+
+type OGCAPIJobList struct {
+	Jobs  []*OGCAPIStatusInfo `json:"jobs"`
+	Links []OGCAPILink        `json:"links"`
+}
+
+// OGCAPIResult is the inline value of a process output.
+// JSON outputs are embedded as is, other outputs as strings.
+type OGCAPIResult struct {
+	Value     interface{} `json:"value"`
+	MediaType string      `json:"mediaType,omitempty"`
+}
+
+var ogcapiProcessDateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+// NewOGCAPIProcessSummary describes a process without
+// its inputs and outputs, as listed by /processes.
+func NewOGCAPIProcessSummary(process *Process, procURL string) *OGCAPIProcessSummary {
+	return &OGCAPIProcessSummary{ID: process.Identifier,
+		Title:              process.Title,
+		Description:        process.Abstract,
+		Version:            "1.0.0",
+		JobControlOptions:  []string{"sync-execute", "async-execute", "dismiss"},
+		OutputTransmission: []string{"value"},
+		Links: []OGCAPILink{
+			{Href: procURL, Rel: "self", Type: "application/json", Title: "Process description"},
+			{Href: procURL + "/execution", Rel: "http://www.opengis.net/def/rel/ogc/1.0/execute", Type: "application/json", Title: "Execute endpoint"},
+		},
+	}
+}
+
+// NewOGCAPIProcess derives the description of a process from
+// its literal and complex data as advertised by DescribeProcess.
+func NewOGCAPIProcess(process *Process, procURL string) *OGCAPIProcess {
+	doc := &OGCAPIProcess{OGCAPIProcessSummary: *NewOGCAPIProcessSummary(process, procURL),
+		Inputs:  make(map[string]*OGCAPIProcessInput),
+		Outputs: make(map[string]*OGCAPIProcessOutput),
+	}
+
+	for _, lit := range process.LiteralData {
+		schema := map[string]interface{}{"type": ogcapiSchemaType(lit.DataType)}
+		if len(lit.AllowedValues) > 0 {
+			schema["enum"] = lit.AllowedValues
+		}
+		doc.Inputs[lit.Identifier] = &OGCAPIProcessInput{Title: lit.Title,
+			Description: lit.Abstract,
+			MinOccurs:   lit.MinOccurs,
+			MaxOccurs:   1,
+			Schema:      schema,
+		}
+	}
+
+	for _, comp := range process.ComplexData {
+		schema := map[string]interface{}{"type": "string"}
+		if strings.Contains(comp.MimeType, "json") {
+			schema["type"] = "object"
+		}
+		if len(comp.MimeType) > 0 {
+			schema["contentMediaType"] = comp.MimeType
+		}
+		if len(comp.Schema) > 0 {
+			schema["contentSchema"] = comp.Schema
+		}
+		doc.Inputs[comp.Identifier] = &OGCAPIProcessInput{Title: comp.Title,
+			Description: comp.Abstract,
+			MinOccurs:   comp.MinOccurs,
+			MaxOccurs:   1,
+			Schema:      schema,
+		}
+	}
+
+	doc.Outputs["Result"] = &OGCAPIProcessOutput{Title: "Time Series Output",
+		Description: "Time series data for location.",
+		Schema: map[string]interface{}{"type": "object",
+			"contentMediaType": "application/vnd.terriajs.catalog-member+json",
+		},
+	}
+
+	return doc
+}
+
+func ogcapiSchemaType(dataType string) string {
+	dataType = strings.ToLower(dataType)
+	switch {
+	case strings.Contains(dataType, "float"), strings.Contains(dataType, "double"), strings.Contains(dataType, "decimal"):
+		return "number"
+	case strings.Contains(dataType, "int"):
+		return "integer"
+	case strings.Contains(dataType, "bool"):
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// OGCAPIExecuteToWPS translates the JSON body of an OGC API
+// execute request into WPS Execute parameters to be checked
+// by WPSParamsChecker. The geometry input can be a GeoJSON
+// FeatureCollection, Feature or Geometry.
+func OGCAPIExecuteToWPS(process *Process, body []byte) (map[string][]string, error) {
+	var req struct {
+		Inputs   map[string]json.RawMessage `json:"inputs"`
+		Response string                     `json:"response"`
+	}
+	err := json.Unmarshal(body, &req)
+	if err != nil {
+		return nil, fmt.Errorf("invalid execute request: %v", err)
+	}
+
+	params := map[string][]string{"service": {"WPS"},
+		"request":    {"Execute"},
+		"identifier": {process.Identifier},
+	}
+	if len(req.Response) > 0 {
+		params["response"] = []string{req.Response}
+	}
+
+	declared := make(map[string]bool)
+	for _, lit := range process.LiteralData {
+		declared[strings.ToLower(lit.Identifier)] = true
+	}
+	for _, comp := range process.ComplexData {
+		declared[strings.ToLower(comp.Identifier)] = true
+	}
+
+	provided := make(map[string]bool)
+	for id, raw := range req.Inputs {
+		inputID := strings.ToLower(strings.TrimSpace(id))
+		provided[inputID] = true
+
+		value, err := ogcapiInputValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("input %s: %v", id, err)
+		}
+
+		switch {
+		case inputID == "geometry":
+			featCol, err := ogcapiFeatureCollection(value)
+			if err != nil {
+				return nil, fmt.Errorf("input %s: %v", id, err)
+			}
+			params["geometry"] = []string{"geometry=" + string(featCol)}
+		case inputID == "start_datetime" || inputID == "end_datetime":
+			timeObj, err := ogcapiDateTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("input %s: %v", id, err)
+			}
+			params[inputID] = []string{timeObj}
+		case inputID == "geometry_id":
+			var geomID interface{}
+			json.Unmarshal(value, &geomID)
+			switch geomID := geomID.(type) {
+			case string:
+				params[inputID] = []string{geomID}
+			case float64:
+				params[inputID] = []string{strconv.FormatFloat(geomID, 'f', -1, 64)}
+			default:
+				return nil, fmt.Errorf("input %s must be a string", id)
+			}
+		case strings.HasSuffix(inputID, "_clip_lower") || strings.HasSuffix(inputID, "_clip_upper"):
+			var clip float64
+			err := json.Unmarshal(value, &clip)
+			if err != nil {
+				return nil, fmt.Errorf("input %s must be a number", id)
+			}
+			params[inputID] = []string{strconv.FormatFloat(clip, 'f', -1, 64)}
+		case !declared[inputID]:
+			return nil, fmt.Errorf("unknown input: %s", id)
+		}
+	}
+
+	for _, lit := range process.LiteralData {
+		if lit.MinOccurs > 0 && !provided[strings.ToLower(lit.Identifier)] {
+			return nil, fmt.Errorf("missing input: %s", lit.Identifier)
+		}
+	}
+	for _, comp := range process.ComplexData {
+		if comp.MinOccurs > 0 && !provided[strings.ToLower(comp.Identifier)] {
+			return nil, fmt.Errorf("missing input: %s", comp.Identifier)
+		}
+	}
+
+	return params, nil
+}
+
+// ogcapiInputValue unwraps qualified input values
+// such as {"value": ..., "mediaType": ...}
+func ogcapiInputValue(raw json.RawMessage) (json.RawMessage, error) {
+	var qualified struct {
+		Value json.RawMessage `json:"value"`
+		Href  string          `json:"href"`
+		Type  string          `json:"type"`
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		err := json.Unmarshal(raw, &qualified)
+		if err != nil {
+			return nil, err
+		}
+		if len(qualified.Href) > 0 {
+			return nil, fmt.Errorf("inputs by reference are not supported")
+		}
+		if len(qualified.Value) > 0 && len(qualified.Type) == 0 {
+			return qualified.Value, nil
+		}
+	}
+	return raw, nil
+}
+
+// ogcapiFeatureCollection normalises a GeoJSON
+// object into a compact FeatureCollection.
+func ogcapiFeatureCollection(value json.RawMessage) ([]byte, error) {
+	type feature struct {
+		Type       string          `json:"type"`
+		Geometry   json.RawMessage `json:"geometry"`
+		Properties json.RawMessage `json:"properties,omitempty"`
+	}
+	var obj struct {
+		Type       string          `json:"type"`
+		Features   []feature       `json:"features"`
+		Geometry   json.RawMessage `json:"geometry"`
+		Properties json.RawMessage `json:"properties"`
+	}
+	err := json.Unmarshal(value, &obj)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+
+	featCol := struct {
+		Type     string    `json:"type"`
+		Features []feature `json:"features"`
+	}{Type: "FeatureCollection"}
+
+	switch obj.Type {
+	case "FeatureCollection":
+		for _, feat := range obj.Features {
+			featCol.Features = append(featCol.Features, feature{Type: "Feature", Geometry: feat.Geometry, Properties: feat.Properties})
+		}
+	case "Feature":
+		featCol.Features = []feature{{Type: "Feature", Geometry: obj.Geometry, Properties: obj.Properties}}
+	case "Point", "MultiPoint", "LineString", "MultiLineString", "Polygon", "MultiPolygon":
+		var geom bytes.Buffer
+		err = json.Compact(&geom, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+		}
+		featCol.Features = []feature{{Type: "Feature", Geometry: geom.Bytes()}}
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON type: %s", obj.Type)
+	}
+
+	if len(featCol.Features) == 0 {
+		return nil, fmt.Errorf("no features found")
+	}
+	return json.Marshal(&featCol)
+}
+
+// ogcapiDateTime converts a date-time input into the
+// timestamp object expected by WPS Execute. Timestamp
+// objects are passed through.
+func ogcapiDateTime(value json.RawMessage) (string, error) {
+	var dateStr string
+	err := json.Unmarshal(value, &dateStr)
+	if err != nil {
+		var timeObj bytes.Buffer
+		if json.Compact(&timeObj, value) != nil {
+			return "", fmt.Errorf("invalid date-time")
+		}
+		return timeObj.String(), nil
+	}
+
+	dateStr = strings.TrimSpace(dateStr)
+	for _, layout := range ogcapiProcessDateLayouts {
+		t, err := time.Parse(layout, dateStr)
+		if err == nil {
+			return fmt.Sprintf(`{"properties":{"timestamp":{"date-time":"%s"}}}`, t.UTC().Format("2006-01-02T15:04")), nil
+		}
+	}
+	return "", fmt.Errorf("invalid date-time: %s", dateStr)
+}
+
+// NewOGCAPIResults converts the outputs of a WPS process
+// into the results document of an OGC API job. Outputs
+// sharing the same identifier are numbered.
+func NewOGCAPIResults(outputs []*WPSOutput) map[string]*OGCAPIResult {
+	results := make(map[string]*OGCAPIResult)
+	for _, out := range outputs {
+		id := out.Identifier
+		for i := 2; results[id] != nil; i++ {
+			id = fmt.Sprintf("%s_%d", out.Identifier, i)
+		}
+
+		res := &OGCAPIResult{Value: out.Value, MediaType: out.MimeType}
+		if strings.Contains(out.MimeType, "json") && json.Valid([]byte(out.Value)) {
+			res.Value = json.RawMessage(out.Value)
+		}
+		results[id] = res
+	}
+	return results
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+)
+
+func TestOGCAPIExecuteToWPS(t *testing.T) {
+	process := &Process{Identifier: "geometryDrill",
+		LiteralData: []LitData{{Identifier: "geometry_id"}},
+		ComplexData: []CompData{{Identifier: "geometry", MimeType: "application/vnd.geo+json", MinOccurs: 1}},
+	}
+
+	body := `{"inputs": {
+		"geometry": {"type": "Feature", "properties": {"name": "a=b;c"}, "geometry": {"type": "Point", "coordinates": [148.5, -35.2]}},
+		"start_datetime": "2019-01-01",
+		"end_datetime": {"value": "2019-06-30T12:30:00Z"},
+		"geometry_id": "site_1",
+		"chirps_clip_upper": 100
+	}}`
+
+	params, err := OGCAPIExecuteToWPS(process, []byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"identifier":        "geometryDrill",
+		"geometry":          `geometry={"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[148.5,-35.2]},"properties":{"name":"a=b;c"}}]}`,
+		"start_datetime":    `{"properties":{"timestamp":{"date-time":"2019-01-01T00:00"}}}`,
+		"end_datetime":      `{"properties":{"timestamp":{"date-time":"2019-06-30T12:30"}}}`,
+		"geometry_id":       "site_1",
+		"chirps_clip_upper": "100",
+	}
+	for key, val := range expected {
+		if len(params[key]) != 1 || params[key][0] != val {
+			t.Errorf("%s: expected %s, got %v", key, val, params[key])
+		}
+	}
+
+	remap := make(map[string]*regexp.Regexp)
+	for key, re := range WPSRegexpMap {
+		remap[key] = regexp.MustCompile(re)
+	}
+	wpsParams, err := WPSParamsChecker(params, remap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wpsParams.FeatCol.Features) != 1 || *wpsParams.StartDateTime != "2019-01-01T00:00:00.000Z" {
+		t.Errorf("unexpected WPS params: %+v", wpsParams)
+	}
+	if len(wpsParams.FeatProps.Features) != 1 || wpsParams.FeatProps.Features[0].Properties["name"] != "a=b;c" {
+		t.Errorf("unexpected feature properties: %+v", wpsParams.FeatProps)
+	}
+
+	for _, body := range []string{
+		`{"inputs": {}}`,
+		`{"inputs": {"geometry": {"type": "Circle"}}}`,
+		`{"inputs": {"geometry": {"type": "Point", "coordinates": [0, 0]}, "start_datetime": "yesterday"}}`,
+		`{"inputs": {"geometry": {"type": "Point", "coordinates": [0, 0]}, "colour": "red"}}`,
+		`{"inputs": {"geometry": {"href": "http://example.com/geom.json"}}}`,
+	} {
+		_, err := OGCAPIExecuteToWPS(process, []byte(body))
+		if err == nil {
+			t.Errorf("expected error for %s", body)
+		}
+	}
+}
+
+func TestOGCAPIResults(t *testing.T) {
+	outputs := []*WPSOutput{
+		{Identifier: "precipitation", MimeType: "application/vnd.terriajs.catalog-member+json", Value: `{"type":"csv"}`},
+		{Identifier: "precipitation", MimeType: "text/plain", Value: "1.5"},
+	}
+
+	out, err := json.Marshal(NewOGCAPIResults(outputs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"precipitation":{"value":{"type":"csv"},"mediaType":"application/vnd.terriajs.catalog-member+json"},"precipitation_2":{"value":"1.5","mediaType":"text/plain"}}`
+	if string(out) != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestOGCAPICoverageToWCS(t *testing.T) {
+	layer := &Layer{Name: "landsat", Styles: []Layer{{Name: "ndvi"}}}
+
+	params := map[string][]string{
+		"subset":     {`Lat(-35:-30),Lon(140:150),time("2020-01-01":"2020-02-01T00:00:00Z")`},
+		"scale-size": {"Lon(512),Lat(256)"},
+		"properties": {"ndvi"},
+		"f":          {"netcdf"},
+	}
+
+	kvp, err := OGCAPICoverageToWCS(layer, params)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected := map[string]string{
+		"request":  "GetCoverage",
+		"coverage": "landsat",
+		"crs":      "EPSG:4326",
+		"bbox":     "140.000000,-35.000000,150.000000,-30.000000",
+		"subset":   "time(2020-01-01T00:00:00.000Z,2020-02-01T00:00:00.000Z)",
+		"width":    "512",
+		"height":   "256",
+		"styles":   "ndvi",
+		"format":   "NetCDF",
+	}
+	for key, val := range expected {
+		if kvp.Get(key) != val {
+			t.Errorf("%s: expected %s, got %s", key, val, kvp.Get(key))
+		}
+	}
+
+	kvp, err = OGCAPICoverageToWCS(layer, map[string][]string{"datetime": {"2020-01-01T00:00:00Z"}, "properties": {"b1,b2"}})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if kvp.Get("time") != "2020-01-01T00:00:00.000Z" || kvp.Get("width") != "-1" || kvp.Get("rangesubset") != "b1;b2" {
+		t.Errorf("unexpected translation: %v", kvp)
+	}
+
+	// Requests without bbox span the extent of the layer
+	bboxLayer := &Layer{Name: "landsat", DefaultGeoBbox: []float64{110, -45, 155, -10}}
+	kvp, err = OGCAPICoverageToWCS(bboxLayer, map[string][]string{"subset": {"Lon(120:130)"}})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if kvp.Get("bbox") != "120.000000,-45.000000,130.000000,-10.000000" {
+		t.Errorf("unexpected bbox: %v", kvp.Get("bbox"))
+	}
+
+	domainSet := NewOGCAPIDomainSet(bboxLayer)
+	if lon := domainSet.GeneralGrid.Axis[0]; *lon.LowerBound != 110 || *lon.UpperBound != 155 {
+		t.Errorf("unexpected domain set: %v, %v", *lon.LowerBound, *lon.UpperBound)
+	}
+
+	badParams := []map[string][]string{
+		{"bbox": {"1,2,3"}},
+		{"subset": {"Lat(10:0)"}},
+		{"datetime": {"2020-01-01"}, "subset": {"time(2020-01-01:2020-01-02)"}},
+		{"f": {"png"}},
+	}
+	for _, p := range badParams {
+		if _, err := OGCAPICoverageToWCS(layer, p); err == nil {
+			t.Errorf("expected error for %v", p)
+		}
+	}
+}
+
+func TestOGCAPIMapToWMS(t *testing.T) {
+	layer := &Layer{Name: "landsat"}
+
+	kvp, err := OGCAPIMapToWMS(layer, "ndvi", map[string][]string{"bbox": {"100,-40,160,-10"}, "width": {"600"}, "datetime": {"2020-01-01"}})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected := map[string]string{
+		"request": "GetMap",
+		"layers":  "landsat",
+		"styles":  "ndvi",
+		"srs":     "EPSG:4326",
+		"width":   "600",
+		"height":  "300",
+		"time":    "2020-01-01T00:00:00.000Z",
+	}
+	for key, val := range expected {
+		if kvp.Get(key) != val {
+			t.Errorf("%s: expected %s, got %s", key, val, kvp.Get(key))
+		}
+	}
+
+	if _, err := OGCAPIMapToWMS(layer, "", map[string][]string{"datetime": {"2020-01-01/2020-02-01"}}); err == nil {
+		t.Errorf("expected error for datetime interval")
+	}
+}
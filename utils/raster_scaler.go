@@ -326,6 +326,185 @@ func scale(r Raster, params ScaleParams) (*ByteRaster, error) {
 		}
 		return out, nil
 
+	case *Int32Raster:
+		out := &ByteRaster{NameSpace: t.NameSpace, NoData: t.NoData, Data: make([]uint8, t.Height*t.Width), Width: t.Width, Height: t.Height}
+		noData := int32(t.NoData)
+		offset := int32(params.Offset)
+		clip := int32(params.Clip)
+
+		if params.Scale == 0.0 && params.Clip == 0.0 && params.Offset == 0.0 {
+			var minVal, maxVal float32
+			for i, value := range t.Data {
+				if value == noData {
+					continue
+				}
+
+				val := float32(value)
+				if i == 0 {
+					minVal = val
+					maxVal = val
+				} else {
+					if val < minVal {
+						minVal = val
+					}
+
+					if val > maxVal {
+						maxVal = val
+					}
+				}
+			}
+
+			if minVal == maxVal {
+				maxVal += 0.1
+			}
+
+			scale = 254.0 / (maxVal - minVal)
+			dfOffset := -minVal
+
+			offset = int32(dfOffset)
+			clip = int32(maxVal + dfOffset)
+		}
+
+		for i, value := range t.Data {
+			if value == noData {
+				out.Data[i] = 0xFF
+			} else {
+				value += offset
+				if value > clip {
+					value = clip
+				}
+				if value < 0 {
+					value = 0
+				}
+				out.Data[i] = uint8((float32(value) * scale) + 0.5)
+			}
+		}
+		return out, nil
+
+	case *UInt32Raster:
+		out := &ByteRaster{NameSpace: t.NameSpace, NoData: t.NoData, Data: make([]uint8, t.Height*t.Width), Width: t.Width, Height: t.Height}
+		noData := uint32(t.NoData)
+		offset := uint32(params.Offset)
+		clip := uint32(params.Clip)
+
+		if params.Scale == 0.0 && params.Clip == 0.0 && params.Offset == 0.0 {
+			var minVal, maxVal float32
+			for i, value := range t.Data {
+				if value == noData {
+					continue
+				}
+
+				val := float32(value)
+				if i == 0 {
+					minVal = val
+					maxVal = val
+				} else {
+					if val < minVal {
+						minVal = val
+					}
+
+					if val > maxVal {
+						maxVal = val
+					}
+				}
+			}
+
+			if minVal == maxVal {
+				maxVal += 0.1
+			}
+
+			scale = 254.0 / (maxVal - minVal)
+			dfOffset := -minVal
+
+			offset = uint32(dfOffset)
+			clip = uint32(maxVal + dfOffset)
+		}
+
+		for i, value := range t.Data {
+			if value == noData {
+				out.Data[i] = 0xFF
+			} else {
+				value += offset
+				if value > clip {
+					value = clip
+				}
+				if value < 0 {
+					value = 0
+				}
+				out.Data[i] = uint8((float32(value) * scale) + 0.5)
+			}
+		}
+		return out, nil
+
+	case *Float64Raster:
+		out := &ByteRaster{NameSpace: t.NameSpace, NoData: t.NoData, Data: make([]uint8, t.Height*t.Width), Width: t.Width, Height: t.Height}
+		noData := t.NoData
+		offset := params.Offset
+		clip := params.Clip
+
+		if params.Scale == 0.0 && params.Clip == 0.0 && params.Offset == 0.0 {
+			var minVal, maxVal float64
+			for i, value := range t.Data {
+				if value == noData {
+					continue
+				}
+
+				if params.ColourScale > 0 {
+					v := normalise(value, params.ColourScale, t.NoData)
+					if v == t.NoData {
+						continue
+					}
+					value = v
+				}
+
+				if i == 0 {
+					minVal = value
+					maxVal = value
+				} else {
+					if value < minVal {
+						minVal = value
+					}
+
+					if value > maxVal {
+						maxVal = value
+					}
+				}
+			}
+
+			if minVal == maxVal {
+				maxVal += 0.1
+			}
+
+			scale = float32(254.0 / (maxVal - minVal))
+			offset = -minVal
+
+			clip = maxVal + offset
+		}
+
+		for i, value := range t.Data {
+			if value == noData {
+				out.Data[i] = 0xFF
+			} else {
+				if params.ColourScale > 0 {
+					v := normalise(value, params.ColourScale, t.NoData)
+					if v == t.NoData {
+						out.Data[i] = 0xFF
+						continue
+					}
+					value = v
+				}
+				value += offset
+				if value > clip {
+					value = clip
+				}
+				if value < 0.0 {
+					value = 0.0
+				}
+				out.Data[i] = uint8((value * float64(scale)) + 0.5)
+			}
+		}
+		return out, nil
+
 	default:
 		return &ByteRaster{}, fmt.Errorf("Raster type not implemented")
 	}
@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Terrain rendering modes of elevation layers
+const TerrainHillshade = "hillshade"
+const TerrainSlope = "slope"
+const TerrainAspect = "aspect"
+
+// TerrainNoData is the nodata value of terrain rasters
+const TerrainNoData = -9999.0
+
+// TerrainPadding is the number of pixels added around the
+// bbox of a request so that the pixels at the edges of a
+// tile have all their neighbours.
+const TerrainPadding = 1
+
+// TerrainConfig renders the elevation of a layer as relief.
+// Azimuth is the compass direction of the light source and
+// altitude its angle above the horizon, both in degrees,
+// 315 and 45 by default.
+// ZFactor converts the elevation to the horizontal units.
+// If Blend is set, the hillshade shades the elevation coloured
+// by the palette of the layer.
+type TerrainConfig struct {
+	Mode     string   `json:"mode"`
+	Azimuth  *float64 `json:"azimuth,omitempty"`
+	Altitude *float64 `json:"altitude,omitempty"`
+	ZFactor  float64  `json:"z_factor"`
+	Blend    bool     `json:"blend"`
+}
+
+// parseTerrain checks the terrain rendering of a layer
+// and sets the defaults of the light source.
+func parseTerrain(terrain *TerrainConfig) error {
+	terrain.Mode = strings.ToLower(strings.TrimSpace(terrain.Mode))
+	switch terrain.Mode {
+	case "":
+		terrain.Mode = TerrainHillshade
+	case TerrainHillshade, TerrainSlope, TerrainAspect:
+	default:
+		return fmt.Errorf("unknown terrain mode: %v", terrain.Mode)
+	}
+
+	if terrain.Blend && terrain.Mode != TerrainHillshade {
+		return fmt.Errorf("terrain blending requires the hillshade mode")
+	}
+
+	if terrain.Azimuth == nil {
+		azimuth := 315.0
+		terrain.Azimuth = &azimuth
+	}
+	if *terrain.Azimuth < 0 || *terrain.Azimuth > 360 {
+		return fmt.Errorf("terrain azimuth must be within [0, 360]: %v", *terrain.Azimuth)
+	}
+
+	if terrain.Altitude == nil {
+		altitude := 45.0
+		terrain.Altitude = &altitude
+	}
+	if *terrain.Altitude < 0 || *terrain.Altitude > 90 {
+		return fmt.Errorf("terrain altitude must be within [0, 90]: %v", *terrain.Altitude)
+	}
+
+	if terrain.ZFactor == 0 {
+		terrain.ZFactor = 1
+	}
+	return nil
+}
+
+// TerrainPixelSize returns the size in metres of the pixels
+// of a bbox. The pixel sizes of geographic and web mercator
+// bboxes are those at the latitude of the centre of the bbox.
+func TerrainPixelSize(crs string, bbox []float64, width int, height int) (float64, float64) {
+	xRes := math.Abs(bbox[2]-bbox[0]) / float64(width)
+	yRes := math.Abs(bbox[3]-bbox[1]) / float64(height)
+
+	switch strings.ToUpper(crs) {
+	case "EPSG:4326", "CRS:84":
+		lat := (bbox[1] + bbox[3]) / 2 * math.Pi / 180
+		return xRes * 111320 * math.Cos(lat), yRes * 110574
+	case "EPSG:3857", "EPSG:900913":
+		y := (bbox[1] + bbox[3]) / 2
+		lat := math.Atan(math.Sinh(y / 6378137))
+		return xRes * math.Cos(lat), yRes * math.Cos(lat)
+	default:
+		return xRes, yRes
+	}
+}
+
+// ComputeTerrain computes the terrain rendering of an elevation
+// raster padded by TerrainPadding pixels. The pixel sizes are
+// in metres. Both the terrain and the elevation are returned
+// without the padding. The hillshade is within [0, 255], the
+// slope and the aspect are in degrees, the aspect of flat
+// pixels being nodata.
+func ComputeTerrain(r Raster, xRes float64, yRes float64, terrain *TerrainConfig) (*Float32Raster, *Float32Raster, error) {
+	elev, ns, err := RasterFloat64(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var width, height int
+	switch t := r.(type) {
+	case *SignedByteRaster:
+		width, height = t.Width, t.Height
+	case *ByteRaster:
+		width, height = t.Width, t.Height
+	case *Int16Raster:
+		width, height = t.Width, t.Height
+	case *UInt16Raster:
+		width, height = t.Width, t.Height
+	case *Int32Raster:
+		width, height = t.Width, t.Height
+	case *UInt32Raster:
+		width, height = t.Width, t.Height
+	case *Float32Raster:
+		width, height = t.Width, t.Height
+	case *Float64Raster:
+		width, height = t.Width, t.Height
+	}
+
+	outWidth := width - 2*TerrainPadding
+	outHeight := height - 2*TerrainPadding
+	if outWidth <= 0 || outHeight <= 0 {
+		return nil, nil, fmt.Errorf("terrain raster too small: %dx%d", width, height)
+	}
+
+	out := &Float32Raster{NoData: TerrainNoData, Data: make([]float32, outWidth*outHeight), Width: outWidth, Height: outHeight, NameSpace: ns}
+	outElev := &Float32Raster{NoData: TerrainNoData, Data: make([]float32, outWidth*outHeight), Width: outWidth, Height: outHeight, NameSpace: ns}
+
+	zenith := (90 - *terrain.Altitude) * math.Pi / 180
+	azimuth := math.Mod(360-*terrain.Azimuth+90, 360) * math.Pi / 180
+
+	var win [9]float64
+	for iy := 0; iy < outHeight; iy++ {
+		for ix := 0; ix < outWidth; ix++ {
+			iOut := iy*outWidth + ix
+			centre := elev[(iy+TerrainPadding)*width+ix+TerrainPadding]
+			if math.IsNaN(centre) {
+				out.Data[iOut] = TerrainNoData
+				outElev.Data[iOut] = TerrainNoData
+				continue
+			}
+			outElev.Data[iOut] = float32(centre)
+
+			// Neighbours without data take the value of the centre
+			for wy := 0; wy < 3; wy++ {
+				for wx := 0; wx < 3; wx++ {
+					v := elev[(iy+wy)*width+ix+wx]
+					if math.IsNaN(v) {
+						v = centre
+					}
+					win[wy*3+wx] = v
+				}
+			}
+
+			dzdx := ((win[2] + 2*win[5] + win[8]) - (win[0] + 2*win[3] + win[6])) / (8 * xRes)
+			dzdy := ((win[6] + 2*win[7] + win[8]) - (win[0] + 2*win[1] + win[2])) / (8 * yRes)
+			slope := math.Atan(terrain.ZFactor * math.Hypot(dzdx, dzdy))
+
+			switch terrain.Mode {
+			case TerrainSlope:
+				out.Data[iOut] = float32(slope * 180 / math.Pi)
+			case TerrainAspect:
+				if dzdx == 0 && dzdy == 0 {
+					out.Data[iOut] = TerrainNoData
+					continue
+				}
+				aspect := math.Atan2(dzdy, -dzdx) * 180 / math.Pi
+				if aspect > 90 {
+					aspect = 450 - aspect
+				} else {
+					aspect = 90 - aspect
+				}
+				out.Data[iOut] = float32(aspect)
+			default:
+				aspect := math.Atan2(dzdy, -dzdx)
+				shade := math.Cos(zenith)*math.Cos(slope) + math.Sin(zenith)*math.Sin(slope)*math.Cos(azimuth-aspect)
+				out.Data[iOut] = float32(255 * math.Max(0, shade))
+			}
+		}
+	}
+
+	return out, outElev, nil
+}
+
+// BlendHillshade shades the scaled elevation coloured by the
+// palette with a hillshade within [0, 255]. The result is made
+// of the red, green and blue bands of the blended image.
+func BlendHillshade(elev *ByteRaster, shade *Float32Raster, palette *Palette) ([]*ByteRaster, error) {
+	if len(elev.Data) != len(shade.Data) {
+		return nil, fmt.Errorf("hillshade and elevation sizes differ")
+	}
+
+	plt, err := GradientRGBAPalette(palette)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*ByteRaster, 3)
+	for i := range out {
+		out[i] = &ByteRaster{NoData: 0xFF, Data: make([]uint8, len(elev.Data)), Width: elev.Width, Height: elev.Height, NameSpace: elev.NameSpace}
+	}
+
+	for i, val := range elev.Data {
+		if val == 0xFF || shade.Data[i] == TerrainNoData {
+			for _, band := range out {
+				band.Data[i] = 0xFF
+			}
+			continue
+		}
+
+		colour := [3]uint8{val, val, val}
+		if plt != nil {
+			colour = [3]uint8{plt[val].R, plt[val].G, plt[val].B}
+		}
+
+		// The blended values are capped to 254 as 0xFF
+		// across all the bands is nodata
+		factor := float64(shade.Data[i]) / 255
+		for ib, band := range out {
+			band.Data[i] = uint8(math.Min(254, float64(colour[ib])*factor+0.5))
+		}
+	}
+	return out, nil
+}
+
+// RenderTerrain computes the terrain rendering of the raster of
+// a WMS GetMap request padded by TerrainPadding pixels. The bbox
+// and sizes are those of the request without the padding. The
+// rasters to scale are returned along with the hillshade to
+// shade them with if the terrain is blended. Empty tiles are
+// returned as such.
+func RenderTerrain(rs []Raster, terrain *TerrainConfig, crs string, bbox []float64, width int, height int) ([]Raster, *Float32Raster, error) {
+	if len(rs) == 1 {
+		if br, ok := rs[0].(*ByteRaster); ok && (br.Width == 0 || br.Height == 0 || isEmptyTile(br.NameSpace)) {
+			return []Raster{&ByteRaster{Data: make([]uint8, 0), NameSpace: EmptyTileNS}}, nil, nil
+		}
+	}
+	if len(rs) != 1 {
+		return nil, nil, fmt.Errorf("terrain rendering requires a single band, received %d", len(rs))
+	}
+
+	xRes, yRes := TerrainPixelSize(crs, bbox, width, height)
+	out, elev, err := ComputeTerrain(rs[0], xRes, yRes, terrain)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if terrain.Blend {
+		return []Raster{elev}, out, nil
+	}
+	return []Raster{out}, nil, nil
+}
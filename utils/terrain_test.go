@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+// newTerrainPlane returns a padded elevation raster
+// rising by dx per pixel eastward and dy southward.
+func newTerrainPlane(width int, height int, dx float32, dy float32) *Float32Raster {
+	r := &Float32Raster{NoData: -1, Data: make([]float32, width*height), Width: width, Height: height, NameSpace: "elevation"}
+	for iy := 0; iy < height; iy++ {
+		for ix := 0; ix < width; ix++ {
+			r.Data[iy*width+ix] = 100 + dx*float32(ix) + dy*float32(iy)
+		}
+	}
+	return r
+}
+
+func TestComputeTerrain(t *testing.T) {
+	plane := newTerrainPlane(5, 4, 10, 0)
+
+	terrain := &TerrainConfig{Mode: "Slope"}
+	if err := parseTerrain(terrain); err != nil {
+		t.Fatalf("%v", err)
+	}
+	slope, elev, err := ComputeTerrain(plane, 10, 10, terrain)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if slope.Width != 3 || slope.Height != 2 || elev.Width != 3 || elev.Height != 2 {
+		t.Fatalf("unexpected sizes: %dx%d", slope.Width, slope.Height)
+	}
+	if elev.Data[0] != 110 {
+		t.Errorf("expected elevation 110, got %v", elev.Data[0])
+	}
+	for _, v := range slope.Data {
+		if math.Abs(float64(v)-45) > 1e-4 {
+			t.Errorf("expected slope 45, got %v", v)
+		}
+	}
+
+	// A plane rising eastward faces west
+	terrain = &TerrainConfig{Mode: TerrainAspect}
+	parseTerrain(terrain)
+	aspect, _, _ := ComputeTerrain(plane, 10, 10, terrain)
+	if math.Abs(float64(aspect.Data[0])-270) > 1e-4 {
+		t.Errorf("expected aspect 270, got %v", aspect.Data[0])
+	}
+
+	flat := newTerrainPlane(3, 3, 0, 0)
+	aspect, _, _ = ComputeTerrain(flat, 10, 10, terrain)
+	if aspect.Data[0] != TerrainNoData {
+		t.Errorf("expected nodata aspect for flat terrain, got %v", aspect.Data[0])
+	}
+
+	// The default light source lights flat terrain by
+	// the sine of its altitude and slopes facing it more
+	terrain = &TerrainConfig{}
+	parseTerrain(terrain)
+	shade, _, _ := ComputeTerrain(flat, 10, 10, terrain)
+	if math.Abs(float64(shade.Data[0])-255*math.Sin(math.Pi/4)) > 1e-3 {
+		t.Errorf("unexpected hillshade of flat terrain: %v", shade.Data[0])
+	}
+	shadeWest, _, _ := ComputeTerrain(newTerrainPlane(3, 3, 2, 0), 10, 10, terrain)
+	shadeEast, _, _ := ComputeTerrain(newTerrainPlane(3, 3, -2, 0), 10, 10, terrain)
+	if shadeWest.Data[0] <= shade.Data[0] || shadeEast.Data[0] >= shade.Data[0] {
+		t.Errorf("unexpected hillshade of slopes: west %v, flat %v, east %v", shadeWest.Data[0], shade.Data[0], shadeEast.Data[0])
+	}
+
+	plane.Data[1*5+2] = -1
+	shade, elev, _ = ComputeTerrain(plane, 10, 10, terrain)
+	if shade.Data[1] != TerrainNoData || elev.Data[1] != TerrainNoData {
+		t.Errorf("expected nodata, got %v, %v", shade.Data[1], elev.Data[1])
+	}
+
+	// A light source at the horizon leaves flat terrain dark
+	altitude := 0.0
+	terrain = &TerrainConfig{Altitude: &altitude}
+	parseTerrain(terrain)
+	if *terrain.Altitude != 0 || *terrain.Azimuth != 315 {
+		t.Errorf("unexpected light source: %v, %v", *terrain.Azimuth, *terrain.Altitude)
+	}
+	shade, _, _ = ComputeTerrain(flat, 10, 10, terrain)
+	if math.Abs(float64(shade.Data[0])) > 1e-3 {
+		t.Errorf("unexpected hillshade at zero altitude: %v", shade.Data[0])
+	}
+
+	if err := parseTerrain(&TerrainConfig{Mode: "slope", Blend: true}); err == nil {
+		t.Errorf("expected error for blending slopes")
+	}
+}
+
+func TestBlendHillshade(t *testing.T) {
+	elev := &ByteRaster{NoData: 0xFF, Data: []uint8{0, 0xFF}, Width: 2, Height: 1}
+	shade := &Float32Raster{NoData: TerrainNoData, Data: []float32{127.5, 255}, Width: 2, Height: 1}
+	palette := &Palette{Colours: []color.RGBA{{200, 100, 50, 255}}}
+
+	out, err := BlendHillshade(elev, shade, palette)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if out[0].Data[0] != 100 || out[1].Data[0] != 50 || out[2].Data[0] != 25 {
+		t.Errorf("unexpected blended colour: %v, %v, %v", out[0].Data[0], out[1].Data[0], out[2].Data[0])
+	}
+	if out[0].Data[1] != 0xFF || out[1].Data[1] != 0xFF || out[2].Data[1] != 0xFF {
+		t.Errorf("expected nodata")
+	}
+}
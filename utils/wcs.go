@@ -14,22 +14,27 @@ import (
 // WCSParams contains the serialised version
 // of the parameters contained in a WCS request.
 type WCSParams struct {
-	Service        *string      `json:"service,omitempty"`
-	Version        *string      `json:"version,omitempty"`
-	Request        *string      `json:"request,omitempty"`
-	Coverages      []string     `json:"coverage,omitempty"`
-	CRS            *string      `json:"crs,omitempty"`
-	ReqCRS         *string      `json:"req_crs,omitempty"`
-	BBox           []float64    `json:"bbox,omitempty"`
-	Time           *time.Time   `json:"time,omitempty"`
-	Height         *int         `json:"height,omitempty"`
-	Width          *int         `json:"width,omitempty"`
-	Format         *string      `json:"format,omitempty"`
-	Styles         []string     `json:"styles,omitempty"`
-	Axes           []*AxisParam `json:"axes,omitempty"`
-	BandExpr       *BandExpressions
-	NoReprojection bool
-	AxisMapping    int
+	Service         *string      `json:"service,omitempty"`
+	Version         *string      `json:"version,omitempty"`
+	Request         *string      `json:"request,omitempty"`
+	Coverages       []string     `json:"coverage,omitempty"`
+	CRS             *string      `json:"crs,omitempty"`
+	ReqCRS          *string      `json:"req_crs,omitempty"`
+	BBox            []float64    `json:"bbox,omitempty"`
+	Time            *time.Time   `json:"time,omitempty"`
+	EndTime         *time.Time   `json:"end_time,omitempty"`
+	Agg             *string      `json:"agg,omitempty"`
+	Resampling      *string      `json:"resampling,omitempty"`
+	ContourInterval *float64     `json:"contour_interval,omitempty"`
+	ContourLevels   []float64    `json:"contour_levels,omitempty"`
+	Height          *int         `json:"height,omitempty"`
+	Width           *int         `json:"width,omitempty"`
+	Format          *string      `json:"format,omitempty"`
+	Styles          []string     `json:"styles,omitempty"`
+	Axes            []*AxisParam `json:"axes,omitempty"`
+	BandExpr        *BandExpressions
+	NoReprojection  bool
+	AxisMapping     int
 }
 
 // WCSRegexpMap maps WCS request parameters to
@@ -48,7 +53,8 @@ var WCSRegexpMap = map[string]string{"service": `^WCS$`,
 	"width":    `^[-+]?[0-9]+$`,
 	"height":   `^[-+]?[0-9]+$`,
 	"axis":     `^[A-Za-z_][A-Za-z0-9_]*$`,
-	"format":   `^(?i)(GeoTIFF|NetCDF|DAP4)$`}
+	"agg":      `^(?i)(mean|min|max|median|sum|count|stddev)$`,
+	"format":   `^(?i)(GeoTIFF|COG|NetCDF|Zarr|DAP4|GeoJSON|CoverageJSON|CSV)$`}
 
 func CompileWCSRegexMap() map[string]*regexp.Regexp {
 	REMap := make(map[string]*regexp.Regexp)
@@ -59,10 +65,15 @@ func CompileWCSRegexMap() map[string]*regexp.Regexp {
 	return REMap
 }
 
+// wcsInterpolations maps the interpolation methods
+// of the WCS 1.0.0 specification to GDAL resampling
+// algorithms. No interpolation is nearest neighbour.
+var wcsInterpolations = map[string]string{"none": "nearest", "nearest neighbor": "nearest", "nearest_neighbor": "nearest", "bicubic": "cubic"}
+
 // CheckWCSVersion checks if the requested
 // version of WCS is supported by the server
 func CheckWCSVersion(version string) bool {
-	return version == "1.0.0"
+	return version == "1.0.0" || version == "2.0.0" || version == WCS2Version
 }
 
 // WCSParamsChecker checks and marshals the content
@@ -119,11 +130,42 @@ func WCSParamsChecker(params map[string][]string, compREMap map[string]*regexp.R
 	}
 
 	if time, timeOK := params["time"]; timeOK {
-		if compREMap["time"].MatchString(time[0]) {
+		if strings.Contains(time[0], "/") {
+			startTime, endTime, err := parseTimeInterval(time[0], compREMap)
+			if err != nil {
+				return WCSParams{}, err
+			}
+			jsonFields = append(jsonFields, fmt.Sprintf(`"time":"%s"`, startTime), fmt.Sprintf(`"end_time":"%s"`, endTime))
+		} else if compREMap["time"].MatchString(time[0]) {
 			jsonFields = append(jsonFields, fmt.Sprintf(`"time":"%s"`, time[0]))
 		}
 	}
 
+	if agg, aggOK := params["agg"]; aggOK {
+		if !compREMap["agg"].MatchString(agg[0]) {
+			return WCSParams{}, fmt.Errorf("agg must be one of %s", strings.Join(TemporalAggregations, ", "))
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"agg":"%s"`, strings.ToLower(agg[0])))
+	}
+
+	if interpolation, interpolationOK := params["interpolation"]; interpolationOK {
+		method := strings.ToLower(strings.TrimSpace(interpolation[0]))
+		if alg, found := wcsInterpolations[method]; found {
+			method = alg
+		}
+		alg, err := CheckResampling(method)
+		if err != nil {
+			return WCSParams{}, fmt.Errorf("interpolation: %v", err)
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"resampling":"%s"`, alg))
+	}
+
+	contourFields, err := parseContourParams(params)
+	if err != nil {
+		return WCSParams{}, err
+	}
+	jsonFields = append(jsonFields, contourFields...)
+
 	if format, formatOK := params["format"]; formatOK {
 		if compREMap["format"].MatchString(format[0]) {
 			jsonFields = append(jsonFields, fmt.Sprintf(`"format":"%s"`, format[0]))
@@ -166,7 +208,7 @@ func WCSParamsChecker(params map[string][]string, compREMap map[string]*regexp.R
 	jsonFields = append(jsonFields, fmt.Sprintf(`"axes":[%s]`, strings.Join(axesInfo, ",")))
 	jsonParams := fmt.Sprintf("{%s}", strings.Join(jsonFields, ","))
 
-	err := json.Unmarshal([]byte(jsonParams), &wcsParams)
+	err = json.Unmarshal([]byte(jsonParams), &wcsParams)
 	if err != nil {
 		return wcsParams, err
 	}
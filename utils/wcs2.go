@@ -0,0 +1,679 @@
+package utils
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WCS2Version is the version of the WCS 2.0
+// KVP and XML requests served
+const WCS2Version = "2.0.1"
+
+// WCS2Namespace is the XML namespace of WCS 2.0 requests
+const WCS2Namespace = "http://www.opengis.net/wcs/2.0"
+
+// WCS2DefaultResolution is the resolution in degrees of the
+// grid of coverages without a default_geo_size.
+const WCS2DefaultResolution = 0.025
+
+// WCS2Interval is a trim of a spatial axis whose
+// unbounded ends are nil or a slice of the axis at Low.
+type WCS2Interval struct {
+	Low   *float64
+	High  *float64
+	Slice bool
+}
+
+// WCS2Params contains the parameters of a WCS 2.0 request. The
+// parameters shared with WCS 1.0.0 are mapped onto WCSParams
+// while those depending on the coverage are resolved later.
+type WCS2Params struct {
+	WCSParams
+	SubsettingCRS *string
+	XSubset       *WCS2Interval
+	YSubset       *WCS2Interval
+	ScaleWidth    *int
+	ScaleHeight   *int
+	RangeSubset   []string
+}
+
+// wcs2Formats maps the media types of WCS 2.0
+// to the formats of WCS 1.0.0.
+var wcs2Formats = map[string]string{"image/tiff": "GeoTIFF",
+	"image/geotiff":       "GeoTIFF",
+	"application/geotiff": "GeoTIFF",
+	"image/tiff;application=geotiff;profile=cloud-optimized": "COG",
+	"application/x-netcdf": "NetCDF",
+	"application/netcdf":   "NetCDF",
+	ZarrMediaType:          "Zarr",
+	"application/geo+json": "GeoJSON",
+	CovJSONMediaType:       "CoverageJSON",
+	"text/csv":             "CSV",
+}
+
+// wcs2Interpolations maps the interpolation methods of the
+// WCS 2.0 interpolation extension to GDAL resampling algorithms.
+var wcs2Interpolations = map[string]string{"nearest-neighbor": "nearest",
+	"nearest-neighbour": "nearest",
+	"linear":            "bilinear",
+	"cubic-spline":      "cubicspline",
+}
+
+// Names of the spatial and temporal axes of WCS 2.0 subsets
+var wcs2XAxes = map[string]struct{}{"x": {}, "long": {}, "lon": {}, "longitude": {}, "e": {}, "easting": {}}
+var wcs2YAxes = map[string]struct{}{"y": {}, "lat": {}, "latitude": {}, "n": {}, "northing": {}}
+var wcs2TimeAxes = map[string]struct{}{"time": {}, "t": {}, "ansi": {}, "date": {}}
+
+// IsWCS2Request returns true if the parameters of a WCS
+// request follow the WCS 2.0 KVP encoding.
+func IsWCS2Request(params map[string][]string) bool {
+	if version, found := params["version"]; found && len(version) > 0 {
+		return strings.HasPrefix(strings.TrimSpace(version[0]), "2.")
+	}
+	if versions, found := params["acceptversions"]; found && len(versions) > 0 {
+		return strings.HasPrefix(strings.TrimSpace(versions[0]), "2.")
+	}
+	_, found := params["coverageid"]
+	return found
+}
+
+// ParseCRSURI returns the EPSG code of a CRS given either
+// as an OGC URI or URN or as an EPSG code, e.g.
+// http://www.opengis.net/def/crs/EPSG/0/4326 returns EPSG:4326
+func ParseCRSURI(crs string) (string, error) {
+	crs = strings.TrimSpace(crs)
+	upper := strings.ToUpper(crs)
+	if strings.HasSuffix(upper, "CRS84") {
+		return "EPSG:4326", nil
+	}
+
+	var code string
+	switch {
+	case strings.HasPrefix(upper, "EPSG:"):
+		code = crs[len("EPSG:"):]
+	case strings.Contains(upper, "/EPSG/"):
+		code = crs[strings.LastIndex(crs, "/")+1:]
+	case strings.HasPrefix(upper, "URN:OGC:DEF:CRS:EPSG:"):
+		code = crs[strings.LastIndex(crs, ":")+1:]
+	}
+
+	if _, err := strconv.Atoi(code); err != nil {
+		return "", fmt.Errorf("unsupported CRS: %s", crs)
+	}
+	return "EPSG:" + code, nil
+}
+
+// parseWCS2Time parses the quoted or unquoted timestamps
+// of WCS 2.0 subsets.
+func parseWCS2Time(val string) (time.Time, error) {
+	val = strings.Trim(strings.TrimSpace(val), `"'`)
+	for _, layout := range []string{ISOFormat, time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		t, err := time.Parse(layout, val)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s", val)
+}
+
+// parseWCS2AxisValues splits a list of axis(value) or
+// axis(low:high) items such as those of scalesize and
+// scaleextent.
+func parseWCS2AxisValues(list string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		iOpen := strings.Index(item, "(")
+		if iOpen <= 0 || !strings.HasSuffix(item, ")") {
+			return nil, fmt.Errorf("invalid syntax: %s", item)
+		}
+		values[strings.TrimSpace(item[:iOpen])] = strings.TrimSpace(item[iOpen+1 : len(item)-1])
+	}
+	return values, nil
+}
+
+// splitWCS2Subset splits a subset of the form axis[,crs](low,high)
+// or axis[,crs](point) into its axis, CRS and values.
+func splitWCS2Subset(subset string) (string, string, []string, error) {
+	subset = strings.TrimSpace(subset)
+	iOpen := strings.Index(subset, "(")
+	if iOpen <= 0 || !strings.HasSuffix(subset, ")") {
+		return "", "", nil, fmt.Errorf("invalid subset syntax: %s", subset)
+	}
+
+	axis := strings.TrimSpace(subset[:iOpen])
+	var crs string
+	if iComma := strings.Index(axis, ","); iComma >= 0 {
+		crs = strings.TrimSpace(axis[iComma+1:])
+		axis = strings.TrimSpace(axis[:iComma])
+	}
+
+	var values []string
+	for _, val := range strings.Split(subset[iOpen+1:len(subset)-1], ",") {
+		values = append(values, strings.Trim(strings.TrimSpace(val), `"'`))
+	}
+	if len(values) > 2 || len(values[0]) == 0 || (len(values) == 2 && len(values[1]) == 0) {
+		return "", "", nil, fmt.Errorf("invalid subset syntax: %s", subset)
+	}
+	return axis, crs, values, nil
+}
+
+// WCS2ParamsChecker checks the parameters of a WCS 2.0 KVP
+// request and maps the ones shared with WCS 1.0.0 onto the
+// WCSParams of the request.
+func WCS2ParamsChecker(params map[string][]string, compREMap map[string]*regexp.Regexp) (WCS2Params, error) {
+	var wcs2Params WCS2Params
+
+	// GSKY extensions are parsed the same as in WCS 1.0.0
+	kvp := make(map[string][]string)
+	for _, key := range []string{"service", "request", "styles", "agg", "contour_interval", "contour_levels", "code", "code_format", "width", "height"} {
+		if val, found := params[key]; found {
+			kvp[key] = val
+		}
+	}
+	kvp["version"] = []string{WCS2Version}
+
+	var coverages []string
+	if ids, idsOK := params["coverageid"]; idsOK {
+		for _, id := range strings.Split(strings.Join(ids, ","), ",") {
+			id = strings.TrimSpace(id)
+			if !compREMap["coverage"].MatchString(id) {
+				return wcs2Params, fmt.Errorf("invalid coverageId: %s", id)
+			}
+			coverages = append(coverages, id)
+		}
+		if len(coverages) > 0 {
+			kvp["coverage"] = coverages[:1]
+		}
+	}
+
+	format := "GeoTIFF"
+	if formats, formatOK := params["format"]; formatOK {
+		format = strings.TrimSpace(formats[0])
+		if f, found := wcs2Formats[strings.ToLower(strings.Replace(format, " ", "", -1))]; found {
+			format = f
+		} else if !compREMap["format"].MatchString(format) {
+			return wcs2Params, fmt.Errorf("unsupported format: %s", format)
+		}
+	}
+	kvp["format"] = []string{format}
+
+	if interpolation, interpolationOK := params["interpolation"]; interpolationOK {
+		method := strings.TrimSpace(interpolation[0])
+		method = strings.ToLower(method[strings.LastIndex(method, "/")+1:])
+		if alg, found := wcs2Interpolations[method]; found {
+			method = alg
+		}
+		kvp["interpolation"] = []string{method}
+	}
+
+	subsettingCRS := "EPSG:4326"
+	if crs, crsOK := params["subsettingcrs"]; crsOK {
+		epsg, err := ParseCRSURI(crs[0])
+		if err != nil {
+			return wcs2Params, fmt.Errorf("subsettingCrs: %v", err)
+		}
+		subsettingCRS = epsg
+	}
+
+	outputCRS := "EPSG:4326"
+	if crs, crsOK := params["outputcrs"]; crsOK {
+		epsg, err := ParseCRSURI(crs[0])
+		if err != nil {
+			return wcs2Params, fmt.Errorf("outputCrs: %v", err)
+		}
+		outputCRS = epsg
+	}
+	kvp["crs"] = []string{outputCRS}
+
+	// Spatial trims are resolved against the extent of the coverage
+	// whereas temporal and extra axes become WCS 1.0.0 subsets
+	var clauses []string
+	foundAxes := make(map[string]struct{})
+	for _, subset := range params["subset"] {
+		axis, crs, values, err := splitWCS2Subset(subset)
+		if err != nil {
+			return wcs2Params, err
+		}
+		if !compREMap["axis"].MatchString(axis) {
+			return wcs2Params, fmt.Errorf("invalid axis name '%v' in subset: %v", axis, subset)
+		}
+
+		axisName := strings.ToLower(axis)
+		if _, found := wcs2XAxes[axisName]; found {
+			axisName = "x"
+		} else if _, found := wcs2YAxes[axisName]; found {
+			axisName = "y"
+		} else if _, found := wcs2TimeAxes[axisName]; found {
+			axisName = "time"
+		} else {
+			axisName = axis
+		}
+		if _, found := foundAxes[axisName]; found {
+			return wcs2Params, fmt.Errorf("axis '%v' subset more than once", axis)
+		}
+		foundAxes[axisName] = struct{}{}
+
+		switch axisName {
+		case "x", "y":
+			if len(crs) > 0 {
+				epsg, err := ParseCRSURI(crs)
+				if err != nil {
+					return wcs2Params, fmt.Errorf("subset %s: %v", subset, err)
+				}
+				if _, found := params["subsettingcrs"]; found && epsg != subsettingCRS {
+					return wcs2Params, fmt.Errorf("subset %s: CRS differs from subsettingCrs", subset)
+				}
+				subsettingCRS = epsg
+			}
+			if len(values) == 1 && values[0] == "*" {
+				return wcs2Params, fmt.Errorf("invalid spatial slice: %s", subset)
+			}
+
+			interval := &WCS2Interval{Slice: len(values) == 1}
+			for iv, val := range values {
+				if val == "*" {
+					continue
+				}
+				fVal, err := strconv.ParseFloat(val, 64)
+				if err != nil || math.IsNaN(fVal) || math.IsInf(fVal, 0) {
+					return wcs2Params, fmt.Errorf("invalid value '%v' in subset: %v", val, subset)
+				}
+				if iv == 0 {
+					interval.Low = &fVal
+				} else {
+					interval.High = &fVal
+				}
+			}
+			if interval.Low != nil && interval.High != nil && *interval.High <= *interval.Low {
+				return wcs2Params, fmt.Errorf("upper bound must be greater than lower bound: %v", subset)
+			}
+
+			if axisName == "x" {
+				wcs2Params.XSubset = interval
+			} else {
+				wcs2Params.YSubset = interval
+			}
+
+		case "time":
+			var endpoints []string
+			for _, val := range values {
+				if val == "*" {
+					endpoints = append(endpoints, val)
+					continue
+				}
+				t, err := parseWCS2Time(val)
+				if err != nil {
+					return wcs2Params, fmt.Errorf("subset %s: %v", subset, err)
+				}
+				endpoints = append(endpoints, t.Format(ISOFormat))
+			}
+
+			if len(endpoints) == 1 {
+				if endpoints[0] == "*" {
+					return wcs2Params, fmt.Errorf("invalid time slice: %s", subset)
+				}
+				kvp["time"] = endpoints
+			} else {
+				clauses = append(clauses, fmt.Sprintf("time(%s)", strings.Join(endpoints, ",")))
+			}
+
+		default:
+			for iv, val := range values {
+				if _, err := strconv.ParseFloat(val, 64); err != nil && val != "*" {
+					t, err := parseWCS2Time(val)
+					if err != nil {
+						return wcs2Params, fmt.Errorf("invalid value '%v' in subset: %v", val, subset)
+					}
+					values[iv] = t.Format(ISOFormat)
+				}
+			}
+			clauses = append(clauses, fmt.Sprintf("%s(%s)", axis, strings.Join(values, ",")))
+		}
+	}
+	if len(clauses) > 0 {
+		kvp["subset"] = []string{strings.Join(clauses, ";")}
+	}
+
+	for _, key := range []string{"scaleaxes", "scalefactor"} {
+		if _, found := params[key]; found {
+			return wcs2Params, fmt.Errorf("%s is not supported, use scalesize or scaleextent", key)
+		}
+	}
+
+	scaleSizes := make(map[string]int)
+	if scaleSize, scaleSizeOK := params["scalesize"]; scaleSizeOK {
+		values, err := parseWCS2AxisValues(scaleSize[0])
+		if err != nil {
+			return wcs2Params, fmt.Errorf("scalesize: %v", err)
+		}
+		for axis, val := range values {
+			size, err := strconv.Atoi(val)
+			if err != nil || size <= 0 {
+				return wcs2Params, fmt.Errorf("scalesize: invalid size of axis %s: %s", axis, val)
+			}
+			scaleSizes[axis] = size
+		}
+	}
+
+	if scaleExtent, scaleExtentOK := params["scaleextent"]; scaleExtentOK {
+		values, err := parseWCS2AxisValues(scaleExtent[0])
+		if err != nil {
+			return wcs2Params, fmt.Errorf("scaleextent: %v", err)
+		}
+		for axis, val := range values {
+			parts := strings.Split(val, ":")
+			if len(parts) != 2 {
+				return wcs2Params, fmt.Errorf("scaleextent: invalid extent of axis %s: %s", axis, val)
+			}
+			low, errLow := strconv.Atoi(strings.TrimSpace(parts[0]))
+			high, errHigh := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if errLow != nil || errHigh != nil || high < low {
+				return wcs2Params, fmt.Errorf("scaleextent: invalid extent of axis %s: %s", axis, val)
+			}
+			scaleSizes[axis] = high - low + 1
+		}
+	}
+
+	for axis, size := range scaleSizes {
+		size := size
+		axisName := strings.ToLower(axis)
+		if _, found := wcs2XAxes[axisName]; found || axisName == "i" {
+			if wcs2Params.XSubset != nil && wcs2Params.XSubset.Slice {
+				return wcs2Params, fmt.Errorf("sliced axis cannot be scaled: %s", axis)
+			}
+			wcs2Params.ScaleWidth = &size
+		} else if _, found := wcs2YAxes[axisName]; found || axisName == "j" {
+			if wcs2Params.YSubset != nil && wcs2Params.YSubset.Slice {
+				return wcs2Params, fmt.Errorf("sliced axis cannot be scaled: %s", axis)
+			}
+			wcs2Params.ScaleHeight = &size
+		} else {
+			return wcs2Params, fmt.Errorf("scaling is only supported on spatial axes: %s", axis)
+		}
+	}
+
+	if rangeSubsets, rangeSubsetsOK := params["rangesubset"]; rangeSubsetsOK {
+		for _, item := range strings.Split(strings.Join(rangeSubsets, ","), ",") {
+			item = strings.TrimSpace(item)
+			if len(item) > 0 {
+				wcs2Params.RangeSubset = append(wcs2Params.RangeSubset, item)
+			}
+		}
+	}
+
+	wcsParams, err := WCSParamsChecker(kvp, compREMap)
+	if err != nil {
+		return wcs2Params, err
+	}
+	if len(coverages) > 0 {
+		wcsParams.Coverages = coverages
+	}
+	wcs2Params.WCSParams = wcsParams
+	wcs2Params.SubsettingCRS = &subsettingCRS
+
+	return wcs2Params, nil
+}
+
+// ResolveWCS2RangeSubset returns the band expressions of the
+// components of a coverage selected by a WCS 2.0 range subset.
+// Intervals of components are given as start:end.
+func ResolveWCS2RangeSubset(rangeSubset []string, bandExpr *BandExpressions) ([]string, error) {
+	findComponent := func(name string) (int, error) {
+		for i, exprName := range bandExpr.ExprNames {
+			if exprName == name {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("unknown range component: %s", name)
+	}
+
+	var bands []string
+	for _, item := range rangeSubset {
+		parts := strings.Split(item, ":")
+		if len(parts) > 2 {
+			return nil, fmt.Errorf("invalid range interval: %s", item)
+		}
+
+		iStart, err := findComponent(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, err
+		}
+		iEnd := iStart
+		if len(parts) == 2 {
+			iEnd, err = findComponent(strings.TrimSpace(parts[1]))
+			if err != nil {
+				return nil, err
+			}
+			if iEnd < iStart {
+				return nil, fmt.Errorf("invalid range interval: %s", item)
+			}
+		}
+
+		for i := iStart; i <= iEnd; i++ {
+			bands = append(bands, bandExpr.ExprText[i])
+		}
+	}
+	return bands, nil
+}
+
+// WCS2Coverage is the payload describing a layer as a WCS 2.0
+// rectified grid coverage in EPSG:4326 in the GetCapabilities
+// and DescribeCoverage templates.
+type WCS2Coverage struct {
+	*Layer
+	ID       string
+	BBox     []float64
+	Width    int
+	Height   int
+	XRes     float64
+	YRes     float64
+	Origin   []float64
+	GridHigh []int
+	Bands    []string
+}
+
+// NewWCS2Coverage returns the coverage of a layer whose grid
+// spans its default_geo_bbox, the whole globe by default, with
+// its default_geo_size or WCS2DefaultResolution.
+func NewWCS2Coverage(layer *Layer) *WCS2Coverage {
+	cov := &WCS2Coverage{Layer: layer, BBox: []float64{-180, -90, 180, 90}}
+	if len(layer.DefaultGeoBbox) == 4 {
+		cov.BBox = layer.DefaultGeoBbox
+	}
+
+	if len(layer.DefaultGeoSize) == 2 && layer.DefaultGeoSize[0] > 0 && layer.DefaultGeoSize[1] > 0 {
+		cov.Height = layer.DefaultGeoSize[0]
+		cov.Width = layer.DefaultGeoSize[1]
+	} else {
+		cov.Width = int(math.Max(1, math.Round((cov.BBox[2]-cov.BBox[0])/WCS2DefaultResolution)))
+		cov.Height = int(math.Max(1, math.Round((cov.BBox[3]-cov.BBox[1])/WCS2DefaultResolution)))
+	}
+	cov.XRes = (cov.BBox[2] - cov.BBox[0]) / float64(cov.Width)
+	cov.YRes = (cov.BBox[3] - cov.BBox[1]) / float64(cov.Height)
+
+	// The origin is the centre of the top-left pixel
+	// in the latitude, longitude order of EPSG:4326
+	cov.Origin = []float64{cov.BBox[3] - cov.YRes/2, cov.BBox[0] + cov.XRes/2}
+	cov.GridHigh = []int{cov.Height - 1, cov.Width - 1}
+
+	cov.ID = regexp.MustCompile(`[^A-Za-z0-9._-]`).ReplaceAllString(layer.Name, "_")
+	if len(cov.ID) == 0 || !(cov.ID[0] == '_' || (cov.ID[0] >= 'A' && cov.ID[0] <= 'Z') || (cov.ID[0] >= 'a' && cov.ID[0] <= 'z')) {
+		cov.ID = "_" + cov.ID
+	}
+
+	if layer.RGBExpressions != nil {
+		cov.Bands = layer.RGBExpressions.ExprNames
+	} else {
+		cov.Bands = layer.RGBProducts
+	}
+	return cov
+}
+
+// WCS2Capabilities is the payload passed to
+// the WCS 2.0 GetCapabilities template.
+type WCS2Capabilities struct {
+	*Config
+	Coverages []*WCS2Coverage
+}
+
+// Elements of WCS 2.0 XML requests
+type wcs2DimensionTrim struct {
+	Dimension string
+	TrimLow   string
+	TrimHigh  string
+}
+
+type wcs2DimensionSlice struct {
+	Dimension  string
+	SlicePoint string
+}
+
+type wcs2TargetAxis struct {
+	Axis       string `xml:"axis"`
+	TargetSize string `xml:"targetSize"`
+	Low        string `xml:"low"`
+	High       string `xml:"high"`
+}
+
+type wcs2RangeItem struct {
+	RangeComponent string
+	StartComponent string `xml:"RangeInterval>startComponent"`
+	EndComponent   string `xml:"RangeInterval>endComponent"`
+}
+
+type wcs2Extension struct {
+	ScaleToSize   []wcs2TargetAxis `xml:"ScaleToSize>TargetAxisSize"`
+	ScaleToExtent []wcs2TargetAxis `xml:"ScaleToExtent>TargetAxisExtent"`
+	RangeItems    []wcs2RangeItem  `xml:"RangeSubset>RangeItem"`
+	SubsettingCRS string           `xml:"subsettingCrs"`
+	OutputCRS     string           `xml:"outputCrs"`
+	Interpolation string           `xml:"Interpolation>globalInterpolation"`
+}
+
+type wcs2Request struct {
+	XMLName        xml.Name
+	Service        string               `xml:"service,attr"`
+	Version        string               `xml:"version,attr"`
+	AcceptVersions []string             `xml:"AcceptVersions>Version"`
+	CoverageIDs    []string             `xml:"CoverageId"`
+	Format         string               `xml:"format"`
+	Trims          []wcs2DimensionTrim  `xml:"DimensionTrim"`
+	Slices         []wcs2DimensionSlice `xml:"DimensionSlice"`
+	Extension      wcs2Extension        `xml:"Extension"`
+}
+
+// IsWCS2XMLRequest returns true if the payload
+// of a POST request is a WCS 2.0 XML request.
+func IsWCS2XMLRequest(payload []byte) bool {
+	var root struct {
+		XMLName xml.Name
+	}
+	err := xml.Unmarshal(payload, &root)
+	return err == nil && root.XMLName.Space == WCS2Namespace
+}
+
+// ParseWCS2Post maps a WCS 2.0 XML request onto
+// the parameters of its KVP encoding.
+func ParseWCS2Post(payload []byte) (map[string][]string, error) {
+	var req wcs2Request
+	err := xml.Unmarshal(payload, &req)
+	if err != nil {
+		return map[string][]string{}, err
+	}
+
+	switch req.XMLName.Local {
+	case "GetCapabilities", "DescribeCoverage", "GetCoverage":
+	default:
+		return map[string][]string{}, fmt.Errorf("unsupported WCS request: %s", req.XMLName.Local)
+	}
+
+	version := strings.TrimSpace(req.Version)
+	if len(version) == 0 {
+		version = WCS2Version
+	}
+	params := map[string][]string{"service": []string{"WCS"},
+		"request": []string{req.XMLName.Local},
+		"version": []string{version},
+	}
+
+	if len(req.AcceptVersions) > 0 {
+		params["acceptversions"] = []string{strings.Join(req.AcceptVersions, ",")}
+	}
+
+	var ids []string
+	for _, id := range req.CoverageIDs {
+		ids = append(ids, strings.TrimSpace(id))
+	}
+	if len(ids) > 0 {
+		params["coverageid"] = []string{strings.Join(ids, ",")}
+	}
+
+	if format := strings.TrimSpace(req.Format); len(format) > 0 {
+		params["format"] = []string{format}
+	}
+
+	for _, trim := range req.Trims {
+		low := strings.TrimSpace(trim.TrimLow)
+		if len(low) == 0 {
+			low = "*"
+		}
+		high := strings.TrimSpace(trim.TrimHigh)
+		if len(high) == 0 {
+			high = "*"
+		}
+		params["subset"] = append(params["subset"], fmt.Sprintf("%s(%s,%s)", strings.TrimSpace(trim.Dimension), low, high))
+	}
+	for _, slice := range req.Slices {
+		params["subset"] = append(params["subset"], fmt.Sprintf("%s(%s)", strings.TrimSpace(slice.Dimension), strings.TrimSpace(slice.SlicePoint)))
+	}
+
+	ext := req.Extension
+	var sizes []string
+	for _, axis := range ext.ScaleToSize {
+		sizes = append(sizes, fmt.Sprintf("%s(%s)", strings.TrimSpace(axis.Axis), strings.TrimSpace(axis.TargetSize)))
+	}
+	if len(sizes) > 0 {
+		params["scalesize"] = []string{strings.Join(sizes, ",")}
+	}
+
+	var extents []string
+	for _, axis := range ext.ScaleToExtent {
+		extents = append(extents, fmt.Sprintf("%s(%s:%s)", strings.TrimSpace(axis.Axis), strings.TrimSpace(axis.Low), strings.TrimSpace(axis.High)))
+	}
+	if len(extents) > 0 {
+		params["scaleextent"] = []string{strings.Join(extents, ",")}
+	}
+
+	var items []string
+	for _, item := range ext.RangeItems {
+		if component := strings.TrimSpace(item.RangeComponent); len(component) > 0 {
+			items = append(items, component)
+		} else {
+			items = append(items, fmt.Sprintf("%s:%s", strings.TrimSpace(item.StartComponent), strings.TrimSpace(item.EndComponent)))
+		}
+	}
+	if len(items) > 0 {
+		params["rangesubset"] = []string{strings.Join(items, ",")}
+	}
+
+	if crs := strings.TrimSpace(ext.SubsettingCRS); len(crs) > 0 {
+		params["subsettingcrs"] = []string{crs}
+	}
+	if crs := strings.TrimSpace(ext.OutputCRS); len(crs) > 0 {
+		params["outputcrs"] = []string{crs}
+	}
+	if interpolation := strings.TrimSpace(ext.Interpolation); len(interpolation) > 0 {
+		params["interpolation"] = []string{interpolation}
+	}
+
+	return params, nil
+}
@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsWCS2Request(t *testing.T) {
+	tests := []struct {
+		params   map[string][]string
+		expected bool
+	}{
+		{map[string][]string{"version": {"2.0.1"}}, true},
+		{map[string][]string{"version": {"1.0.0"}, "coverageid": {"a"}}, false},
+		{map[string][]string{"acceptversions": {"2.0.1,1.0.0"}}, true},
+		{map[string][]string{"coverageid": {"a"}}, true},
+		{map[string][]string{"coverage": {"a"}}, false},
+	}
+	for _, test := range tests {
+		if res := IsWCS2Request(test.params); res != test.expected {
+			t.Errorf("IsWCS2Request(%v): expected %v, got %v", test.params, test.expected, res)
+		}
+	}
+}
+
+func TestParseCRSURI(t *testing.T) {
+	tests := map[string]string{
+		"http://www.opengis.net/def/crs/EPSG/0/3857":   "EPSG:3857",
+		"urn:ogc:def:crs:EPSG::4326":                   "EPSG:4326",
+		"http://www.opengis.net/def/crs/OGC/1.3/CRS84": "EPSG:4326",
+		"EPSG:32755": "EPSG:32755",
+		"http://www.opengis.net/def/crs/EPSG/0/notacode": "",
+	}
+	for crs, expected := range tests {
+		epsg, err := ParseCRSURI(crs)
+		if len(expected) == 0 {
+			if err == nil {
+				t.Errorf("ParseCRSURI(%s): expected an error", crs)
+			}
+			continue
+		}
+		if err != nil || epsg != expected {
+			t.Errorf("ParseCRSURI(%s): expected %s, got %s %v", crs, expected, epsg, err)
+		}
+	}
+}
+
+func TestWCS2ParamsChecker(t *testing.T) {
+	compREMap := CompileWCSRegexMap()
+	params := map[string][]string{"service": {"WCS"},
+		"request":       {"GetCoverage"},
+		"version":       {"2.0.1"},
+		"coverageid":    {"landsat"},
+		"format":        {"application/x-netcdf"},
+		"subset":        {"Long(140,*)", `Lat(-40.5,-30)`, `ansi("2020-01-02T00:00:00Z")`, "depth(5,10)"},
+		"scalesize":     {"Long(200)"},
+		"outputcrs":     {"http://www.opengis.net/def/crs/EPSG/0/3857"},
+		"interpolation": {"http://www.opengis.net/def/interpolation/OGC/1/linear"},
+		"rangesubset":   {"red,green:blue"},
+	}
+
+	wcs2Params, err := WCS2ParamsChecker(params, compREMap)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if *wcs2Params.Version != WCS2Version || *wcs2Params.Format != "NetCDF" || *wcs2Params.CRS != "EPSG:3857" || *wcs2Params.SubsettingCRS != "EPSG:4326" {
+		t.Errorf("unexpected parameters: %v %v %v %v", *wcs2Params.Version, *wcs2Params.Format, *wcs2Params.CRS, *wcs2Params.SubsettingCRS)
+	}
+	if len(wcs2Params.Coverages) != 1 || wcs2Params.Coverages[0] != "landsat" {
+		t.Errorf("unexpected coverages: %v", wcs2Params.Coverages)
+	}
+	if *wcs2Params.Resampling != "bilinear" {
+		t.Errorf("unexpected resampling: %v", *wcs2Params.Resampling)
+	}
+	if x := wcs2Params.XSubset; x == nil || x.Low == nil || *x.Low != 140 || x.High != nil {
+		t.Errorf("unexpected x subset: %v", x)
+	}
+	if y := wcs2Params.YSubset; y == nil || *y.Low != -40.5 || *y.High != -30 {
+		t.Errorf("unexpected y subset: %v", y)
+	}
+	if wcs2Params.Time == nil || wcs2Params.Time.Format(ISOFormat) != "2020-01-02T00:00:00.000Z" {
+		t.Errorf("unexpected time: %v", wcs2Params.Time)
+	}
+	if wcs2Params.ScaleWidth == nil || *wcs2Params.ScaleWidth != 200 || wcs2Params.ScaleHeight != nil {
+		t.Errorf("unexpected scaling: %v %v", wcs2Params.ScaleWidth, wcs2Params.ScaleHeight)
+	}
+	if strings.Join(wcs2Params.RangeSubset, ";") != "red;green:blue" {
+		t.Errorf("unexpected range subset: %v", wcs2Params.RangeSubset)
+	}
+
+	foundDepth := false
+	for _, axis := range wcs2Params.Axes {
+		if axis.Name == "depth" {
+			foundDepth = true
+			if axis.Start == nil || *axis.Start != 5 || axis.End == nil || *axis.End != 10 {
+				t.Errorf("unexpected depth subset: %v %v", axis.Start, axis.End)
+			}
+		}
+	}
+	if !foundDepth {
+		t.Errorf("depth subset not found: %v", wcs2Params.Axes)
+	}
+
+	errParams := []map[string][]string{
+		{"request": {"GetCoverage"}, "coverageid": {"landsat"}, "subset": {"Long(*)"}},
+		{"request": {"GetCoverage"}, "coverageid": {"landsat"}, "subset": {"Long(140)"}, "scalesize": {"Long(10)"}},
+		{"request": {"GetCoverage"}, "coverageid": {"landsat"}, "subset": {"Lat(10,0)"}},
+		{"request": {"GetCoverage"}, "coverageid": {"landsat"}, "subset": {"Lat(0,10)", "y(0,10)"}},
+		{"request": {"GetCoverage"}, "coverageid": {"landsat"}, "scalefactor": {"2"}},
+		{"request": {"GetCoverage"}, "coverageid": {"landsat"}, "scalesize": {"time(2)"}},
+		{"request": {"GetCoverage"}, "coverageid": {"landsat"}, "format": {"image/png"}},
+		{"request": {"GetCoverage"}, "coverageid": {"landsat"}, "outputcrs": {"http://www.opengis.net/def/crs/EPSG/0/abc"}},
+	}
+	for _, p := range errParams {
+		if _, err := WCS2ParamsChecker(p, compREMap); err == nil {
+			t.Errorf("expected an error: %v", p)
+		}
+	}
+}
+
+func TestWCS2ParamsCheckerSlice(t *testing.T) {
+	params := map[string][]string{"request": {"GetCoverage"},
+		"coverageid": {"landsat"},
+		"subset":     {"Long(140.5)", "Lat(-35,-30)"},
+		"scalesize":  {"Lat(20)"},
+	}
+	wcs2Params, err := WCS2ParamsChecker(params, CompileWCSRegexMap())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if x := wcs2Params.XSubset; x == nil || !x.Slice || x.Low == nil || *x.Low != 140.5 || x.High != nil {
+		t.Errorf("unexpected x slice: %v", x)
+	}
+	if y := wcs2Params.YSubset; y == nil || y.Slice || *y.Low != -35 || *y.High != -30 {
+		t.Errorf("unexpected y subset: %v", y)
+	}
+	if wcs2Params.ScaleWidth != nil || *wcs2Params.ScaleHeight != 20 {
+		t.Errorf("unexpected scaling: %v %v", wcs2Params.ScaleWidth, wcs2Params.ScaleHeight)
+	}
+}
+
+func TestWCS2ScaleExtent(t *testing.T) {
+	params := map[string][]string{"request": {"GetCoverage"},
+		"coverageid":  {"landsat"},
+		"scaleextent": {"i(0:99),j(10:59)"},
+	}
+	wcs2Params, err := WCS2ParamsChecker(params, CompileWCSRegexMap())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if *wcs2Params.ScaleWidth != 100 || *wcs2Params.ScaleHeight != 50 {
+		t.Errorf("unexpected scaling: %v %v", *wcs2Params.ScaleWidth, *wcs2Params.ScaleHeight)
+	}
+}
+
+func TestResolveWCS2RangeSubset(t *testing.T) {
+	bandExpr, err := ParseBandExpressions([]string{"red", "green", "blue", "ndvi=(nir-red)/(nir+red)"})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	bands, err := ResolveWCS2RangeSubset([]string{"ndvi", "green:blue"}, bandExpr)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(bands) != 3 || bands[0] != bandExpr.ExprText[3] || bands[1] != "green" || bands[2] != "blue" {
+		t.Errorf("unexpected bands: %v", bands)
+	}
+
+	for _, rangeSubset := range [][]string{{"nir"}, {"blue:red"}, {"red:green:blue"}} {
+		if _, err := ResolveWCS2RangeSubset(rangeSubset, bandExpr); err == nil {
+			t.Errorf("expected an error: %v", rangeSubset)
+		}
+	}
+}
+
+func TestParseWCS2Post(t *testing.T) {
+	payload := `<?xml version="1.0" encoding="UTF-8"?>
+<wcs:GetCoverage xmlns:wcs="http://www.opengis.net/wcs/2.0" xmlns:scal="http://www.opengis.net/wcs/scaling/1.0" xmlns:rsub="http://www.opengis.net/wcs/range-subsetting/1.0" xmlns:crs="http://www.opengis.net/wcs/crs/1.0" service="WCS" version="2.0.1">
+  <wcs:Extension>
+    <scal:ScaleToSize>
+      <scal:TargetAxisSize>
+        <scal:axis>Long</scal:axis>
+        <scal:targetSize>256</scal:targetSize>
+      </scal:TargetAxisSize>
+    </scal:ScaleToSize>
+    <rsub:RangeSubset>
+      <rsub:RangeItem>
+        <rsub:RangeComponent>red</rsub:RangeComponent>
+      </rsub:RangeItem>
+      <rsub:RangeItem>
+        <rsub:RangeInterval>
+          <rsub:startComponent>green</rsub:startComponent>
+          <rsub:endComponent>blue</rsub:endComponent>
+        </rsub:RangeInterval>
+      </rsub:RangeItem>
+    </rsub:RangeSubset>
+    <crs:outputCrs>http://www.opengis.net/def/crs/EPSG/0/3857</crs:outputCrs>
+  </wcs:Extension>
+  <wcs:CoverageId>landsat</wcs:CoverageId>
+  <wcs:DimensionTrim>
+    <wcs:Dimension>Long</wcs:Dimension>
+    <wcs:TrimLow>140</wcs:TrimLow>
+    <wcs:TrimHigh>150</wcs:TrimHigh>
+  </wcs:DimensionTrim>
+  <wcs:DimensionSlice>
+    <wcs:Dimension>ansi</wcs:Dimension>
+    <wcs:SlicePoint>2020-01-02T00:00:00Z</wcs:SlicePoint>
+  </wcs:DimensionSlice>
+  <wcs:format>image/tiff</wcs:format>
+</wcs:GetCoverage>`
+
+	if !IsWCS2XMLRequest([]byte(payload)) {
+		t.Fatalf("WCS 2.0 payload not detected")
+	}
+	params, err := ParseWCS2Post([]byte(payload))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected := map[string]string{"request": "GetCoverage",
+		"version":     "2.0.1",
+		"coverageid":  "landsat",
+		"format":      "image/tiff",
+		"subset":      "Long(140,150);ansi(2020-01-02T00:00:00Z)",
+		"scalesize":   "Long(256)",
+		"rangesubset": "red,green:blue",
+		"outputcrs":   "http://www.opengis.net/def/crs/EPSG/0/3857",
+	}
+	for key, val := range expected {
+		if res := strings.Join(params[key], ";"); res != val {
+			t.Errorf("%s: expected %s, got %s", key, val, res)
+		}
+	}
+
+	if _, err := WCS2ParamsChecker(params, CompileWCSRegexMap()); err != nil {
+		t.Errorf("%v", err)
+	}
+
+	if IsWCS2XMLRequest([]byte(`<wps:Execute xmlns:wps="http://www.opengis.net/wps/1.0.0"/>`)) {
+		t.Errorf("WPS payload detected as WCS 2.0")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -47,6 +48,28 @@ type AxisParam struct {
 	IdxSelectors []*AxisIdxSelector
 }
 
+// TemporalAggregations are the per-pixel reductions of
+// the rasters of a time interval available to WMS GetMap
+// and WCS GetCoverage requests through the agg parameter.
+var TemporalAggregations = []string{"mean", "min", "max", "median", "sum", "count", "stddev"}
+
+// ResamplingAlgorithms are the GDAL resampling algorithms
+// available to warp the granules of WMS GetMap and WCS
+// GetCoverage requests. Nearest neighbour is the default.
+var ResamplingAlgorithms = []string{"nearest", "bilinear", "cubic", "cubicspline", "lanczos", "average", "mode", "min", "max", "med", "q1", "q3"}
+
+// CheckResampling returns the resampling algorithm in
+// lower case if it is one of ResamplingAlgorithms.
+func CheckResampling(resampling string) (string, error) {
+	resampling = strings.ToLower(strings.TrimSpace(resampling))
+	for _, alg := range ResamplingAlgorithms {
+		if resampling == alg {
+			return resampling, nil
+		}
+	}
+	return "", fmt.Errorf("resampling must be one of %s", strings.Join(ResamplingAlgorithms, ", "))
+}
+
 // WMSParams contains the serialised version
 // of the parameters contained in a WMS request.
 type WMSParams struct {
@@ -55,11 +78,17 @@ type WMSParams struct {
 	CRS              *string      `json:"crs,omitempty"`
 	BBox             []float64    `json:"bbox,omitempty"`
 	Format           *string      `json:"format,omitempty"`
+	InfoFormat       *string      `json:"info_format,omitempty"`
 	X                *int         `json:"x,omitempty"`
 	Y                *int         `json:"y,omitempty"`
 	Height           *int         `json:"height,omitempty"`
 	Width            *int         `json:"width,omitempty"`
 	Time             *time.Time   `json:"time,omitempty"`
+	EndTime          *time.Time   `json:"end_time,omitempty"`
+	Agg              *string      `json:"agg,omitempty"`
+	Resampling       *string      `json:"resampling,omitempty"`
+	ContourInterval  *float64     `json:"contour_interval,omitempty"`
+	ContourLevels    []float64    `json:"contour_levels,omitempty"`
 	Layers           []string     `json:"layers,omitempty"`
 	Styles           []string     `json:"styles,omitempty"`
 	Version          *string      `json:"version,omitempty"`
@@ -88,6 +117,7 @@ var WMSRegexpMap = map[string]string{"service": `^WMS$`,
 	"width":   `^[0-9]+$`,
 	"height":  `^[0-9]+$`,
 	"axis":    `^[A-Za-z_][A-Za-z0-9_]*$`,
+	"agg":     `^(?i)(mean|min|max|median|sum|count|stddev)$`,
 	"time":    `^\d{4}-(?:1[0-2]|0[1-9])-(?:3[01]|0[1-9]|[12][0-9])T[0-2]\d:[0-5]\d:[0-5]\d(Z|\.\d+Z)$`}
 
 // BBox2Geot return the geotransform from the
@@ -222,11 +252,29 @@ func WMSParamsChecker(params map[string][]string, compREMap map[string]*regexp.R
 		}
 	}
 
+	// Legends are rendered at the requested size, which
+	// must then be valid rather than left out as for maps
+	if request, requestOK := params["request"]; requestOK && request[0] == "GetLegendGraphic" {
+		for _, key := range []string{"width", "height"} {
+			if val, found := params[key]; found {
+				if size, err := strconv.Atoi(val[0]); err != nil || size <= 0 {
+					return WMSParams{}, fmt.Errorf("legend %s must be a positive integer: %s", key, val[0])
+				}
+			}
+		}
+	}
+
 	if geojsonFeatureId, geojsonFeatureIdOk := params["geojson_feature_id"]; geojsonFeatureIdOk && geojsonFeatureId[0] != "" {
 		jsonFields = append(jsonFields, fmt.Sprintf(`"geojson_feature_id":"%s"`, geojsonFeatureId[0]))
 	}
 
-	if timeRaw, timeOK := params["time"]; timeOK {
+	if timeRaw, timeOK := params["time"]; timeOK && strings.Contains(timeRaw[0], "/") {
+		startTime, endTime, err := parseTimeInterval(timeRaw[0], compREMap)
+		if err != nil {
+			return wmsParams, err
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"time":"%s"`, startTime), fmt.Sprintf(`"end_time":"%s"`, endTime))
+	} else if timeOK {
 		var times []string
 		for _, t := range strings.Split(timeRaw[0], ",") {
 			t = strings.TrimSpace(t)
@@ -258,6 +306,41 @@ func WMSParamsChecker(params map[string][]string, compREMap map[string]*regexp.R
 		}
 	}
 
+	if agg, aggOK := params["agg"]; aggOK {
+		if !compREMap["agg"].MatchString(agg[0]) {
+			return wmsParams, fmt.Errorf("agg must be one of %s", strings.Join(TemporalAggregations, ", "))
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"agg":"%s"`, strings.ToLower(agg[0])))
+	}
+
+	if resampling, resamplingOK := params["resampling"]; resamplingOK {
+		alg, err := CheckResampling(resampling[0])
+		if err != nil {
+			return wmsParams, err
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"resampling":"%s"`, alg))
+	}
+
+	contourFields, err := parseContourParams(params)
+	if err != nil {
+		return wmsParams, err
+	}
+	jsonFields = append(jsonFields, contourFields...)
+
+	if format, formatOK := params["format"]; formatOK {
+		if !strings.ContainsAny(format[0], "\"\\") {
+			jsonFields = append(jsonFields, fmt.Sprintf(`"format":"%s"`, strings.TrimSpace(format[0])))
+		}
+	}
+
+	if infoFormat, infoFormatOK := params["info_format"]; infoFormatOK {
+		format, err := CheckFeatureInfoFormat(infoFormat[0])
+		if err != nil {
+			return wmsParams, err
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"info_format":"%s"`, format))
+	}
+
 	var layers []string
 	if _layers, layersOK := params["layers"]; layersOK {
 		layers = _layers
@@ -368,7 +451,7 @@ func WMSParamsChecker(params map[string][]string, compREMap map[string]*regexp.R
 	jsonParams := fmt.Sprintf("{%s}", strings.Join(jsonFields, ","))
 
 	axesTmp := wmsParams.Axes
-	err := json.Unmarshal([]byte(jsonParams), &wmsParams)
+	err = json.Unmarshal([]byte(jsonParams), &wmsParams)
 	if err != nil {
 		return wmsParams, err
 	}
@@ -610,6 +693,62 @@ func GetCanonicalBbox(srs string, bbox []float64) ([]float64, error) {
 	}
 }
 
+// TransformBbox transforms a bounding box between two spatial
+// reference systems. The edges of the box are sampled so that
+// the result covers the whole box where they become curved.
+func TransformBbox(srcSRS string, dstSRS string, bbox []float64) ([]float64, error) {
+	srcSRS = strings.ToUpper(strings.TrimSpace(srcSRS))
+	dstSRS = strings.ToUpper(strings.TrimSpace(dstSRS))
+	if srcSRS == dstSRS {
+		box := make([]float64, len(bbox))
+		copy(box, bbox)
+		return box, nil
+	}
+
+	var opts []*C.char
+	opts = append(opts, C.CString(fmt.Sprintf("SRC_SRS=%s", srcSRS)))
+	opts = append(opts, C.CString(fmt.Sprintf("DST_SRS=%s", dstSRS)))
+	for _, opt := range opts {
+		defer C.free(unsafe.Pointer(opt))
+	}
+	opts = append(opts, nil)
+	transformArg := C.GDALCreateGenImgProjTransformer2(nil, nil, &opts[0])
+	if transformArg == nil {
+		return bbox, fmt.Errorf("GDALCreateGenImgProjTransformer2 failed")
+	}
+	defer C.GDALDestroyGenImgProjTransformer(transformArg)
+
+	const nSteps = 20
+	var dx, dy []C.double
+	for i := 0; i <= nSteps; i++ {
+		x := bbox[0] + float64(i)*(bbox[2]-bbox[0])/nSteps
+		y := bbox[1] + float64(i)*(bbox[3]-bbox[1])/nSteps
+		dx = append(dx, C.double(x), C.double(x), C.double(bbox[0]), C.double(bbox[2]))
+		dy = append(dy, C.double(bbox[1]), C.double(bbox[3]), C.double(y), C.double(y))
+	}
+	dz := make([]C.double, len(dx))
+	bSuccess := make([]C.int, len(dx))
+
+	C.GDALGenImgProjTransform(transformArg, C.int(0), C.int(len(dx)), &dx[0], &dy[0], &dz[0], &bSuccess[0])
+
+	box := []float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	nValid := 0
+	for i := range dx {
+		if bSuccess[i] == 0 {
+			continue
+		}
+		nValid++
+		box[0] = math.Min(box[0], float64(dx[i]))
+		box[1] = math.Min(box[1], float64(dy[i]))
+		box[2] = math.Max(box[2], float64(dx[i]))
+		box[3] = math.Max(box[3], float64(dy[i]))
+	}
+	if nValid == 0 {
+		return bbox, fmt.Errorf("GDALGenImgProjTransform failed")
+	}
+	return box, nil
+}
+
 func GetPixelResolution(bbox []float64, width int, height int) float64 {
 	xRes := (bbox[2] - bbox[0]) / float64(width)
 	yRes := (bbox[3] - bbox[1]) / float64(height)
@@ -641,6 +780,34 @@ func FindLayerBestOverview(layer *Layer, reqRes float64, allowExtrapolation bool
 	return bestOvr
 }
 
+// parseTimeInterval returns the start and end times of
+// a start/end time interval.
+func parseTimeInterval(interval string, compREMap map[string]*regexp.Regexp) (string, string, error) {
+	parts := strings.Split(interval, "/")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid time interval: %s", interval)
+	}
+
+	startTime := strings.TrimSpace(parts[0])
+	endTime := strings.TrimSpace(parts[1])
+	if !compREMap["time"].MatchString(startTime) || !compREMap["time"].MatchString(endTime) {
+		return "", "", fmt.Errorf("invalid time format")
+	}
+
+	start, err := parseTime(startTime)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid time format")
+	}
+	end, err := parseTime(endTime)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid time format")
+	}
+	if end.Before(start) {
+		return "", "", fmt.Errorf("end time %s is before start time %s", endTime, startTime)
+	}
+	return startTime, endTime, nil
+}
+
 func parseTime(input string) (time.Time, error) {
 	for _, format := range ISOTimeFormats {
 		t, err := time.Parse(format, input)
@@ -24,3 +24,97 @@ func TestGetCurrentTimeStamp(t *testing.T) {
 		return
 	}
 }
+
+func TestWMSParamsCheckerTimeInterval(t *testing.T) {
+	params := map[string][]string{
+		"time": {"2020-01-01T00:00:00.000Z/2020-01-31T00:00:00.000Z"},
+		"agg":  {"Mean"},
+	}
+	wmsParams, err := WMSParamsChecker(params, CompileWMSRegexMap())
+	if err != nil {
+		t.Fatalf("failed to parse time interval: %v", err)
+	}
+	if wmsParams.Time == nil || wmsParams.Time.Format(ISOFormat) != "2020-01-01T00:00:00.000Z" {
+		t.Errorf("unexpected start time: %v", wmsParams.Time)
+	}
+	if wmsParams.EndTime == nil || wmsParams.EndTime.Format(ISOFormat) != "2020-01-31T00:00:00.000Z" {
+		t.Errorf("unexpected end time: %v", wmsParams.EndTime)
+	}
+	if wmsParams.Agg == nil || *wmsParams.Agg != "mean" {
+		t.Errorf("unexpected agg: %v", wmsParams.Agg)
+	}
+
+	params["time"] = []string{"2020-01-31T00:00:00.000Z/2020-01-01T00:00:00.000Z"}
+	if _, err = WMSParamsChecker(params, CompileWMSRegexMap()); err == nil {
+		t.Errorf("expected error for end time before start time")
+	}
+
+	params["time"] = []string{"2020-01-01T00:00:00.000Z"}
+	params["agg"] = []string{"mode"}
+	if _, err = WMSParamsChecker(params, CompileWMSRegexMap()); err == nil {
+		t.Errorf("expected error for unknown agg")
+	}
+}
+
+func TestWMSParamsCheckerResampling(t *testing.T) {
+	params := map[string][]string{"resampling": {"Bilinear"}}
+	wmsParams, err := WMSParamsChecker(params, CompileWMSRegexMap())
+	if err != nil {
+		t.Fatalf("failed to parse resampling: %v", err)
+	}
+	if wmsParams.Resampling == nil || *wmsParams.Resampling != "bilinear" {
+		t.Errorf("unexpected resampling: %v", wmsParams.Resampling)
+	}
+
+	params["resampling"] = []string{"sinc"}
+	if _, err = WMSParamsChecker(params, CompileWMSRegexMap()); err == nil {
+		t.Errorf("expected error for unknown resampling")
+	}
+
+	// Every interpolation method advertised by
+	// DescribeCoverage must be accepted
+	interpolations := map[string]string{"none": "nearest", "nearest neighbor": "nearest", "bilinear": "bilinear", "bicubic": "cubic"}
+	for method, expected := range interpolations {
+		wcsParams, err := WCSParamsChecker(map[string][]string{"interpolation": {method}}, CompileWCSRegexMap())
+		if err != nil {
+			t.Fatalf("failed to parse interpolation %s: %v", method, err)
+		}
+		if wcsParams.Resampling == nil || *wcsParams.Resampling != expected {
+			t.Errorf("unexpected interpolation of %s: %v", method, wcsParams.Resampling)
+		}
+	}
+}
+
+func TestWMSParamsCheckerInfoFormat(t *testing.T) {
+	params := map[string][]string{"info_format": {"Text/HTML"}}
+	wmsParams, err := WMSParamsChecker(params, CompileWMSRegexMap())
+	if err != nil {
+		t.Fatalf("failed to parse info_format: %v", err)
+	}
+	if wmsParams.InfoFormat == nil || *wmsParams.InfoFormat != FeatureInfoHTML {
+		t.Errorf("unexpected info_format: %v", wmsParams.InfoFormat)
+	}
+
+	params["info_format"] = []string{"image/png"}
+	if _, err = WMSParamsChecker(params, CompileWMSRegexMap()); err == nil {
+		t.Errorf("expected error for unknown info_format")
+	}
+}
+
+func TestWMSParamsCheckerLegendSize(t *testing.T) {
+	params := map[string][]string{"request": {"GetLegendGraphic"}, "width": {"200"}, "height": {"40"}}
+	wmsParams, err := WMSParamsChecker(params, CompileWMSRegexMap())
+	if err != nil {
+		t.Fatalf("failed to parse legend size: %v", err)
+	}
+	if *wmsParams.Width != 200 || *wmsParams.Height != 40 {
+		t.Errorf("unexpected legend size: %v, %v", *wmsParams.Width, *wmsParams.Height)
+	}
+
+	for _, size := range []string{"0", "-10", "abc"} {
+		params := map[string][]string{"request": {"GetLegendGraphic"}, "width": {size}, "height": {"40"}}
+		if _, err := WMSParamsChecker(params, CompileWMSRegexMap()); err == nil {
+			t.Errorf("expected error for legend width %s", size)
+		}
+	}
+}
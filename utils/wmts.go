@@ -0,0 +1,287 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const WMTSTileSize = 256
+const WMTSMaxZoomLevel = 18
+
+// Half of the equatorial circumference of the
+// WGS84 ellipsoid used by EPSG:3857
+const WebMercatorHalfExtent = 20037508.3427892
+
+// Scale denominators of zoom level 0 of the well known
+// scale sets assuming the standardised 0.28mm pixel size
+const GoogleMapsCompatibleScale0 = 559082264.0287178
+const WorldCRS84QuadScale0 = 279541132.0143589
+
+// WMTSParams contains the serialised version
+// of the parameters contained in a WMTS request.
+type WMTSParams struct {
+	Service       *string `json:"service,omitempty"`
+	Request       *string `json:"request,omitempty"`
+	Version       *string `json:"version,omitempty"`
+	Layer         *string `json:"layer,omitempty"`
+	Style         *string `json:"style,omitempty"`
+	Format        *string `json:"format,omitempty"`
+	InfoFormat    *string `json:"info_format,omitempty"`
+	TileMatrixSet *string `json:"tile_matrix_set,omitempty"`
+	TileMatrix    *int    `json:"tile_matrix,omitempty"`
+	TileRow       *int    `json:"tile_row,omitempty"`
+	TileCol       *int    `json:"tile_col,omitempty"`
+	I             *int    `json:"i,omitempty"`
+	J             *int    `json:"j,omitempty"`
+}
+
+// WMTSRegexpMap maps WMTS request parameters to
+// regular expressions for doing validation
+// when parsing.
+var WMTSRegexpMap = map[string]string{"service": `^WMTS$`,
+	"request":       `^GetCapabilities$|^GetTile$|^GetFeatureInfo$`,
+	"layer":         `^[^"]+$`,
+	"style":         `^[^"]*$`,
+	"format":        `^image/png$`,
+	"infoformat":    `^application/json$|^text/html$|^text/plain$|^application/vnd\.ogc\.gml$`,
+	"tilematrixset": `^[A-Za-z0-9_:.]+$`,
+	"tilematrix":    `^(?:[A-Za-z0-9_.]+:)*[0-9]+$`,
+	"tilerow":       `^[0-9]+$`,
+	"tilecol":       `^[0-9]+$`,
+	"i":             `^[0-9]+$`,
+	"j":             `^[0-9]+$`}
+
+// TileMatrix describes a single zoom level
+// of a WMTS tile matrix set.
+type TileMatrix struct {
+	Identifier       string
+	ScaleDenominator float64
+	TopLeftCorner    [2]float64
+	TileWidth        int
+	TileHeight       int
+	MatrixWidth      int
+	MatrixHeight     int
+	TileSpanX        float64
+	TileSpanY        float64
+}
+
+// TileMatrixSet describes a fixed tile grid
+// in the WMTS sense.
+type TileMatrixSet struct {
+	Identifier        string
+	Title             string
+	SupportedCRS      string
+	SRS               string
+	WellKnownScaleSet string
+	BoundingBox       []float64
+	TileMatrices      []*TileMatrix
+}
+
+// WMTSCapabilities is the payload passed to
+// the WMTS GetCapabilities template.
+type WMTSCapabilities struct {
+	*Config
+	TileMatrixSets []*TileMatrixSet
+}
+
+// NewGoogleMapsCompatible returns the Web Mercator
+// tile matrix set used by most slippy map clients.
+func NewGoogleMapsCompatible(maxZoom int) *TileMatrixSet {
+	tms := &TileMatrixSet{Identifier: "GoogleMapsCompatible",
+		Title:             "Google Maps Compatible for the World",
+		SupportedCRS:      "urn:ogc:def:crs:EPSG::3857",
+		SRS:               "EPSG:3857",
+		WellKnownScaleSet: "urn:ogc:def:wkss:OGC:1.0:GoogleMapsCompatible",
+		BoundingBox:       []float64{-WebMercatorHalfExtent, -WebMercatorHalfExtent, WebMercatorHalfExtent, WebMercatorHalfExtent},
+	}
+
+	for z := 0; z <= maxZoom; z++ {
+		n := 1 << uint(z)
+		span := 2 * WebMercatorHalfExtent / float64(n)
+		tms.TileMatrices = append(tms.TileMatrices, &TileMatrix{Identifier: fmt.Sprintf("%d", z),
+			ScaleDenominator: GoogleMapsCompatibleScale0 / float64(n),
+			TopLeftCorner:    [2]float64{-WebMercatorHalfExtent, WebMercatorHalfExtent},
+			TileWidth:        WMTSTileSize,
+			TileHeight:       WMTSTileSize,
+			MatrixWidth:      n,
+			MatrixHeight:     n,
+			TileSpanX:        span,
+			TileSpanY:        span,
+		})
+	}
+
+	return tms
+}
+
+// NewWorldCRS84Quad returns the geographic tile
+// matrix set with two tiles at zoom level 0.
+func NewWorldCRS84Quad(maxZoom int) *TileMatrixSet {
+	tms := &TileMatrixSet{Identifier: "WorldCRS84Quad",
+		Title:             "CRS84 for the World",
+		SupportedCRS:      "urn:ogc:def:crs:OGC:1.3:CRS84",
+		SRS:               "EPSG:4326",
+		WellKnownScaleSet: "urn:ogc:def:wkss:OGC:1.0:GoogleCRS84Quad",
+		BoundingBox:       []float64{-180, -90, 180, 90},
+	}
+
+	for z := 0; z <= maxZoom; z++ {
+		n := 1 << uint(z)
+		span := 180.0 / float64(n)
+		tms.TileMatrices = append(tms.TileMatrices, &TileMatrix{Identifier: fmt.Sprintf("%d", z),
+			ScaleDenominator: WorldCRS84QuadScale0 / float64(n),
+			TopLeftCorner:    [2]float64{-180, 90},
+			TileWidth:        WMTSTileSize,
+			TileHeight:       WMTSTileSize,
+			MatrixWidth:      2 * n,
+			MatrixHeight:     n,
+			TileSpanX:        span,
+			TileSpanY:        span,
+		})
+	}
+
+	return tms
+}
+
+// GetTileMatrixSets returns all the tile matrix
+// sets supported by this server.
+func GetTileMatrixSets() []*TileMatrixSet {
+	return []*TileMatrixSet{NewGoogleMapsCompatible(WMTSMaxZoomLevel), NewWorldCRS84Quad(WMTSMaxZoomLevel)}
+}
+
+// GetTileMatrixSet looks up a tile matrix set by
+// its identifier. Common aliases are accepted.
+func GetTileMatrixSet(identifier string) (*TileMatrixSet, error) {
+	switch strings.ToLower(strings.TrimSpace(identifier)) {
+	case "googlemapscompatible", "webmercatorquad", "epsg:3857", "epsg:900913":
+		return NewGoogleMapsCompatible(WMTSMaxZoomLevel), nil
+	case "worldcrs84quad", "googlecrs84quad", "crs84", "epsg:4326":
+		return NewWorldCRS84Quad(WMTSMaxZoomLevel), nil
+	default:
+		return nil, fmt.Errorf("unknown tile matrix set: %s", identifier)
+	}
+}
+
+// TileBBox returns the bounding box in the native CRS
+// of the tile matrix set for the tile at the given
+// zoom level, row and column.
+func (tms *TileMatrixSet) TileBBox(zoom int, row int, col int) ([]float64, error) {
+	if zoom < 0 || zoom >= len(tms.TileMatrices) {
+		return nil, fmt.Errorf("tile matrix %d out of range [0, %d]", zoom, len(tms.TileMatrices)-1)
+	}
+
+	tm := tms.TileMatrices[zoom]
+	if row < 0 || row >= tm.MatrixHeight {
+		return nil, fmt.Errorf("tile row %d out of range [0, %d]", row, tm.MatrixHeight-1)
+	}
+
+	if col < 0 || col >= tm.MatrixWidth {
+		return nil, fmt.Errorf("tile col %d out of range [0, %d]", col, tm.MatrixWidth-1)
+	}
+
+	xMin := tm.TopLeftCorner[0] + float64(col)*tm.TileSpanX
+	yMax := tm.TopLeftCorner[1] - float64(row)*tm.TileSpanY
+	xMax := xMin + tm.TileSpanX
+	yMin := yMax - tm.TileSpanY
+
+	return []float64{xMin, yMin, xMax, yMax}, nil
+}
+
+func CompileWMTSRegexMap() map[string]*regexp.Regexp {
+	REMap := make(map[string]*regexp.Regexp)
+	for key, re := range WMTSRegexpMap {
+		REMap[key] = regexp.MustCompile(re)
+	}
+
+	return REMap
+}
+
+func CheckWMTSVersion(version string) bool {
+	return version == "1.0.0"
+}
+
+// WMTSParamsChecker checks and marshals the content
+// of the parameters of a WMTS request into a
+// WMTSParams struct.
+func WMTSParamsChecker(params map[string][]string, compREMap map[string]*regexp.Regexp) (WMTSParams, error) {
+	var wmtsParams WMTSParams
+
+	jsonFields := []string{}
+
+	if service, serviceOK := params["service"]; serviceOK {
+		if compREMap["service"].MatchString(service[0]) {
+			jsonFields = append(jsonFields, fmt.Sprintf(`"service":"%s"`, service[0]))
+		}
+	}
+
+	if version, versionOK := params["version"]; versionOK {
+		jsonFields = append(jsonFields, fmt.Sprintf(`"version":"%s"`, version[0]))
+	}
+
+	if request, requestOK := params["request"]; requestOK {
+		if !compREMap["request"].MatchString(request[0]) {
+			return wmtsParams, fmt.Errorf("invalid request: %s", request[0])
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"request":"%s"`, request[0]))
+	}
+
+	if layer, layerOK := params["layer"]; layerOK {
+		if compREMap["layer"].MatchString(layer[0]) {
+			jsonFields = append(jsonFields, fmt.Sprintf(`"layer":"%s"`, layer[0]))
+		}
+	}
+
+	if style, styleOK := params["style"]; styleOK {
+		if compREMap["style"].MatchString(style[0]) {
+			jsonFields = append(jsonFields, fmt.Sprintf(`"style":"%s"`, style[0]))
+		}
+	}
+
+	if format, formatOK := params["format"]; formatOK {
+		if !compREMap["format"].MatchString(format[0]) {
+			return wmtsParams, fmt.Errorf("unsupported format: %s", format[0])
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"format":"%s"`, format[0]))
+	}
+
+	if infoFormat, infoFormatOK := params["infoformat"]; infoFormatOK {
+		if !compREMap["infoformat"].MatchString(infoFormat[0]) {
+			return wmtsParams, fmt.Errorf("unsupported info format: %s", infoFormat[0])
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"info_format":"%s"`, infoFormat[0]))
+	}
+
+	if tms, tmsOK := params["tilematrixset"]; tmsOK {
+		if compREMap["tilematrixset"].MatchString(tms[0]) {
+			jsonFields = append(jsonFields, fmt.Sprintf(`"tile_matrix_set":"%s"`, tms[0]))
+		}
+	}
+
+	// Some clients prefix the tile matrix identifier
+	// with the tile matrix set, e.g. EPSG:3857:5
+	if tm, tmOK := params["tilematrix"]; tmOK {
+		if !compREMap["tilematrix"].MatchString(tm[0]) {
+			return wmtsParams, fmt.Errorf("invalid tile matrix: %s", tm[0])
+		}
+		parts := strings.Split(tm[0], ":")
+		jsonFields = append(jsonFields, fmt.Sprintf(`"tile_matrix":%s`, parts[len(parts)-1]))
+	}
+
+	intFields := []struct {
+		key   string
+		field string
+	}{{"tilerow", "tile_row"}, {"tilecol", "tile_col"}, {"i", "i"}, {"j", "j"}}
+	for _, f := range intFields {
+		if val, valOK := params[f.key]; valOK {
+			if !compREMap[f.key].MatchString(val[0]) {
+				return wmtsParams, fmt.Errorf("invalid %s: %s", f.key, val[0])
+			}
+			jsonFields = append(jsonFields, fmt.Sprintf(`"%s":%s`, f.field, val[0]))
+		}
+	}
+
+	jsonParams := fmt.Sprintf("{%s}", strings.Join(jsonFields, ","))
+	err := json.Unmarshal([]byte(jsonParams), &wmtsParams)
+	return wmtsParams, err
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTileBBox(t *testing.T) {
+	tms, err := GetTileMatrixSet("GoogleMapsCompatible")
+	if err != nil {
+		t.Errorf("failed to get tile matrix set: %v", err)
+		return
+	}
+
+	bbox, err := tms.TileBBox(1, 0, 1)
+	if err != nil {
+		t.Errorf("failed to compute tile bbox: %v", err)
+		return
+	}
+
+	expected := []float64{0, 0, WebMercatorHalfExtent, WebMercatorHalfExtent}
+	for i := range expected {
+		if math.Abs(bbox[i]-expected[i]) > 1e-6 {
+			t.Errorf("unexpected bbox %v, expected %v", bbox, expected)
+			return
+		}
+	}
+
+	tms, err = GetTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Errorf("failed to get tile matrix set: %v", err)
+		return
+	}
+
+	bbox, err = tms.TileBBox(0, 0, 1)
+	if err != nil {
+		t.Errorf("failed to compute tile bbox: %v", err)
+		return
+	}
+
+	expected = []float64{0, -90, 180, 90}
+	for i := range expected {
+		if math.Abs(bbox[i]-expected[i]) > 1e-6 {
+			t.Errorf("unexpected bbox %v, expected %v", bbox, expected)
+			return
+		}
+	}
+
+	_, err = tms.TileBBox(0, 1, 0)
+	if err == nil {
+		t.Errorf("expected out of range error for tile row")
+	}
+}
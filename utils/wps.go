@@ -33,28 +33,95 @@ type DataInputs struct {
 	Input []Input
 }
 
+type ResponseDocument struct {
+	StoreExecuteResponse bool `xml:"storeExecuteResponse,attr"`
+	Status               bool `xml:"status,attr"`
+}
+
+type ResponseForm struct {
+	ResponseDocument ResponseDocument
+}
+
+// InputV2 is a WPS 2.0 Execute input whose data is
+// either a plain value or wrapped in a LiteralValue.
+type InputV2 struct {
+	ID   string `xml:"id,attr"`
+	Data struct {
+		LiteralValue string
+		Value        string `xml:",chardata"`
+	}
+}
+
+// Execute holds the WPS POST requests. Besides the 1.0.0
+// Execute document it accepts the 2.0.0 Execute, GetStatus,
+// GetResult and Dismiss documents distinguished by XMLName.
 type Execute struct {
-	Version    string `xml:"version,attr"`
-	Service    string `xml:"service,attr"`
-	Identifier string
-	DataInputs DataInputs
+	XMLName      xml.Name
+	Version      string `xml:"version,attr"`
+	Service      string `xml:"service,attr"`
+	Mode         string `xml:"mode,attr"`
+	Response     string `xml:"response,attr"`
+	Identifier   string
+	DataInputs   DataInputs
+	ResponseForm ResponseForm
+	Inputs       []InputV2 `xml:"Input"`
+	JobID        string
 }
 
 func ParsePost(rc io.ReadCloser) (map[string][]string, error) {
 	buf := new(bytes.Buffer)
 	buf.ReadFrom(rc)
 	rc.Close()
+	if IsWCS2XMLRequest(buf.Bytes()) {
+		return ParseWCS2Post(buf.Bytes())
+	}
+
 	var exec Execute
 	err := xml.Unmarshal(buf.Bytes(), &exec)
 	if err != nil {
 		return map[string][]string{}, err
 	}
 
-	parsedBody := map[string][]string{"status": []string{"true"},
-		"service":    []string{exec.Service},
-		"request":    []string{"Execute"},
-		"version":    []string{exec.Version},
-		"identifier": []string{exec.Identifier}}
+	switch exec.XMLName.Local {
+	case "Execute":
+	case "GetCapabilities", "DescribeProcess":
+		return map[string][]string{"service": []string{exec.Service},
+			"request":    []string{exec.XMLName.Local},
+			"version":    []string{exec.Version},
+			"identifier": []string{strings.TrimSpace(exec.Identifier)}}, nil
+	case "GetStatus", "GetResult", "Dismiss":
+		return map[string][]string{"service": []string{exec.Service},
+			"request": []string{exec.XMLName.Local},
+			"version": []string{exec.Version},
+			"jobid":   []string{strings.TrimSpace(exec.JobID)}}, nil
+	default:
+		return map[string][]string{}, fmt.Errorf("unsupported WPS request: %s", exec.XMLName.Local)
+	}
+
+	respDoc := exec.ResponseForm.ResponseDocument
+	parsedBody := map[string][]string{"status": []string{fmt.Sprintf("%t", respDoc.Status)},
+		"storeexecuteresponse": []string{fmt.Sprintf("%t", respDoc.StoreExecuteResponse)},
+		"service":              []string{exec.Service},
+		"request":              []string{"Execute"},
+		"version":              []string{exec.Version},
+		"identifier":           []string{strings.TrimSpace(exec.Identifier)}}
+
+	if len(exec.Mode) > 0 {
+		parsedBody["mode"] = []string{exec.Mode}
+	}
+	if len(exec.Response) > 0 {
+		parsedBody["response"] = []string{exec.Response}
+	}
+
+	// WPS 2.0 inputs carry both complex and literal
+	// data in the same element
+	for _, input := range exec.Inputs {
+		value := strings.TrimSpace(input.Data.LiteralValue)
+		if len(value) == 0 {
+			value = strings.TrimSpace(input.Data.Value)
+		}
+		exec.DataInputs.Input = append(exec.DataInputs.Input, Input{Identifier: input.ID, Data: Data{ComplexData: value, LiteralData: value}})
+	}
 
 	for _, input := range exec.DataInputs.Input {
 		inputID := strings.ToLower(strings.TrimSpace(input.Identifier))
@@ -84,9 +151,41 @@ type WPSParams struct {
 	EndDateTime   *string               `json:"end_datetime"`
 	Product       *string               `json:"product"`
 	FeatCol       geo.FeatureCollection `json:"feature_collection"`
+	FeatProps     FeatureProperties     `json:"feature_properties"`
 	GeometryId    *string               `json:"geometry_id"`
 	ClipUppers    map[string]float32    `json:"clip_uppers"`
 	ClipLowers    map[string]float32    `json:"clip_lowers"`
+
+	StoreExecuteResponse bool    `json:"store_execute_response"`
+	Status               bool    `json:"status"`
+	JobID                *string `json:"job_id"`
+
+	Version  *string `json:"version"`
+	Mode     *string `json:"mode"`
+	Response *string `json:"response"`
+}
+
+// FeatureProperties holds the properties of the
+// features of the feature collection of a request.
+type FeatureProperties struct {
+	Features []struct {
+		Properties map[string]interface{} `json:"properties"`
+	} `json:"features"`
+}
+
+// WPSExecuteStatus holds the status of an asynchronous
+// Execute request as rendered by WPS_ExecuteStatus.tpl.
+type WPSExecuteStatus struct {
+	Process          *Process
+	JobID            string
+	Status           string
+	Message          string
+	PercentCompleted int
+	CreationTime     string
+	ServiceInstance  string
+	StatusLocation   string
+	ExpirationDate   string
+	Outputs          string
 }
 
 // WPSRegexpMap maps WPS request parameters to
@@ -97,8 +196,13 @@ type WPSParams struct {
 // --- cases. Error free JSON deserialisation into types
 // --- also validates correct values.
 var WPSRegexpMap = map[string]string{"service": `^WPS$`,
-	"request": `^GetCapabilities$|^DescribeProcess$|^Execute$`,
-	"time":    `^\d{4}-(?:1[0-2]|0[1-9])-(?:3[01]|0[1-9]|[12][0-9])T[0-2]\d:[0-5]\d$`}
+	"request":  `^GetCapabilities$|^DescribeProcess$|^Execute$|^GetExecuteStatus$|^CancelExecute$|^GetStatus$|^GetResult$|^Dismiss$`,
+	"version":  `^1\.0\.0$|^2\.0\.0$`,
+	"mode":     `^sync$|^async$|^auto$`,
+	"response": `^document$|^raw$`,
+	"jobid":    `^[0-9a-f]{32}$`,
+	"bool":     `^(?i)(true|false)$`,
+	"time":     `^\d{4}-(?:1[0-2]|0[1-9])-(?:3[01]|0[1-9]|[12][0-9])T[0-2]\d:[0-5]\d$`}
 
 func CompileWPSRegexMap() map[string]*regexp.Regexp {
 	REMap := make(map[string]*regexp.Regexp)
@@ -160,6 +264,43 @@ func WPSParamsChecker(params map[string][]string, compREMap map[string]*regexp.R
 		return WPSParams{}, fmt.Errorf("WPS 'request' not found")
 	}
 
+	// WPS 2.0 is selected by version or, for
+	// GetCapabilities, by AcceptVersions.
+	// The job control operations only exist in 2.0
+	version := ""
+	if ver, verOK := params["version"]; verOK && len(ver[0]) > 0 {
+		version = ver[0]
+	} else if acceptVers, avOK := params["acceptversions"]; avOK {
+		for _, ver := range strings.Split(acceptVers[0], ",") {
+			ver = strings.TrimSpace(ver)
+			if ver == "1.0.0" || ver == "2.0.0" {
+				version = ver
+				break
+			}
+		}
+	}
+	switch params["request"][0] {
+	case "GetStatus", "GetResult", "Dismiss":
+		version = "2.0.0"
+	}
+	if len(version) > 0 {
+		// Clients of WPS 1.0 are not strict about the version
+		// they send, anything but 2.0.0 is handled as 1.0.0
+		if !compREMap["version"].MatchString(version) {
+			version = "1.0.0"
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"version":"%s"`, version))
+	}
+
+	for _, key := range []string{"mode", "response"} {
+		if val, valOK := params[key]; valOK {
+			if !compREMap[key].MatchString(val[0]) {
+				return WPSParams{}, fmt.Errorf("Invalid %s: %v", key, val[0])
+			}
+			jsonFields = append(jsonFields, fmt.Sprintf(`"%s":"%s"`, key, val[0]))
+		}
+	}
+
 	if id, idOK := params["identifier"]; idOK {
 		jsonFields = append(jsonFields, fmt.Sprintf(`"identifier":"%s"`, id[0]))
 	} else {
@@ -197,23 +338,53 @@ func WPSParamsChecker(params map[string][]string, compREMap map[string]*regexp.R
 	}
 
 	if inputs, inputsOK := params["geometry"]; inputsOK {
-		rawInputs := strings.Split(inputs[0], ";")
-		if len(rawInputs) > 1 {
-			prod := strings.Split(rawInputs[0], "=")
+		// Only the first separators are split as feature
+		// properties may contain any character
+		featCol := inputs[0]
+		if strings.HasPrefix(featCol, "product=") {
+			rawInputs := strings.SplitN(featCol, ";", 2)
+			prod := strings.SplitN(rawInputs[0], "=", 2)
 			jsonFields = append(jsonFields, fmt.Sprintf(`"product":"%s"`, prod[1]))
-			featCol := strings.Split(rawInputs[1], "=")
-			jsonFields = append(jsonFields, fmt.Sprintf(`"feature_collection":%s`, featCol[1]))
-		} else {
-			featCol := strings.Split(rawInputs[0], "=")
-			jsonFields = append(jsonFields, fmt.Sprintf(`"feature_collection":%s`, featCol[1]))
+			featCol = ""
+			if len(rawInputs) > 1 {
+				featCol = rawInputs[1]
+			}
 		}
-
+		if iSep := strings.Index(featCol, "="); iSep >= 0 {
+			featCol = featCol[iSep+1:]
+		}
+		featCol, err := expandMultiPoints(featCol)
+		if err != nil {
+			return WPSParams{}, fmt.Errorf("Invalid MultiPoint geometry: %v", err)
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"feature_collection":%s`, featCol))
+		jsonFields = append(jsonFields, fmt.Sprintf(`"feature_properties":%s`, featCol))
 	}
 
 	if geometryId, geometryIdOk := params["geometry_id"]; geometryIdOk {
 		jsonFields = append(jsonFields, fmt.Sprintf(`"geometry_id":"%s"`, geometryId[0]))
 	}
 
+	for _, key := range []string{"storeexecuteresponse", "status"} {
+		if val, valOK := params[key]; valOK {
+			if !compREMap["bool"].MatchString(val[0]) {
+				return WPSParams{}, fmt.Errorf("Invalid %s: %v", key, val[0])
+			}
+			field := key
+			if key == "storeexecuteresponse" {
+				field = "store_execute_response"
+			}
+			jsonFields = append(jsonFields, fmt.Sprintf(`"%s":%s`, field, strings.ToLower(val[0])))
+		}
+	}
+
+	if jobID, jobIDOK := params["jobid"]; jobIDOK {
+		if !compREMap["jobid"].MatchString(jobID[0]) {
+			return WPSParams{}, fmt.Errorf("Invalid jobid: %v", jobID[0])
+		}
+		jsonFields = append(jsonFields, fmt.Sprintf(`"job_id":"%s"`, jobID[0]))
+	}
+
 	var clipLowerFields []string
 	for k, p := range params {
 		if strings.Index(k, "_clip_lower") >= 0 {
@@ -242,6 +413,192 @@ func WPSParamsChecker(params map[string][]string, compREMap map[string]*regexp.R
 	return wpsParamms, err
 }
 
+// expandMultiPoints replaces the MultiPoint features of a feature
+// collection by a Point feature per point since MultiPoint is not
+// supported by geo.Feature. The properties are copied to every
+// point with the "id" property suffixed by the point position.
+func expandMultiPoints(featCol string) (string, error) {
+	var fc struct {
+		Type     string            `json:"type"`
+		Features []json.RawMessage `json:"features"`
+	}
+	// Malformed collections are reported when
+	// the request parameters are unmarshalled
+	if err := json.Unmarshal([]byte(featCol), &fc); err != nil {
+		return featCol, nil
+	}
+
+	hasMultiPoint := false
+	var features []json.RawMessage
+	for _, rawFeat := range fc.Features {
+		var feat struct {
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		}
+		if err := json.Unmarshal(rawFeat, &feat); err != nil || feat.Geometry.Type != "MultiPoint" {
+			features = append(features, rawFeat)
+			continue
+		}
+		hasMultiPoint = true
+
+		var coords [][]float64
+		if err := json.Unmarshal(feat.Geometry.Coordinates, &coords); err != nil {
+			return "", err
+		}
+		if len(coords) == 0 {
+			return "", fmt.Errorf("no point")
+		}
+
+		for ip, coord := range coords {
+			props := make(map[string]interface{}, len(feat.Properties))
+			for key, val := range feat.Properties {
+				props[key] = val
+			}
+			if id, found := props["id"]; found && id != nil {
+				props["id"] = fmt.Sprintf("%v_%d", id, ip+1)
+			}
+
+			point := map[string]interface{}{
+				"type":       "Feature",
+				"geometry":   map[string]interface{}{"type": "Point", "coordinates": coord},
+				"properties": props,
+			}
+			out, err := json.Marshal(point)
+			if err != nil {
+				return "", err
+			}
+			features = append(features, out)
+		}
+	}
+
+	if !hasMultiPoint {
+		return featCol, nil
+	}
+
+	fc.Features = features
+	out, err := json.Marshal(&fc)
+	return string(out), err
+}
+
+// WPSOutput is an output rendered by the
+// templates under WPS_Outputs.
+type WPSOutput struct {
+	Identifier string
+	Title      string
+	Abstract   string
+	MimeType   string
+	Schema     string
+	Encoding   string
+	Value      string
+}
+
+type wpsOutputs struct {
+	Outputs []struct {
+		Identifier string
+		Title      string
+		Abstract   string
+		Data       struct {
+			ComplexData struct {
+				MimeType string `xml:"mimeType,attr"`
+				Schema   string `xml:"schema,attr"`
+				Encoding string `xml:"encoding,attr"`
+				Value    string `xml:",chardata"`
+			}
+			LiteralData struct {
+				Value string `xml:",chardata"`
+			}
+		}
+	} `xml:"Output"`
+}
+
+// ParseWPSOutputs parses the WPS 1.0.0 outputs of a
+// process as rendered by the WPS_Outputs templates.
+func ParseWPSOutputs(outputs string) ([]*WPSOutput, error) {
+	doc := `<Outputs xmlns:wps="http://www.opengis.net/wps/1.0.0" xmlns:ows="http://www.opengis.net/ows/1.1">` + outputs + `</Outputs>`
+
+	var parsed wpsOutputs
+	err := xml.Unmarshal([]byte(doc), &parsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse WPS outputs: %v", err)
+	}
+
+	var res []*WPSOutput
+	for _, out := range parsed.Outputs {
+		wpsOut := &WPSOutput{Identifier: strings.TrimSpace(out.Identifier),
+			Title:    strings.TrimSpace(out.Title),
+			Abstract: strings.TrimSpace(out.Abstract),
+			MimeType: out.Data.ComplexData.MimeType,
+			Schema:   out.Data.ComplexData.Schema,
+			Encoding: out.Data.ComplexData.Encoding,
+			Value:    strings.TrimSpace(out.Data.ComplexData.Value),
+		}
+		if len(wpsOut.MimeType) == 0 {
+			wpsOut.MimeType = "text/plain"
+			wpsOut.Value = strings.TrimSpace(out.Data.LiteralData.Value)
+		}
+		res = append(res, wpsOut)
+	}
+	return res, nil
+}
+
+// EncodeWPSOutputs encodes outputs as the wps:Output
+// elements of a WPS 1.0.0 ExecuteResponse.
+func EncodeWPSOutputs(outputs []*WPSOutput) string {
+	escape := func(str string) string {
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(str))
+		return buf.String()
+	}
+
+	var buf strings.Builder
+	for _, out := range outputs {
+		fmt.Fprintf(&buf, "<wps:Output>\n")
+		fmt.Fprintf(&buf, "<ows:Identifier>%s</ows:Identifier>\n", escape(out.Identifier))
+		fmt.Fprintf(&buf, "<ows:Title>%s</ows:Title>\n", escape(out.Title))
+		fmt.Fprintf(&buf, "<ows:Abstract>%s</ows:Abstract>\n", escape(out.Abstract))
+		fmt.Fprintf(&buf, "<wps:Data>\n")
+		fmt.Fprintf(&buf, "<wps:ComplexData mimeType=\"%s\"", escape(out.MimeType))
+		if len(out.Schema) > 0 {
+			fmt.Fprintf(&buf, " schema=\"%s\"", escape(out.Schema))
+		}
+		if len(out.Encoding) > 0 {
+			fmt.Fprintf(&buf, " encoding=\"%s\"", escape(out.Encoding))
+		}
+		fmt.Fprintf(&buf, ">\n<![CDATA[%s]]>\n</wps:ComplexData>\n", strings.Replace(out.Value, "]]>", "]]]]><![CDATA[>", -1))
+		fmt.Fprintf(&buf, "</wps:Data>\n")
+		fmt.Fprintf(&buf, "</wps:Output>\n")
+	}
+	return buf.String()
+}
+
+// EncodeWPSOutputsV2 encodes outputs as the
+// wps:Output elements of a WPS 2.0.0 Result.
+func EncodeWPSOutputsV2(outputs []*WPSOutput) string {
+	escape := func(str string) string {
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(str))
+		return buf.String()
+	}
+
+	var buf strings.Builder
+	for _, out := range outputs {
+		fmt.Fprintf(&buf, "<wps:Output id=\"%s\">\n", escape(out.Identifier))
+		fmt.Fprintf(&buf, "<wps:Data mimeType=\"%s\"", escape(out.MimeType))
+		if len(out.Schema) > 0 {
+			fmt.Fprintf(&buf, " schema=\"%s\"", escape(out.Schema))
+		}
+		if len(out.Encoding) > 0 {
+			fmt.Fprintf(&buf, " encoding=\"%s\"", escape(out.Encoding))
+		}
+		fmt.Fprintf(&buf, "><![CDATA[%s]]></wps:Data>\n", strings.Replace(out.Value, "]]>", "]]]]><![CDATA[>", -1))
+		fmt.Fprintf(&buf, "</wps:Output>\n")
+	}
+	return buf.String()
+}
+
 func GetArea(wgs84Poly geo.Geometry) float64 {
 	geomJSON, _ := json.Marshal(wgs84Poly)
 	geomJSONC := C.CString(string(geomJSON))
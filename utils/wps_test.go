@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	geo "github.com/nci/geometry"
+)
+
+func TestParsePostV2(t *testing.T) {
+	body := `<wps:Execute xmlns:wps="http://www.opengis.net/wps/2.0" xmlns:ows="http://www.opengis.net/ows/2.0" service="WPS" version="2.0.0" response="document" mode="async">
+<ows:Identifier>geometryDrill</ows:Identifier>
+<wps:Input id="geometry"><wps:Data>{"type":"FeatureCollection","features":[]}</wps:Data></wps:Input>
+<wps:Input id="geometry_id"><wps:Data><wps:LiteralValue>abc</wps:LiteralValue></wps:Data></wps:Input>
+<wps:Output id="Result" transmission="value"/>
+</wps:Execute>`
+
+	params, err := ParsePost(ioutil.NopCloser(strings.NewReader(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["request"][0] != "Execute" || params["version"][0] != "2.0.0" || params["mode"][0] != "async" || params["identifier"][0] != "geometryDrill" {
+		t.Errorf("unexpected params: %v", params)
+	}
+	if params["geometry"][0] != `geometry={"type":"FeatureCollection","features":[]}` || params["geometry_id"][0] != "abc" {
+		t.Errorf("unexpected inputs: %v", params)
+	}
+
+	body = `<wps:GetStatus xmlns:wps="http://www.opengis.net/wps/2.0" service="WPS" version="2.0.0"><wps:JobID>0123456789abcdef0123456789abcdef</wps:JobID></wps:GetStatus>`
+	params, err = ParsePost(ioutil.NopCloser(strings.NewReader(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["request"][0] != "GetStatus" || params["jobid"][0] != "0123456789abcdef0123456789abcdef" {
+		t.Errorf("unexpected params: %v", params)
+	}
+}
+
+func TestWPSParamsCheckerVersion(t *testing.T) {
+	reMap := CompileWPSRegexMap()
+
+	params, err := WPSParamsChecker(map[string][]string{"service": {"WPS"}, "request": {"GetCapabilities"}, "acceptversions": {"2.0.0,1.0.0"}}, reMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Version == nil || *params.Version != "2.0.0" {
+		t.Errorf("unexpected version: %v", params.Version)
+	}
+
+	params, err = WPSParamsChecker(map[string][]string{"service": {"WPS"}, "request": {"Dismiss"}, "jobid": {"0123456789abcdef0123456789abcdef"}}, reMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *params.Version != "2.0.0" || *params.JobID != "0123456789abcdef0123456789abcdef" {
+		t.Errorf("unexpected params: %v, %v", *params.Version, *params.JobID)
+	}
+
+	for _, ver := range []string{"1.0", "1.0.0", "2.0"} {
+		params, err = WPSParamsChecker(map[string][]string{"service": {"WPS"}, "request": {"Execute"}, "version": {ver}}, reMap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *params.Version != "1.0.0" {
+			t.Errorf("unexpected version of %s: %v", ver, *params.Version)
+		}
+	}
+
+	_, err = WPSParamsChecker(map[string][]string{"service": {"WPS"}, "request": {"GetStatus"}, "jobid": {"../../etc/passwd"}}, reMap)
+	if err == nil {
+		t.Errorf("expected error for invalid jobid")
+	}
+}
+
+func TestWPSOutputsV2(t *testing.T) {
+	outputs := `<wps:Output>
+<ows:Identifier>ndvi</ows:Identifier>
+<ows:Title>NDVI</ows:Title>
+<wps:Data>
+<wps:ComplexData mimeType="application/vnd.terriajs.catalog-member+json" schema="https://tools.ietf.org/html/rfc7159">
+<![CDATA[{ "data": "date,ndvi\n2020-01-01,0.5\n" }]]>
+</wps:ComplexData>
+</wps:Data>
+</wps:Output>`
+
+	parsed, err := ParseWPSOutputs(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 1 || parsed[0].Identifier != "ndvi" || parsed[0].MimeType != "application/vnd.terriajs.catalog-member+json" || parsed[0].Value != `{ "data": "date,ndvi\n2020-01-01,0.5\n" }` {
+		t.Errorf("unexpected outputs: %+v", parsed[0])
+	}
+
+	encoded := EncodeWPSOutputsV2(parsed)
+	if !strings.Contains(encoded, `<wps:Output id="ndvi">`) || !strings.Contains(encoded, `<![CDATA[{ "data": "date,ndvi\n2020-01-01,0.5\n" }]]>`) {
+		t.Errorf("unexpected encoded outputs: %s", encoded)
+	}
+}
+
+func TestWPSParamsCheckerMultiPoint(t *testing.T) {
+	reMap := CompileWPSRegexMap()
+
+	featCol := `geometry={"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","geometry":{"type":"MultiPoint","coordinates":[[148.5,-35.2],[149.1,-35.3]]},"properties":{"id":"site","name":"a"}},` +
+		`{"type":"Feature","geometry":{"type":"Point","coordinates":[150,-34]},"properties":{"id":"gauge"}}]}`
+
+	params, err := WPSParamsChecker(map[string][]string{"service": {"WPS"}, "request": {"Execute"}, "geometry": {featCol}}, reMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(params.FeatCol.Features) != 3 || len(params.FeatProps.Features) != 3 {
+		t.Fatalf("expected 3 points, got %d features", len(params.FeatCol.Features))
+	}
+
+	expected := []struct {
+		x, y float64
+		id   string
+	}{{148.5, -35.2, "site_1"}, {149.1, -35.3, "site_2"}, {150, -34, "gauge"}}
+	for i, exp := range expected {
+		point, ok := params.FeatCol.Features[i].Geometry.(*geo.Point)
+		if !ok || point.X != exp.x || point.Y != exp.y {
+			t.Errorf("feature %d: unexpected geometry %v", i, params.FeatCol.Features[i].Geometry)
+		}
+		if params.FeatProps.Features[i].Properties["id"] != exp.id {
+			t.Errorf("feature %d: expected id %s, got %v", i, exp.id, params.FeatProps.Features[i].Properties["id"])
+		}
+	}
+	if params.FeatProps.Features[1].Properties["name"] != "a" {
+		t.Errorf("expected properties copied to every point: %v", params.FeatProps.Features[1].Properties)
+	}
+
+	_, err = WPSParamsChecker(map[string][]string{"service": {"WPS"}, "request": {"Execute"}, "geometry": {`geometry={"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"MultiPoint","coordinates":[]}}]}`}}, reMap)
+	if err == nil {
+		t.Errorf("expected error for empty MultiPoint")
+	}
+}
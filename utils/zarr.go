@@ -0,0 +1,426 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of the Zarr stores of WCS GetCoverage and DAP requests
+const DefaultZarrVersion = 2
+const DefaultZarrChunkSize = 512
+const DefaultZarrCompression = "gzip"
+const DefaultZarrCompressionLevel = 5
+
+// ZarrMediaType is the media type of zipped Zarr stores
+const ZarrMediaType = "application/zarr+zip"
+
+// ZarrCompressions lists the compression methods of Zarr chunks.
+// zlib is only defined by version 2 of the Zarr specification.
+var ZarrCompressions = []string{"gzip", "zlib", "none"}
+
+// zarrDataTypes maps GDAL data type names to Zarr v3 data types
+var zarrDataTypes = map[string]string{"Byte": "uint8",
+	"UInt16":  "uint16",
+	"Int16":   "int16",
+	"UInt32":  "uint32",
+	"Int32":   "int32",
+	"Float32": "float32",
+	"Float64": "float64",
+	"Int64":   "int64",
+}
+
+// zarrV2DataTypes maps Zarr v3 data types to
+// the little endian NumPy types of Zarr v2.
+var zarrV2DataTypes = map[string]string{"uint8": "|u1",
+	"uint16":  "<u2",
+	"int16":   "<i2",
+	"uint32":  "<u4",
+	"int32":   "<i4",
+	"int64":   "<i8",
+	"float32": "<f4",
+	"float64": "<f8",
+}
+
+// ZarrParams configures the Zarr stores returned by
+// WCS GetCoverage requests with format=Zarr and by
+// DAP requests on the .zarr path of a dataset.
+type ZarrParams struct {
+	Version     int    `json:"version"`
+	ChunkSize   int    `json:"chunk_size"`
+	Compression string `json:"compression"`
+	Level       int    `json:"level"`
+}
+
+// parseZarr checks the Zarr parameters of a layer
+// and sets the defaults of the missing ones.
+func parseZarr(layer *Layer) error {
+	if layer.Zarr == nil {
+		layer.Zarr = &ZarrParams{}
+	}
+	zarr := layer.Zarr
+
+	if zarr.Version == 0 {
+		zarr.Version = DefaultZarrVersion
+	}
+	if zarr.Version != 2 && zarr.Version != 3 {
+		return fmt.Errorf("Zarr version must be either 2 or 3: %v", zarr.Version)
+	}
+
+	if zarr.ChunkSize == 0 {
+		zarr.ChunkSize = DefaultZarrChunkSize
+	}
+	if zarr.ChunkSize < 16 || zarr.ChunkSize > 8192 {
+		return fmt.Errorf("Zarr chunk size must be between 16 and 8192: %v", zarr.ChunkSize)
+	}
+
+	zarr.Compression = strings.ToLower(strings.TrimSpace(zarr.Compression))
+	if len(zarr.Compression) == 0 {
+		zarr.Compression = DefaultZarrCompression
+	}
+
+	switch zarr.Compression {
+	case "gzip", "zlib":
+		if zarr.Compression == "zlib" && zarr.Version != 2 {
+			return fmt.Errorf("Zarr zlib compression requires version 2")
+		}
+		if zarr.Level == 0 {
+			zarr.Level = DefaultZarrCompressionLevel
+		}
+		if zarr.Level < 1 || zarr.Level > 9 {
+			return fmt.Errorf("Zarr %s level must be between 1 and 9: %v", zarr.Compression, zarr.Level)
+		}
+	case "none":
+		if zarr.Level != 0 {
+			return fmt.Errorf("Zarr chunks without compression have no level")
+		}
+	default:
+		return fmt.Errorf("Zarr compression must be one of %s", strings.Join(ZarrCompressions, ", "))
+	}
+	return nil
+}
+
+// ZarrDataType returns the Zarr data type of a GDAL data type name
+func ZarrDataType(gdalType string) (string, error) {
+	dataType, found := zarrDataTypes[gdalType]
+	if !found {
+		return "", fmt.Errorf("unsupported Zarr data type: %v", gdalType)
+	}
+	return dataType, nil
+}
+
+// ZarrDataTypeSize returns the number of bytes of a Zarr data type
+func ZarrDataTypeSize(dataType string) int {
+	switch dataType {
+	case "uint8":
+		return 1
+	case "uint16", "int16":
+		return 2
+	case "uint32", "int32", "float32":
+		return 4
+	default:
+		return 8
+	}
+}
+
+// ZarrArray is the metadata of an array of a Zarr store
+type ZarrArray struct {
+	Name      string
+	Shape     []int
+	Chunks    []int
+	DataType  string
+	FillValue float64
+	Dims      []string
+	Attrs     map[string]interface{}
+}
+
+// ZarrStore writes the groups and arrays of a Zarr
+// store as the entries of a zip archive. Version 2
+// stores come with consolidated metadata.
+type ZarrStore struct {
+	params   *ZarrParams
+	zw       *zip.Writer
+	metadata map[string]interface{}
+}
+
+// NewZarrStore starts a zipped Zarr store with
+// a root group of the given attributes.
+func NewZarrStore(w io.Writer, params *ZarrParams, attrs map[string]interface{}) (*ZarrStore, error) {
+	if attrs == nil {
+		attrs = make(map[string]interface{})
+	}
+	store := &ZarrStore{params: params, zw: zip.NewWriter(w), metadata: make(map[string]interface{})}
+
+	if params.Version == 3 {
+		group := map[string]interface{}{"zarr_format": 3, "node_type": "group", "attributes": attrs}
+		return store, store.writeJSON("zarr.json", group)
+	}
+
+	if err := store.writeJSON(".zgroup", map[string]interface{}{"zarr_format": 2}); err != nil {
+		return store, err
+	}
+	return store, store.writeJSON(".zattrs", attrs)
+}
+
+// CreateArray writes the metadata of an array
+func (s *ZarrStore) CreateArray(arr *ZarrArray) error {
+	attrs := make(map[string]interface{})
+	for k, v := range arr.Attrs {
+		attrs[k] = v
+	}
+
+	fillValue := zarrFillValue(arr.DataType, arr.FillValue)
+	if s.params.Version == 3 {
+		codecs := []interface{}{map[string]interface{}{"name": "bytes", "configuration": map[string]interface{}{"endian": "little"}}}
+		if s.params.Compression == "gzip" {
+			codecs = append(codecs, map[string]interface{}{"name": "gzip", "configuration": map[string]interface{}{"level": s.params.Level}})
+		}
+
+		meta := map[string]interface{}{"zarr_format": 3,
+			"node_type": "array",
+			"shape":     arr.Shape,
+			"data_type": arr.DataType,
+			"chunk_grid": map[string]interface{}{"name": "regular",
+				"configuration": map[string]interface{}{"chunk_shape": arr.Chunks}},
+			"chunk_key_encoding": map[string]interface{}{"name": "default",
+				"configuration": map[string]interface{}{"separator": "/"}},
+			"codecs":          codecs,
+			"fill_value":      fillValue,
+			"dimension_names": arr.Dims,
+			"attributes":      attrs,
+		}
+		return s.writeJSON(arr.Name+"/zarr.json", meta)
+	}
+
+	var compressor interface{}
+	if s.params.Compression != "none" {
+		compressor = map[string]interface{}{"id": s.params.Compression, "level": s.params.Level}
+	}
+
+	dtype, found := zarrV2DataTypes[arr.DataType]
+	if !found {
+		return fmt.Errorf("unsupported Zarr data type: %v", arr.DataType)
+	}
+
+	meta := map[string]interface{}{"zarr_format": 2,
+		"shape":      arr.Shape,
+		"chunks":     arr.Chunks,
+		"dtype":      dtype,
+		"compressor": compressor,
+		"fill_value": fillValue,
+		"order":      "C",
+		"filters":    nil,
+	}
+	if err := s.writeJSON(arr.Name+"/.zarray", meta); err != nil {
+		return err
+	}
+
+	attrs["_ARRAY_DIMENSIONS"] = arr.Dims
+	return s.writeJSON(arr.Name+"/.zattrs", attrs)
+}
+
+// WriteChunk compresses and writes the little endian
+// values of the chunk of an array at the chunk index.
+func (s *ZarrStore) WriteChunk(arr *ZarrArray, index []int, data []byte) error {
+	key := ZarrChunkKey(s.params.Version, index)
+
+	if s.params.Compression != "none" {
+		var buf bytes.Buffer
+		var zw io.WriteCloser
+		var err error
+		if s.params.Compression == "zlib" {
+			zw, err = zlib.NewWriterLevel(&buf, s.params.Level)
+		} else {
+			zw, err = gzip.NewWriterLevel(&buf, s.params.Level)
+		}
+		if err != nil {
+			return err
+		}
+		if _, err = zw.Write(data); err != nil {
+			return err
+		}
+		if err = zw.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+
+	return s.writeEntry(arr.Name+"/"+key, data)
+}
+
+// WriteBand writes the chunks of a y, x band of a data variable at
+// the given index of its other axes. readRows fills buf with ySize
+// rows of the band starting at yOff. Edge chunks are padded with
+// the fill value of the array.
+func (s *ZarrStore) WriteBand(arr *ZarrArray, index []int, readRows func(yOff int, ySize int, buf []byte) error) error {
+	nDims := len(arr.Shape)
+	height, width := arr.Shape[nDims-2], arr.Shape[nDims-1]
+	chunkHeight, chunkWidth := arr.Chunks[nDims-2], arr.Chunks[nDims-1]
+	dataSize := ZarrDataTypeSize(arr.DataType)
+	fill := ZarrEncodeValues(arr.DataType, []float64{arr.FillValue})
+
+	chunkIdx := make([]int, nDims)
+	copy(chunkIdx, index)
+
+	for yOff := 0; yOff < height; yOff += chunkHeight {
+		ySize := chunkHeight
+		if yOff+ySize > height {
+			ySize = height - yOff
+		}
+
+		rows := make([]byte, ySize*width*dataSize)
+		if err := readRows(yOff, ySize, rows); err != nil {
+			return err
+		}
+
+		for xOff := 0; xOff < width; xOff += chunkWidth {
+			xSize := chunkWidth
+			if xOff+xSize > width {
+				xSize = width - xOff
+			}
+
+			chunk := make([]byte, chunkHeight*chunkWidth*dataSize)
+			for i := 0; i < len(chunk); i += dataSize {
+				copy(chunk[i:], fill)
+			}
+			for iy := 0; iy < ySize; iy++ {
+				src := rows[(iy*width+xOff)*dataSize : (iy*width+xOff+xSize)*dataSize]
+				copy(chunk[iy*chunkWidth*dataSize:], src)
+			}
+
+			chunkIdx[nDims-2] = yOff / chunkHeight
+			chunkIdx[nDims-1] = xOff / chunkWidth
+			if err := s.WriteChunk(arr, chunkIdx, chunk); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteCoordinate writes a one dimensional coordinate
+// array as a single chunk.
+func (s *ZarrStore) WriteCoordinate(name string, dataType string, values []float64, attrs map[string]interface{}) error {
+	arr := &ZarrArray{Name: name,
+		Shape:     []int{len(values)},
+		Chunks:    []int{len(values)},
+		DataType:  dataType,
+		FillValue: math.NaN(),
+		Dims:      []string{name},
+		Attrs:     attrs,
+	}
+	if dataType != "float32" && dataType != "float64" {
+		arr.FillValue = 0
+	}
+
+	if err := s.CreateArray(arr); err != nil {
+		return err
+	}
+	return s.WriteChunk(arr, []int{0}, ZarrEncodeValues(dataType, values))
+}
+
+// Close writes the consolidated metadata of
+// version 2 stores and closes the zip archive.
+func (s *ZarrStore) Close() error {
+	if s.params.Version == 2 {
+		consolidated := map[string]interface{}{"zarr_consolidated_format": 1, "metadata": s.metadata}
+		if err := s.writeJSON(".zmetadata", consolidated); err != nil {
+			return err
+		}
+	}
+	return s.zw.Close()
+}
+
+func (s *ZarrStore) writeJSON(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if s.params.Version == 2 && key != ".zmetadata" {
+		s.metadata[key] = v
+	}
+	return s.writeEntry(key, data)
+}
+
+// writeEntry stores an entry without compressing it again so
+// that readers can access the chunks of the archive directly.
+func (s *ZarrStore) writeEntry(key string, data []byte) error {
+	fw, err := s.zw.CreateHeader(&zip.FileHeader{Name: key, Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(data)
+	return err
+}
+
+// ZarrChunkKey returns the key of a chunk relative to its
+// array with the default chunk key encoding of the version.
+func ZarrChunkKey(version int, index []int) string {
+	parts := make([]string, len(index))
+	for i, idx := range index {
+		parts[i] = strconv.Itoa(idx)
+	}
+
+	if version == 3 {
+		return strings.Join(append([]string{"c"}, parts...), "/")
+	}
+	if len(parts) == 0 {
+		return "0"
+	}
+	return strings.Join(parts, ".")
+}
+
+// ZarrEncodeValues encodes values as little endian
+// numbers of a Zarr data type.
+func ZarrEncodeValues(dataType string, values []float64) []byte {
+	dataSize := ZarrDataTypeSize(dataType)
+	buf := make([]byte, len(values)*dataSize)
+	for i, v := range values {
+		b := buf[i*dataSize:]
+		switch dataType {
+		case "uint8":
+			b[0] = uint8(v)
+		case "uint16":
+			binary.LittleEndian.PutUint16(b, uint16(v))
+		case "int16":
+			binary.LittleEndian.PutUint16(b, uint16(int16(v)))
+		case "uint32":
+			binary.LittleEndian.PutUint32(b, uint32(v))
+		case "int32":
+			binary.LittleEndian.PutUint32(b, uint32(int32(v)))
+		case "int64":
+			binary.LittleEndian.PutUint64(b, uint64(int64(v)))
+		case "float32":
+			binary.LittleEndian.PutUint32(b, math.Float32bits(float32(v)))
+		default:
+			binary.LittleEndian.PutUint64(b, math.Float64bits(v))
+		}
+	}
+	return buf
+}
+
+// zarrFillValue returns the JSON fill value of a data type
+// where the non-finite floating point values are strings.
+func zarrFillValue(dataType string, value float64) interface{} {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "Infinity"
+	case math.IsInf(value, -1):
+		return "-Infinity"
+	case dataType == "float32" || dataType == "float64":
+		return value
+	default:
+		return int64(value)
+	}
+}
@@ -0,0 +1,172 @@
+package utils
+
+// #include "gdal.h"
+// #include "ogr_srs_api.h"
+// #cgo pkg-config: gdal
+import "C"
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"unsafe"
+)
+
+// EncodeZarr writes the bands of a merged GeoTIFF as a zipped
+// Zarr store of data variables of dimensions (axes..., y, x)
+// along with the CF coordinates of x, y and the other axes.
+func EncodeZarr(w io.Writer, dataFile string, bandNames []string, params *ZarrParams, attrs map[string]interface{}, verbose bool) error {
+	if params == nil {
+		params = &ZarrParams{Version: DefaultZarrVersion, ChunkSize: DefaultZarrChunkSize, Compression: DefaultZarrCompression, Level: DefaultZarrCompressionLevel}
+	}
+
+	ds, err := NewBandLayout(bandNames)
+	if err != nil {
+		return err
+	}
+
+	dataFileC := C.CString(dataFile)
+	defer C.free(unsafe.Pointer(dataFileC))
+
+	driverName := "GTiff"
+	driverList := []*C.char{C.CString(driverName)}
+	defer C.free(unsafe.Pointer(driverList[0]))
+
+	hSrcDS := C.GDALOpenEx(dataFileC, C.GDAL_OF_READONLY, &driverList[0], nil, nil)
+	if hSrcDS == nil {
+		return fmt.Errorf("Failed to open data file: %v", dataFile)
+	}
+	defer C.GDALClose(hSrcDS)
+
+	width := int(C.GDALGetRasterXSize(hSrcDS))
+	height := int(C.GDALGetRasterYSize(hSrcDS))
+	nBands := int(C.GDALGetRasterCount(hSrcDS))
+	if nBands != len(ds.Bands) {
+		return fmt.Errorf("band count mismatch: %d != %d", nBands, len(ds.Bands))
+	}
+
+	hBand := C.GDALGetRasterBand(hSrcDS, C.int(1))
+	gdalType := C.GDALGetRasterDataType(hBand)
+	dataType, err := ZarrDataType(getDataType(gdalType))
+	if err != nil {
+		return err
+	}
+	noData := float64(C.GDALGetRasterNoDataValue(hBand, nil))
+
+	geot := make([]float64, 6)
+	C.GDALGetGeoTransform(hSrcDS, (*C.double)(&geot[0]))
+
+	projWKT := C.GoString(C.GDALGetProjectionRef(hSrcDS))
+	projWKTC := C.CString(projWKT)
+	defer C.free(unsafe.Pointer(projWKTC))
+	hSRS := C.OSRNewSpatialReference(projWKTC)
+	defer C.OSRDestroySpatialReference(hSRS)
+	isGeographic := C.OSRIsGeographic(hSRS) != 0
+
+	store, err := NewZarrStore(w, params, attrs)
+	if err != nil {
+		return err
+	}
+
+	xs := make([]float64, width)
+	for i := range xs {
+		xs[i] = geot[0] + (float64(i)+0.5)*geot[1]
+	}
+	ys := make([]float64, height)
+	for i := range ys {
+		ys[i] = geot[3] + (float64(i)+0.5)*geot[5]
+	}
+
+	xAttrs, yAttrs := CFSpatialAttrs(isGeographic)
+	if err = store.WriteCoordinate("x", "float64", xs, xAttrs); err != nil {
+		return err
+	}
+	if err = store.WriteCoordinate("y", "float64", ys, yAttrs); err != nil {
+		return err
+	}
+
+	dims := make([]string, 0, len(ds.Axes)+2)
+	for _, ax := range ds.Axes {
+		if ax.Name == "time" {
+			err = store.WriteCoordinate(ax.Name, "int64", ax.Values, CFTimeAttrs())
+		} else {
+			err = store.WriteCoordinate(ax.Name, "float64", ax.Values, map[string]interface{}{"long_name": ax.Name})
+		}
+		if err != nil {
+			return err
+		}
+		dims = append(dims, ax.Name)
+	}
+	dims = append(dims, "y", "x")
+
+	gridMapping := &ZarrArray{Name: "spatial_ref",
+		DataType: "int32",
+		Dims:     []string{},
+		Attrs:    map[string]interface{}{"crs_wkt": projWKT, "spatial_ref": projWKT, "GeoTransform": fmt.Sprintf("%v %v %v %v %v %v", geot[0], geot[1], geot[2], geot[3], geot[4], geot[5])},
+	}
+	if err = store.CreateArray(gridMapping); err != nil {
+		return err
+	}
+
+	shape := make([]int, 0, len(dims))
+	chunks := make([]int, 0, len(dims))
+	for _, ax := range ds.Axes {
+		shape = append(shape, len(ax.Values))
+		chunks = append(chunks, 1)
+	}
+	chunkWidth, chunkHeight := params.ChunkSize, params.ChunkSize
+	if chunkWidth > width {
+		chunkWidth = width
+	}
+	if chunkHeight > height {
+		chunkHeight = height
+	}
+	shape = append(shape, height, width)
+	chunks = append(chunks, chunkHeight, chunkWidth)
+
+	arrays := make([]*ZarrArray, len(ds.Variables))
+	for iv, varName := range ds.Variables {
+		arrays[iv] = &ZarrArray{Name: varName,
+			Shape:     shape,
+			Chunks:    chunks,
+			DataType:  dataType,
+			FillValue: noData,
+			Dims:      dims,
+			Attrs:     map[string]interface{}{"long_name": ds.LongNames[iv], "grid_mapping": "spatial_ref"},
+		}
+		if err = store.CreateArray(arrays[iv]); err != nil {
+			return err
+		}
+	}
+
+	for ib, band := range ds.Bands {
+		if band.Variable < 0 {
+			continue
+		}
+
+		hBand := C.GDALGetRasterBand(hSrcDS, C.int(ib+1))
+		readRows := func(yOff int, ySize int, buf []byte) error {
+			gerr := C.GDALRasterIO(hBand, C.GF_Read, 0, C.int(yOff), C.int(width), C.int(ySize), unsafe.Pointer(&buf[0]), C.int(width), C.int(ySize), gdalType, 0, 0)
+			if gerr != 0 {
+				return fmt.Errorf("Error reading raster band: %d, yOff:%d", ib, yOff)
+			}
+			return nil
+		}
+
+		if err = store.WriteBand(arrays[band.Variable], band.Index, readRows); err != nil {
+			return err
+		}
+
+		if verbose {
+			progress := nBands / 10
+			if progress < 1 {
+				progress = 1
+			}
+			if ib%progress == 0 {
+				log.Printf("Zarr: %d of %d bands done", ib+1, nBands)
+			}
+		}
+	}
+
+	return store.Close()
+}
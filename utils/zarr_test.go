@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+func TestParseZarr(t *testing.T) {
+	layer := &Layer{}
+	if err := parseZarr(layer); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if layer.Zarr.Version != DefaultZarrVersion || layer.Zarr.ChunkSize != DefaultZarrChunkSize || layer.Zarr.Compression != DefaultZarrCompression || layer.Zarr.Level != DefaultZarrCompressionLevel {
+		t.Errorf("unexpected defaults: %v", *layer.Zarr)
+	}
+
+	invalid := []*ZarrParams{
+		{Version: 1},
+		{ChunkSize: 8},
+		{Compression: "blosc"},
+		{Compression: "none", Level: 1},
+		{Compression: "gzip", Level: 10},
+		{Version: 3, Compression: "zlib"},
+	}
+	for _, zarr := range invalid {
+		if err := parseZarr(&Layer{Zarr: zarr}); err == nil {
+			t.Errorf("expected an error: %v", *zarr)
+		}
+	}
+}
+
+func TestZarrStore(t *testing.T) {
+	for _, version := range []int{2, 3} {
+		var buf bytes.Buffer
+		params := &ZarrParams{Version: version, ChunkSize: 2, Compression: "gzip", Level: 5}
+		store, err := NewZarrStore(&buf, params, map[string]interface{}{"Conventions": "CF-1.8"})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		arr := &ZarrArray{Name: "ndvi", Shape: []int{1, 3, 3}, Chunks: []int{1, 2, 2}, DataType: "uint8", FillValue: 255, Dims: []string{"time", "y", "x"}}
+		if err = store.CreateArray(arr); err != nil {
+			t.Fatalf("%v", err)
+		}
+		band := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+		err = store.WriteBand(arr, []int{0}, func(yOff int, ySize int, rows []byte) error {
+			copy(rows, band[yOff*3:(yOff+ySize)*3])
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err = store.WriteCoordinate("x", "float64", []float64{0.5, 1.5, 2.5}, nil); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err = store.Close(); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		entries := make(map[string][]byte)
+		for _, f := range zr.File {
+			rc, _ := f.Open()
+			entries[f.Name], _ = ioutil.ReadAll(rc)
+			rc.Close()
+		}
+
+		metaKey, chunkKey := "ndvi/.zarray", "ndvi/0.1.0"
+		if version == 3 {
+			metaKey, chunkKey = "ndvi/zarr.json", "ndvi/c/0/1/0"
+		}
+
+		var meta map[string]interface{}
+		if err = json.Unmarshal(entries[metaKey], &meta); err != nil {
+			t.Fatalf("version %d: %v", version, err)
+		}
+		if meta["fill_value"] != float64(255) {
+			t.Errorf("version %d: unexpected fill value: %v", version, meta["fill_value"])
+		}
+
+		if version == 2 {
+			if meta["dtype"] != "|u1" {
+				t.Errorf("unexpected dtype: %v", meta["dtype"])
+			}
+			var consolidated map[string]map[string]interface{}
+			json.Unmarshal(entries[".zmetadata"], &consolidated)
+			if _, found := consolidated["metadata"]["ndvi/.zattrs"]; !found {
+				t.Errorf("array attributes not consolidated: %s", entries[".zmetadata"])
+			}
+		} else if meta["data_type"] != "uint8" || len(meta["dimension_names"].([]interface{})) != 3 {
+			t.Errorf("unexpected metadata: %s", entries[metaKey])
+		}
+
+		zr2, err := gzip.NewReader(bytes.NewReader(entries[chunkKey]))
+		if err != nil {
+			t.Fatalf("version %d: chunk %s: %v", version, chunkKey, err)
+		}
+		chunk, _ := ioutil.ReadAll(zr2)
+		if !bytes.Equal(chunk, []byte{7, 8, 255, 255}) {
+			t.Errorf("version %d: unexpected edge chunk: %v", version, chunk)
+		}
+	}
+}
+
+func TestZarrChunkKey(t *testing.T) {
+	if key := ZarrChunkKey(2, []int{0, 1, 2}); key != "0.1.2" {
+		t.Errorf("unexpected v2 key: %v", key)
+	}
+	if key := ZarrChunkKey(3, []int{0, 1, 2}); key != "c/0/1/2" {
+		t.Errorf("unexpected v3 key: %v", key)
+	}
+	if key := ZarrChunkKey(2, nil); key != "0" {
+		t.Errorf("unexpected v2 scalar key: %v", key)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+
+	"github.com/nci/gsky/metrics"
+	"github.com/nci/gsky/utils"
+)
+
+func serveWCS2(ctx context.Context, params utils.WCS2Params, conf *utils.Config, r *http.Request, w http.ResponseWriter, query map[string][]string, metricsCollector *metrics.MetricsCollector) {
+	if params.Request == nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, "Malformed WCS, a Request field needs to be specified", 400)
+		return
+	}
+
+	switch *params.Request {
+	case "GetCapabilities":
+		newConf := conf.Copy(r)
+		caps := &utils.WCS2Capabilities{Config: newConf}
+		for iLayer := range conf.Layers {
+			if utils.CheckDisableServices(&conf.Layers[iLayer], "wcs") {
+				continue
+			}
+			caps.Coverages = append(caps.Coverages, utils.NewWCS2Coverage(&newConf.Layers[iLayer]))
+		}
+
+		tpl, _ := fileResolver.Lookup("templates/WCS2_GetCapabilities.tpl")
+		err := utils.ExecuteWriteTemplateFile(w, caps, tpl)
+		if err != nil {
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, err.Error(), 500)
+		}
+
+	case "DescribeCoverage":
+		if len(params.Coverages) == 0 {
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, "Malformed WCS DescribeCoverage request: coverageId not specified", 400)
+			return
+		}
+
+		newConf := conf.Copy(r)
+		var coverages []*utils.WCS2Coverage
+		for _, coverage := range params.Coverages {
+			idx, err := utils.GetCoverageIndex(utils.WCSParams{Coverages: []string{coverage}}, conf)
+			if err != nil {
+				Info.Printf("Error in the pipeline: %v\n", err)
+				metricsCollector.Info.HTTPStatus = 404
+				http.Error(w, fmt.Sprintf("Malformed WCS DescribeCoverage request: %v", err), 404)
+				return
+			}
+
+			newConf.GetLayerDates(idx, *verbose)
+			coverages = append(coverages, utils.NewWCS2Coverage(&newConf.Layers[idx]))
+		}
+
+		tpl, _ := fileResolver.Lookup("templates/WCS2_DescribeCoverage.tpl")
+		err := utils.ExecuteWriteTemplateFile(w, coverages, tpl)
+		if err != nil {
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, err.Error(), 500)
+		}
+
+	case "GetCoverage":
+		wcsParams, err := wcs2ToWcs(params, conf)
+		if err != nil {
+			Error.Printf("%s\n", err)
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, fmt.Sprintf("Malformed WCS GetCoverage request: %v", err), 400)
+			return
+		}
+
+		// The tiles of large coverages are requested from the
+		// workers with the URL of the request, so POST requests
+		// are turned into their KVP encoding.
+		if r.Method == "POST" {
+			r.URL.RawQuery = url.Values(query).Encode()
+		}
+
+		serveWCS(ctx, wcsParams, conf, r, w, query, metricsCollector)
+
+	default:
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("%s not recognised.", *params.Request), 400)
+	}
+}
+
+// wcs2ToWcs resolves the spatial subsets, scaling and range
+// subset of a WCS 2.0 GetCoverage request against its coverage,
+// returning the equivalent WCS 1.0.0 parameters.
+func wcs2ToWcs(params utils.WCS2Params, conf *utils.Config) (utils.WCSParams, error) {
+	wcsParams := params.WCSParams
+	idx, err := utils.GetCoverageIndex(wcsParams, conf)
+	if err != nil {
+		return wcsParams, err
+	}
+	layer := &conf.Layers[idx]
+
+	outputCRS := *wcsParams.CRS
+	subsettingCRS := *params.SubsettingCRS
+
+	bbox := []float64{-180, -90, 180, 90}
+	if len(layer.DefaultGeoBbox) == 4 {
+		bbox = append([]float64{}, layer.DefaultGeoBbox...)
+	}
+	if subsettingCRS != "EPSG:4326" {
+		bbox, err = utils.TransformBbox("EPSG:4326", subsettingCRS, bbox)
+		if err != nil {
+			return wcsParams, fmt.Errorf("failed to transform coverage extent to %s: %v", subsettingCRS, err)
+		}
+	}
+
+	// A slice is a trim one pixel of the coverage grid wide
+	// centred on the slice point, the sliced axis is then a
+	// single pixel wide in the output.
+	cov := utils.NewWCS2Coverage(layer)
+	xRes := (bbox[2] - bbox[0]) / float64(cov.Width)
+	yRes := (bbox[3] - bbox[1]) / float64(cov.Height)
+	trimWCS2Axis(params.XSubset, &bbox[0], &bbox[2], xRes)
+	trimWCS2Axis(params.YSubset, &bbox[1], &bbox[3], yRes)
+	if bbox[2] <= bbox[0] || bbox[3] <= bbox[1] {
+		return wcsParams, fmt.Errorf("empty spatial subset: %v", bbox)
+	}
+	gridWidth := int(math.Max(1, math.Round((bbox[2]-bbox[0])/xRes)))
+	gridHeight := int(math.Max(1, math.Round((bbox[3]-bbox[1])/yRes)))
+
+	if subsettingCRS != outputCRS {
+		bbox, err = utils.TransformBbox(subsettingCRS, outputCRS, bbox)
+		if err != nil {
+			return wcsParams, fmt.Errorf("failed to transform subset to %s: %v", outputCRS, err)
+		}
+	}
+	wcsParams.BBox = bbox
+
+	// Without scaling the size of the output is left to serveWCS
+	// which computes it from the resolution of the data, then
+	// passes it to the workers as explicit width and height.
+	// Slices fix the size of the output along both axes, the
+	// axis not sliced keeping the resolution of the coverage.
+	xSlice := params.XSubset != nil && params.XSubset.Slice
+	ySlice := params.YSubset != nil && params.YSubset.Slice
+	if wcsParams.Width == nil || wcsParams.Height == nil || xSlice || ySlice {
+		width, height := 0, 0
+		if params.ScaleWidth != nil {
+			width = *params.ScaleWidth
+		}
+		if params.ScaleHeight != nil {
+			height = *params.ScaleHeight
+		}
+		if xSlice || ySlice {
+			if xSlice {
+				width = 1
+			} else if width <= 0 {
+				width = gridWidth
+			}
+			if ySlice {
+				height = 1
+			} else if height <= 0 {
+				height = gridHeight
+			}
+		}
+
+		aspect := (bbox[3] - bbox[1]) / (bbox[2] - bbox[0])
+		if width > 0 && height <= 0 {
+			height = int(math.Max(1, math.Round(float64(width)*aspect)))
+		} else if height > 0 && width <= 0 {
+			width = int(math.Max(1, math.Round(float64(height)/aspect)))
+		}
+		wcsParams.Width = &width
+		wcsParams.Height = &height
+	}
+
+	if len(params.RangeSubset) > 0 {
+		bandExpr := wcsParams.BandExpr
+		if bandExpr == nil {
+			styleLayer := layer
+			styleIdx, err := utils.GetCoverageStyleIndex(wcsParams, conf, idx)
+			if err != nil {
+				return wcsParams, err
+			}
+			if styleIdx < 0 && len(layer.Styles) == 1 {
+				styleIdx = 0
+			}
+			if styleIdx >= 0 {
+				styleLayer = &layer.Styles[styleIdx]
+			}
+			bandExpr = styleLayer.RGBExpressions
+		}
+		if bandExpr == nil {
+			return wcsParams, fmt.Errorf("coverage has no range components: %s", layer.Name)
+		}
+
+		bands, err := utils.ResolveWCS2RangeSubset(params.RangeSubset, bandExpr)
+		if err != nil {
+			return wcsParams, err
+		}
+		wcsParams.BandExpr, err = utils.ParseBandExpressions(bands)
+		if err != nil {
+			return wcsParams, fmt.Errorf("parsing error in band expressions: %v", err)
+		}
+	}
+
+	return wcsParams, nil
+}
+
+// trimWCS2Axis applies the trim or slice of a spatial axis to
+// the low and high bounds of the axis given the resolution of
+// the coverage grid along the axis.
+func trimWCS2Axis(interval *utils.WCS2Interval, low *float64, high *float64, res float64) {
+	if interval == nil {
+		return
+	}
+	if interval.Slice {
+		*low = *interval.Low - res/2
+		*high = *interval.Low + res/2
+		return
+	}
+	if interval.Low != nil {
+		*low = *interval.Low
+	}
+	if interval.High != nil {
+		*high = *interval.High
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nci/gsky/metrics"
+	proc "github.com/nci/gsky/processor"
+	"github.com/nci/gsky/utils"
+)
+
+// serveWCSJSON renders the coverage of a small WCS GetCoverage
+// request as a single tile and writes its values as either a
+// CoverageJSON grid or CSV rows in the CRS of the request.
+func serveWCSJSON(ctx context.Context, params utils.WCSParams, conf *utils.Config, idx int, styleLayer *utils.Layer, geoReq *proc.GeoTileRequest, w http.ResponseWriter, metricsCollector *metrics.MetricsCollector) {
+	maxCells := conf.Layers[idx].WcsJSONMaxCells
+	if geoReq.Width*geoReq.Height > maxCells {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("The requested coverage of %d x %d cells is too large for %s, maximum cells: %d", geoReq.Width, geoReq.Height, *params.Format, maxCells), 400)
+		return
+	}
+
+	if len(styleLayer.Overviews) > 0 {
+		bbox, err := utils.GetCanonicalBbox(geoReq.CRS, geoReq.BBox)
+		if err == nil {
+			reqRes := utils.GetPixelResolution(bbox, geoReq.Width, geoReq.Height)
+			iOvr := utils.FindLayerBestOverview(styleLayer, reqRes, false)
+			if iOvr >= 0 {
+				geoReq.Overview = &styleLayer.Overviews[iOvr]
+			}
+		}
+	}
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), time.Duration(conf.Layers[idx].WcsTimeout)*time.Second)
+	defer timeoutCancel()
+
+	errChan := make(chan error, 100)
+	tp := proc.InitTilePipeline(ctx, styleLayer.MASAddress, conf.ServiceConfig.WorkerNodes, conf.Layers[idx].MaxGrpcRecvMsgSize, conf.Layers[idx].WcsPolygonShardConcLimit, conf.ServiceConfig.MaxGrpcBufferSize, errChan)
+
+	var grid *utils.RasterGrid
+	var err error
+	select {
+	case res := <-tp.Process(geoReq, *verbose):
+		grid, err = utils.NewRasterGrid(res, geoReq.Width, geoReq.Height)
+		if err != nil {
+			Info.Printf("WCS: %v\n", err)
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, err.Error(), 500)
+			return
+		}
+	case err := <-errChan:
+		Info.Printf("WCS: error in the pipeline: %v\n", err)
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, err.Error(), 500)
+		return
+	case <-ctx.Done():
+		Error.Printf("Context cancelled with message: %v\n", ctx.Err())
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, ctx.Err().Error(), 500)
+		return
+	case <-timeoutCtx.Done():
+		Error.Printf("WCS pipeline timed out, threshold:%v seconds", conf.Layers[idx].WcsTimeout)
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, "WCS pipeline timed out", 500)
+		return
+	}
+
+	nCells := len(grid.Parameters) * len(grid.Times) * grid.Width * grid.Height
+	if nCells > maxCells {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("The requested coverage of %d cells is too large for %s, maximum cells: %d", nCells, *params.Format, maxCells), 400)
+		return
+	}
+
+	// Rasters without a time axis are
+	// at the time of the request
+	if len(grid.Times) == 1 && len(grid.Times[0]) == 0 {
+		grid.Times[0] = params.Time.Format(utils.ISOFormat)
+	}
+
+	var out []byte
+	var fileExt, contentType string
+	if strings.ToLower(*params.Format) == "csv" {
+		out, err = utils.EncodeRasterGridCSV(grid, params.BBox)
+		fileExt = "csv"
+		contentType = "text/csv"
+	} else {
+		out, err = json.Marshal(utils.NewCovJSONGrid(grid, params.BBox, *params.CRS))
+		fileExt = "covjson"
+		contentType = utils.CovJSONMediaType
+	}
+	if err != nil {
+		Info.Printf("WCS: %v\n", err)
+		metricsCollector.Info.HTTPStatus = 500
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	re := regexp.MustCompile(`[^a-zA-Z0-9\-_\s]`)
+	fileNameCoverages := re.ReplaceAllString(params.Coverages[0], `-`)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s.%s", fileNameCoverages, params.Time.Format(utils.ISOFormat), fileExt))
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(out)))
+	w.Write(out)
+}
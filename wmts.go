@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/nci/gsky/metrics"
+	"github.com/nci/gsky/utils"
+)
+
+func serveWMTS(ctx context.Context, params utils.WMTSParams, conf *utils.Config, r *http.Request, w http.ResponseWriter, query map[string][]string, metricsCollector *metrics.MetricsCollector) {
+	if params.Request == nil {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, "Malformed WMTS, a Request field needs to be specified", 400)
+		return
+	}
+
+	reqURL := r.URL.String()
+	if params.Version != nil && !utils.CheckWMTSVersion(*params.Version) {
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("This server can only accept WMTS requests compliant with version 1.0.0: %s", reqURL), 400)
+		return
+	}
+
+	switch *params.Request {
+	case "GetCapabilities":
+		newConf := conf.Copy(r)
+		err := utils.LoadConfigTimestamps(newConf, *verbose)
+		if err != nil {
+			log.Printf("WMTS GetCapabilities LoadConfigTimestamps error: %v", err)
+		}
+
+		var layers []utils.Layer
+		for iLayer := range conf.Layers {
+			if utils.CheckDisableServices(&conf.Layers[iLayer], "wmts") {
+				continue
+			}
+			layers = append(layers, newConf.Layers[iLayer])
+		}
+		newConf.Layers = layers
+
+		caps := &utils.WMTSCapabilities{Config: newConf, TileMatrixSets: utils.GetTileMatrixSets()}
+		tpl, _ := fileResolver.Lookup("templates/WMTS_GetCapabilities.tpl")
+		err = utils.ExecuteWriteTemplateFile(w, caps, tpl)
+		if err != nil {
+			metricsCollector.Info.HTTPStatus = 500
+			http.Error(w, err.Error(), 500)
+		}
+
+	case "GetTile", "GetFeatureInfo":
+		wmsParams, err := wmtsToWms(params, conf, query)
+		if err != nil {
+			Error.Printf("%s\n", err)
+			metricsCollector.Info.HTTPStatus = 400
+			http.Error(w, fmt.Sprintf("Malformed WMTS %s request: %v", *params.Request, err), 400)
+			return
+		}
+		serveWMS(ctx, wmsParams, conf, r, w, metricsCollector)
+
+	default:
+		metricsCollector.Info.HTTPStatus = 400
+		http.Error(w, fmt.Sprintf("%s not recognised.", *params.Request), 400)
+	}
+}
+
+// wmtsToWms maps a WMTS GetTile or GetFeatureInfo request
+// onto the equivalent WMS request for the tile extent.
+func wmtsToWms(params utils.WMTSParams, conf *utils.Config, query map[string][]string) (utils.WMSParams, error) {
+	var wmsParams utils.WMSParams
+	if params.Layer == nil {
+		return wmsParams, fmt.Errorf("layer must be specified")
+	}
+
+	if params.TileMatrixSet == nil || params.TileMatrix == nil || params.TileRow == nil || params.TileCol == nil {
+		return wmsParams, fmt.Errorf("tilematrixset, tilematrix, tilerow and tilecol must be specified")
+	}
+
+	tms, err := utils.GetTileMatrixSet(*params.TileMatrixSet)
+	if err != nil {
+		return wmsParams, err
+	}
+
+	bbox, err := tms.TileBBox(*params.TileMatrix, *params.TileRow, *params.TileCol)
+	if err != nil {
+		return wmsParams, err
+	}
+	tm := tms.TileMatrices[*params.TileMatrix]
+
+	var layer *utils.Layer
+	for i := range conf.Layers {
+		if conf.Layers[i].Name == *params.Layer {
+			layer = &conf.Layers[i]
+			break
+		}
+	}
+	if layer == nil {
+		return wmsParams, fmt.Errorf("%s not found in config Layers", *params.Layer)
+	}
+
+	style := ""
+	if params.Style != nil && strings.ToLower(*params.Style) != "default" {
+		style = *params.Style
+	}
+
+	if utils.CheckDisableServices(layer, "wmts") {
+		return wmsParams, fmt.Errorf("WMTS is disabled for this layer")
+	}
+	for i := range layer.Styles {
+		if layer.Styles[i].Name == style && utils.CheckDisableServices(&layer.Styles[i], "wmts") {
+			return wmsParams, fmt.Errorf("WMTS is disabled for this style")
+		}
+	}
+
+	// WMTS dimensions are sent using their identifiers as keys
+	// while WMS expects them to be prefixed with dim_
+	for _, axis := range layer.AxesInfo {
+		name := strings.ToLower(axis.Name)
+		if val, found := query[name]; found {
+			if _, hasDim := query["dim_"+name]; !hasDim {
+				query["dim_"+name] = val
+			}
+		}
+	}
+
+	// Default to the latest timestamp for time=current and
+	// for unsubstituted ResourceURL template variables
+	if t, found := query["time"]; found && len(t) > 0 {
+		tStr := strings.ToLower(strings.TrimSpace(t[0]))
+		if len(tStr) == 0 || tStr == "current" || strings.HasPrefix(tStr, "{") {
+			delete(query, "time")
+		}
+	}
+
+	query["layers"] = []string{layer.Name}
+	query["styles"] = []string{style}
+	delete(query, "style")
+	delete(query, "layer")
+
+	wmsParams, err = utils.WMSParamsChecker(query, reWMSMap)
+	if err != nil {
+		return wmsParams, err
+	}
+
+	service := "WMS"
+	wmsVersion := "1.1.1"
+	request := "GetMap"
+	if *params.Request == "GetFeatureInfo" {
+		request = "GetFeatureInfo"
+		if params.I == nil || params.J == nil {
+			return wmsParams, fmt.Errorf("i and j must be specified")
+		}
+		if *params.I >= tm.TileWidth || *params.J >= tm.TileHeight {
+			return wmsParams, fmt.Errorf("i, j out of tile range")
+		}
+		wmsParams.X = params.I
+		wmsParams.Y = params.J
+		wmsParams.InfoFormat = params.InfoFormat
+	}
+
+	crs := tms.SRS
+	width := tm.TileWidth
+	height := tm.TileHeight
+
+	wmsParams.Service = &service
+	wmsParams.Version = &wmsVersion
+	wmsParams.Request = &request
+	wmsParams.CRS = &crs
+	wmsParams.BBox = bbox
+	wmsParams.Width = &width
+	wmsParams.Height = &height
+
+	return wmsParams, nil
+}
+
+// wmtsHandler serves the RESTful encoding of WMTS by
+// rewriting the request into its KVP equivalent, e.g.
+// /wmts/{namespace}/1.0.0/WMTSCapabilities.xml
+// /wmts/{namespace}/1.0.0/{layer}/{style}/{tms}/{z}/{row}/{col}.png
+// /wmts/{namespace}/1.0.0/{layer}/{style}/{tms}/{z}/{row}/{col}/{i}/{j}.json
+func wmtsHandler(w http.ResponseWriter, r *http.Request) {
+	var parts []string
+	for _, p := range strings.Split(r.URL.Path[len("/wmts/"):], "/") {
+		if len(p) > 0 {
+			parts = append(parts, p)
+		}
+	}
+
+	iVer := -1
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] == "1.0.0" {
+			iVer = i
+			break
+		}
+	}
+	if iVer < 0 {
+		http.Error(w, fmt.Sprintf("Invalid WMTS path: %s", r.URL.Path), 404)
+		return
+	}
+
+	namespace := strings.Join(parts[:iVer], "/")
+	rest := parts[iVer+1:]
+
+	kvp := url.Values{}
+	kvp.Set("service", "WMTS")
+	kvp.Set("version", "1.0.0")
+
+	trimExt := func(val string, ext string) (string, bool) {
+		if !strings.HasSuffix(val, ext) {
+			return val, false
+		}
+		return val[:len(val)-len(ext)], true
+	}
+
+	switch len(rest) {
+	case 1:
+		if rest[0] != "WMTSCapabilities.xml" {
+			http.Error(w, fmt.Sprintf("Invalid WMTS path: %s", r.URL.Path), 404)
+			return
+		}
+		kvp.Set("request", "GetCapabilities")
+	case 6, 8:
+		ext := ".png"
+		kvp.Set("request", "GetTile")
+		if len(rest) == 8 {
+			ext = ".json"
+			kvp.Set("request", "GetFeatureInfo")
+			kvp.Set("infoformat", "application/json")
+		}
+
+		last, ok := trimExt(rest[len(rest)-1], ext)
+		if !ok {
+			http.Error(w, fmt.Sprintf("Invalid WMTS path: %s", r.URL.Path), 404)
+			return
+		}
+		rest[len(rest)-1] = last
+
+		kvp.Set("layer", rest[0])
+		kvp.Set("style", rest[1])
+		kvp.Set("tilematrixset", rest[2])
+		kvp.Set("tilematrix", rest[3])
+		kvp.Set("tilerow", rest[4])
+		kvp.Set("tilecol", rest[5])
+		if len(rest) == 8 {
+			kvp.Set("i", rest[6])
+			kvp.Set("j", rest[7])
+		}
+	default:
+		http.Error(w, fmt.Sprintf("Invalid WMTS path: %s", r.URL.Path), 404)
+		return
+	}
+
+	r.URL.Path = "/ows/" + namespace
+	if len(r.URL.RawQuery) > 0 {
+		r.URL.RawQuery = kvp.Encode() + "&" + r.URL.RawQuery
+	} else {
+		r.URL.RawQuery = kvp.Encode()
+	}
+	owsHandler(w, r)
+}
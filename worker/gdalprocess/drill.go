@@ -81,13 +81,13 @@ func DrillDataset(in *pb.GeoRPCGranule) *pb.Result {
 
 	C.OGR_G_AssignSpatialReference(geom, selSRS)
 
-	res := readData(ds, float64(in.Width), float64(in.Height), in.Bands, geom, int(in.BandStrides), int(in.DrillDecileCount), int(in.PixelCount), in.PixelStat, in.ClipUpper, in.ClipLower)
+	res := readData(ds, float64(in.Width), float64(in.Height), in.Bands, geom, int(in.BandStrides), int(in.DrillDecileCount), in.Percentiles, in.HistogramBins, int(in.PixelCount), in.PixelStat, in.ClipUpper, in.ClipLower)
 	C.OGR_G_DestroyGeometry(geom)
 	return res
 }
 
-func readData(ds C.GDALDatasetH, rasterXSize float64, rasterYSize float64, bands []int32, geom C.OGRGeometryH, bandStrides int, decileCount int, pixelCount int, pixelStat string, clipUpper float32, clipLower float32) *pb.Result {
-	nCols := 1 + decileCount
+func readData(ds C.GDALDatasetH, rasterXSize float64, rasterYSize float64, bands []int32, geom C.OGRGeometryH, bandStrides int, decileCount int, percentiles []float64, histogramBins []float64, pixelCount int, pixelStat string, clipUpper float32, clipLower float32) *pb.Result {
+	statCols := 1
 
 	avgs := []*pb.TimeSeries{}
 
@@ -114,6 +114,7 @@ func readData(ds C.GDALDatasetH, rasterXSize float64, rasterYSize float64, bands
 		"mean":       true,
 		"sum":        true,
 		"minmaxmean": true,
+		"zonal":      true,
 	}
 
 	if pixelStat != "" && !stats[pixelStat] {
@@ -123,9 +124,26 @@ func readData(ds C.GDALDatasetH, rasterXSize float64, rasterYSize float64, bands
 
 	if pixelStat == "minmaxmean" {
 		// 3 columns in the output in the order min max mean
-		nCols = 3
+		statCols = 3
 	}
 
+	if pixelStat == "zonal" {
+		// 4 columns in the output in the order mean min max
+		// and mean of squares. All the columns carry the
+		// pixel count so that the results of several granules
+		// can be merged.
+		statCols = 4
+	}
+
+	// The statistics are followed by the deciles, the
+	// percentiles and the pixel counts of the histogram bins
+	nBins := 0
+	if len(histogramBins) > 1 {
+		nBins = len(histogramBins) - 1
+	}
+	nCols := statCols + decileCount + len(percentiles) + nBins
+	needsPixels := len(percentiles) > 0 || nBins > 0
+
 	nodata := float32(C.GDALGetRasterNoDataValue(bandH, nil))
 	metrics := &pb.WorkerMetrics{}
 
@@ -179,7 +197,7 @@ func readData(ds C.GDALDatasetH, rasterXSize float64, rasterYSize float64, bands
 					}
 
 					if pixelCount == 0 {
-						if pixelStat != "" && pixelStat != "sum" {
+						if (pixelStat != "" && pixelStat != "sum") || needsPixels {
 							vals = append(vals, float64(val))
 						}
 
@@ -224,6 +242,30 @@ func readData(ds C.GDALDatasetH, rasterXSize float64, rasterYSize float64, bands
 					// mean
 					iRes++
 					boundAvgs[iRes] = &pb.TimeSeries{Value: float64(sum / float32(total)), Count: 1}
+				} else if pixelStat == "zonal" {
+					var sumSq float64
+					min := math.Inf(1)
+					max := math.Inf(-1)
+					for _, v := range vals {
+						if math.IsNaN(v) {
+							continue
+						}
+						if min > v {
+							min = v
+						}
+						if max < v {
+							max = v
+						}
+						sumSq += v * v
+					}
+
+					boundAvgs[iRes] = &pb.TimeSeries{Value: float64(sum / float32(total)), Count: total}
+					iRes++
+					boundAvgs[iRes] = &pb.TimeSeries{Value: min, Count: total}
+					iRes++
+					boundAvgs[iRes] = &pb.TimeSeries{Value: max, Count: total}
+					iRes++
+					boundAvgs[iRes] = &pb.TimeSeries{Value: sumSq / float64(total), Count: total}
 				} else {
 					// mean by default
 					boundAvgs[iRes] = &pb.TimeSeries{Value: float64(sum / float32(total)), Count: total}
@@ -231,9 +273,13 @@ func readData(ds C.GDALDatasetH, rasterXSize float64, rasterYSize float64, bands
 
 			} else {
 				boundAvgs[iRes] = &pb.TimeSeries{Value: 0, Count: 0}
+				for ic := 1; ic < statCols; ic++ {
+					iRes++
+					boundAvgs[iRes] = &pb.TimeSeries{Value: 0, Count: 0}
+				}
 			}
 
-			if nCols > 1 {
+			if decileCount > 0 {
 				if total > 0 {
 					deciles := computeDeciles(decileCount, dataBuf, bandSize, bandOffset, nodata, dsDscr)
 					for ic := 0; ic < len(deciles); ic++ {
@@ -247,6 +293,30 @@ func readData(ds C.GDALDatasetH, rasterXSize float64, rasterYSize float64, bands
 					}
 				}
 			}
+
+			if needsPixels {
+				// Percentiles are weighted by the pixel counts
+				// when merged across granules whereas the pixel
+				// counts of the histogram bins are added up
+				var pixels []float64
+				for _, v := range vals {
+					if !math.IsNaN(v) {
+						pixels = append(pixels, v)
+					}
+				}
+				sort.Float64s(pixels)
+				count := int32(len(pixels))
+
+				for _, pct := range computePercentiles(pixels, percentiles) {
+					iRes++
+					boundAvgs[iRes] = &pb.TimeSeries{Value: pct, Count: count}
+				}
+
+				for _, binCount := range computeHistogram(pixels, histogramBins) {
+					iRes++
+					boundAvgs[iRes] = &pb.TimeSeries{Value: float64(binCount), Count: count}
+				}
+			}
 		}
 
 		avgs = append(avgs, boundAvgs[:nCols]...)
@@ -329,6 +399,55 @@ func computeDeciles(decileCount int, dataBuf []float32, bandSize int, bandOffset
 	return deciles
 }
 
+// computePercentiles returns the percentiles of sorted values
+// interpolating linearly between the closest ranks.
+func computePercentiles(sorted []float64, percentiles []float64) []float64 {
+	res := make([]float64, len(percentiles))
+	if len(sorted) == 0 {
+		return res
+	}
+
+	for i, pct := range percentiles {
+		rank := pct / 100 * float64(len(sorted)-1)
+		lo := int(math.Floor(rank))
+		hi := int(math.Ceil(rank))
+		if lo < 0 {
+			lo = 0
+		}
+		if hi >= len(sorted) {
+			hi = len(sorted) - 1
+		}
+		res[i] = sorted[lo] + (rank-float64(lo))*(sorted[hi]-sorted[lo])
+	}
+	return res
+}
+
+// computeHistogram returns the number of sorted values in each
+// bin delimited by consecutive edges. Bins include their lower
+// edge, the last one includes its upper edge as well.
+func computeHistogram(sorted []float64, edges []float64) []int {
+	if len(edges) < 2 {
+		return nil
+	}
+
+	counts := make([]int, len(edges)-1)
+	for _, v := range sorted {
+		if v < edges[0] || v > edges[len(edges)-1] {
+			continue
+		}
+		ib := sort.SearchFloat64s(edges, v)
+		if ib < len(edges) && edges[ib] == v {
+			ib++
+		}
+		ib--
+		if ib >= len(counts) {
+			ib = len(counts) - 1
+		}
+		counts[ib]++
+	}
+	return counts
+}
+
 func createMask(ds C.GDALDatasetH, g C.OGRGeometryH, geoTrans []float64, bbox []int32) ([]uint8, error) {
 	canvas := make([]uint8, bbox[2]*bbox[3])
 
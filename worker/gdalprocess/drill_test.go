@@ -0,0 +1,42 @@
+package gdalprocess
+
+import (
+	"testing"
+)
+
+func TestComputePercentiles(t *testing.T) {
+	vals := []float64{1, 2, 3, 4, 5}
+
+	res := computePercentiles(vals, []float64{0, 5, 50, 95, 100})
+	expected := []float64{1, 1.2, 3, 4.8, 5}
+	for i := range expected {
+		if diff := res[i] - expected[i]; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("percentile %d: expected %v, got %v", i, expected[i], res[i])
+		}
+	}
+
+	res = computePercentiles(nil, []float64{50})
+	if len(res) != 1 || res[0] != 0 {
+		t.Errorf("unexpected percentiles of no value: %v", res)
+	}
+}
+
+func TestComputeHistogram(t *testing.T) {
+	vals := []float64{-1, 0, 0.5, 1, 1.5, 2, 3}
+
+	counts := computeHistogram(vals, []float64{0, 1, 2})
+	expected := []int{2, 3}
+	if len(counts) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, counts)
+	}
+	for i := range expected {
+		if counts[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, counts)
+			break
+		}
+	}
+
+	if counts := computeHistogram(vals, []float64{0}); counts != nil {
+		t.Errorf("expected no bin, got %v", counts)
+	}
+}
@@ -0,0 +1,281 @@
+package gdalprocess
+
+// #include "gdal.h"
+// #include "ogr_api.h"
+// #include "ogr_srs_api.h"
+// #include "cpl_string.h"
+// #cgo pkg-config: gdal
+import "C"
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"syscall"
+	"unsafe"
+
+	pb "github.com/nci/gsky/worker/gdalservice"
+)
+
+// DrillPoints samples the bands of a dataset at every point of
+// a Point or MultiPoint geometry. The result has a row per band
+// and a column per point. Points falling outside the dataset or
+// on nodata pixels have a zero count.
+func DrillPoints(in *pb.GeoRPCGranule) *pb.Result {
+	sampler, err := newPixelSampler(in)
+	if err != nil {
+		log.Println(err)
+		return &pb.Result{Error: err.Error()}
+	}
+	defer sampler.Close()
+
+	geom := sampler.geom
+	var points []C.OGRGeometryH
+	switch C.OGR_GT_Flatten(C.OGR_G_GetGeometryType(geom)) {
+	case C.wkbPoint:
+		points = append(points, geom)
+	case C.wkbMultiPoint:
+		for i := 0; i < int(C.OGR_G_GetGeometryCount(geom)); i++ {
+			points = append(points, C.OGR_G_GetGeometryRef(geom, C.int(i)))
+		}
+	default:
+		msg := fmt.Sprintf("Geometry %s is neither a Point nor a MultiPoint", in.Geometry)
+		return &pb.Result{Error: msg}
+	}
+
+	var resUsage0, resUsage1 syscall.Rusage
+	syscall.Getrusage(syscall.RUSAGE_SELF, &resUsage0)
+
+	nBands := len(in.Bands)
+	nPoints := len(points)
+	values := make([]*pb.TimeSeries, nBands*nPoints)
+	for i := range values {
+		values[i] = &pb.TimeSeries{Value: 0, Count: 0}
+	}
+
+	for ip, pt := range points {
+		vals, err := sampler.Sample(float64(C.OGR_G_GetX(pt, 0)), float64(C.OGR_G_GetY(pt, 0)))
+		if err != nil {
+			return &pb.Result{Error: fmt.Sprintf("point %d: %v", ip, err)}
+		}
+		for ib, val := range vals {
+			values[ib*nPoints+ip] = val
+		}
+	}
+
+	syscall.Getrusage(syscall.RUSAGE_SELF, &resUsage1)
+	metrics := &pb.WorkerMetrics{BytesRead: sampler.bytesRead}
+	metrics.UserTime = resUsage1.Utime.Nano() - resUsage0.Utime.Nano()
+	metrics.SysTime = resUsage1.Stime.Nano() - resUsage0.Stime.Nano()
+
+	return &pb.Result{TimeSeries: values, Raster: &pb.Raster{NoData: float64(sampler.nodata)}, Shape: []int32{int32(nBands), int32(nPoints)}, Error: "OK", Metrics: metrics}
+}
+
+// pixelSampler reads the values of the bands of a dataset at
+// given locations with either nearest neighbour or bilinear
+// interpolation. The geometry of the request is transformed
+// into the CRS of the dataset.
+type pixelSampler struct {
+	ds        C.GDALDatasetH
+	geom      C.OGRGeometryH
+	geot      []float64
+	invGeot   []float64
+	xSize     int
+	ySize     int
+	bands     []int32
+	bilinear  bool
+	nodata    float32
+	clipLower float32
+	clipUpper float32
+	dSize     int
+	bytesRead int64
+	vrtMgr    *VRTManager
+}
+
+func newPixelSampler(in *pb.GeoRPCGranule) (*pixelSampler, error) {
+	// Geometries such as MultiPoint are not supported by
+	// geo.Feature, hence they are handed over to OGR as is
+	var feat struct {
+		Geometry json.RawMessage `json:"geometry"`
+	}
+	err := json.Unmarshal([]byte(in.Geometry), &feat)
+	if err != nil || len(feat.Geometry) == 0 {
+		return nil, fmt.Errorf("Problem unmarshalling geometry %v", in)
+	}
+
+	s := &pixelSampler{bands: in.Bands, clipLower: in.ClipLower, clipUpper: in.ClipUpper}
+	switch in.Interpolation {
+	case "", "nearest":
+	case "bilinear":
+		s.bilinear = true
+	default:
+		return nil, fmt.Errorf("Interpolation method not implemented: %s", in.Interpolation)
+	}
+
+	if len(in.Bands) == 0 {
+		return nil, fmt.Errorf("No band requested")
+	}
+
+	path := in.Path
+	if len(in.VRT) > 0 {
+		s.vrtMgr, err = NewVRTManager([]byte(in.VRT))
+		if err != nil {
+			return nil, fmt.Errorf("VRT Manager error: %v", err)
+		}
+		path = s.vrtMgr.DSFileName
+	}
+
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+	s.ds = C.GDALOpen(cPath, C.GDAL_OF_READONLY)
+	if s.ds == nil {
+		s.Close()
+		return nil, fmt.Errorf("GDAL could not open dataset: %s", path)
+	}
+
+	cGeom := C.CString(string(feat.Geometry))
+	defer C.free(unsafe.Pointer(cGeom))
+	s.geom = C.OGR_G_CreateGeometryFromJson(cGeom)
+	if s.geom == nil {
+		s.Close()
+		return nil, fmt.Errorf("Geometry %s could not be parsed", in.Geometry)
+	}
+
+	if C.GoString(C.GDALGetProjectionRef(s.ds)) != "" {
+		desSRS := C.OSRNewSpatialReference(C.GDALGetProjectionRef(s.ds))
+		defer C.OSRDestroySpatialReference(desSRS)
+		srcSRS := C.OSRNewSpatialReference(cWGS84WKT)
+		defer C.OSRDestroySpatialReference(srcSRS)
+		C.OSRSetAxisMappingStrategy(srcSRS, C.OAMS_TRADITIONAL_GIS_ORDER)
+		C.OSRSetAxisMappingStrategy(desSRS, C.OAMS_TRADITIONAL_GIS_ORDER)
+		trans := C.OCTNewCoordinateTransformation(srcSRS, desSRS)
+		C.OGR_G_Transform(s.geom, trans)
+		C.OCTDestroyCoordinateTransformation(trans)
+	}
+
+	s.geot = make([]float64, 6)
+	gdalErr := C.GDALGetGeoTransform(s.ds, (*C.double)(&s.geot[0]))
+	if gdalErr != 0 {
+		s.Close()
+		return nil, fmt.Errorf("Couldn't get the geotransform from the source dataset %v", gdalErr)
+	}
+
+	s.invGeot = make([]float64, 6)
+	if C.GDALInvGeoTransform((*C.double)(&s.geot[0]), (*C.double)(&s.invGeot[0])) == 0 {
+		s.Close()
+		return nil, fmt.Errorf("Couldn't invert the geotransform of the source dataset")
+	}
+
+	bandH := C.GDALGetRasterBand(s.ds, C.int(1))
+	s.dSize = int(C.GDALGetDataTypeSizeBytes(C.GDALGetRasterDataType(bandH)))
+	if s.dSize == 0 {
+		s.Close()
+		return nil, fmt.Errorf("GDAL data type not implemented")
+	}
+	s.nodata = float32(C.GDALGetRasterNoDataValue(bandH, nil))
+
+	s.xSize = int(C.GDALGetRasterXSize(s.ds))
+	s.ySize = int(C.GDALGetRasterYSize(s.ds))
+
+	return s, nil
+}
+
+// Sample returns the value of every band at a location in the
+// CRS of the dataset. Locations outside the dataset or on nodata
+// pixels have a zero count.
+func (s *pixelSampler) Sample(x float64, y float64) ([]*pb.TimeSeries, error) {
+	nBands := len(s.bands)
+	values := make([]*pb.TimeSeries, nBands)
+	for i := range values {
+		values[i] = &pb.TimeSeries{Value: 0, Count: 0}
+	}
+
+	var pixel, line C.double
+	C.GDALApplyGeoTransform((*C.double)(&s.invGeot[0]), C.double(x), C.double(y), &pixel, &line)
+
+	px := float64(pixel)
+	py := float64(line)
+	if px < 0 || py < 0 || px >= float64(s.xSize) || py >= float64(s.ySize) {
+		return values, nil
+	}
+
+	// The neighbourhood of the location is a single pixel for
+	// nearest neighbour and the 2x2 pixels around the location
+	// for bilinear interpolation. Neighbours falling outside
+	// the dataset are left out of the interpolation.
+	x0, y0 := int(math.Floor(px)), int(math.Floor(py))
+	winSize := 1
+	weights := []float64{1}
+	if s.bilinear {
+		x0, y0 = int(math.Floor(px-0.5)), int(math.Floor(py-0.5))
+		winSize = 2
+
+		fx := px - 0.5 - float64(x0)
+		fy := py - 0.5 - float64(y0)
+		weights = []float64{(1 - fx) * (1 - fy), fx * (1 - fy), (1 - fx) * fy, fx * fy}
+	}
+
+	rx0, ry0 := maxInt(x0, 0), maxInt(y0, 0)
+	rx1, ry1 := minInt(x0+winSize, s.xSize), minInt(y0+winSize, s.ySize)
+	readXSize, readYSize := rx1-rx0, ry1-ry0
+
+	dataBuf := make([]float32, readXSize*readYSize*nBands)
+	gdalErr := C.GDALDatasetRasterIO(s.ds, C.GF_Read, C.int(rx0), C.int(ry0), C.int(readXSize), C.int(readYSize), unsafe.Pointer(&dataBuf[0]), C.int(readXSize), C.int(readYSize), C.GDT_Float32, C.int(nBands), (*C.int)(unsafe.Pointer(&s.bands[0])), 0, 0, 0)
+	if gdalErr != C.CE_None {
+		return nil, fmt.Errorf("Failed to read the pixels at pixel %f, line %f", px, py)
+	}
+	s.bytesRead += int64(len(dataBuf)) * int64(s.dSize)
+
+	bandSize := readXSize * readYSize
+	for ib := 0; ib < nBands; ib++ {
+		sum := 0.0
+		sumWeights := 0.0
+		for iw, weight := range weights {
+			x := x0 + iw%winSize
+			y := y0 + iw/winSize
+			if x < rx0 || x >= rx1 || y < ry0 || y >= ry1 {
+				continue
+			}
+
+			val := dataBuf[ib*bandSize+(y-ry0)*readXSize+(x-rx0)]
+			if val == s.nodata || math.IsNaN(float64(val)) || val < s.clipLower || val > s.clipUpper {
+				continue
+			}
+			sum += weight * float64(val)
+			sumWeights += weight
+		}
+
+		if sumWeights > 0 {
+			values[ib] = &pb.TimeSeries{Value: sum / sumWeights, Count: 1}
+		}
+	}
+
+	return values, nil
+}
+
+func (s *pixelSampler) Close() {
+	if s.geom != nil {
+		C.OGR_G_DestroyGeometry(s.geom)
+	}
+	if s.ds != nil {
+		C.GDALClose(s.ds)
+	}
+	if s.vrtMgr != nil {
+		s.vrtMgr.Close()
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
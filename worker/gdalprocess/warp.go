@@ -59,6 +59,24 @@ var GDALTypes = map[C.GDALDataType]string{0: "Unkown", 1: "Byte", 2: "UInt16", 3
 	8: "CInt16", 9: "CInt32", 10: "CFloat32", 11: "CFloat64",
 	12: "TypeCount"}
 
+// GDALResampleAlgs maps the resampling of the
+// warp requests to the GDAL resampling algorithms.
+var GDALResampleAlgs = map[string]C.GDALResampleAlg{
+	"":            C.GRA_NearestNeighbour,
+	"nearest":     C.GRA_NearestNeighbour,
+	"bilinear":    C.GRA_Bilinear,
+	"cubic":       C.GRA_Cubic,
+	"cubicspline": C.GRA_CubicSpline,
+	"lanczos":     C.GRA_Lanczos,
+	"average":     C.GRA_Average,
+	"mode":        C.GRA_Mode,
+	"max":         C.GRA_Max,
+	"min":         C.GRA_Min,
+	"med":         C.GRA_Med,
+	"q1":          C.GRA_Q1,
+	"q3":          C.GRA_Q3,
+}
+
 func ComputeReprojectExtent(in *pb.GeoRPCGranule) *pb.Result {
 	srcFileC := C.CString(in.Path)
 	defer C.free(unsafe.Pointer(srcFileC))
@@ -116,6 +134,10 @@ func ComputeReprojectExtent(in *pb.GeoRPCGranule) *pb.Result {
 }
 
 func WarpRaster(in *pb.GeoRPCGranule) *pb.Result {
+	resampleAlg, found := GDALResampleAlgs[in.Resampling]
+	if !found {
+		return &pb.Result{Error: fmt.Sprintf("Unsupported resampling: %v", in.Resampling)}
+	}
 
 	filePathC := C.CString(in.Path)
 	defer C.free(unsafe.Pointer(filePathC))
@@ -182,7 +204,7 @@ func WarpRaster(in *pb.GeoRPCGranule) *pb.Result {
 
 	var resUsage0, resUsage1 syscall.Rusage
 	syscall.Getrusage(syscall.RUSAGE_SELF, &resUsage0)
-	cErr := C.warp_operation_fast(filePathC, srcProjRefC, pSrcGeot, pGeoLoc, dstProjRefC, (*C.double)(&in.DstGeot[0]), C.int(in.Width), C.int(in.Height), C.int(in.Bands[0]), C.int(in.SRSCf), (*unsafe.Pointer)(&dstBufC), (*C.int)(&dstBufSize), (*C.int)(&dstBboxC[0]), (*C.double)(&noData), (*C.GDALDataType)(&dType), &bytesReadC)
+	cErr := C.warp_operation_fast(filePathC, srcProjRefC, pSrcGeot, pGeoLoc, dstProjRefC, (*C.double)(&in.DstGeot[0]), C.int(in.Width), C.int(in.Height), C.int(in.Bands[0]), C.int(in.SRSCf), resampleAlg, (*unsafe.Pointer)(&dstBufC), (*C.int)(&dstBufSize), (*C.int)(&dstBboxC[0]), (*C.double)(&noData), (*C.GDALDataType)(&dType), &bytesReadC)
 	syscall.Getrusage(syscall.RUSAGE_SELF, &resUsage1)
 
 	metrics := &pb.WorkerMetrics{
@@ -296,7 +318,7 @@ func WarpRaster(in *pb.GeoRPCGranule) *pb.Result {
 		var bytesReadCG C.size_t
 
 		// warp geometry raster
-		cErr := C.warp_operation_fast(outFileC, srcProjRefC, pSrcGeotG, pGeoLoc, dstProjRefC, (*C.double)(&in.DstGeot[0]), C.int(in.Width), C.int(in.Height), C.int(in.Bands[0]), C.int(in.SRSCf), (*unsafe.Pointer)(&dstBufCG), (*C.int)(&dstBufSizeG), (*C.int)(&dstBboxCG[0]), (*C.double)(&noDataG), (*C.GDALDataType)(&dTypeG), &bytesReadCG)
+		cErr := C.warp_operation_fast(outFileC, srcProjRefC, pSrcGeotG, pGeoLoc, dstProjRefC, (*C.double)(&in.DstGeot[0]), C.int(in.Width), C.int(in.Height), C.int(in.Bands[0]), C.int(in.SRSCf), C.GRA_NearestNeighbour, (*unsafe.Pointer)(&dstBufCG), (*C.int)(&dstBufSizeG), (*C.int)(&dstBboxCG[0]), (*C.double)(&noDataG), (*C.GDALDataType)(&dTypeG), &bytesReadCG)
 		if cErr != 0 {
 			return &pb.Result{Error: fmt.Sprintf("warp_operation() fail: %v", int(cErr))}
 		}
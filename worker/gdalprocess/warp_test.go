@@ -1,7 +1,11 @@
 package gdalprocess
 
 import (
+	"encoding/binary"
+	"io/ioutil"
+	"math"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/nci/gsky/utils"
@@ -31,3 +35,57 @@ func TestComputeReprojectExtent(t *testing.T) {
 	}
 
 }
+
+func TestWarpRasterResampleNoData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gsky_warp_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The source band has no nodata and is rotated by 45
+	// degrees so that it only partly covers the destination
+	grid := "ncols 4\nnrows 4\nxllcorner 0\nyllcorner 0\ncellsize 1\n"
+	for i := 0; i < 4; i++ {
+		grid += "7.5 7.5 7.5 7.5\n"
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "src.asc"), []byte(grid), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vrt := `<VRTDataset rasterXSize="4" rasterYSize="4">
+  <GeoTransform>0, 1, -1, 4, 1, 1</GeoTransform>
+  <VRTRasterBand dataType="Float32" band="1">
+    <SimpleSource>
+      <SourceFilename relativeToVRT="1">src.asc</SourceFilename>
+      <SourceBand>1</SourceBand>
+    </SimpleSource>
+  </VRTRasterBand>
+</VRTDataset>`
+	srcFile := filepath.Join(dir, "src.vrt")
+	if err = ioutil.WriteFile(srcFile, []byte(vrt), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	in := &pb.GeoRPCGranule{Path: srcFile, Width: 16, Height: 16, DstGeot: []float64{-4, 0.5, 0, 12, 0, -0.5}, Bands: []int32{1}, Resampling: "bilinear"}
+	res := WarpRaster(in)
+	if res.Error != "OK" {
+		t.Fatal(res.Error)
+	}
+	if res.Raster.RasterType != "Float32" {
+		t.Fatalf("unexpected raster type: %v", res.Raster.RasterType)
+	}
+
+	bbox := res.Raster.Bbox
+	value := func(x, y int) float32 {
+		i := 4 * ((y-int(bbox[1]))*int(bbox[2]) + x - int(bbox[0]))
+		return math.Float32frombits(binary.LittleEndian.Uint32(res.Raster.Data[i:]))
+	}
+
+	if v := value(int(bbox[0]), int(bbox[1])); v != float32(res.Raster.NoData) {
+		t.Errorf("expected nodata %v outside of the source, got %v", res.Raster.NoData, v)
+	}
+	if v := value(8, 8); v != 7.5 {
+		t.Errorf("expected 7.5 inside of the source, got %v", v)
+	}
+}
@@ -13,16 +13,20 @@ target buffer but the subwindow projected from the source band.
 4) Since we now only warp over a subwindow, we will only need to send
 the subwindow of data over the network, which results in large
 reduction of overheads in grpc (de-)serialisation and network traffic.
+Resampling algorithms other than nearest neighbour go through the GDAL
+warper over the same target subwindow.
 */
 
 
 #include "warper.hxx"
 #include "coordinate_transform_cache.hxx"
+#include "gdalwarper.h"
 #include <utility>
 #include <map>
 #include <vector>
 
 #include <iostream>
+#include <algorithm>
 
 auto coordTransformCache = new CoordinateTransformCache();
 
@@ -78,7 +82,95 @@ int roundCoord(double coord, int maxExtent) {
 	return c;
 }
 
-int warp_operation_fast(const char *srcFilePath, char *srcProjRef, double *srcGeot, const char **geoLocOpts, const char *dstProjRef, double *dstGeot, int dstXImageSize, int dstYImageSize, int band, int srsCf, void **dstBuf, int *dstBufSize, int *dstBbox, double *noData, GDALDataType *dType, size_t *bytesRead)
+// warp_resample warps a subwindow of the destination with the
+// GDAL warper for resampling algorithms other than nearest
+// neighbour. The source band is read from the overview level
+// picked for the destination resolution, if any, so that the
+// likes of average and mode take into account all the pixels
+// of that level falling into the destination pixels.
+int warp_resample(GDALDatasetH hSrcDS, int band, void *hTransformArg, GDALResampleAlg resampleAlg, int dstXOff, int dstYOff, int dstXSize, int dstYSize, void *dstBuf, GDALDataType dType, double noData, int hasNoData, size_t *bytesRead)
+{
+	GDALWarpOptions *psWOptions = GDALCreateWarpOptions();
+	psWOptions->hSrcDS = hSrcDS;
+	psWOptions->nBandCount = 1;
+	psWOptions->panSrcBands = (int *)CPLMalloc(sizeof(int));
+	psWOptions->panSrcBands[0] = band;
+	psWOptions->panDstBands = (int *)CPLMalloc(sizeof(int));
+	psWOptions->panDstBands[0] = 1;
+	psWOptions->eResampleAlg = resampleAlg;
+	psWOptions->eWorkingDataType = dType;
+	psWOptions->pfnTransformer = GDALApproxTransform;
+	psWOptions->pTransformerArg = hTransformArg;
+	if(hasNoData) {
+		psWOptions->padfSrcNoDataReal = (double *)CPLMalloc(sizeof(double));
+		psWOptions->padfSrcNoDataReal[0] = noData;
+	}
+
+	// There is no destination dataset to read the initial
+	// values from, the buffer is initialised with the nodata
+	// fill of the caller even if the source band has no nodata.
+	// Otherwise the pixels outside of the source footprint
+	// would be zeros taken as valid values.
+	psWOptions->padfDstNoDataReal = (double *)CPLMalloc(sizeof(double));
+	psWOptions->padfDstNoDataReal[0] = noData;
+	psWOptions->papszWarpOptions = CSLSetNameValue(psWOptions->papszWarpOptions, "INIT_DEST", "NO_DATA");
+
+	GDALWarpOperation oWO;
+	CPLErr err = oWO.Initialize(psWOptions);
+	if(err == CE_None) {
+		err = oWO.WarpRegionToBuffer(dstXOff, dstYOff, dstXSize, dstYSize, dstBuf, dType);
+	}
+	GDALDestroyWarpOptions(psWOptions);
+	if(err != CE_None) {
+		return 4;
+	}
+
+	// The source window is estimated by projecting
+	// the edges of the destination window
+	const int nSteps = 20;
+	double srcMinX = 0, srcMinY = 0, srcMaxX = -1, srcMaxY = -1;
+	for(int i = 0; i <= nSteps; i++) {
+		double t = (double)i / nSteps;
+		double ex[4] = {dstXOff + t * dstXSize, dstXOff + t * dstXSize, (double)dstXOff, (double)(dstXOff + dstXSize)};
+		double ey[4] = {(double)dstYOff, (double)(dstYOff + dstYSize), dstYOff + t * dstYSize, dstYOff + t * dstYSize};
+		double ez[4] = {0, 0, 0, 0};
+		int bSuccess[4];
+		GDALApproxTransform(hTransformArg, TRUE, 4, ex, ey, ez, bSuccess);
+		for(int j = 0; j < 4; j++) {
+			if(!bSuccess[j]) continue;
+			if(srcMaxX < srcMinX) {
+				srcMinX = srcMaxX = ex[j];
+				srcMinY = srcMaxY = ey[j];
+				continue;
+			}
+			srcMinX = std::min(srcMinX, ex[j]);
+			srcMaxX = std::max(srcMaxX, ex[j]);
+			srcMinY = std::min(srcMinY, ey[j]);
+			srcMaxY = std::max(srcMaxY, ey[j]);
+		}
+	}
+
+	GDALRasterBandH hBand = GDALGetRasterBand(hSrcDS, band);
+	const int srcXSize = GDALGetRasterBandXSize(hBand);
+	const int srcYSize = GDALGetRasterBandYSize(hBand);
+	const int srcDataSize = GDALGetDataTypeSizeBytes(GDALGetRasterDataType(hBand));
+	int srcXBlockSize, srcYBlockSize;
+	GDALGetBlockSize(hBand, &srcXBlockSize, &srcYBlockSize);
+
+	*bytesRead = 0;
+	if(srcMaxX >= srcMinX) {
+		const int minXBlock = roundCoord(srcMinX, srcXSize) / srcXBlockSize;
+		const int maxXBlock = roundCoord(srcMaxX, srcXSize) / srcXBlockSize;
+		const int minYBlock = roundCoord(srcMinY, srcYSize) / srcYBlockSize;
+		const int maxYBlock = roundCoord(srcMaxY, srcYSize) / srcYBlockSize;
+		const size_t nBlocksRead = (size_t)(maxXBlock - minXBlock + 1) * (maxYBlock - minYBlock + 1);
+		*bytesRead = (size_t)srcXBlockSize * srcYBlockSize * srcDataSize * nBlocksRead;
+	}
+
+	return 0;
+}
+
+int warp_operation_fast(const char *srcFilePath, char *srcProjRef, double *srcGeot, const char **geoLocOpts, const char *dstProjRef, double *dstGeot, int dstXImageSize, int dstYImageSize, int band, int srsCf, GDALResampleAlg resampleAlg, void **dstBuf, int *dstBufSize, int *dstBbox, double *noData, GDALDataType *dType, size_t *bytesRead)
 {
 	*bytesRead = 0;
 
@@ -176,6 +268,12 @@ int warp_operation_fast(const char *srcFilePath, char *srcProjRef, double *srcGe
 	double bbox[4];
 	int err = GDALSuggestedWarpOutput2(hSrcDS, pTransFunc, hTransformArg, geotOut, &nPixels, &nLines, bbox, 0);
 
+	// Resampling algorithms other than nearest neighbour go
+	// through the GDAL warper which reads the dataset of the
+	// overview level rather than its band
+	GDALDatasetH hWarpDS = hSrcDS;
+	int warpBand = band;
+
 	int nOverviews = GDALGetOverviewCount(hBand);
 	int useOverview = 0;
 	if(!hasGeoLoc && err == CE_None && nOverviews > 0) {
@@ -201,8 +299,18 @@ int warp_operation_fast(const char *srcFilePath, char *srcProjRef, double *srcGe
 				if(diff > -1e-1 && diff < 1e-1) break;
 			}
 
+			GDALDatasetH hOvrDS = nullptr;
+			int ovrBand = 0;
 			if(iOvr >= 0) {
+				GDALRasterBandH hOvr = GDALGetOverview(hBand, iOvr);
+				hOvrDS = GDALGetBandDataset(hOvr);
+				ovrBand = GDALGetBandNumber(hOvr);
+			}
+
+			if(iOvr >= 0 && (resampleAlg == GRA_NearestNeighbour || (hOvrDS != nullptr && ovrBand > 0))) {
 				hBand = GDALGetOverview(hBand, iOvr);
+				hWarpDS = hOvrDS;
+				warpBand = ovrBand;
 				int ovrXSize = GDALGetRasterBandXSize(hBand);
         			int ovrYSize = GDALGetRasterBandYSize(hBand);
 
@@ -254,7 +362,7 @@ int warp_operation_fast(const char *srcFilePath, char *srcProjRef, double *srcGe
 	const GDALDataType srcDataType = *dType;
         const int srcDataSize = GDALGetDataTypeSizeBytes(*dType);
 
-	const int supportedDataType = *dType == GDT_Byte || *dType == GDT_Int16 || *dType == GDT_UInt16 || *dType == GDT_Float32;
+	const int supportedDataType = *dType == GDT_Byte || *dType == GDT_Int16 || *dType == GDT_UInt16 || *dType == GDT_Int32 || *dType == GDT_UInt32 || *dType == GDT_Float32 || *dType == GDT_Float64;
 	if(!supportedDataType) {
 		*dType = GDT_Float32;
 	}
@@ -265,9 +373,42 @@ int warp_operation_fast(const char *srcFilePath, char *srcProjRef, double *srcGe
 	uint8_t* pDstBuf = (uint8_t *)malloc(*dstBufSize);
 	*dstBuf = pDstBuf;
 
-	*noData = GDALGetRasterNoDataValue(hBand, nullptr);
+	int hasNoData = 0;
+	*noData = GDALGetRasterNoDataValue(hBand, &hasNoData);
 	GDALCopyWords(noData, GDT_Float64, 0, *dstBuf, *dType, dataSize, dstXSize * dstYSize);
 
+	auto finalise = [&]() {
+		dstBbox[0] = dstXOff;
+		dstBbox[1] = dstYOff;
+		dstBbox[2] = dstXSize;
+		dstBbox[3] = dstYSize;
+
+		if(*dType == GDT_Byte) {
+			const char *pixelType = GDALGetMetadataItem((GDALMajorObjectH)hBand, "PIXELTYPE", "IMAGE_STRUCTURE");
+			if(pixelType != nullptr && !strcmp(pixelType, "SIGNEDBYTE")) {
+				*dType = (GDALDataType)100;
+			}
+		}
+
+		GDALDestroyApproxTransformer(hApproxTransformArg);
+		if(!hasCoordCache) {
+			GDALDestroyGenImgProjTransformer(hTransformArg);
+		}
+
+		GDALClose(hSrcDS);
+	};
+
+	if(resampleAlg != GRA_NearestNeighbour) {
+		int rErr = warp_resample(hWarpDS, warpBand, hApproxTransformArg, resampleAlg, dstXOff, dstYOff, dstXSize, dstYSize, pDstBuf, *dType, *noData, hasNoData, bytesRead);
+		finalise();
+		if(rErr != 0) {
+			free(pDstBuf);
+			*dstBuf = nullptr;
+			*dstBufSize = 0;
+		}
+		return rErr;
+	}
+
 	auto dVec = std::vector<double>();
 	dVec.resize(4 * dstXSize);
 	double *dx = dVec.data();
@@ -371,23 +512,6 @@ int warp_operation_fast(const char *srcFilePath, char *srcProjRef, double *srcGe
 
 	*bytesRead = srcXBlockSize * srcYBlockSize * srcDataSize * nBlocksRead;
 
-	dstBbox[0] = dstXOff;
-        dstBbox[1] = dstYOff;
-        dstBbox[2] = dstXSize;
-        dstBbox[3] = dstYSize;
-
-	if(*dType == GDT_Byte) {
-		const char *pixelType = GDALGetMetadataItem((GDALMajorObjectH)hBand, "PIXELTYPE", "IMAGE_STRUCTURE");
-		if(pixelType != nullptr && !strcmp(pixelType, "SIGNEDBYTE")) {
-			*dType = (GDALDataType)100;
-		}
-	}
-
-	GDALDestroyApproxTransformer(hApproxTransformArg);
-	if(!hasCoordCache) {
-       		GDALDestroyGenImgProjTransformer(hTransformArg);
-	}
-
-	GDALClose(hSrcDS);
+	finalise();
 	return 0;
 }
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nci/gsky/utils"
+)
+
+const XYZMaxZoomLevel = 24
+
+// tilesHandler serves slippy map tiles on
+// /tiles/{namespace}/{layer}/{z}/{x}/{y}.png
+// The tile is rendered in EPSG:3857 through the
+// same pipeline as WMS GetMap. Time, styles and
+// axes are taken from the query parameters.
+// Tiles ending with .mvt are the contour lines
+// of the layer as Mapbox Vector Tiles.
+func tilesHandler(w http.ResponseWriter, r *http.Request) {
+	var parts []string
+	for _, p := range strings.Split(r.URL.Path[len("/tiles/"):], "/") {
+		if len(p) > 0 {
+			parts = append(parts, p)
+		}
+	}
+
+	if len(parts) < 4 {
+		http.Error(w, fmt.Sprintf("Invalid tile path: %s, expecting /tiles/{layer}/{z}/{x}/{y}.png", r.URL.Path), 404)
+		return
+	}
+
+	nParts := len(parts)
+	layerName := parts[nParts-4]
+	yStr := parts[nParts-1]
+	format := "image/png"
+	if strings.HasSuffix(yStr, ".png") {
+		yStr = yStr[:len(yStr)-len(".png")]
+	} else if strings.HasSuffix(yStr, ".mvt") {
+		yStr = yStr[:len(yStr)-len(".mvt")]
+		format = utils.ContourMVTFormat
+	} else {
+		http.Error(w, fmt.Sprintf("Invalid tile path: %s, only png and mvt tiles are supported", r.URL.Path), 404)
+		return
+	}
+
+	z, zErr := strconv.Atoi(parts[nParts-3])
+	x, xErr := strconv.Atoi(parts[nParts-2])
+	y, yErr := strconv.Atoi(yStr)
+	if zErr != nil || xErr != nil || yErr != nil {
+		http.Error(w, fmt.Sprintf("Invalid tile path: %s, z, x and y must be integers", r.URL.Path), 400)
+		return
+	}
+
+	if z < 0 || z > XYZMaxZoomLevel {
+		http.Error(w, fmt.Sprintf("Invalid zoom level: %d, must be within [0, %d]", z, XYZMaxZoomLevel), 400)
+		return
+	}
+
+	tms := utils.NewGoogleMapsCompatible(z)
+	bbox, err := tms.TileBBox(z, y, x)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid tile: %v", err), 400)
+		return
+	}
+
+	namespace := "."
+	if nParts > 4 {
+		namespace = strings.Join(parts[:nParts-4], "/")
+	}
+
+	conf := getNamespaceConfig(namespace, w, r)
+	if conf == nil {
+		return
+	}
+
+	var layer *utils.Layer
+	for i := range conf.Layers {
+		if conf.Layers[i].Name == layerName {
+			layer = &conf.Layers[i]
+			break
+		}
+	}
+	if layer == nil {
+		http.Error(w, fmt.Sprintf("Layer not found: %s", layerName), 404)
+		return
+	}
+
+	if utils.CheckDisableServices(layer, "xyz") {
+		http.Error(w, "XYZ tiles are disabled for this layer", 400)
+		return
+	}
+
+	query := r.URL.Query()
+	if styles, found := query["styles"]; found && len(styles) > 0 {
+		for i := range layer.Styles {
+			if layer.Styles[i].Name == styles[0] && utils.CheckDisableServices(&layer.Styles[i], "xyz") {
+				http.Error(w, "XYZ tiles are disabled for this style", 400)
+				return
+			}
+		}
+	}
+
+	kvp := url.Values{}
+	kvp.Set("service", "WMS")
+	kvp.Set("request", "GetMap")
+	kvp.Set("version", "1.1.1")
+	kvp.Set("layers", layer.Name)
+	kvp.Set("srs", "EPSG:3857")
+	kvp.Set("format", format)
+	kvp.Set("width", fmt.Sprintf("%d", utils.WMTSTileSize))
+	kvp.Set("height", fmt.Sprintf("%d", utils.WMTSTileSize))
+	kvp.Set("bbox", fmt.Sprintf("%f,%f,%f,%f", bbox[0], bbox[1], bbox[2], bbox[3]))
+
+	// Axes can be given either by their names or
+	// WMS style with the dim_ prefix
+	for _, axis := range layer.AxesInfo {
+		name := strings.ToLower(axis.Name)
+		for key, val := range query {
+			if strings.ToLower(key) != name || len(val) == 0 {
+				continue
+			}
+			if _, hasDim := query["dim_"+name]; !hasDim {
+				kvp.Set("dim_"+name, val[0])
+			}
+		}
+	}
+
+	if len(r.URL.RawQuery) > 0 {
+		r.URL.RawQuery = kvp.Encode() + "&" + r.URL.RawQuery
+	} else {
+		r.URL.RawQuery = kvp.Encode()
+	}
+
+	generalHandler(conf, w, r)
+}
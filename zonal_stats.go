@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	geo "github.com/nci/geometry"
+	proc "github.com/nci/gsky/processor"
+	"github.com/nci/gsky/utils"
+)
+
+// zonalStats holds the statistics of a feature
+// keyed by date. The values are in the order of
+// the columns returned by zonalStatsColumns.
+type zonalStats map[string][]*float64
+
+// executeZonalStats drills every feature of the request with
+// the zonal pixel statistic and returns the statistics of the
+// features as CSV and GeoJSON outputs. The features are drilled
+// ZonalConcLimit at a time. On failure it returns the HTTP
+// status code of the error.
+func executeZonalStats(ctx context.Context, timeoutCtx context.Context, conf *utils.Config, process *utils.Process, params utils.WPSParams, progress *proc.DrillProgress) (string, int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	features := params.FeatCol.Features
+	stats := make([]zonalStats, len(features))
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var drillErr error
+	errStatus := 500
+
+	concLimit := make(chan struct{}, process.ZonalConcLimit)
+	for i := range features {
+		concLimit <- struct{}{}
+		if ctx.Err() != nil {
+			<-concLimit
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-concLimit
+				wg.Done()
+			}()
+
+			res, status, err := drillZonalFeature(ctx, timeoutCtx, conf, process, params, features[i].Geometry, progress)
+			if err != nil {
+				errOnce.Do(func() {
+					drillErr = fmt.Errorf("feature %d: %v", i, err)
+					errStatus = status
+					cancel()
+				})
+				return
+			}
+			stats[i] = res
+		}(i)
+	}
+	wg.Wait()
+
+	if drillErr != nil {
+		return "", errStatus, drillErr
+	}
+
+	columns := zonalStatsColumns(process)
+
+	var props []map[string]interface{}
+	for i := range features {
+		var prop map[string]interface{}
+		if i < len(params.FeatProps.Features) {
+			prop = params.FeatProps.Features[i].Properties
+		}
+		props = append(props, prop)
+	}
+
+	csvOut, err := encodeZonalStatsCSV(columns, props, stats)
+	if err != nil {
+		return "", 500, err
+	}
+
+	geojsonOut, err := encodeZonalStatsGeoJSON(columns, features, props, stats)
+	if err != nil {
+		return "", 500, err
+	}
+
+	outputs := []*utils.WPSOutput{
+		{Identifier: "zonal_stats_csv",
+			Title:    "Zonal Statistics",
+			Abstract: "Statistics of every feature over time.",
+			MimeType: "text/csv",
+			Value:    csvOut,
+		},
+		{Identifier: "zonal_stats_geojson",
+			Title:    "Zonal Statistics",
+			Abstract: "Features with their statistics over time.",
+			MimeType: "application/geo+json",
+			Schema:   "https://tools.ietf.org/html/rfc7946",
+			Value:    geojsonOut,
+		},
+	}
+	return utils.EncodeWPSOutputs(outputs), 200, nil
+}
+
+// drillZonalFeature drills every data source of the process
+// over a feature and merges the statistics by date.
+func drillZonalFeature(ctx context.Context, timeoutCtx context.Context, conf *utils.Config, process *utils.Process, params utils.WPSParams, geom geo.Geometry, progress *proc.DrillProgress) (zonalStats, int, error) {
+	feat, err := json.Marshal(&geo.Feature{Type: "Feature", Geometry: geom})
+	if err != nil {
+		return nil, 400, err
+	}
+
+	nStats := len(zonalStatNames(process))
+	nTotal := len(zonalStatsColumns(process))
+
+	stats := make(zonalStats)
+	offset := 0
+	for ids := range process.DataSources {
+		dataSource := &process.DataSources[ids]
+
+		// The features are drilled concurrently, hence no
+		// metrics collector shared among the drill requests
+		geoReq, err := newWPSDrillRequest(process, dataSource, params, feat, nil)
+		if err != nil {
+			return nil, 400, err
+		}
+
+		// Zonal statistics need the pixels, the approximated
+		// means of the index are not enough
+		res, err := runWPSDrill(ctx, timeoutCtx, conf, process, dataSource, geoReq, "", "", false, progress)
+		if err != nil {
+			return nil, 500, err
+		}
+
+		nCols := len(drillVariables(dataSource)) * nStats
+		for _, line := range strings.Split(res, "\n") {
+			if len(strings.TrimSpace(line)) == 0 {
+				continue
+			}
+			cols := strings.Split(line, ",")
+			if len(cols) != nCols+1 {
+				return nil, 500, fmt.Errorf("unexpected drill result: %s", line)
+			}
+
+			row, found := stats[cols[0]]
+			if !found {
+				row = make([]*float64, nTotal)
+				stats[cols[0]] = row
+			}
+			for ic := 0; ic < nCols; ic++ {
+				if v, err := strconv.ParseFloat(cols[ic+1], 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+					row[offset+ic] = &v
+				}
+			}
+		}
+		offset += nCols
+	}
+
+	return stats, 200, nil
+}
+
+// zonalStatNames returns the names of the statistics
+// computed for every variable, the deciles being available
+// with the deciles drill algorithm along with the percentiles
+// and histogram bins of the process.
+func zonalStatNames(process *utils.Process) []string {
+	decileCount := 0
+	for _, algo := range strings.Split(process.DrillAlgorithm, ",") {
+		if strings.ToLower(strings.TrimSpace(algo)) == "deciles" {
+			decileCount = proc.DefaultDecileAnchorPoints
+			break
+		}
+	}
+	return proc.DrillStatNames("zonal", decileCount, process.Percentiles, process.HistogramBins)
+}
+
+// drillVariables returns the names of the values drilled
+// from a data source, i.e. the band expressions if any.
+func drillVariables(dataSource *utils.Layer) []string {
+	if len(dataSource.RGBExpressions.Expressions) == 0 {
+		return dataSource.RGBExpressions.VarList
+	}
+	return dataSource.RGBExpressions.ExprNames
+}
+
+// zonalStatsColumns returns the statistics columns named
+// {variable}_{statistic} across all the data sources.
+func zonalStatsColumns(process *utils.Process) []string {
+	statNames := zonalStatNames(process)
+
+	var columns []string
+	for ids := range process.DataSources {
+		for _, variable := range drillVariables(&process.DataSources[ids]) {
+			for _, stat := range statNames {
+				columns = append(columns, variable+"_"+stat)
+			}
+		}
+	}
+	return columns
+}
+
+func sortedZonalDates(stats zonalStats) []string {
+	var dates []string
+	for date := range stats {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+// encodeZonalStatsCSV writes a row per feature and date
+// starting with the properties of the feature. Features
+// without data get a single row without date.
+func encodeZonalStatsCSV(columns []string, props []map[string]interface{}, stats []zonalStats) (string, error) {
+	keySet := make(map[string]bool)
+	for _, prop := range props {
+		for key := range prop {
+			keySet[key] = true
+		}
+	}
+	var keys []string
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := append(append(append([]string{}, keys...), "date"), columns...)
+	w.Write(header)
+
+	for i := range stats {
+		var record []string
+		for _, key := range keys {
+			record = append(record, zonalPropString(props[i][key]))
+		}
+
+		dates := sortedZonalDates(stats[i])
+		if len(dates) == 0 {
+			w.Write(append(record, make([]string, 1+len(columns))...))
+			continue
+		}
+
+		for _, date := range dates {
+			row := append(append([]string{}, record...), date)
+			for _, val := range stats[i][date] {
+				cell := ""
+				if val != nil {
+					cell = strconv.FormatFloat(*val, 'f', -1, 64)
+				}
+				row = append(row, cell)
+			}
+			w.Write(row)
+		}
+	}
+
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// encodeZonalStatsGeoJSON writes the features with their
+// statistics added to the properties as a list of records.
+func encodeZonalStatsGeoJSON(columns []string, features []geo.Feature, props []map[string]interface{}, stats []zonalStats) (string, error) {
+	type feature struct {
+		Type       string                 `json:"type"`
+		Geometry   geo.Geometry           `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	}
+	featCol := struct {
+		Type     string     `json:"type"`
+		Features []*feature `json:"features"`
+	}{Type: "FeatureCollection"}
+
+	for i := range features {
+		properties := make(map[string]interface{})
+		for key, val := range props[i] {
+			properties[key] = val
+		}
+
+		records := []map[string]interface{}{}
+		for _, date := range sortedZonalDates(stats[i]) {
+			record := map[string]interface{}{"date": date}
+			for ic, val := range stats[i][date] {
+				record[columns[ic]] = val
+			}
+			records = append(records, record)
+		}
+		properties["statistics"] = records
+
+		featCol.Features = append(featCol.Features, &feature{Type: "Feature", Geometry: features[i].Geometry, Properties: properties})
+	}
+
+	out, err := json.Marshal(&featCol)
+	return string(out), err
+}
+
+func zonalPropString(val interface{}) string {
+	switch val := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		out, _ := json.Marshal(val)
+		return string(out)
+	}
+}